@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/log"
+	"github.com/y3owk1n/nvs/internal/ui"
+)
+
+// aliasCmd represents the "alias" command.
+// It manages user-defined aliases: short names that point at an
+// installed version and are accepted wherever a version is (use, run,
+// pin, uninstall).
+//
+// Example usage:
+//
+//	nvs alias work v0.10.2   # create or retarget
+//	nvs alias work           # print the target
+//	nvs alias ls             # list every alias
+//	nvs alias rm work        # delete
+var aliasCmd = &cobra.Command{
+	Use:   "alias [name] [version]",
+	Short: "Create, show or list named version aliases",
+	Long: `Create a named alias for an installed version, e.g. 'nvs alias work v0.10.2'.
+
+Aliases can be used anywhere a version is accepted:
+  nvs use work
+  nvs run work -- --clean
+  nvs pin work
+  nvs uninstall work
+
+A version that an alias points at is protected: 'nvs uninstall' refuses
+to remove it until the alias is removed or --force is given.
+
+With one argument, prints the alias target; with none, lists every alias.`,
+	Args: cobra.MaximumNArgs(2), //nolint:mnd
+	RunE: RunAlias,
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List aliases and their targets",
+	Args:    cobra.NoArgs,
+	RunE:    RunAliasList,
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:     "rm <name>",
+	Aliases: []string{"remove", "unset"},
+	Short:   "Remove an alias (the version stays installed)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    RunAliasRemove,
+}
+
+// aliasJSON is the --json shape of one alias.
+type aliasJSON struct {
+	Name      string `json:"name"`
+	Target    string `json:"target"`
+	Installed bool   `json:"installed"`
+}
+
+// RunAlias executes the alias command.
+func RunAlias(cmd *cobra.Command, args []string) error {
+	switch len(args) {
+	case 0:
+		return RunAliasList(cmd, args)
+	case 1:
+		aliases, err := GetVersionService().Aliases()
+		if err != nil {
+			return err
+		}
+
+		target, ok := aliases[args[0]]
+		if !ok {
+			return fmt.Errorf("%w: %s", vtypes.ErrAliasNotFound, args[0])
+		}
+
+		_, err = fmt.Fprintln(os.Stdout, target)
+		if err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+
+		return nil
+	}
+
+	name, version := args[0], args[1]
+
+	target, err := GetVersionService().SetAlias(name, version)
+	if err != nil {
+		if errors.Is(err, vtypes.ErrVersionNotFound) {
+			return fmt.Errorf("%w (aliases must point at an installed version)", err)
+		}
+
+		return err
+	}
+
+	log.Debugf("Alias %s -> %s", name, target)
+
+	ui.Message.Successf("Alias %s -> %s", ui.Message.Accent(name), ui.Message.Accent(target))
+
+	return nil
+}
+
+// RunAliasList executes the alias ls command.
+func RunAliasList(cmd *cobra.Command, _ []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	aliases, err := GetVersionService().Aliases()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	if jsonOutput {
+		out := make([]aliasJSON, 0, len(names))
+		for _, name := range names {
+			out = append(out, aliasJSON{
+				Name:      name,
+				Target:    aliases[name],
+				Installed: GetVersionService().IsVersionInstalled(aliases[name]),
+			})
+		}
+
+		return outputJSON(out)
+	}
+
+	if len(names) == 0 {
+		ui.Message.Infof("No aliases defined. Create one with 'nvs alias <name> <version>'.")
+
+		return nil
+	}
+
+	tbl := ui.Table.New("Alias", "Version", "Status")
+	for _, name := range names {
+		status := "installed"
+		if !GetVersionService().IsVersionInstalled(aliases[name]) {
+			status = "missing"
+		}
+
+		tbl.Row(name, ui.Message.Accent(aliases[name]), status)
+	}
+
+	_, _ = fmt.Fprint(os.Stdout, tbl.Render(ui.Style.Palette()))
+
+	return nil
+}
+
+// RunAliasRemove executes the alias rm command.
+func RunAliasRemove(_ *cobra.Command, args []string) error {
+	err := GetVersionService().RemoveAlias(args[0])
+	if err != nil {
+		return err
+	}
+
+	ui.Message.Successf("Removed alias %s", ui.Message.Accent(args[0]))
+
+	return nil
+}
+
+func init() {
+	aliasCmd.Flags().Bool("json", false, "Output in JSON format (when listing)")
+	aliasListCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	aliasCmd.AddCommand(aliasListCmd, aliasRemoveCmd)
+	rootCmd.AddCommand(aliasCmd)
+}
@@ -0,0 +1,428 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/bisectsvc"
+	"github.com/y3owk1n/nvs/internal/app/settings"
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/bisect"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/log"
+	"github.com/y3owk1n/nvs/internal/ui"
+)
+
+// Exit codes of an 'nvs bisect run' script, as for 'git bisect run'.
+const (
+	// bisectExitSkip marks the candidate as untestable.
+	bisectExitSkip = 125
+	// bisectExitMaxBad is the highest exit code that marks the
+	// candidate bad; higher codes abort the bisection.
+	bisectExitMaxBad = 127
+)
+
+// bisectNvimEnv tells an 'nvs bisect run' script which nvim to test.
+const bisectNvimEnv = "NVS_BISECT_NVIM"
+
+// bisectCmd represents the "bisect" command.
+// It finds the first release or commit that introduced a change, most
+// often a regression, by testing candidates between a good and a bad
+// version.
+//
+// Example usage:
+//
+//	nvs bisect start v0.10.0 v0.11.0
+//	nvs bisect good
+//	nvs bisect bad
+//	nvs bisect run ./repro.lua
+//	nvs bisect reset
+var bisectCmd = &cobra.Command{
+	Use:   "bisect",
+	Short: "Find the first bad release or commit between two versions",
+	Long: `Find the release or commit that introduced a change, typically a regression,
+by testing versions between a good and a bad one.
+
+Between two release tags, nvs first tests the prebuilt releases in between,
+then bisects the commits between the two adjacent releases it narrowed the
+range down to (stop at the releases with --releases-only). Between any other
+bounds (commit hashes, or installed versions such as nightly, which stand for
+the commit they were built from) it bisects commits from the start. Commits
+are bisected with git bisect in the source mirror and each candidate is built
+from source under the disposable name "<hash>-bisect", using the build_*
+settings.
+
+Candidates nvs installs for the bisection are uninstalled once judged; your
+own installs are reused and left alone.
+
+Examples:
+  nvs bisect start v0.10.0 v0.11.0   # install the first candidate
+  nvs run v0.10.2                    # try it
+  nvs bisect bad                     # or good, or skip
+  nvs bisect run ./repro.lua         # or let a script decide
+  nvs bisect reset                   # clean up`,
+}
+
+// bisectStartCmd represents the "bisect start" subcommand.
+var bisectStartCmd = &cobra.Command{
+	Use:   "start <good> <bad>",
+	Short: "Start a bisection and install the first candidate",
+	Args:  cobra.ExactArgs(2), //nolint:mnd // good and bad
+	RunE:  RunBisectStart,
+}
+
+// bisectGoodCmd, bisectBadCmd and bisectSkipCmd represent the verdict
+// subcommands.
+var (
+	bisectGoodCmd = &cobra.Command{
+		Use:   "good",
+		Short: "Mark the current candidate good and install the next one",
+		Args:  cobra.NoArgs,
+		RunE:  bisectMarkRunE(bisect.Good),
+	}
+	bisectBadCmd = &cobra.Command{
+		Use:   "bad",
+		Short: "Mark the current candidate bad and install the next one",
+		Args:  cobra.NoArgs,
+		RunE:  bisectMarkRunE(bisect.Bad),
+	}
+	bisectSkipCmd = &cobra.Command{
+		Use:   "skip",
+		Short: "Skip the current candidate and install the next one",
+		Args:  cobra.NoArgs,
+		RunE:  bisectMarkRunE(bisect.Skip),
+	}
+)
+
+// bisectRunCmd represents the "bisect run" subcommand.
+var bisectRunCmd = &cobra.Command{
+	Use:   "run <script> [args...]",
+	Short: "Test every candidate with a script until the bisection is done",
+	Long: `Install each candidate in turn and run <script> against it until the first bad
+version is found. A script ending in .lua runs with the candidate's 'nvim -l';
+"nvim" is the candidate's nvim; anything else runs with the candidate's bin
+directory first on PATH and ` + bisectNvimEnv + ` set to its nvim.
+
+The script's exit code is the verdict, as for 'git bisect run':
+
+  0         good
+  125       skip (cannot be tested)
+  1-127     bad
+  other     abort the bisection
+
+Commits that fail to build are skipped.
+
+Examples:
+  nvs bisect run ./repro.lua
+  nvs bisect run nvim --headless -u repro.vim -c q
+  nvs bisect run sh -c '"$NVS_BISECT_NVIM" --headless -l repro.lua'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: RunBisectRun,
+}
+
+// bisectStatusCmd represents the "bisect status" subcommand.
+var bisectStatusCmd = &cobra.Command{
+	Use:     "status",
+	Aliases: []string{"log"},
+	Short:   "Show the bisection in progress",
+	Args:    cobra.NoArgs,
+	RunE:    RunBisectStatus,
+}
+
+// bisectResetCmd represents the "bisect reset" subcommand.
+var bisectResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "End the bisection and uninstall its candidates",
+	Args:  cobra.NoArgs,
+	RunE:  RunBisectReset,
+}
+
+// RunBisectStart executes the bisect start command.
+func RunBisectStart(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(
+		cmd.Context(),
+		GetSettings().Duration(settings.KeyCommandTimeout),
+	)
+	defer cancel()
+
+	releasesOnly, _ := cmd.Flags().GetBool("releases-only")
+
+	state, err := GetBisectService().Start(ctx, args[0], args[1], releasesOnly)
+	if err != nil {
+		return err
+	}
+
+	return showBisectStep(ctx, state)
+}
+
+// bisectMarkRunE returns the RunE of the subcommand that gives the
+// current candidate verdict.
+func bisectMarkRunE(verdict bisect.Verdict) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		ctx, cancel := context.WithTimeout(
+			cmd.Context(),
+			GetSettings().Duration(settings.KeyCommandTimeout),
+		)
+		defer cancel()
+
+		state, err := GetBisectService().Mark(ctx, verdict)
+		if err != nil {
+			return err
+		}
+
+		return showBisectStep(ctx, state)
+	}
+}
+
+// showBisectStep installs the candidate to test next and tells the
+// user how to test it, or prints the result once there is none.
+func showBisectStep(ctx context.Context, state *bisectsvc.State) error {
+	if state.Done() {
+		printBisectResult(state)
+
+		return nil
+	}
+
+	name, err := checkoutBisectCandidate(ctx, state)
+	if err != nil {
+		return err
+	}
+
+	ui.Message.Infof(
+		"Testing %s (roughly %d steps left after this one)",
+		bisectLabel(state, state.Candidate),
+		state.StepsLeft,
+	)
+	ui.Message.Bulletf("Try it with 'nvs run %s'", name)
+	ui.Message.Bulletf("Then run 'nvs bisect good', 'nvs bisect bad' or 'nvs bisect skip'")
+
+	return nil
+}
+
+// checkoutBisectCandidate installs the current candidate behind a
+// spinner and returns its version name.
+func checkoutBisectCandidate(ctx context.Context, state *bisectsvc.State) (string, error) {
+	progressSpinner := ui.NewSpinner(
+		os.Stdout,
+		time.Duration(installSpinnerSpeed)*time.Millisecond,
+	)
+	progressSpinner.SetPrefix(ui.Message.Icons().Info + " ")
+	progressSpinner.SetSuffix(
+		fmt.Sprintf(" Installing %s...", bisectLabel(state, state.Candidate)),
+	)
+	progressSpinner.Start()
+
+	defer progressSpinner.Stop()
+
+	return GetBisectService().Checkout(ctx, func(phase string, progress int) {
+		progressSpinner.SetSuffix(" " + ui.FormatPhaseProgress(phase, progress))
+	})
+}
+
+// RunBisectRun executes the bisect run command.
+func RunBisectRun(cmd *cobra.Command, args []string) error {
+	// No command timeout: a run builds and tests many candidates and
+	// may take hours; Ctrl-C stops it between or during candidates.
+	ctx := cmd.Context()
+
+	// The spinner runs while a candidate installs and stops before
+	// the script writes to the terminal.
+	progressSpinner := ui.NewSpinner(
+		os.Stdout,
+		time.Duration(installSpinnerSpeed)*time.Millisecond,
+	)
+	progressSpinner.SetPrefix(ui.Message.Icons().Info + " ")
+
+	defer progressSpinner.Stop()
+
+	test := func(ctx context.Context, versionName string) (bisect.Verdict, error) {
+		progressSpinner.Stop()
+
+		return runBisectScript(ctx, versionName, args)
+	}
+
+	progress := func(phase string, progress int) {
+		progressSpinner.SetSuffix(" " + ui.FormatPhaseProgress(phase, progress))
+		progressSpinner.Start()
+	}
+
+	report := func(candidate string, verdict bisect.Verdict, state *bisectsvc.State) {
+		progressSpinner.Stop()
+		ui.Message.Bulletf("%s is %s", bisectLabel(state, candidate), verdict)
+	}
+
+	state, err := GetBisectService().Run(ctx, test, progress, report)
+	if err != nil {
+		return err
+	}
+
+	progressSpinner.Stop()
+	printBisectResult(state)
+
+	return nil
+}
+
+// runBisectScript runs the bisect script against the installed version
+// versionName and maps its exit code to a verdict.
+func runBisectScript(
+	ctx context.Context,
+	versionName string,
+	args []string,
+) (bisect.Verdict, error) {
+	nvimPath, err := getNvimBinaryPath(versionName)
+	if err != nil {
+		return "", fmt.Errorf("failed to find nvim binary: %w", err)
+	}
+
+	binDir := filepath.Dir(nvimPath)
+
+	program, programArgs := args[0], args[1:]
+	if strings.HasSuffix(program, ".lua") {
+		program, programArgs = nvimPath, append([]string{"-l"}, args...)
+	} else {
+		program, err = resolveExecProgram(program, nvimPath, binDir)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	log.Debugf("Testing %s with %s %v", versionName, program, programArgs)
+
+	//nolint:gosec // the user's own test script
+	script := exec.CommandContext(ctx, program, programArgs...)
+	script.Env = append(execEnv(os.Environ(), binDir), bisectNvimEnv+"="+nvimPath)
+	script.Stdin = os.Stdin
+	script.Stdout = os.Stdout
+	script.Stderr = os.Stderr
+
+	err = script.Run()
+	if err == nil {
+		return bisect.Good, nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return "", fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+
+	code := exitErr.ExitCode()
+
+	switch {
+	case code == bisectExitSkip:
+		return bisect.Skip, nil
+	case code > 0 && code <= bisectExitMaxBad:
+		return bisect.Bad, nil
+	default:
+		return "", fmt.Errorf("%w: %s exited with %v", ErrBisectScriptAborted, args[0], err)
+	}
+}
+
+// RunBisectStatus executes the bisect status command.
+func RunBisectStatus(_ *cobra.Command, _ []string) error {
+	state, err := GetBisectService().Status()
+	if err != nil {
+		return err
+	}
+
+	ui.Message.Infof(
+		"Bisecting %ss between %s (good) and %s (bad)",
+		state.Mode,
+		bisectLabel(state, state.Good),
+		bisectLabel(state, state.Bad),
+	)
+
+	for _, entry := range state.Log {
+		ui.Message.Bulletf("%s is %s", bisectLabel(state, entry.Candidate), entry.Verdict)
+	}
+
+	if state.Done() {
+		printBisectResult(state)
+
+		return nil
+	}
+
+	ui.Message.Infof(
+		"Testing %s (roughly %d steps left after this one)",
+		bisectLabel(state, state.Candidate),
+		state.StepsLeft,
+	)
+
+	return nil
+}
+
+// RunBisectReset executes the bisect reset command.
+func RunBisectReset(cmd *cobra.Command, _ []string) error {
+	state, err := GetBisectService().Reset(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if len(state.Installed) > 0 {
+		ui.Message.Warnf("Could not uninstall %s", strings.Join(state.Installed, ", "))
+	}
+
+	ui.Message.Successf("Bisection reset")
+
+	return nil
+}
+
+// printBisectResult prints the first bad version of a finished
+// bisection, or the versions it is among.
+func printBisectResult(state *bisectsvc.State) {
+	kind := "commit"
+	if state.Mode == bisectsvc.ModeRelease {
+		kind = "release"
+	}
+
+	if state.FirstBad != "" {
+		ui.Message.Successf("%s is the first bad %s", bisectLabel(state, state.FirstBad), kind)
+
+		if kind == "commit" {
+			ui.Message.Bulletf("%s/commit/%s", GetGitHubRepo().WebURL(), state.FirstBad)
+		}
+	} else {
+		ui.Message.Warnf("Skipped candidates left the first bad %s undecided; it is one of:", kind)
+
+		for _, suspect := range state.Suspects {
+			ui.Message.Bulletf("%s", bisectLabel(state, suspect))
+		}
+	}
+
+	ui.Message.Infof("Run 'nvs bisect reset' to clean up")
+}
+
+// bisectLabel shortens the commit hashes of a commit bisection;
+// release tags and other revisions are shown as they are.
+func bisectLabel(state *bisectsvc.State, revision string) string {
+	if state.Mode != bisectsvc.ModeCommit || !vtypes.IsCommitReference(revision) {
+		return revision
+	}
+
+	return shortHash(revision, constants.ShortCommitLen)
+}
+
+// init registers the bisectCmd and its subcommands with the root command.
+func init() {
+	bisectStartCmd.Flags().Bool("releases-only", false,
+		"Stop at two adjacent releases instead of bisecting the commits between them")
+	// Everything after the script belongs to the script.
+	bisectRunCmd.Flags().SetInterspersed(false)
+
+	bisectCmd.AddCommand(
+		bisectStartCmd,
+		bisectGoodCmd,
+		bisectBadCmd,
+		bisectSkipCmd,
+		bisectRunCmd,
+		bisectStatusCmd,
+		bisectResetCmd,
+	)
+	rootCmd.AddCommand(bisectCmd)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/domain/bisect"
+)
+
+func TestRunBisectScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as nvim")
+	}
+
+	oldVersionsDir := versionsDir
+	versionsDir = t.TempDir()
+
+	t.Cleanup(func() { versionsDir = oldVersionsDir })
+
+	// The fake nvim exits with the code in $BISECT_CODE.
+	binDir := filepath.Join(versionsDir, "v0.10.0", "bin")
+
+	err := os.MkdirAll(binDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(
+		filepath.Join(binDir, "nvim"),
+		[]byte("#!/bin/sh\nexit \"$BISECT_CODE\"\n"),
+		0o755,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		code int
+		want bisect.Verdict
+	}{
+		{0, bisect.Good},
+		{1, bisect.Bad},
+		{125, bisect.Skip},
+		{127, bisect.Bad},
+		{128, ""},
+	}
+
+	for _, tc := range cases {
+		t.Setenv("BISECT_CODE", strconv.Itoa(tc.code))
+
+		for _, args := range [][]string{
+			{"nvim", "--headless"},
+			{"repro.lua"},
+			{"sh", "-c", `exec "$NVS_BISECT_NVIM"`},
+		} {
+			got, err := runBisectScript(t.Context(), "v0.10.0", args)
+			if tc.want == "" {
+				if !errors.Is(err, ErrBisectScriptAborted) {
+					t.Errorf("%v exiting %d: error = %v, want an abort", args, tc.code, err)
+				}
+
+				continue
+			}
+
+			if err != nil || got != tc.want {
+				t.Errorf("%v exiting %d = %q, %v; want %q", args, tc.code, got, err, tc.want)
+			}
+		}
+	}
+}
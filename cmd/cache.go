@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/settings"
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/archivestore"
+	"github.com/y3owk1n/nvs/internal/infra/depscache"
+	"github.com/y3owk1n/nvs/internal/ui"
+)
+
+// cacheCmd represents the "cache" command.
+// It manages the archive cache: release archives kept by checksum so
+// that reinstalling a version does not download it again; and the
+// dependency cache, which source builds share their third-party
+// dependencies through.
+//
+// Example usage:
+//
+//	nvs cache ls
+//	nvs cache deps
+//	nvs cache prune
+//	nvs cache clear
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean the downloaded archive cache",
+	Long: `Downloaded release archives are kept under NVS_CACHE_DIR/archives, keyed by
+their SHA256, so uninstalling and reinstalling a version (or installing it
+into a fresh config dir) copies the archive from disk instead of
+downloading it. The checksum is verified against the cached file as for a
+download.
+
+The cache is pruned automatically after each download: archives unused for
+longer than archive_cache_max_age go first, then the least recently used
+ones until it fits in archive_cache_max_mb.
+
+Source builds build Neovim's third-party dependencies (LuaJIT, libuv,
+tree-sitter, ...) under NVS_CACHE_DIR/deps, once per set of pinned
+versions (cmake.deps/deps.txt), and reuse them for every commit that pins
+the same set. After each new set is built, sets unused for 30 days go,
+then the least recently used ones until 5 remain.
+
+prune and clear apply to both caches.
+
+With no subcommand, lists the cached archives.`,
+	Args: cobra.NoArgs,
+	RunE: RunCacheList,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List cached archives",
+	Args:    cobra.NoArgs,
+	RunE:    RunCacheList,
+}
+
+var cacheDepsCmd = &cobra.Command{
+	Use:   "deps",
+	Short: "List cached source build dependency sets",
+	Args:  cobra.NoArgs,
+	RunE:  RunCacheDeps,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove archives and dependency sets beyond the caches' limits",
+	Args:  cobra.NoArgs,
+	RunE:  RunCachePrune,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached archive, dependency set and interrupted download",
+	Args:  cobra.NoArgs,
+	RunE:  RunCacheClear,
+}
+
+// cacheEntryJSON is the --json shape of one cached archive.
+//
+//nolint:tagliatelle
+type cacheEntryJSON struct {
+	Name     string    `json:"name"`
+	SHA256   string    `json:"sha256"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+	Path     string    `json:"path"`
+}
+
+// depsEntryJSON is the --json shape of one cached dependency set.
+//
+//nolint:tagliatelle
+type depsEntryJSON struct {
+	Key      string    `json:"key"`
+	Ready    bool      `json:"ready"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+	Path     string    `json:"path"`
+}
+
+// RunCacheList executes the cache ls command.
+func RunCacheList(cmd *cobra.Command, _ []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	entries, err := GetArchiveStore().List()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		out := make([]cacheEntryJSON, 0, len(entries))
+		for _, entry := range entries {
+			out = append(out, cacheEntryJSON{
+				Name:     entry.Name,
+				SHA256:   entry.SHA256,
+				Size:     entry.Size,
+				LastUsed: entry.LastUsed.UTC(),
+				Path:     entry.Path,
+			})
+		}
+
+		return outputJSON(out)
+	}
+
+	if len(entries) == 0 {
+		ui.Message.Infof("The archive cache is empty (%s).", GetArchiveStore().Dir())
+
+		if !GetSettings().Bool(settings.KeyArchiveCache) {
+			ui.Message.Infof("Caching is off; enable it with 'nvs settings set archive_cache true'.")
+		}
+
+		return nil
+	}
+
+	var total int64
+
+	tbl := ui.Table.New("Archive", "SHA256", "Size", "Last used")
+	for _, entry := range entries {
+		total += entry.Size
+
+		tbl.Row(
+			entry.Name,
+			ui.Message.Dim(shortHash(entry.SHA256, constants.ShortHashLength)),
+			formatSize(entry.Size),
+			entry.LastUsed.Format("2006-01-02 15:04"),
+		)
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout, tbl.Render(ui.Style.Palette()))
+
+	ui.Message.Infof(
+		"%d archive(s), %s of %d MB in %s",
+		len(entries),
+		formatSize(total),
+		GetSettings().Int(settings.KeyArchiveCacheMaxMB),
+		GetArchiveStore().Dir(),
+	)
+
+	return nil
+}
+
+// RunCacheDeps executes the cache deps command.
+func RunCacheDeps(cmd *cobra.Command, _ []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	entries, err := GetDepsCache().List()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		out := make([]depsEntryJSON, 0, len(entries))
+		for _, entry := range entries {
+			out = append(out, depsEntryJSON{
+				Key:      entry.Key,
+				Ready:    entry.Ready,
+				Size:     entry.Size,
+				LastUsed: entry.LastUsed.UTC(),
+				Path:     entry.Path,
+			})
+		}
+
+		return outputJSON(out)
+	}
+
+	if len(entries) == 0 {
+		ui.Message.Infof("The dependency cache is empty (%s).", GetDepsCache().Dir())
+
+		return nil
+	}
+
+	var total int64
+
+	tbl := ui.Table.New("Dependency set", "Size", "Last used")
+	for _, entry := range entries {
+		total += entry.Size
+
+		key := entry.Key
+		if !entry.Ready {
+			key += ui.Message.Dim(" (incomplete)")
+		}
+
+		tbl.Row(key, formatSize(entry.Size), entry.LastUsed.Format("2006-01-02 15:04"))
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout, tbl.Render(ui.Style.Palette()))
+
+	ui.Message.Infof(
+		"%d dependency set(s), %s in %s",
+		len(entries),
+		formatSize(total),
+		GetDepsCache().Dir(),
+	)
+
+	return nil
+}
+
+// RunCachePrune executes the cache prune command.
+func RunCachePrune(_ *cobra.Command, _ []string) error {
+	removed, err := GetArchiveStore().Prune()
+	removedDeps, depsErr := GetDepsCache().Prune()
+	reportRemoved(removed, removedDeps)
+
+	if err != nil {
+		return fmt.Errorf("failed to prune archive cache: %w", err)
+	}
+
+	if depsErr != nil {
+		return fmt.Errorf("failed to prune dependency cache: %w", depsErr)
+	}
+
+	return nil
+}
+
+// RunCacheClear executes the cache clear command.
+func RunCacheClear(_ *cobra.Command, _ []string) error {
+	removed, err := GetArchiveStore().Clear()
+	if err != nil {
+		return err
+	}
+
+	// Sets a build is using right now are kept.
+	removedDeps, err := GetDepsCache().Clear()
+	reportRemoved(removed, removedDeps)
+
+	if err != nil {
+		return fmt.Errorf("failed to clear dependency cache: %w", err)
+	}
+
+	// Partial downloads are only useful for resuming; clearing the
+	// cache is a clean slate.
+	err = os.RemoveAll(GetPartialDownloadDir())
+	if err != nil {
+		return fmt.Errorf("failed to remove interrupted downloads: %w", err)
+	}
+
+	return nil
+}
+
+// reportRemoved prints what prune or clear removed.
+func reportRemoved(archives []archivestore.Entry, depSets []depscache.Entry) {
+	if len(archives) == 0 && len(depSets) == 0 {
+		ui.Message.Infof("Nothing to remove.")
+
+		return
+	}
+
+	if len(archives) > 0 {
+		var total int64
+
+		for _, entry := range archives {
+			total += entry.Size
+
+			ui.Message.Bulletf("%s (%s)", entry.Name, formatSize(entry.Size))
+		}
+
+		ui.Message.Successf("Removed %d archive(s), freed %s", len(archives), formatSize(total))
+	}
+
+	if len(depSets) > 0 {
+		var total int64
+
+		for _, entry := range depSets {
+			total += entry.Size
+
+			ui.Message.Bulletf("dependency set %s (%s)", entry.Key, formatSize(entry.Size))
+		}
+
+		ui.Message.Successf(
+			"Removed %d dependency set(s), freed %s",
+			len(depSets),
+			formatSize(total),
+		)
+	}
+}
+
+// formatSize renders a byte count with a binary unit, e.g. "10.4 MB".
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for rest := size / unit; rest >= unit; rest /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	cacheCmd.Flags().Bool("json", false, "Output in JSON format (when listing)")
+	cacheListCmd.Flags().Bool("json", false, "Output in JSON format")
+	cacheDepsCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	cacheCmd.AddCommand(cacheListCmd, cacheDepsCmd, cachePruneCmd, cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import "testing"
+
+func TestFormatSize(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int64]string{
+		0:                "0 B",
+		1023:             "1023 B",
+		1024:             "1.0 KB",
+		10*1024*1024 + 1: "10.0 MB",
+		3 << 30:          "3.0 GB",
+	}
+
+	for size, want := range cases {
+		got := formatSize(size)
+		if got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
@@ -6,11 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
-	"time"
 	"unicode/utf8"
 
+	"github.com/y3owk1n/nvs/internal/app/settings"
 	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/infra/httpclient"
 	"github.com/y3owk1n/nvs/internal/log"
@@ -71,6 +70,13 @@ func ShowChangelog(ctx context.Context, oldCommit, newCommit string) error {
 		return nil
 	}
 
+	// The changelog is a nicety; offline, skip it rather than fail.
+	if GetSettings().Bool(settings.KeyOffline) {
+		log.Debug("Offline: skipping changelog fetch")
+
+		return nil
+	}
+
 	log.Debugf(
 		"Fetching changelog from %s to %s",
 		shortHash(oldCommit, constants.ShortHashLength),
@@ -78,25 +84,21 @@ func ShowChangelog(ctx context.Context, oldCommit, newCommit string) error {
 	)
 
 	// Fetch comparison from GitHub API
-	// Note: GitHub API has rate limits (60 requests/hour for unauthenticated)
+	// Note: GitHub API has rate limits (60 requests/hour unless a token is set)
 	log.Debug("Fetching changelog from GitHub API (subject to rate limits)")
 
-	url := fmt.Sprintf(
-		"%s/%s...%s",
-		constants.GitHubCompareURL,
-		url.PathEscape(oldCommit),
-		url.PathEscape(newCommit),
-	)
+	compareURL := GetGitHubRepo().CompareURL(oldCommit, newCommit)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, compareURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", "nvs")
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	GetGitHubRepo().Authorize(req, githubToken())
 
-	client := httpclient.NewClient(constants.HTTPTimeoutSeconds * time.Second)
+	client := httpclient.NewClient(GetSettings().Duration(settings.KeyHTTPTimeout))
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -195,8 +197,8 @@ func ShowChangelog(ctx context.Context, oldCommit, newCommit string) error {
 	ui.Message.Mutedf(
 		"View full changelog: %s",
 		ui.Message.Accent(fmt.Sprintf(
-			"%s/neovim/neovim/compare/%s...%s",
-			constants.DefaultGitHubBaseURL,
+			"%s/compare/%s...%s",
+			GetGitHubRepo().WebURL(),
 			shortHash(oldCommit, constants.DisplayHashLength),
 			shortHash(newCommit, constants.DisplayHashLength),
 		)),
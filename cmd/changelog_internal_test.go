@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/infra/github"
+)
+
+// TestShowChangelog_Repo verifies the changelog is fetched from the
+// configured repository, here on a fake GitHub Enterprise API, with
+// the token.
+func TestShowChangelog_Repo(t *testing.T) {
+	var requested, auth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		auth = r.Header.Get("Authorization")
+
+		_ = json.NewEncoder(w).Encode(GitHubCompareResponse{TotalCommits: 0})
+	}))
+	defer server.Close()
+
+	originalRepo, originalToken := githubRepo, githubToken
+	githubRepo = github.NewRepo(server.URL+"/api/v3", "editors/neovim")
+	githubToken = func() string { return "s3cret" }
+
+	t.Cleanup(func() {
+		githubRepo, githubToken = originalRepo, originalToken
+	})
+
+	err := ShowChangelog(t.Context(), "aaa1111", "bbb2222")
+	if err != nil {
+		t.Fatalf("ShowChangelog() error = %v", err)
+	}
+
+	want := "/api/v3/repos/editors/neovim/compare/aaa1111...bbb2222"
+	if requested != want {
+		t.Errorf("ShowChangelog requested %q, want %q", requested, want)
+	}
+
+	if auth != "Bearer s3cret" {
+		t.Errorf("ShowChangelog Authorization = %q, want the bearer token", auth)
+	}
+}
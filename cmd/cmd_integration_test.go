@@ -3,8 +3,14 @@
 package cmd_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -57,6 +63,10 @@ func (m *mockVersionManagerForIntegration) Switch(v vtypes.Version) error {
 	return nil
 }
 
+func (m *mockVersionManagerForIntegration) SyncGlobalBin() error {
+	return nil
+}
+
 func (m *mockVersionManagerForIntegration) IsInstalled(v vtypes.Version) bool {
 	return m.installed[v.Name()]
 }
@@ -73,6 +83,12 @@ func (m *mockVersionManagerForIntegration) GetInstalledReleaseIdentifier(
 	return versionName, nil
 }
 
+func (m *mockVersionManagerForIntegration) GetManifest(
+	versionName string,
+) (vtypes.Manifest, error) {
+	return vtypes.Manifest{Identifier: versionName}, nil
+}
+
 // mockInstallerForIntegration implements installer.Installer for integration testing.
 type mockInstallerForIntegration struct {
 	installed map[string]bool
@@ -92,12 +108,33 @@ func (m *mockInstallerForIntegration) InstallRelease(
 
 func (m *mockInstallerForIntegration) BuildFromCommit(
 	ctx context.Context,
-	commit, dest string,
+	commit string,
+	profile vtypes.BuildProfile,
+	dest string,
 	progress installer.ProgressFunc,
 ) (string, error) {
 	return constants.TestCommitHash, nil
 }
 
+func (m *mockInstallerForIntegration) BuildFromRef(
+	ctx context.Context,
+	ref vtypes.SourceRef,
+	profile vtypes.BuildProfile,
+	dest string,
+	progress installer.ProgressFunc,
+) (string, error) {
+	m.installed[ref.DirName()] = true
+
+	return constants.TestCommitHash, nil
+}
+
+func (m *mockInstallerForIntegration) ResolveRef(
+	ctx context.Context,
+	ref vtypes.SourceRef,
+) (string, error) {
+	return constants.TestCommitHash, nil
+}
+
 func (m *mockInstallerForIntegration) UpgradeRelease(
 	ctx context.Context,
 	rel installer.ReleaseInfo,
@@ -287,6 +324,58 @@ func TestRunEnv_Source(t *testing.T) {
 	}
 }
 
+func TestRunSettings_FileLayer(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Setenv("NVS_CONFIG_DIR", tempDir)
+	t.Setenv("NVS_CACHE_DIR", tempDir)
+	t.Setenv("NVS_BIN_DIR", tempDir)
+	t.Setenv("NVS_CACHE_TTL", "")
+	t.Setenv("NVS_ROLLBACK_LIMIT", "2")
+
+	initErr := cmd.InitConfig()
+	if initErr != nil {
+		t.Fatalf("InitConfig failed: %v", initErr)
+	}
+
+	cobraCmd := &cobra.Command{}
+	cobraCmd.SetContext(t.Context())
+
+	for _, args := range [][]string{
+		{"cache_ttl", "42m"},
+		{"rollback_limit", "9"},
+	} {
+		err := cmd.RunSettingsSet(cobraCmd, args)
+		if err != nil {
+			t.Fatalf("RunSettingsSet(%v) failed: %v", args, err)
+		}
+	}
+
+	err := cmd.RunSettingsSet(cobraCmd, []string{"rollback_limit", "-1"})
+	if err == nil {
+		t.Error("RunSettingsSet accepted an invalid value")
+	}
+
+	// Re-initialize so the new file is picked up.
+	initErr = cmd.InitConfig()
+	if initErr != nil {
+		t.Fatalf("InitConfig failed: %v", initErr)
+	}
+
+	effective := cmd.GetSettings()
+
+	cacheTTL := effective.Get("cache_ttl")
+	if cacheTTL.Value != "42m" || cacheTTL.Source != "file" {
+		t.Errorf("cache_ttl = %q (%s), want 42m (file)", cacheTTL.Value, cacheTTL.Source)
+	}
+
+	// NVS_ROLLBACK_LIMIT outranks config.toml.
+	rollbackLimit := effective.Get("rollback_limit")
+	if rollbackLimit.Value != "2" || rollbackLimit.Source != "env" {
+		t.Errorf("rollback_limit = %q (%s), want 2 (env)", rollbackLimit.Value, rollbackLimit.Source)
+	}
+}
+
 func TestExecute(t *testing.T) {
 	originalArgs := os.Args
 	defer func() { os.Args = originalArgs }()
@@ -1180,6 +1269,40 @@ func TestReadVersionFile_NotFound(t *testing.T) {
 	}
 }
 
+// TestReadVersionFile_OtherSources tests that pins from other tools'
+// files are honoured, nearest directory first.
+func TestReadVersionFile_OtherSources(t *testing.T) {
+	tempDir := t.TempDir()
+
+	nestedDir := filepath.Join(tempDir, "project", "src")
+
+	err := os.MkdirAll(nestedDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(tempDir, ".nvs-version"), []byte("v0.9.5\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	toolVersions := filepath.Join(tempDir, "project", ".tool-versions")
+
+	err = os.WriteFile(toolVersions, []byte("neovim 0.10.2\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, foundFile, err := cmd.ReadVersionFile(nestedDir, false)
+	if err != nil {
+		t.Fatalf("ReadVersionFile failed: %v", err)
+	}
+
+	if version != "0.10.2" || foundFile != toolVersions {
+		t.Errorf("got %q from %q, want 0.10.2 from %q", version, foundFile, toolVersions)
+	}
+}
+
 // TestGetNightlyHistory tests getting nightly history.
 func TestGetNightlyHistory(t *testing.T) {
 	tempDir := t.TempDir()
@@ -1354,6 +1477,106 @@ func TestRunPin(t *testing.T) {
 	}
 }
 
+// TestRunPin_Lock tests that 'pin --lock' records the installed
+// archive and that 'use' refuses an install that no longer matches.
+func TestRunPin_Lock(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Setenv("NVS_CONFIG_DIR", tempDir)
+	t.Setenv("NVS_CACHE_DIR", tempDir)
+	t.Setenv("NVS_BIN_DIR", tempDir)
+
+	initErr := cmd.InitConfig()
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	versionDir := filepath.Join(cmd.GetVersionsDir(), constants.TestVersion)
+
+	writeManifest := func(sha string) {
+		t.Helper()
+
+		data, err := json.Marshal(vtypes.Manifest{
+			Schema:     vtypes.ManifestSchema,
+			Source:     vtypes.ManifestSourceRelease,
+			Identifier: constants.TestVersion,
+			Tag:        constants.TestVersion,
+			Asset:      &vtypes.ManifestAsset{Name: "nvim-test.tar.gz", SHA256: sha},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.MkdirAll(versionDir, 0o755)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(versionDir, constants.ManifestFileName), data, 0o644)
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeManifest("1111")
+
+	projectDir := filepath.Join(tempDir, "project")
+
+	err := os.MkdirAll(projectDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(projectDir)
+
+	pinCmd := &cobra.Command{}
+	pinCmd.Flags().Bool("global", false, "")
+	pinCmd.Flags().Bool("lock", true, "")
+	pinCmd.SetContext(t.Context())
+
+	err = cmd.RunPin(pinCmd, []string{testStable})
+	if !errors.Is(err, cmd.ErrLockNeedsTag) {
+		t.Errorf("RunPin --lock stable error = %v, want ErrLockNeedsTag", err)
+	}
+
+	err = cmd.RunPin(pinCmd, []string{constants.TestVersion})
+	if err != nil {
+		t.Fatalf("RunPin --lock failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectDir, constants.LockFileName))
+	if err != nil {
+		t.Fatalf("Failed to read lock file: %v", err)
+	}
+
+	var lock struct {
+		Version string `json:"version"`
+		Assets  map[string]struct {
+			SHA256 string `json:"sha256"`
+		} `json:"assets"`
+	}
+
+	err = json.Unmarshal(data, &lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	if lock.Version != constants.TestVersion || lock.Assets[platform].SHA256 != "1111" {
+		t.Errorf("lock file = %s", data)
+	}
+
+	writeManifest("2222")
+
+	useCmd := &cobra.Command{}
+	useCmd.Flags().Bool("force", true, "")
+	useCmd.SetContext(t.Context())
+
+	err = cmd.RunUse(useCmd, nil)
+	if !errors.Is(err, installer.ErrLockMismatch) {
+		t.Errorf("RunUse with a changed archive error = %v, want ErrLockMismatch", err)
+	}
+}
+
 // TestRunRollback_NoHistory tests rollback when no history exists.
 func TestRunRollback_NoHistory(t *testing.T) {
 	tempDir := t.TempDir()
@@ -2573,3 +2796,181 @@ func TestRunUpgrade_Pick(t *testing.T) {
 		t.Errorf("RunUpgrade with --pick failed: %v", err)
 	}
 }
+
+func TestRunAlias_ProtectsTarget(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Setenv("NVS_CONFIG_DIR", tempDir)
+	t.Setenv("NVS_CACHE_DIR", tempDir)
+	t.Setenv("NVS_BIN_DIR", tempDir)
+
+	initErr := cmd.InitConfig()
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+
+	versionDir := filepath.Join(cmd.GetVersionsDir(), testV100)
+
+	err := os.MkdirAll(versionDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cobraCmd := &cobra.Command{}
+	cobraCmd.Flags().Bool("json", false, "")
+	cobraCmd.Flags().Bool("pick", false, "")
+	cobraCmd.Flags().Bool("force", false, "")
+	cobraCmd.SetContext(t.Context())
+
+	err = cmd.RunAlias(cobraCmd, []string{"work", testV100})
+	if err != nil {
+		t.Fatalf("RunAlias failed: %v", err)
+	}
+
+	err = cmd.RunAliasList(cobraCmd, nil)
+	if err != nil {
+		t.Fatalf("RunAliasList failed: %v", err)
+	}
+
+	err = cmd.RunUninstall(cobraCmd, []string{"work"})
+	if !errors.Is(err, versionsvc.ErrAliasTarget) {
+		t.Fatalf("RunUninstall error = %v, want ErrAliasTarget", err)
+	}
+
+	_, statErr := os.Stat(versionDir)
+	if statErr != nil {
+		t.Fatalf("aliased version was removed: %v", statErr)
+	}
+
+	_ = cobraCmd.Flags().Set("force", "true")
+
+	err = cmd.RunUninstall(cobraCmd, []string{"work"})
+	if err != nil {
+		t.Fatalf("RunUninstall --force failed: %v", err)
+	}
+
+	aliases, err := cmd.GetVersionService().Aliases()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(aliases) != 0 {
+		t.Errorf("aliases after forced uninstall = %v, want none", aliases)
+	}
+}
+
+func TestRunInstall_LocalReleaseSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("release archives for Windows are zip files")
+	}
+
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "releases")
+
+	t.Setenv("NVS_CONFIG_DIR", tempDir)
+	t.Setenv("NVS_CACHE_DIR", tempDir)
+	t.Setenv("NVS_BIN_DIR", tempDir)
+	t.Setenv("NVS_OFFLINE", "1")
+	t.Setenv("NVS_RELEASE_SOURCE", sourceDir)
+	t.Setenv("NVS_TEST_MODE", "1")
+
+	assetName := "nvim-" + map[string]string{
+		"linux/amd64":  "linux-x86_64",
+		"linux/arm64":  "linux-arm64",
+		"darwin/amd64": "macos-x86_64",
+		"darwin/arm64": "macos-arm64",
+	}[runtime.GOOS+"/"+runtime.GOARCH] + ".tar.gz"
+
+	archive := writeNvimArchive(t, filepath.Join(sourceDir, "v0.10.2", assetName))
+	sum := sha256.Sum256(archive)
+
+	err := os.WriteFile(
+		filepath.Join(sourceDir, "v0.10.2", "shasum.txt"),
+		[]byte(hex.EncodeToString(sum[:])+"  "+assetName+"\n"),
+		0o644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := `{"schema": 1, "releases": [{"tag": "v0.10.2", "published_at": "2024-10-03T00:00:00Z",
+		"assets": [{"name": "` + assetName + `"}, {"name": "shasum.txt"}]}]}`
+
+	err = os.WriteFile(filepath.Join(sourceDir, "index.json"), []byte(index), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalVersionService := cmd.GetVersionService()
+	defer func() {
+		cmd.SetVersionServiceForTesting(originalVersionService)
+	}()
+
+	err = cmd.InitConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cobraCmd := &cobra.Command{}
+	cobraCmd.Flags().Bool("pick", false, "")
+	cobraCmd.SetContext(t.Context())
+
+	err = cmd.RunInstall(cobraCmd, []string{"v0.10.2"})
+	if err != nil {
+		t.Fatalf("RunInstall from a local release source failed: %v", err)
+	}
+
+	_, err = os.Stat(filepath.Join(cmd.GetVersionsDir(), "v0.10.2"))
+	if err != nil {
+		t.Errorf("v0.10.2 was not installed: %v", err)
+	}
+}
+
+// writeNvimArchive writes a release archive holding a stub nvim to
+// path and returns its bytes.
+func writeNvimArchive(t *testing.T, path string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	stub := []byte("#!/bin/sh\necho 'NVIM v0.10.2'\n")
+
+	for _, hdr := range []*tar.Header{
+		{Name: "nvim/", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: "nvim/bin/", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: "nvim/bin/nvim", Typeflag: tar.TypeReg, Mode: 0o755, Size: int64(len(stub))},
+	} {
+		err := tw.WriteHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hdr.Typeflag == tar.TypeReg {
+			_, err = tw.Write(stub)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	err := tw.Close()
+	if err == nil {
+		err = gzw.Close()
+	}
+
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+
+	if err == nil {
+		err = os.WriteFile(path, buf.Bytes(), 0o644)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
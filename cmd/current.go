@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/settings"
 	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/domain/vtypes"
 	"github.com/y3owk1n/nvs/internal/log"
@@ -31,25 +32,33 @@ var currentCmd = &cobra.Command{
 // currentInfo is the structured result of RunCurrent. The shape
 // is the public JSON contract (TestRunCurrent_JSON asserts on
 // it), so the struct fields and their JSON tags must not
-// change shape.
+// change shape; new fields are only ever added.
 type currentInfo struct {
-	Name      string `json:"name"`
-	Type      string `json:"type"`
-	Version   string `json:"version,omitempty"`
-	Commit    string `json:"commit,omitempty"`
-	Published string `json:"published,omitempty"`
+	Name      string           `json:"name"`
+	Type      string           `json:"type"`
+	Version   string           `json:"version,omitempty"`
+	Commit    string           `json:"commit,omitempty"`
+	Published string           `json:"published,omitempty"`
+	Manifest  *vtypes.Manifest `json:"manifest,omitempty"`
+	Source    string           `json:"source,omitempty"`
 }
 
 // RunCurrent executes the current command.
 func RunCurrent(cmd *cobra.Command, _ []string) error {
 	log.Debug("Executing current command")
 
-	current, err := GetVersionService().Current()
-	if err != nil {
-		return fmt.Errorf("error getting current version: %w", err)
+	// A version chosen with 'nvs shell' wins for this session.
+	current, fromShell := shellVersion()
+	if !fromShell {
+		var err error
+
+		current, err = GetVersionService().Current()
+		if err != nil {
+			return fmt.Errorf("error getting current version: %w", err)
+		}
 	}
 
-	log.Debugf("Current version detected: %s", current.Name())
+	log.Debugf("Current version detected: %s (shell override: %t)", current.Name(), fromShell)
 
 	jsonOutput, flagErr := cmd.Flags().GetBool("json")
 	if flagErr != nil {
@@ -69,7 +78,14 @@ func RunCurrent(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
+	if fromShell {
+		info.Source = "shell"
+		body += "\n" + ui.Message.Dim("Set for this shell by 'nvs shell' ("+shellVersionEnv+")")
+	}
+
 	if jsonOutput {
+		info.Manifest = readManifest(current.Name())
+
 		return outputJSON(info)
 	}
 
@@ -101,6 +117,17 @@ func populateCurrentInfo(
 		info.Type = "stable"
 
 		stable, findErr := GetVersionService().FindStable(cmd.Context())
+		if findErr != nil && GetSettings().Bool(settings.KeyOffline) {
+			manifest := readManifest(constants.Stable)
+			if manifest != nil && manifest.Tag != "" {
+				log.Debugf("Offline without cached releases; using the install manifest")
+
+				info.Version = manifest.Tag
+
+				return renderStableBody(manifest.Tag), nil
+			}
+		}
+
 		if findErr != nil {
 			log.Warnf("Error fetching latest stable release: %v", findErr)
 
@@ -123,6 +150,17 @@ func populateCurrentInfo(
 		info.Type = "nightly"
 
 		nightly, findErr := GetVersionService().FindNightly(cmd.Context())
+		if findErr != nil && GetSettings().Bool(settings.KeyOffline) {
+			manifest := readManifest(constants.Nightly)
+			if manifest != nil && manifest.Commit != "" {
+				log.Debugf("Offline without cached releases; using the install manifest")
+
+				info.Commit = shortHash(manifest.Commit, constants.ShortCommitLen)
+
+				return renderNightlyBody(info.Commit, "unknown (offline)"), nil
+			}
+		}
+
 		if findErr != nil {
 			log.Warnf("Error fetching latest nightly release: %v", findErr)
 
@@ -147,7 +185,7 @@ func populateCurrentInfo(
 
 		return renderNightlyBody(shortCommit, publishedStr), nil
 	default:
-		isCommitHash := GetVersionService().IsCommitReference(current.Name())
+		isCommitHash := vtypes.IsCommitBuildName(current.Name())
 		log.Debugf("isCommitHash: %t", isCommitHash)
 
 		info.Name = current.Name()
@@ -161,6 +199,9 @@ func populateCurrentInfo(
 		}
 
 		info.Type = "tag"
+		if current.Type() == vtypes.TypeSourceRef {
+			info.Type = current.Type().String()
+		}
 
 		log.Debugf("Displaying custom version: %s", current.Name())
 
@@ -1,15 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/mirror"
 	"github.com/y3owk1n/nvs/internal/log"
 	"github.com/y3owk1n/nvs/internal/ui"
 )
@@ -27,6 +30,12 @@ type CheckResult struct {
 	Status string `json:"status"`
 }
 
+// MirrorResult is a download mirror's health in --json output.
+type MirrorResult struct {
+	URL string `json:"url"`
+	mirror.Stat
+}
+
 // checkOutcome is the in-memory representation of a check
 // after it has run. The Status field mirrors what is emitted
 // in --json mode (so the public JSON contract is preserved),
@@ -58,6 +67,13 @@ func RunDoctor(cmd *cobra.Command, _ []string) error {
 		{"Permissions", checkPermissions},
 	}
 
+	if GetDownloadMirrors() != nil {
+		checks = append(checks, struct {
+			name  string
+			check func() (string, error)
+		}{"Mirrors", checkMirrors})
+	}
+
 	outcomes := make([]checkOutcome, 0, len(checks))
 	for _, check := range checks {
 		log.Debugf("Running doctor check: %s", check.name)
@@ -103,7 +119,12 @@ func renderDoctorJSON(outcomes []checkOutcome) error {
 		}
 	}
 
-	jsonErr := outputJSON(map[string]any{"checks": results, "issues": issues})
+	out := map[string]any{"checks": results, "issues": issues}
+	if GetDownloadMirrors() != nil {
+		out["mirrors"] = mirrorResults()
+	}
+
+	jsonErr := outputJSON(out)
 	if jsonErr != nil {
 		return jsonErr
 	}
@@ -127,6 +148,10 @@ func renderDoctorText(outcomes []checkOutcome) error {
 	_, _ = fmt.Fprint(os.Stdout, ui.Banner.Logo())
 	_, _ = fmt.Fprint(os.Stdout, ui.Panel.Section("System health", renderDoctorBody(outcomes)))
 
+	if GetDownloadMirrors() != nil {
+		_, _ = fmt.Fprint(os.Stdout, ui.Panel.Section("Mirrors", renderMirrorTable()))
+	}
+
 	issues := collectDoctorIssues(outcomes)
 	if len(issues) > 0 {
 		ui.Message.Warnf("%d issue(s) found:", len(issues))
@@ -334,6 +359,76 @@ func checkPermissions() (string, error) {
 	return "", nil
 }
 
+// checkMirrors warns about download mirrors whose last request
+// failed, as recorded by the downloader.
+func checkMirrors() (string, error) {
+	var failing []string
+
+	for _, result := range mirrorResults() {
+		if result.Failing() {
+			failing = append(failing, fmt.Sprintf("%s (%s)", result.URL, result.LastError))
+		}
+	}
+
+	if len(failing) == 0 {
+		return "", nil
+	}
+
+	return fmt.Sprintf(
+		"%d of %d mirror(s) failing: %s",
+		len(failing),
+		len(GetDownloadMirrors().Bases()),
+		strings.Join(failing, ", "),
+	), nil
+}
+
+// mirrorResults returns the recorded health of each configured
+// mirror, in failover order. A mirror nvs has not used yet has a
+// zero Stat.
+func mirrorResults() []MirrorResult {
+	mirrors := GetDownloadMirrors()
+
+	stats, err := mirror.ReadStats(mirrors.StatsPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf("Failed to read mirror stats: %v", err)
+	}
+
+	results := make([]MirrorResult, 0, len(mirrors.Bases()))
+	for _, base := range mirrors.Bases() {
+		results = append(results, MirrorResult{URL: base, Stat: stats[base]})
+	}
+
+	return results
+}
+
+// renderMirrorTable renders mirrorResults as the body of the
+// Mirrors section.
+func renderMirrorTable() string {
+	tbl := ui.Table.New("Mirror", "Requests", "Failures", "Latency", "Last error")
+
+	for _, result := range mirrorResults() {
+		latency := "-"
+		if result.LatencyMS > 0 {
+			latency = fmt.Sprintf("%d ms", result.LatencyMS)
+		}
+
+		lastError := ui.Message.Dim("-")
+		if result.Failing() {
+			lastError = result.LastError
+		}
+
+		tbl.Row(
+			result.URL,
+			strconv.Itoa(result.Requests),
+			strconv.Itoa(result.Failures),
+			latency,
+			lastError,
+		)
+	}
+
+	return tbl.Render(ui.Style.Palette())
+}
+
 func init() {
 	doctorCmd.Flags().Bool("json", false, "Output in JSON format")
 	rootCmd.AddCommand(doctorCmd)
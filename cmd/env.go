@@ -13,6 +13,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/settings"
 	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/log"
 	"github.com/y3owk1n/nvs/internal/ui"
@@ -20,17 +21,21 @@ import (
 )
 
 const (
-	sectionPaths   = "Paths"
-	sectionTheming = "Theming"
+	sectionPaths    = "Paths"
+	sectionBehavior = "Behavior"
+	sectionTimeouts = "Timeouts"
+	sectionLogging  = "Logging"
+	sectionTheming  = "Theming"
 )
 
 // envCmd represents the "env" command.
-// It prints the NVS environment configuration variables and
-// their resolved values: paths (NVS_CONFIG_DIR, NVS_CACHE_DIR,
-// NVS_BIN_DIR), behavior toggles (NVS_GITHUB_MIRROR,
-// NVS_USE_GLOBAL_CACHE), logger settings (NVS_LOG,
-// NVS_LOG_FILE), and the active theme (NVS_COLOR_* and
-// NVS_PICKER_*).
+// It prints the NVS environment configuration variables, their
+// resolved values and the layer each value came from: paths
+// (NVS_CONFIG_DIR, NVS_CACHE_DIR, NVS_BIN_DIR), behavior toggles
+// (NVS_GITHUB_MIRROR, NVS_USE_GLOBAL_CACHE, NVS_PIN_SOURCES,
+// NVS_CACHE_TTL, NVS_ROLLBACK_LIMIT), timeouts (NVS_*_TIMEOUT),
+// logger settings (NVS_LOG, NVS_LOG_FILE), and the active theme
+// (NVS_COLOR_* and NVS_PICKER_*).
 //
 // Example usage:
 //
@@ -49,9 +54,15 @@ var envCmd = &cobra.Command{
 	Short: "Print NVS env configurations",
 	Long: `Prints the env configuration used by NVS.
 
+Each value is shown with its source: "flag", "env", "file"
+(config.toml, see 'nvs settings') or "default".
+
 Variables shown:
   Paths     NVS_CONFIG_DIR, NVS_CACHE_DIR, NVS_BIN_DIR
-  Behavior  NVS_GITHUB_MIRROR, NVS_USE_GLOBAL_CACHE
+  Behavior  NVS_GITHUB_MIRROR, NVS_USE_GLOBAL_CACHE, NVS_PIN_SOURCES,
+            NVS_CACHE_TTL, NVS_ROLLBACK_LIMIT
+  Timeouts  NVS_COMMAND_TIMEOUT, NVS_API_TIMEOUT, NVS_HTTP_TIMEOUT,
+            NVS_DOWNLOAD_TIMEOUT
   Logging   NVS_LOG, NVS_LOG_FILE
   Theming   NVS_COLOR_*, NVS_PICKER_* (resolved to the active palette)`,
 	RunE: RunEnv,
@@ -71,6 +82,9 @@ type envVar struct {
 
 	Name  string
 	Value string
+	// Source is the layer Value came from: flag, env, file or
+	// default (see settings.Source).
+	Source string
 	// IsPath is true for the three NVS_*_DIR vars. The
 	// --source path emits only these (see envCmd doc).
 	IsPath bool
@@ -108,10 +122,12 @@ func RunEnv(cmd *cobra.Command, _ []string) error {
 }
 
 // collectEnvVars resolves every env var nvs cares about to its
-// current effective value. For unset optional vars we substitute
-// a human-readable placeholder ("(unset)" or the default) so the
-// table never has empty cells — a blank value is easy to misread
-// as "set to empty string", which is a different thing.
+// current effective value and the layer it came from (flag, env,
+// file or default; see 'nvs settings'). For unset optional vars we
+// substitute a human-readable placeholder ("(unset)" or the
+// default) so the table never has empty cells — a blank value is
+// easy to misread as "set to empty string", which is a different
+// thing.
 func collectEnvVars() []envVar {
 	configDir := filepath.Dir(GetVersionsDir())
 	cacheDir := filepath.Dir(GetCacheFilePath())
@@ -119,18 +135,50 @@ func collectEnvVars() []envVar {
 
 	log.Debug("resolved paths", "config", configDir, "cache", cacheDir, "bin", binDir)
 
-	githubMirror := os.Getenv("NVS_GITHUB_MIRROR")
+	effective := GetSettings()
+
+	// NVS_CONFIG_DIR is not a setting: config.toml lives inside
+	// it, so it can only come from the environment or the
+	// platform default.
+	configDirSource := settings.SourceDefault
+	if os.Getenv("NVS_CONFIG_DIR") != "" {
+		configDirSource = settings.SourceEnv
+	}
+
+	githubMirror := effective.String(settings.KeyGitHubMirror)
 	if githubMirror == "" {
 		githubMirror = "(unset, using github.com)"
 	}
 
-	// Show the EFFECTIVE boolean state, after validation. If
-	// the user set NVS_USE_GLOBAL_CACHE to a typo, parseBoolEnv
-	// has already warned at startup; here we just report the
-	// effective value.
-	resolved, _ := parseBoolEnv("NVS_USE_GLOBAL_CACHE", os.Getenv("NVS_USE_GLOBAL_CACHE"))
+	githubAPIURL := effective.String(settings.KeyGitHubAPIURL)
+	if githubAPIURL == "" {
+		githubAPIURL = "(unset, using api.github.com)"
+	}
+
+	sourceRepoURL := effective.String(settings.KeySourceRepoURL)
+	if sourceRepoURL == "" {
+		sourceRepoURL = "(unset, using " + GetGitHubRepo().CloneURL() + ")"
+	}
+
+	buildCMakeFlags := effective.String(settings.KeyBuildCMakeFlags)
+	if buildCMakeFlags == "" {
+		buildCMakeFlags = "(unset)"
+	}
+
+	buildDepsFlags := effective.String(settings.KeyBuildDepsFlags)
+	if buildDepsFlags == "" {
+		buildDepsFlags = "(unset)"
+	}
+
+	buildName := effective.String(settings.KeyBuildName)
+	if buildName == "" {
+		buildName = "(unset, derived from the profile)"
+	}
 
-	useGlobalCache := strconv.FormatBool(resolved)
+	s3Endpoint := effective.String(settings.KeyS3Endpoint)
+	if s3Endpoint == "" {
+		s3Endpoint = "(unset, using AWS)"
+	}
 
 	// Show the EFFECTIVE log level (after parsing, after
 	// fallbacks) rather than the raw env var, so an invalid
@@ -139,21 +187,152 @@ func collectEnvVars() []envVar {
 	// typed.
 	logLevel := log.GetLevel().String()
 
-	logFile := os.Getenv("NVS_LOG_FILE")
+	signingKey := effective.String(settings.KeySigningKey)
+	if signingKey == "" {
+		signingKey = "(unset, signatures not checked)"
+	}
+
+	knownHashes := effective.String(settings.KeyKnownHashes)
+	if knownHashes == "" {
+		knownHashes = "(unset)"
+	}
+
+	logFile := effective.String(settings.KeyLogFile)
 	if logFile == "" {
 		logFile = "(unset, stderr only)"
 	}
 
-	return appendTheming(
-		[]envVar{
-			{Section: sectionPaths, Name: "NVS_CONFIG_DIR", Value: configDir, IsPath: true},
-			{Section: sectionPaths, Name: "NVS_CACHE_DIR", Value: cacheDir, IsPath: true},
-			{Section: sectionPaths, Name: "NVS_BIN_DIR", Value: binDir, IsPath: true},
-			{Section: "Behavior", Name: "NVS_GITHUB_MIRROR", Value: githubMirror},
-			{Section: "Behavior", Name: "NVS_USE_GLOBAL_CACHE", Value: useGlobalCache},
-			{Section: "Logging", Name: "NVS_LOG", Value: logLevel},
-			{Section: "Logging", Name: "NVS_LOG_FILE", Value: logFile},
+	// setting builds a row from the effective value of key,
+	// after validation: an invalid NVS_* value has already
+	// warned at startup and fallen back to the next layer.
+	setting := func(section, key, value string) envVar {
+		resolved := effective.Get(key)
+
+		return envVar{
+			Section: section,
+			Name:    resolved.Env,
+			Value:   value,
+			Source:  string(resolved.Source),
+		}
+	}
+
+	paths := []envVar{
+		{
+			Section: sectionPaths,
+			Name:    "NVS_CONFIG_DIR",
+			Value:   configDir,
+			Source:  string(configDirSource),
 		},
+		setting(sectionPaths, settings.KeyCacheDir, cacheDir),
+		setting(sectionPaths, settings.KeyBinDir, binDir),
+	}
+
+	for idx := range paths {
+		paths[idx].IsPath = true
+	}
+
+	return appendTheming(
+		append(paths,
+			setting(sectionBehavior, settings.KeyGitHubMirror, githubMirror),
+			setting(sectionBehavior, settings.KeyGitHubAPIURL, githubAPIURL),
+			setting(sectionBehavior, settings.KeyGitHubRepo, effective.String(settings.KeyGitHubRepo)),
+			setting(sectionBehavior, settings.KeySourceRepoURL, sourceRepoURL),
+			setting(
+				sectionBehavior,
+				settings.KeyBuildType,
+				effective.String(settings.KeyBuildType),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyBuildSanitize,
+				strconv.FormatBool(effective.Bool(settings.KeyBuildSanitize)),
+			),
+			setting(sectionBehavior, settings.KeyBuildCMakeFlags, buildCMakeFlags),
+			setting(sectionBehavior, settings.KeyBuildDepsFlags, buildDepsFlags),
+			setting(sectionBehavior, settings.KeyBuildName, buildName),
+			setting(
+				sectionBehavior,
+				settings.KeyReleaseSource,
+				effective.String(settings.KeyReleaseSource),
+			),
+			setting(sectionBehavior, settings.KeyS3Endpoint, s3Endpoint),
+			setting(
+				sectionBehavior,
+				settings.KeyUseGlobalCache,
+				strconv.FormatBool(effective.Bool(settings.KeyUseGlobalCache)),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyPinSources,
+				strings.Join(activePinSources().Names(), ","),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyShims,
+				strconv.FormatBool(effective.Bool(settings.KeyShims)),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyOffline,
+				strconv.FormatBool(effective.Bool(settings.KeyOffline)),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyArchiveCache,
+				strconv.FormatBool(effective.Bool(settings.KeyArchiveCache)),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyArchiveCacheMaxMB,
+				effective.String(settings.KeyArchiveCacheMaxMB),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyArchiveCacheMaxAge,
+				effective.String(settings.KeyArchiveCacheMaxAge),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyDownloadConns,
+				effective.String(settings.KeyDownloadConns),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyVerifyPolicy,
+				effective.String(settings.KeyVerifyPolicy),
+			),
+			setting(sectionBehavior, settings.KeySigningKey, signingKey),
+			setting(sectionBehavior, settings.KeyKnownHashes, knownHashes),
+			setting(sectionBehavior, settings.KeyCacheTTL, effective.String(settings.KeyCacheTTL)),
+			setting(
+				sectionBehavior,
+				settings.KeyRollbackLimit,
+				effective.String(settings.KeyRollbackLimit),
+			),
+			setting(
+				sectionBehavior,
+				settings.KeyBuildLogKeep,
+				effective.String(settings.KeyBuildLogKeep),
+			),
+			setting(
+				sectionTimeouts,
+				settings.KeyCommandTimeout,
+				effective.String(settings.KeyCommandTimeout),
+			),
+			setting(sectionTimeouts, settings.KeyAPITimeout, effective.String(settings.KeyAPITimeout)),
+			setting(
+				sectionTimeouts,
+				settings.KeyHTTPTimeout,
+				effective.String(settings.KeyHTTPTimeout),
+			),
+			setting(
+				sectionTimeouts,
+				settings.KeyDownloadTimeout,
+				effective.String(settings.KeyDownloadTimeout),
+			),
+			setting(sectionLogging, settings.KeyLog, logLevel),
+			setting(sectionLogging, settings.KeyLogFile, logFile),
+		),
 		collectThemingVars(),
 	)
 }
@@ -170,7 +349,7 @@ func collectEnvVars() []envVar {
 func collectThemingVars() []envVar {
 	palette := style.Default()
 
-	return []envVar{
+	vars := []envVar{
 		{
 			Section: sectionTheming,
 			Name:    "NVS_COLOR_PRIMARY",
@@ -213,6 +392,18 @@ func collectThemingVars() []envVar {
 			Value:   adaptiveColorValue(palette.Error),
 		},
 	}
+
+	// Colors are not settings (they never come from config.toml),
+	// so the only layers are the environment and the built-in
+	// palette.
+	for idx := range vars {
+		vars[idx].Source = string(settings.SourceDefault)
+		if os.Getenv(vars[idx].Name) != "" {
+			vars[idx].Source = string(settings.SourceEnv)
+		}
+	}
+
+	return vars
 }
 
 // adaptiveColorValue formats an AdaptiveColor as
@@ -237,18 +428,20 @@ func appendTheming(base, theming []envVar) []envVar {
 }
 
 // renderEnvText writes the default human-readable view: a
-// banner followed by a three-column table (Section | Variable |
-// Value). Values are rendered in the Accent color so the data
-// the user is looking for stands out from the variable names.
+// banner followed by a four-column table (Section | Variable |
+// Value | Source). Values are rendered in the Accent color so the
+// data the user is looking for stands out from the variable names.
 //
 // The Section column groups related vars (Paths / Behavior /
-// Logging / Theming) so the table stays readable as more
-// variables are added. Empty Section is rendered as a blank
-// cell — reserved for future flat sections.
+// Timeouts / Logging / Theming) so the table stays readable as
+// more variables are added. Empty Section is rendered as a blank
+// cell — reserved for future flat sections. The Source column
+// names the layer that supplied each value, so "why is it using
+// this?" has an answer without reading the code.
 func renderEnvText(vars []envVar) error {
-	tbl := ui.Table.New("Section", "Variable", "Value")
+	tbl := ui.Table.New("Section", "Variable", "Value", "Source")
 	for _, v := range vars {
-		tbl.Row(v.Section, v.Name, ui.Message.Accent(v.Value))
+		tbl.Row(v.Section, v.Name, ui.Message.Accent(v.Value), v.Source)
 	}
 
 	_, _ = fmt.Fprint(os.Stdout, ui.Banner.Logo())
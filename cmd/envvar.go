@@ -5,6 +5,11 @@ import (
 	"os"
 	"strings"
 	"sync"
+
+	"github.com/y3owk1n/nvs/internal/app/settings"
+	"github.com/y3owk1n/nvs/internal/infra/pinfile"
+	"github.com/y3owk1n/nvs/internal/infra/provenance"
+	"github.com/y3owk1n/nvs/internal/infra/releasesource"
 )
 
 // envValidation collects per-(env var, value) deduplication
@@ -80,44 +85,6 @@ func hasControlChar(s string) bool {
 	return false
 }
 
-const (
-	envTrue  = "true"
-	envFalse = "false"
-	envYes   = "yes"
-	envOff   = "off"
-)
-
-// parseBoolEnv parses a boolean env var value. It returns the
-// resolved boolean and a "set" flag; the flag is false when
-// the env var is unset, empty, or invalid. Invalid values
-// (typos, the empty string, or anything not in the recognized
-// set) emit a one-line warning to stderr and resolve to
-// false, matching the existing lenient behavior of
-// NVS_USE_GLOBAL_CACHE.
-//
-// Recognized true values (case-insensitive): "1", "true",
-// "yes", "on". Recognized false values: "0", "false", "no",
-// "off". Anything else is treated as invalid.
-func parseBoolEnv(envName, value string) (bool, bool) {
-	trimmed := strings.TrimSpace(value)
-	if trimmed == "" {
-		return false, false
-	}
-
-	switch strings.ToLower(trimmed) {
-	case "1", envTrue, envYes, "on":
-		return true, true
-
-	case "0", envFalse, "no", envOff:
-		return false, true
-
-	default:
-		warnInvalidBool(envName, trimmed)
-
-		return false, false
-	}
-}
-
 // warnInvalidPath writes a one-line warning to stderr about a
 // path env var that was set to something the validator
 // rejected. Deduped by (env var, value) so the same bad value
@@ -135,19 +102,56 @@ func warnInvalidPath(envName, value, reason string) {
 	)
 }
 
-// warnInvalidBool writes a one-line warning to stderr about a
-// boolean env var that was set to a value outside the
-// recognized set. Deduped by (env var, value).
-func warnInvalidBool(envName, value string) {
-	key := envName + "\x00bool\x00" + value
-	if _, already := envValidation.LoadOrStore(key, struct{}{}); already {
-		return
+// warnSettings writes a one-line warning to stderr for every
+// settings value that was rejected and skipped in favor of the
+// next layer (see settings.Resolve). Deduped by message, so the
+// same bad value only generates one warning per process.
+func warnSettings(warnings []error) {
+	for _, warning := range warnings {
+		key := "settings\x00" + warning.Error()
+		if _, already := envValidation.LoadOrStore(key, struct{}{}); already {
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "nvs: %v; ignoring\n", warning)
 	}
+}
 
-	fmt.Fprintf(
-		os.Stderr,
-		"nvs: %s=%q is not a recognized boolean (expected 1/true/yes/on or 0/false/no/off); using false\n",
-		envName,
-		value,
-	)
+// settingValidators are the rules of the settings whose values
+// infrastructure packages parse. The settings package sits below
+// them, so init hands it these instead of importing them.
+var settingValidators = map[string]func(string) error{
+	settings.KeyReleaseSource: func(value string) error {
+		if releasesource.IsGitHub(value) {
+			return nil
+		}
+
+		_, err := releasesource.Locate(value)
+
+		return err
+	},
+	settings.KeyPinSources: func(value string) error {
+		_, err := pinfile.ParseChain(value)
+
+		return err
+	},
+	settings.KeyVerifyPolicy: func(value string) error {
+		_, err := provenance.ParsePolicy(value)
+
+		return err
+	},
+	settings.KeySigningKey: func(value string) error {
+		_, err := provenance.ParsePublicKey(value)
+
+		return err
+	},
+}
+
+func init() {
+	for key, validate := range settingValidators {
+		err := settings.SetValidator(key, validate)
+		if err != nil {
+			panic(err)
+		}
+	}
 }
@@ -3,10 +3,16 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/y3owk1n/nvs/internal/app/settings"
+	"github.com/y3owk1n/nvs/internal/infra/pinfile"
+	"github.com/y3owk1n/nvs/internal/infra/provenance"
+	"github.com/y3owk1n/nvs/internal/infra/releasesource"
 )
 
 // resetEnvValidationState clears the package-private dedup
@@ -199,84 +205,71 @@ func TestValidPathWarningIsDeduplicated(t *testing.T) {
 	}
 }
 
-func TestParseBoolEnvAccepts(t *testing.T) {
-	cases := []struct {
-		name  string
-		input string
-		want  bool
-	}{
-		{"true", envTrue, true},
-		{"True", "True", true},
-		{"TRUE", "TRUE", true},
-		{"yes", envYes, true},
-		{"Yes", "Yes", true},
-		{"on", "on", true},
-		{"ON", "ON", true},
-		{"one", "1", true},
-		{"false", envFalse, false},
-		{"FALSE", "FALSE", false},
-		{"no", "no", false},
-		{"off", envOff, false},
-		{"zero", "0", false},
-	}
-
-	for _, testCase := range cases {
-		t.Run(testCase.name, func(t *testing.T) {
-			got, set := parseBoolEnv("TEST", testCase.input)
-			if !set {
-				t.Errorf("parseBoolEnv(%q) = !set, want set", testCase.input)
-			}
+func TestWarnSettingsDeduplicates(t *testing.T) {
+	resetEnvValidationState(t)
 
-			if got != testCase.want {
-				t.Errorf("parseBoolEnv(%q) = %v, want %v", testCase.input, got, testCase.want)
-			}
-		})
+	warnings := []error{
+		&settings.LayerError{Where: "NVS_DEDUP_SETTING", Err: settings.ErrNotBool},
 	}
-}
 
-func TestParseBoolEnvRejectsUnset(t *testing.T) {
-	resetEnvValidationState(t)
-
-	got, set := parseBoolEnv("NVS_TEST", "")
+	warning := captureStderr(t, func() {
+		warnSettings(warnings)
+		warnSettings(warnings)
+	})
 
-	if set {
-		t.Errorf("parseBoolEnv(\"\") = set, want !set")
+	if got := strings.Count(warning, "NVS_DEDUP_SETTING"); got != 1 {
+		t.Errorf("warning appeared %d times, want 1 (dedup failed); warning=%q", got, warning)
 	}
+}
 
-	if got {
-		t.Errorf("parseBoolEnv(\"\") = true, want false")
+// TestSettingValidators verifies the settings parsed by infrastructure
+// packages get their rules, and that their defaults pass them.
+func TestSettingValidators(t *testing.T) {
+	tests := []struct {
+		key string
+		raw string
+	}{
+		{key: settings.KeyReleaseSource, raw: "ftp://artifacts.example.com"},
+		{key: settings.KeyPinSources, raw: "nvs,bogus"},
+		{key: settings.KeyVerifyPolicy, raw: "strict"},
+		{key: settings.KeySigningKey, raw: "ssh-rsa AAAA"},
 	}
-}
 
-func TestParseBoolEnvWarnsOnTypo(t *testing.T) {
-	resetEnvValidationState(t)
+	for _, tt := range tests {
+		setting, ok := settings.Lookup(tt.key)
+		if !ok {
+			t.Fatalf("Lookup(%s) failed", tt.key)
+		}
 
-	warning := captureStderr(t, func() {
-		_, set := parseBoolEnv("NVS_TEST", "ture")
-		if set {
-			t.Error("parseBoolEnv(\"ture\") = set, want !set (typo should be rejected)")
+		_, err := setting.Normalize(tt.raw)
+		if !errors.Is(err, settings.ErrInvalidValue) {
+			t.Errorf("Normalize(%s=%q) error = %v, want ErrInvalidValue", tt.key, tt.raw, err)
 		}
-	})
 
-	if !strings.Contains(warning, "NVS_TEST") {
-		t.Errorf("warning missing env var name; got %q", warning)
+		if setting.Default != "" {
+			_, err = setting.Normalize(setting.Default)
+			if err != nil {
+				t.Errorf("default of %s is invalid: %v", tt.key, err)
+			}
+		}
 	}
 
-	if !strings.Contains(warning, "ture") {
-		t.Errorf("warning missing offending value; got %q", warning)
-	}
-}
+	// The defaults are spelled out in the settings package.
+	defaults := settings.Defaults()
+	pinSources := strings.Join(pinfile.Names(), ",")
 
-func TestParseBoolEnvDeduplicates(t *testing.T) {
-	resetEnvValidationState(t)
+	got := defaults.String(settings.KeyPinSources)
+	if got != pinSources {
+		t.Errorf("pin_sources default = %q, want %q", got, pinSources)
+	}
 
-	warning := captureStderr(t, func() {
-		_, _ = parseBoolEnv("NVS_DEDUP_BOOL", "ture")
-		_, _ = parseBoolEnv("NVS_DEDUP_BOOL", "ture")
-		_, _ = parseBoolEnv("NVS_DEDUP_BOOL", "ture")
-	})
+	got = defaults.String(settings.KeyVerifyPolicy)
+	if got != string(provenance.PolicyWarn) {
+		t.Errorf("verify_policy default = %q, want %q", got, provenance.PolicyWarn)
+	}
 
-	if got := strings.Count(warning, "NVS_DEDUP_BOOL"); got != 1 {
-		t.Errorf("warning appeared %d times, want 1 (dedup failed); warning=%q", got, warning)
+	got = defaults.String(settings.KeyReleaseSource)
+	if !releasesource.IsGitHub(got) {
+		t.Errorf("release_source default = %q, want %q", got, releasesource.GitHub)
 	}
 }
@@ -15,11 +15,15 @@ var (
 	// ErrNvimBinaryNotFound is returned when the nvim binary cannot be found.
 	ErrNvimBinaryNotFound = errors.New("nvim binary not found")
 
+	// ErrCommandNotFound is returned when 'nvs exec' cannot find the command to run.
+	ErrCommandNotFound = errors.New("command not found")
+
 	// ErrNvimExitNonZero is returned when nvim exits with a non-zero exit code.
 	ErrNvimExitNonZero = errors.New("nvim exited with non-zero status")
 
-	// ErrVersionFileNotFound is returned when no .nvs-version file is found.
-	ErrVersionFileNotFound = errors.New("no .nvs-version file found")
+	// ErrVersionFileNotFound is returned when no pin file (.nvs-version,
+	// .nvim-version, .tool-versions or mise.toml) is found.
+	ErrVersionFileNotFound = errors.New("no version pin file found")
 
 	// ErrInvalidIndex is returned when an invalid index is provided.
 	ErrInvalidIndex = errors.New("invalid index")
@@ -28,7 +32,9 @@ var (
 	ErrNightlyVersionNotExists = errors.New("nightly version no longer exists on disk")
 
 	// ErrInvalidUpgradeTarget is returned when an invalid upgrade target is specified.
-	ErrInvalidUpgradeTarget = errors.New("upgrade can only be performed for 'stable' or 'nightly'")
+	ErrInvalidUpgradeTarget = errors.New(
+		"upgrade can only be performed for 'stable', 'nightly' or a branch/pr build",
+	)
 
 	// ErrVersionNotInstalled is returned when attempting to uninstall a version that is not installed.
 	ErrVersionNotInstalled = errors.New("version not installed")
@@ -70,4 +76,23 @@ var (
 
 	// ErrVersionArgRequired is returned when version argument is required but not provided.
 	ErrVersionArgRequired = errors.New("version argument is required when --pick is not used")
+
+	// ErrEditorFailed is returned when the editor opened by 'nvs settings edit' fails.
+	ErrEditorFailed = errors.New("editor exited with an error")
+
+	// ErrShellVersionRequired is returned when 'nvs shell' gets neither a version nor --unset.
+	ErrShellVersionRequired = errors.New("a version or --unset is required")
+
+	// ErrShellUnsetWithVersion is returned when 'nvs shell --unset' also gets a version.
+	ErrShellUnsetWithVersion = errors.New("--unset does not take a version")
+
+	// ErrLockNeedsTag is returned when 'nvs pin --lock' gets a channel, range, alias or source build.
+	ErrLockNeedsTag = errors.New("--lock needs an exact release tag")
+
+	// ErrLockUnverifiable is returned when a locked version's install records no archive hash.
+	ErrLockUnverifiable = errors.New("no archive hash recorded to check against the lock file")
+
+	// ErrBisectScriptAborted is returned when an 'nvs bisect run' script exits with a
+	// code that is neither good, bad nor skip, which stops the bisection.
+	ErrBisectScriptAborted = errors.New("bisect script aborted the bisection")
 )
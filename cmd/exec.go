@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/log"
+	"github.com/y3owk1n/nvs/internal/platform"
+)
+
+// execVersionEnv overrides the version 'nvs exec' (and so the nvim
+// shim) picks, for one process tree.
+const execVersionEnv = "NVS_VERSION"
+
+// execCmd represents the "exec" command.
+// It resolves the version pinned for the current directory and runs a
+// program from that install in place of nvs.
+//
+// Example usage:
+//
+//	nvs exec -- nvim --headless -c 'lua print(vim.version())' -c q
+//	nvs exec --install nvim -l test.lua
+var execCmd = &cobra.Command{
+	Use:   "exec [--install] [--] <command> [args...]",
+	Short: "Run a command with the project's pinned Neovim version",
+	Long: `Resolve the version for the current directory and run <command> with that
+install first on PATH and VIMRUNTIME pointing at its runtime. The version
+is the first of:
+
+  1. the NVS_VERSION environment variable
+  2. the session version set by 'nvs shell' (NVS_SHELL_VERSION)
+  3. the nearest pin file (.nvs-version or another pin source, see 'nvs pin')
+  4. the global default set by 'nvs use'
+
+nvs replaces itself with the command, so stdin, stdout, stderr, signals
+and the exit code are the command's own. "nvim" always means the pinned
+version's nvim; other commands are looked up in its bin directory first,
+then on PATH.
+
+Examples:
+  nvs exec -- nvim --headless -c 'checkhealth' -c q
+  nvs exec --install -- nvim -l tests/run.lua   # install the pin if missing
+  nvs exec -- sh -c 'nvim --version'            # PATH is set up for children too`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: RunExec,
+	// exec usually runs behind the nvim shim or in a script; a usage
+	// dump would bury the actual error.
+	SilenceUsage: true,
+}
+
+// RunExec executes the exec command.
+func RunExec(cmd *cobra.Command, args []string) error {
+	pinnedVersion, versionSource, err := execVersion()
+	if err != nil {
+		return fmt.Errorf("nothing to exec: %w", err)
+	}
+
+	log.Debugf("Using version %s from %s", pinnedVersion, versionSource)
+
+	versionName, err := GetVersionService().Resolve(cmd.Context(), pinnedVersion)
+	if errors.Is(err, vtypes.ErrVersionNotFound) {
+		install, _ := cmd.Flags().GetBool("install")
+		if !install {
+			return fmt.Errorf(
+				"%w (from %s; use --install or 'nvs install %s')",
+				err,
+				versionSource,
+				pinnedVersion,
+			)
+		}
+
+		err = runInstallForAlias(cmd.Context(), cmd, pinnedVersion)
+		if err != nil {
+			return err
+		}
+
+		versionName, err = GetVersionService().Resolve(cmd.Context(), pinnedVersion)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	nvimPath, err := getNvimBinaryPath(versionName)
+	if err != nil {
+		return fmt.Errorf("failed to find nvim binary: %w", err)
+	}
+
+	binDir := filepath.Dir(nvimPath)
+
+	program, err := resolveExecProgram(args[0], nvimPath, binDir)
+	if err != nil {
+		return err
+	}
+
+	env := execEnv(os.Environ(), binDir)
+
+	log.Debugf("Exec %s %v (version %s)", program, args[1:], versionName)
+
+	// Only returns if the exec could not happen.
+	return platform.Exec(program, args, env)
+}
+
+// execVersion returns the version spec 'nvs exec' runs and where it
+// came from: NVS_VERSION, the 'nvs shell' session version, the nearest
+// pin file, or the global default.
+func execVersion() (string, string, error) {
+	for _, key := range []string{execVersionEnv, shellVersionEnv} {
+		override := strings.TrimSpace(os.Getenv(key))
+		if override == "" {
+			continue
+		}
+
+		err := vtypes.ValidateVersionSpec(override)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid %s: %w", key, err)
+		}
+
+		return override, key, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	pinnedVersion, versionFile, err := ReadVersionFile(cwd, true)
+	if err == nil {
+		return pinnedVersion, versionFile, nil
+	}
+
+	if !errors.Is(err, ErrVersionFileNotFound) {
+		return "", "", err
+	}
+
+	current, currentErr := GetVersionService().Current()
+	if currentErr != nil {
+		return "", "", fmt.Errorf("%w and no version is active: %w", err, currentErr)
+	}
+
+	return current.Name(), "the global default", nil
+}
+
+// resolveExecProgram returns the path to run for name: nvimPath for
+// "nvim", the install's bin directory for anything it ships, PATH for
+// the rest. A name with a path separator is used as given.
+func resolveExecProgram(name, nvimPath, binDir string) (string, error) {
+	base := strings.TrimSuffix(name, ".exe")
+	if base == "nvim" {
+		return nvimPath, nil
+	}
+
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		return name, nil
+	}
+
+	candidate := filepath.Join(binDir, name)
+	if runtime.GOOS == constants.WindowsOS && filepath.Ext(name) == "" {
+		candidate += ".exe"
+	}
+
+	info, err := os.Stat(candidate)
+	if err == nil && !info.IsDir() {
+		return candidate, nil
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrCommandNotFound, name)
+	}
+
+	return path, nil
+}
+
+// execEnv returns environ with binDir prepended to PATH and VIMRUNTIME
+// set to the runtime next to it. An inherited VIMRUNTIME or VIM from
+// another install is dropped so it cannot shadow the pinned one; if
+// the install has no runtime directory nvim derives it itself.
+func execEnv(environ []string, binDir string) []string {
+	env := make([]string, 0, len(environ)+2) //nolint:mnd
+
+	path := binDir
+
+	for _, entry := range environ {
+		key, value, _ := strings.Cut(entry, "=")
+
+		switch {
+		case strings.EqualFold(key, "PATH"):
+			if value != "" {
+				path = binDir + string(os.PathListSeparator) + value
+			}
+		case key == "VIMRUNTIME", key == "VIM":
+			// Dropped: set below from the pinned install.
+		default:
+			env = append(env, entry)
+		}
+	}
+
+	env = append(env, "PATH="+path)
+
+	runtimeDir := filepath.Join(filepath.Dir(binDir), "share", "nvim", "runtime")
+
+	info, err := os.Stat(runtimeDir)
+	if err == nil && info.IsDir() {
+		env = append(env, "VIMRUNTIME="+runtimeDir)
+	}
+
+	return env
+}
+
+// init registers the execCmd with the root command.
+func init() {
+	execCmd.Flags().BoolP("install", "i", false, "Install the pinned version if it is missing")
+	// Everything after the command name belongs to the command.
+	execCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(execCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExecEnv(t *testing.T) {
+	t.Parallel()
+
+	prefix := t.TempDir()
+	installBin := filepath.Join(prefix, "bin")
+	runtimeDir := filepath.Join(prefix, "share", "nvim", "runtime")
+
+	err := os.MkdirAll(runtimeDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sep := string(os.PathListSeparator)
+
+	env := execEnv([]string{
+		"HOME=/home/u",
+		"PATH=/usr/bin" + sep + "/bin",
+		"VIMRUNTIME=/other/share/nvim/runtime",
+		"VIM=/other/share/nvim",
+	}, installBin)
+
+	want := []string{
+		"HOME=/home/u",
+		"PATH=" + installBin + sep + "/usr/bin" + sep + "/bin",
+		"VIMRUNTIME=" + runtimeDir,
+	}
+	if !slices.Equal(env, want) {
+		t.Errorf("execEnv = %q, want %q", env, want)
+	}
+
+	// Without a runtime directory VIMRUNTIME is left for nvim to derive.
+	env = execEnv([]string{"VIMRUNTIME=/other"}, filepath.Join(t.TempDir(), "bin"))
+	for _, entry := range env {
+		if strings.HasPrefix(entry, "VIMRUNTIME=") {
+			t.Errorf("unexpected %s", entry)
+		}
+	}
+}
+
+func TestResolveExecProgram(t *testing.T) {
+	t.Parallel()
+
+	installBin := t.TempDir()
+	nvimPath := filepath.Join(installBin, "nvim")
+
+	helper := "nvim-helper"
+	if runtime.GOOS == "windows" {
+		helper += ".exe"
+	}
+
+	err := os.WriteFile(filepath.Join(installBin, helper), []byte("#!/bin/sh\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"nvim", nvimPath},
+		{"nvim-helper", filepath.Join(installBin, helper)},
+		{"./scripts/test.sh", "./scripts/test.sh"},
+	}
+
+	for _, tc := range cases {
+		got, err := resolveExecProgram(tc.name, nvimPath, installBin)
+		if err != nil || got != tc.want {
+			t.Errorf("resolveExecProgram(%q) = %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+
+	_, err = resolveExecProgram("definitely-not-a-real-command-nvs", nvimPath, installBin)
+	if err == nil {
+		t.Error("resolveExecProgram succeeded for a missing command")
+	}
+}
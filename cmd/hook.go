@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/settings"
 	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/log"
 )
@@ -18,7 +19,9 @@ var hookCmd = &cobra.Command{
 	Long: `Output shell integration code for automatic version switching.
 
 Add this to your shell configuration file to enable automatic switching
-when entering directories with .nvs-version files.
+when entering directories with a pin file (.nvs-version, .nvim-version,
+.tool-versions or mise.toml; pin_sources / NVS_PIN_SOURCES selects and
+orders them).
 
 For bash (~/.bashrc):
   eval "$(nvs hook bash)"
@@ -27,7 +30,10 @@ For zsh (~/.zshrc):
   eval "$(nvs hook zsh)"
 
 For fish (~/.config/fish/config.fish):
-  nvs hook fish | source`,
+  nvs hook fish | source
+
+In shim mode (shims = true) nvim resolves the version on every launch,
+so the hook is a no-op.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: RunHook,
 }
@@ -49,6 +55,16 @@ func RunHook(_ *cobra.Command, args []string) error {
 	log.Debugf("Generating hook for shell: %s", shell)
 
 	hookScript, err := constants.HookScript(shell)
+	if err == nil {
+		if GetSettings().Bool(settings.KeyShims) {
+			// The shim resolves the version on every launch; switching
+			// the global version on cd would only fight it.
+			hookScript = shimModeHook
+		} else {
+			hookScript = pinSourcesPrelude(shell) + hookScript
+		}
+	}
+
 	if err != nil {
 		return fmt.Errorf(
 			"%w: %s (supported: %s, %s, %s)",
@@ -68,6 +84,26 @@ func RunHook(_ *cobra.Command, args []string) error {
 	return nil
 }
 
+// pinSourcesPrelude sets _NVS_PIN_SOURCES to the pin sources nvs
+// uses, so the hook reads the pins ReadVersionFile would even when
+// they come from pin_sources in config.toml. NVS_PIN_SOURCES in the
+// shell's environment still wins, as it does for nvs.
+func pinSourcesPrelude(shell string) string {
+	sources := strings.Join(activePinSources().Names(), ",")
+
+	if shell == constants.ShellFish {
+		return fmt.Sprintf("set -g _NVS_PIN_SOURCES '%s'\n", sources)
+	}
+
+	return fmt.Sprintf("_NVS_PIN_SOURCES='%s'\n", sources)
+}
+
+// shimModeHook is printed instead of the hook script in shim mode. It
+// is a comment, so evaluating it is a no-op in every supported shell.
+const shimModeHook = `# nvs: shim mode is on (shims = true), so nvim picks the pinned version
+# on every launch and no directory-change hook is needed.
+`
+
 func init() {
 	rootCmd.AddCommand(hookCmd)
 }
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/pinfile"
+)
+
+func TestPinSourcesPrelude(t *testing.T) {
+	chain, err := pinfile.ParseChain("mise,nvs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldPinSources := pinSources
+	pinSources = chain
+
+	t.Cleanup(func() { pinSources = oldPinSources })
+
+	got := pinSourcesPrelude(constants.ShellFish)
+	if got != "set -g _NVS_PIN_SOURCES 'mise,nvs'\n" {
+		t.Errorf("fish prelude = %q", got)
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	// A directory pinning 0.10.0 in .nvs-version and 0.9.5 in
+	// mise.toml: the hook must follow the configured order.
+	dir := t.TempDir()
+
+	err = os.WriteFile(filepath.Join(dir, ".nvs-version"), []byte("0.10.0\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mise := []byte("[tools]\nneovim = \"0.9.5\"\n")
+
+	err = os.WriteFile(filepath.Join(dir, "mise.toml"), mise, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script, err := constants.HookScript(constants.ShellBash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("NVS_PIN_SOURCES", "")
+
+	cmd := exec.CommandContext(
+		t.Context(),
+		bash,
+		"-c",
+		pinSourcesPrelude(constants.ShellBash)+script+"\n_nvs_read_pin \"$PWD\"",
+	)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil || strings.TrimSpace(string(out)) != "0.9.5" {
+		t.Errorf("hook read pin %q, %v; want 0.9.5 from mise.toml", out, err)
+	}
+}
@@ -8,7 +8,8 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/app/settings"
+	"github.com/y3owk1n/nvs/internal/app/versionsvc"
 	"github.com/y3owk1n/nvs/internal/log"
 	"github.com/y3owk1n/nvs/internal/ui"
 )
@@ -22,10 +23,19 @@ const installSpinnerSpeed = 100
 //   - A version alias ("stable", "nightly", or "master")
 //   - A specific version tag
 //   - A commit hash (which triggers a build from source)
+//   - A branch or pull request ref ("branch:release-0.10", "pr:12345"), built from
+//     source under a stable name ("branch-release-0.10", "pr-12345") that
+//     'nvs upgrade' can refresh
 //
-// Depending on whether the argument is recognized as a commit hash, it either builds Neovim from that commit
+// Depending on whether the argument is recognized as a commit hash or ref, it either builds Neovim from source
 // using the builder package, or installs a pre-built version using the installer package.
 //
+// Source builds use the build profile of the build_* settings; the
+// --build-type, --sanitize, --cmake-flags, --deps-cmake-flags and
+// --build-name flags override it for one install. A build with a
+// non-default profile is installed under a suffixed name, so it can sit
+// next to the plain build of the same commit.
+//
 // The installation process is bound by a 30-minute timeout.
 //
 // Example usage:
@@ -34,9 +44,12 @@ const installSpinnerSpeed = 100
 //	nvs install nightly
 //	nvs install master
 //	nvs install 1a2b3c4 (for a commit hash)
+//	nvs install 1a2b3c4 --build-type Debug --sanitize (installed as 1a2b3c4-debug-asan)
+//	nvs install branch:release-0.10
+//	nvs install pr:12345
 //	nvs install --pick
 var installCmd = &cobra.Command{
-	Use:     "install [version|stable|nightly|master|commit-hash]",
+	Use:     "install [version|stable|nightly|master|commit-hash|branch:<name>|pr:<number>]",
 	Aliases: []string{"i"},
 	Short:   "Install a Neovim version or commit",
 	Args:    cobra.MaximumNArgs(1),
@@ -48,7 +61,10 @@ func RunInstall(cmd *cobra.Command, args []string) error {
 	log.Debug("Starting installation command")
 
 	// Create a context with a timeout to prevent hanging installations.
-	ctx, cancel := context.WithTimeout(cmd.Context(), constants.TimeoutMinutes*time.Minute)
+	ctx, cancel := context.WithTimeout(
+		cmd.Context(),
+		GetSettings().Duration(settings.KeyCommandTimeout),
+	)
 	defer cancel()
 
 	var alias string
@@ -117,18 +133,21 @@ func pickInstallVersion(ctx context.Context) (string, error) {
 // path without re-invoking the picker.
 //
 // context.Context is the first parameter per the revive
-// 'context-as-argument' rule; cmd is kept as a positional argument
-// only to preserve a future use of cmd-derived values (e.g. IO
-// streams), but is currently unused.
+// 'context-as-argument' rule; cmd supplies the build profile flags,
+// which commands other than install do not have.
 func runInstallForAlias(
 	ctx context.Context,
 	cmd *cobra.Command,
 	alias string,
 ) error {
-	_ = cmd
-
 	log.Debugf("Requested version: %s", alias)
 
+	// Build profile flags override the build_* settings
+	profileOpts, err := buildProfileOption(cmd)
+	if err != nil {
+		return err
+	}
+
 	// Create and start a spinner for progress. The spinner
 	// detects non-terminal writers internally and becomes a
 	// no-op when stdout is piped or redirected, so this is
@@ -150,10 +169,18 @@ func runInstallForAlias(
 	// spinner line has been cleared.
 	defer progressSpinner.Stop()
 
+	// A project lock file pins the archive's SHA256 for this platform
+	opts, err := lockedSHA256(alias)
+	if err != nil {
+		return err
+	}
+
+	opts = append(opts, profileOpts...)
+
 	// Use version service to install
-	err := GetVersionService().Install(ctx, alias, func(phase string, progress int) {
+	err = GetVersionService().Install(ctx, alias, func(phase string, progress int) {
 		progressSpinner.SetSuffix(" " + ui.FormatPhaseProgress(phase, progress))
-	})
+	}, opts...)
 	if err != nil {
 		return err
 	}
@@ -168,13 +195,72 @@ func runInstallForAlias(
 	// "spinner replaced by result" UX callers expect.
 	progressSpinner.Stop()
 
+	// An install that was already on disk skipped the download check
+	err = enforceProjectLock(alias)
+	if err != nil {
+		return err
+	}
+
 	ui.Message.Successf("Installation successful!")
 
 	return nil
 }
 
+// buildProfileOption returns the install option for the build profile
+// given by the build flags of cmd, layered over the build_* settings,
+// or nothing when no build flag is set.
+func buildProfileOption(cmd *cobra.Command) ([]versionsvc.InstallOption, error) {
+	profile, err := settingsBuildProfile(GetSettings())
+	if err != nil {
+		return nil, err
+	}
+
+	flags := cmd.Flags()
+	stringFlags := map[string]*string{
+		"build-type":       &profile.Type,
+		"cmake-flags":      &profile.CMakeFlags,
+		"deps-cmake-flags": &profile.DepsCMakeFlags,
+		"build-name":       &profile.Name,
+	}
+	changed := false
+
+	for name, field := range stringFlags {
+		if !flags.Changed(name) {
+			continue
+		}
+
+		*field, _ = flags.GetString(name)
+		changed = true
+	}
+
+	if flags.Changed("sanitize") {
+		profile.Sanitize, _ = flags.GetBool("sanitize")
+		changed = true
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	profile, err = profile.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	return []versionsvc.InstallOption{versionsvc.WithBuildProfile(profile)}, nil
+}
+
 // init registers the installCmd with the root command.
 func init() {
 	rootCmd.AddCommand(installCmd)
 	installCmd.Flags().BoolP("pick", "p", false, "Launch interactive picker to select version")
+	installCmd.Flags().String("build-type", "",
+		"CMake build type of a source build (Release, RelWithDebInfo, Debug, MinSizeRel)")
+	installCmd.Flags().Bool("sanitize", false, "Build from source with ASAN and UBSAN")
+	installCmd.Flags().String("cmake-flags", "",
+		"Extra CMake flags for a source build (CMAKE_EXTRA_FLAGS)")
+	installCmd.Flags().String("deps-cmake-flags", "",
+		"Extra CMake flags for the bundled dependencies (DEPS_CMAKE_FLAGS)")
+	installCmd.Flags().String("build-name", "",
+		"Install a source build as <commit>-<name> instead of a name derived from the profile")
 }
@@ -207,9 +207,32 @@ func RunListRemote(cmd *cobra.Command, _ []string) error {
 	_, _ = fmt.Fprintln(os.Stdout)
 	_, _ = fmt.Fprint(os.Stdout, tbl.Render(ui.Style.Palette()))
 
+	reportRateLimit()
+
 	return nil
 }
 
+// reportRateLimit shows the GitHub API quota left when this run
+// fetched releases from the API.
+func reportRateLimit() {
+	client := GetGitHubClient()
+	if client == nil {
+		return
+	}
+
+	quota, ok := client.RateLimit()
+	if !ok {
+		return
+	}
+
+	ui.Message.Mutedf(
+		"GitHub API: %d of %d requests left, resets at %s",
+		quota.Remaining,
+		quota.Limit,
+		quota.Reset.Local().Format("15:04"),
+	)
+}
+
 // buildReleaseDetails produces the "Details" cell string for a
 // given release. For nightlies it surfaces the publish date and
 // a short commit hash; for the "stable" alias it surfaces the
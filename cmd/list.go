@@ -28,11 +28,12 @@ var listCmd = &cobra.Command{
 // versionInfo is the structured result of RunList. The shape
 // is the public JSON contract (TestRunList_JSON asserts on
 // it), so the struct fields and their JSON tags must not
-// change shape.
+// change shape; new fields are only ever added.
 type versionInfo struct {
-	Name   string `json:"name"`
-	Status string `json:"status"`
-	Type   string `json:"type"`
+	Name     string           `json:"name"`
+	Status   string           `json:"status"`
+	Type     string           `json:"type"`
+	Manifest *vtypes.Manifest `json:"manifest,omitempty"`
 }
 
 // RunList executes the list command.
@@ -74,8 +75,8 @@ func RunList(cmd *cobra.Command, _ []string) error {
 
 // renderListJSON emits the --json contract: an object with
 // "versions" (one VersionInfo per installed version, with
-// "status" set to "current" or "installed"). It is preserved
-// byte-for-byte from the pre-refactor implementation.
+// "status" set to "current" or "installed", and "manifest" holding
+// the install manifest when one can be read).
 func renderListJSON(versions []vtypes.Version, current vtypes.Version) error {
 	infos := make([]versionInfo, 0, len(versions))
 	for _, version := range versions {
@@ -85,15 +86,29 @@ func renderListJSON(versions []vtypes.Version, current vtypes.Version) error {
 		}
 
 		infos = append(infos, versionInfo{
-			Name:   version.Name(),
-			Status: status,
-			Type:   version.Type().String(),
+			Name:     version.Name(),
+			Status:   status,
+			Type:     version.Type().String(),
+			Manifest: readManifest(version.Name()),
 		})
 	}
 
 	return outputJSON(map[string]any{"versions": infos})
 }
 
+// readManifest returns the install manifest of an installed version
+// for --json output, or nil if it cannot be read.
+func readManifest(name string) *vtypes.Manifest {
+	manifest, err := GetVersionService().Manifest(name)
+	if err != nil {
+		log.Debugf("No manifest for %s: %v", name, err)
+
+		return nil
+	}
+
+	return &manifest
+}
+
 // renderListText renders the human-readable list view: a
 // banner, a one-line summary, and a data table with one row
 // per installed version. The current version is rendered
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/infra/buildlog"
+	"github.com/y3owk1n/nvs/internal/ui"
+)
+
+// logsFollowInterval is how often 'nvs logs build --follow' checks the
+// log for new output.
+const logsFollowInterval = 500 * time.Millisecond
+
+// logsCmd represents the "logs" command.
+// It lists and shows the logs source builds keep of their full output.
+//
+// Example usage:
+//
+//	nvs logs
+//	nvs logs build abc1234
+//	nvs logs build master --tail 50 --follow
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "List and show source build logs",
+	Long: `Source builds write their full make and cmake output, for every attempt, to a
+log under NVS_CACHE_DIR/build-logs. The newest logs are kept (build_log_keep,
+20 by default). A failed build's error names its log, and 'nvs logs build'
+shows it with the line the build failed at highlighted, so you can read it,
+or attach it to a bug report, without building again.
+
+With no subcommand, lists the logs.`,
+	Args: cobra.NoArgs,
+	RunE: RunLogsList,
+}
+
+var logsListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List source build logs",
+	Args:    cobra.NoArgs,
+	RunE:    RunLogsList,
+}
+
+var logsBuildCmd = &cobra.Command{
+	Use:   "build [commit|version]",
+	Short: "Show the log of a source build",
+	Long: `Show the log of the newest build of a commit (a hash or a prefix of one) or of
+an installed version name (abc1234-debug, pr:12345, master). With no argument,
+shows the newest log.
+
+Examples:
+  nvs logs build                       # the newest build
+  nvs logs build abc1234 --tail 50     # the last 50 lines
+  nvs logs build master --follow       # watch a build that is running
+  nvs logs build pr:12345 --path       # the file, to attach to a bug report`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: RunLogsBuild,
+}
+
+// buildLogJSON is the --json shape of one build log.
+//
+//nolint:tagliatelle
+type buildLogJSON struct {
+	Name    string    `json:"name"`
+	Label   string    `json:"label"`
+	Commit  string    `json:"commit,omitempty"`
+	Status  string    `json:"status"`
+	Started time.Time `json:"started"`
+	Size    int64     `json:"size"`
+	Path    string    `json:"path"`
+}
+
+// RunLogsList executes the logs ls command.
+func RunLogsList(cmd *cobra.Command, _ []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	entries, err := GetBuildLogs().List()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		out := make([]buildLogJSON, 0, len(entries))
+		for _, entry := range entries {
+			out = append(out, buildLogJSON{
+				Name:    entry.Name,
+				Label:   entry.Label,
+				Commit:  entry.Commit,
+				Status:  string(entry.Status),
+				Started: entry.Started.UTC(),
+				Size:    entry.Size,
+				Path:    entry.Path,
+			})
+		}
+
+		return outputJSON(out)
+	}
+
+	if len(entries) == 0 {
+		ui.Message.Infof("No source build has been logged yet (%s).", GetBuildLogs().Dir())
+
+		return nil
+	}
+
+	tbl := ui.Table.New("Build", "Commit", "Status", "Size", "Started")
+	for _, entry := range entries {
+		tbl.Row(
+			entry.Label,
+			ui.Message.Dim(shortHash(entry.Commit, constants.ShortCommitLen)),
+			buildLogStatus(entry.Status),
+			formatSize(entry.Size),
+			entry.Started.Local().Format("2006-01-02 15:04"),
+		)
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout, tbl.Render(ui.Style.Palette()))
+
+	ui.Message.Infof("%d log(s) in %s", len(entries), GetBuildLogs().Dir())
+
+	return nil
+}
+
+// RunLogsBuild executes the logs build command.
+func RunLogsBuild(cmd *cobra.Command, args []string) error {
+	query := ""
+	if len(args) > 0 {
+		query = args[0]
+	}
+
+	// A branch or pull request build is logged under its directory name.
+	if vtypes.IsSourceRef(query) {
+		ref, err := vtypes.ParseSourceRef(query)
+		if err != nil {
+			return err
+		}
+
+		query = ref.DirName()
+	}
+
+	entry, err := GetBuildLogs().Find(query)
+	if err != nil {
+		return err
+	}
+
+	pathOnly, _ := cmd.Flags().GetBool("path")
+	if pathOnly {
+		_, _ = fmt.Fprintln(os.Stdout, entry.Path)
+
+		return nil
+	}
+
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read build log: %w", err)
+	}
+
+	lines := buildlog.SplitLines(data)
+	failed := buildLogFailure(entry, lines)
+	first := 0
+
+	tail, _ := cmd.Flags().GetInt("tail")
+	if tail > 0 && tail < len(lines) {
+		first = len(lines) - tail
+	}
+
+	for idx := first; idx < len(lines); idx++ {
+		printBuildLogLine(lines[idx], idx == failed)
+	}
+
+	follow, _ := cmd.Flags().GetBool("follow")
+	if follow && entry.Status == buildlog.StatusIncomplete {
+		interrupted, followErr := followBuildLog(cmd.Context(), entry.Path, int64(len(data)))
+		if followErr != nil {
+			return followErr
+		}
+
+		if interrupted {
+			ui.Message.Warnf("The build was interrupted before it finished")
+			ui.Message.Infof("Log: %s", entry.Path)
+
+			return nil
+		}
+
+		// The build has finished: summarize its result.
+		return summarizeFollowedLog(entry.Name)
+	}
+
+	summarizeBuildLog(entry, lines)
+
+	return nil
+}
+
+// followBuildLog prints what is appended to the log at path from
+// offset on, until the build finishes or ctx is canceled. It reports
+// whether the build was interrupted: the log ended without a result
+// and no nvs process is writing it any more.
+func followBuildLog(ctx context.Context, path string, offset int64) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open build log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return false, fmt.Errorf("failed to read build log: %w", err)
+	}
+
+	reader := bufio.NewReader(file)
+	partial := ""
+	writing := true
+
+	for {
+		chunk, readErr := reader.ReadString('\n')
+		if readErr == nil {
+			line := strings.TrimSuffix(partial+chunk, "\n")
+			partial = ""
+
+			printBuildLogLine(line, false)
+
+			if buildlog.IsResult(line) {
+				return false, nil
+			}
+
+			continue
+		}
+
+		if !errors.Is(readErr, io.EOF) {
+			return false, fmt.Errorf("failed to read build log: %w", readErr)
+		}
+
+		// A line still being written.
+		partial += chunk
+
+		if !writing {
+			if partial != "" {
+				printBuildLogLine(partial, false)
+			}
+
+			return true, nil
+		}
+
+		// Once no one writes the log, read to its end once more: the
+		// build may have written its result just before exiting.
+		writing = buildlog.Writing(path)
+		if !writing {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case <-time.After(logsFollowInterval):
+		}
+	}
+}
+
+// summarizeFollowedLog summarizes the log named name once the build
+// being followed has finished.
+func summarizeFollowedLog(name string) error {
+	entry, err := GetBuildLogs().Find(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read build log: %w", err)
+	}
+
+	summarizeBuildLog(entry, buildlog.SplitLines(data))
+
+	return nil
+}
+
+// buildLogFailure returns the index of the line the build logged in
+// lines failed at, or -1 if it did not fail or the line is unknown.
+func buildLogFailure(entry buildlog.Entry, lines []string) int {
+	if entry.Status != buildlog.StatusFailed {
+		return -1
+	}
+
+	return buildlog.FailurePoint(lines)
+}
+
+// summarizeBuildLog prints where a failed build failed, and where its
+// log is.
+func summarizeBuildLog(entry buildlog.Entry, lines []string) {
+	failed := buildLogFailure(entry, lines)
+
+	switch {
+	case entry.Status != buildlog.StatusFailed:
+	case failed >= 0:
+		ui.Message.Warnf("The build failed at line %d: %s", failed+1, lines[failed])
+	default:
+		ui.Message.Warnf("The build failed; the end of the log has the details")
+	}
+
+	ui.Message.Infof("Log: %s", entry.Path)
+}
+
+// printBuildLogLine prints a line of a build log, highlighted if the
+// build failed there.
+func printBuildLogLine(line string, failed bool) {
+	if failed {
+		line = ui.Message.Error(line)
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout, line)
+}
+
+// buildLogStatus renders how a logged build ended.
+func buildLogStatus(status buildlog.Status) string {
+	switch status {
+	case buildlog.StatusSucceeded:
+		return ui.Message.Success(string(status))
+	case buildlog.StatusFailed:
+		return ui.Message.Error(string(status))
+	default:
+		return ui.Message.Dim(string(status))
+	}
+}
+
+// init registers the logsCmd and its subcommands with the root command.
+func init() {
+	logsCmd.Flags().Bool("json", false, "Output in JSON format (when listing)")
+	logsListCmd.Flags().Bool("json", false, "Output in JSON format")
+	logsBuildCmd.Flags().IntP("tail", "n", 0, "Show only the last N lines")
+	logsBuildCmd.Flags().BoolP("follow", "f", false, "Keep showing the output of a running build")
+	logsBuildCmd.Flags().Bool("path", false, "Print the log's path instead of its content")
+
+	logsCmd.AddCommand(logsListCmd, logsBuildCmd)
+	rootCmd.AddCommand(logsCmd)
+}
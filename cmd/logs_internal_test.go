@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFollowBuildLog_Interrupted verifies following the log of a build
+// whose nvs process is gone stops instead of waiting for a result.
+func TestFollowBuildLog_Interrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "20250101T000000.000Z-master.log")
+
+	err := os.WriteFile(path, []byte("== nvs: build attempt 1 of 3\n[1/2] Building\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	interrupted, err := followBuildLog(ctx, path, 0)
+	if err != nil || !interrupted {
+		t.Errorf("followBuildLog() = %v, %v; want interrupted", interrupted, err)
+	}
+
+	if ctx.Err() != nil {
+		t.Error("followBuildLog() waited for the build to finish")
+	}
+}
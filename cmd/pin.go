@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -8,8 +9,12 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/settings"
+	"github.com/y3owk1n/nvs/internal/app/versionsvc"
 	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/installer"
 	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/infra/pinfile"
 	"github.com/y3owk1n/nvs/internal/log"
 	"github.com/y3owk1n/nvs/internal/ui"
 	"github.com/y3owk1n/nvs/internal/ui/picker"
@@ -22,9 +27,17 @@ var pinCmd = &cobra.Command{
 	Short: "Pin a Neovim version for the current directory",
 	Long: `Write a .nvs-version file to the current directory.
 If no version is specified, uses the currently active version.
+A version alias (see 'nvs alias') is written as-is, so the pin follows
+the alias when it is retargeted.
 
 This file can be used to ensure consistent Neovim versions across a team.
-Use 'nvs use' in a directory with .nvs-version to automatically use that version.`,
+Use 'nvs use' in a directory with .nvs-version to automatically use that version.
+
+With --lock, the version must be an exact release tag. It is installed if
+needed, and the name and SHA256 of its archive for this OS/architecture are
+recorded in .nvs-lock next to the pin. 'nvs install' and 'nvs use' then
+refuse an archive that does not match. Other platforms are recorded the
+first time the locked version is installed or used there.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: RunPin,
 }
@@ -88,13 +101,24 @@ func RunPin(cmd *cobra.Command, args []string) error {
 		// to disk. The .nvs-version file is later read back by
 		// 'nvs use' (and other commands) and its contents are
 		// joined into a filesystem path; an unvalidated write would
-		// plant a path-traversal payload in the repo.
-		err := vtypes.ValidateVersionName(versionToPin)
+		// plant a path-traversal payload in the repo. Semver
+		// ranges are allowed here and resolved at use time.
+		err := vtypes.ValidateVersionSpec(versionToPin)
 		if err != nil {
 			return err
 		}
 	}
 
+	lockPin, _ := cmd.Flags().GetBool("lock")
+	if lockPin {
+		tag, err := lockableTag(versionToPin)
+		if err != nil {
+			return err
+		}
+
+		versionToPin = tag
+	}
+
 	// Get directory to write to (current working directory by default)
 	dir, err := os.Getwd()
 	if err != nil {
@@ -120,14 +144,265 @@ func RunPin(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to write version file: %w", err)
 	}
 
-	ui.Message.Successf("Pinned %s to %s", versionToPin, versionFile)
+	// A user alias is pinned by name, so the pin follows the alias
+	// when it is retargeted; say what it currently resolves to.
+	target, err := GetVersionService().ExpandAlias(versionToPin)
+	if err == nil && target != versionToPin {
+		ui.Message.Successf(
+			"Pinned %s (alias for %s) to %s",
+			versionToPin,
+			ui.Message.Accent(target),
+			versionFile,
+		)
+	} else {
+		ui.Message.Successf("Pinned %s to %s", versionToPin, versionFile)
+	}
+
+	if !lockPin {
+		warnStaleLock(versionToPin, versionFile)
+
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(
+		cmd.Context(),
+		GetSettings().Duration(settings.KeyCommandTimeout),
+	)
+	defer cancel()
+
+	return lockPinnedVersion(ctx, cmd, versionToPin, versionFile)
+}
+
+// lockableTag returns the install name of spec if it is an exact
+// release tag. Channels, ranges, aliases and source builds move by
+// design, so a lock file could never hold for them.
+func lockableTag(spec string) (string, error) {
+	target, err := GetVersionService().ExpandAlias(spec)
+	isAlias := err == nil && target != spec
+
+	if isAlias || spec == constants.Stable ||
+		strings.HasPrefix(strings.ToLower(spec), constants.Nightly) ||
+		vtypes.IsVersionRange(spec) || vtypes.IsSourceRef(spec) ||
+		vtypes.IsCommitBuildName(spec) {
+		return "", fmt.Errorf("%w, got %q", ErrLockNeedsTag, spec)
+	}
+
+	err = vtypes.ValidateVersionName(spec)
+	if err != nil {
+		return "", err
+	}
+
+	return vtypes.NormalizeVersionForPath(spec), nil
+}
+
+// lockPinnedVersion records the archive tag was installed from in the
+// lock file next to versionFile, installing tag first if needed. A
+// lock for the same tag keeps the entries of other platforms, but
+// must agree with this one.
+func lockPinnedVersion(
+	ctx context.Context,
+	cmd *cobra.Command,
+	tag, versionFile string,
+) error {
+	if !GetVersionService().IsVersionInstalled(tag) {
+		err := runInstallForAlias(ctx, cmd, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	asset, err := installedAsset(tag)
+	if err != nil {
+		return err
+	}
+
+	lockPath := pinfile.LockPath(versionFile)
+
+	lock, err := pinfile.ReadLock(lockPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err != nil || lock.Version != tag {
+		lock = pinfile.Lock{Version: tag}
+	}
+
+	recorded, ok := lock.Entry()
+	if ok {
+		err = matchLock(lockPath, recorded, asset)
+		if err != nil {
+			return err
+		}
+	}
+
+	lock.Record(asset)
+
+	err = pinfile.WriteLock(lockPath, lock)
+	if err != nil {
+		return err
+	}
+
+	ui.Message.Successf(
+		"Locked %s (%s) to %s",
+		asset.Name,
+		pinfile.Platform(),
+		lockPath,
+	)
+
+	return nil
+}
+
+// warnStaleLock warns when the lock file next to versionFile is for a
+// version other than the one just pinned: it no longer applies.
+func warnStaleLock(spec, versionFile string) {
+	lockPath := pinfile.LockPath(versionFile)
+
+	lock, err := pinfile.ReadLock(lockPath)
+	if err != nil || lock.Version == vtypes.NormalizeVersionForPath(spec) {
+		return
+	}
+
+	ui.Message.Warnf(
+		"%s still locks %s; run 'nvs pin --lock' with a release tag to replace it",
+		lockPath,
+		lock.Version,
+	)
+}
+
+// projectLock returns the lock file next to the nearest pin when it
+// locks spec. A lock file that exists but cannot be read is an error
+// rather than a reason to skip the check.
+func projectLock(spec string) (pinfile.Lock, string, bool, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return pinfile.Lock{}, "", false, nil
+	}
+
+	_, versionFile, err := ReadVersionFile(cwd, true)
+	if err != nil {
+		return pinfile.Lock{}, "", false, nil
+	}
+
+	lockPath := pinfile.LockPath(versionFile)
+
+	lock, err := pinfile.ReadLock(lockPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return pinfile.Lock{}, "", false, nil
+	}
+
+	if err != nil {
+		return pinfile.Lock{}, "", false, err
+	}
+
+	if lock.Version != vtypes.NormalizeVersionForPath(spec) {
+		log.Debugf("%s locks %s, not %s", lockPath, lock.Version, spec)
+
+		return pinfile.Lock{}, "", false, nil
+	}
+
+	return lock, lockPath, true, nil
+}
+
+// lockedSHA256 returns the install options that make a download of
+// spec match the project lock file for this platform.
+func lockedSHA256(spec string) ([]versionsvc.InstallOption, error) {
+	lock, _, ok, err := projectLock(spec)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	recorded, ok := lock.Entry()
+	if !ok {
+		return nil, nil
+	}
+
+	return []versionsvc.InstallOption{versionsvc.WithLockedSHA256(recorded.SHA256)}, nil
+}
+
+// enforceProjectLock checks the installed copy of spec against the
+// project lock file, if one locks it. The first install on a platform
+// the lock does not cover yet is recorded in it (trust on first use).
+func enforceProjectLock(spec string) error {
+	lock, lockPath, ok, err := projectLock(spec)
+	if err != nil || !ok {
+		return err
+	}
+
+	if !GetVersionService().IsVersionInstalled(lock.Version) {
+		return nil
+	}
+
+	asset, err := installedAsset(lock.Version)
+	if err != nil {
+		return err
+	}
+
+	recorded, ok := lock.Entry()
+	if ok {
+		return matchLock(lockPath, recorded, asset)
+	}
+
+	lock.Record(asset)
+
+	err = pinfile.WriteLock(lockPath, lock)
+	if err != nil {
+		return err
+	}
+
+	ui.Message.Infof(
+		"Recorded %s for %s in %s",
+		asset.Name,
+		pinfile.Platform(),
+		ui.Message.Accent(lockPath),
+	)
 
 	return nil
 }
 
-// ReadVersionFile reads the .nvs-version file from the directory hierarchy.
-// It searches from startDir up to the root, returning the first version found.
-// If global is true, also checks the user's home directory.
+// installedAsset returns the archive an installed release came from.
+func installedAsset(name string) (pinfile.LockedAsset, error) {
+	manifest, err := GetVersionService().Manifest(name)
+	if err != nil {
+		return pinfile.LockedAsset{}, fmt.Errorf("failed to read install manifest: %w", err)
+	}
+
+	if manifest.Asset == nil || manifest.Asset.SHA256 == "" {
+		return pinfile.LockedAsset{}, fmt.Errorf(
+			"%w: %s (reinstall it to record one)",
+			ErrLockUnverifiable,
+			name,
+		)
+	}
+
+	return pinfile.LockedAsset{Name: manifest.Asset.Name, SHA256: manifest.Asset.SHA256}, nil
+}
+
+// matchLock compares an installed archive with the one recorded for
+// this platform in the lock file at lockPath.
+func matchLock(lockPath string, recorded, installed pinfile.LockedAsset) error {
+	if recorded.Name == installed.Name && strings.EqualFold(recorded.SHA256, installed.SHA256) {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w: %s records %s (%s), installed is %s (%s)",
+		installer.ErrLockMismatch,
+		lockPath,
+		recorded.Name,
+		recorded.SHA256,
+		installed.Name,
+		installed.SHA256,
+	)
+}
+
+// ReadVersionFile reads the version pin from the directory hierarchy.
+// It searches from startDir up to the root, returning the first version
+// found. If checkGlobal is true, also checks the user's home directory.
+//
+// Each directory is checked against the enabled pin sources (see
+// NVS_PIN_SOURCES) in precedence order, so a nearer .tool-versions still
+// beats a .nvs-version further up the tree: proximity first, then source
+// precedence within a directory.
 func ReadVersionFile(startDir string, checkGlobal bool) (string, string, error) {
 	var (
 		homeVisited bool
@@ -141,6 +416,8 @@ func ReadVersionFile(startDir string, checkGlobal bool) (string, string, error)
 		}
 	}
 
+	chain := activePinSources()
+
 	// Search up the directory tree
 	dir := startDir
 	for {
@@ -148,29 +425,13 @@ func ReadVersionFile(startDir string, checkGlobal bool) (string, string, error)
 			homeVisited = true
 		}
 
-		versionFile := filepath.Join(dir, constants.VersionFileName)
+		version, versionFile, err := readPinInDir(chain, dir)
+		if err != nil {
+			return "", "", err
+		}
 
-		data, err := os.ReadFile(versionFile)
-		if err == nil {
-			version := strings.TrimSpace(string(data))
-			if version != "" {
-				// Reject path-traversal or otherwise malformed
-				// contents before they can flow into 'nvs use',
-				// the service layer, or an exec. A malicious
-				// .nvs-version file in a shared repo would
-				// otherwise be read verbatim and joined onto
-				// VersionsDir.
-				validateErr := vtypes.ValidateVersionName(version)
-				if validateErr != nil {
-					ui.Message.Warnf("Ignoring invalid version in %s: %v", versionFile, validateErr)
-
-					return "", "", validateErr
-				}
-
-				log.Debugf("Found version %s in %s", version, versionFile)
-
-				return version, versionFile, nil
-			}
+		if version != "" {
+			return version, versionFile, nil
 		}
 
 		// Move up one directory
@@ -185,37 +446,66 @@ func ReadVersionFile(startDir string, checkGlobal bool) (string, string, error)
 
 	// Check global version file in home directory if not already visited
 	if checkGlobal && !homeVisited && homeDir != "" {
-		globalFile := filepath.Join(homeDir, constants.VersionFileName)
+		version, versionFile, err := readPinInDir(chain, homeDir)
+		if err != nil {
+			return "", "", err
+		}
 
-		data, err := os.ReadFile(globalFile)
-		if err == nil {
-			version := strings.TrimSpace(string(data))
-			if version != "" {
-				validateErr := vtypes.ValidateVersionName(version)
-				if validateErr != nil {
-					ui.Message.Warnf(
-						"Ignoring invalid global version in %s: %v",
-						globalFile,
-						validateErr,
-					)
-
-					return "", "", validateErr
-				}
-
-				log.Debugf("Found global version %s in %s", version, globalFile)
-
-				return version, globalFile, nil
-			}
+		if version != "" {
+			return version, versionFile, nil
 		}
 	}
 
 	return "", "", ErrVersionFileNotFound
 }
 
+// readPinInDir returns the version pinned in dir by the highest
+// precedence source in chain, or "" when dir pins nothing.
+func readPinInDir(chain pinfile.Chain, dir string) (string, string, error) {
+	match, found, err := chain.Find(dir)
+	if err != nil {
+		ui.Message.Warnf("Ignoring unreadable pin file: %v", err)
+
+		return "", "", err
+	}
+
+	if !found {
+		return "", "", nil
+	}
+
+	// Reject path-traversal or otherwise malformed contents
+	// before they can flow into 'nvs use', the service layer,
+	// or an exec. A malicious pin file in a shared repo would
+	// otherwise be read verbatim and joined onto VersionsDir.
+	validateErr := vtypes.ValidateVersionSpec(match.Version)
+	if validateErr != nil {
+		ui.Message.Warnf("Ignoring invalid version in %s: %v", match.File, validateErr)
+
+		return "", "", validateErr
+	}
+
+	log.Debugf("Found version %s in %s (source %s)", match.Version, match.File, match.Source)
+
+	return match.Version, match.File, nil
+}
+
+// activePinSources returns the pin source chain configured in
+// InitConfig, or the default chain when InitConfig has not run
+// (e.g. ReadVersionFile called directly from tests).
+func activePinSources() pinfile.Chain {
+	if pinSources == nil {
+		return pinfile.Default()
+	}
+
+	return pinSources
+}
+
 // init registers the pinCmd with the root command.
 func init() {
 	rootCmd.AddCommand(pinCmd)
 	pinCmd.Flags().
 		BoolP("global", "g", false, "Write to home directory instead of current directory")
 	pinCmd.Flags().BoolP("pick", "p", false, "Launch interactive picker to select version")
+	pinCmd.Flags().
+		Bool("lock", false, "Record the release archive's SHA256 in .nvs-lock next to the pin")
 }
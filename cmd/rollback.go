@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/settings"
 	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/log"
 	"github.com/y3owk1n/nvs/internal/platform"
@@ -267,7 +268,7 @@ func AddNightlyToHistory(commitHash, tagName string) error {
 		// Create new history if it doesn't exist
 		history = &NightlyHistory{
 			Entries: []NightlyHistoryEntry{},
-			Limit:   constants.DefaultRollbackLimit,
+			Limit:   GetSettings().Int(settings.KeyRollbackLimit),
 		}
 	}
 
@@ -295,6 +296,11 @@ func AddNightlyToHistory(commitHash, tagName string) error {
 	}
 	history.Entries = append([]NightlyHistoryEntry{entry}, history.Entries...)
 
+	// The rollback_limit setting is authoritative: the limit stored
+	// in the history file only records the value last applied, so
+	// lowering the setting trims older entries on the next install.
+	history.Limit = GetSettings().Int(settings.KeyRollbackLimit)
+
 	// Trim to limit
 	if len(history.Entries) > history.Limit {
 		// Clean up old nightly directories
@@ -331,7 +337,7 @@ func loadNightlyHistory() (*NightlyHistory, error) {
 		if os.IsNotExist(err) {
 			return &NightlyHistory{
 				Entries: []NightlyHistoryEntry{},
-				Limit:   constants.DefaultRollbackLimit,
+				Limit:   GetSettings().Int(settings.KeyRollbackLimit),
 			}, nil
 		}
 
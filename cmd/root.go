@@ -2,9 +2,7 @@ package cmd
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -13,27 +11,39 @@ import (
 	"sync"
 
 	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/bisectsvc"
 	"github.com/y3owk1n/nvs/internal/app/config"
+	"github.com/y3owk1n/nvs/internal/app/settings"
 	"github.com/y3owk1n/nvs/internal/app/versionsvc"
 	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/release"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
 	"github.com/y3owk1n/nvs/internal/infra/archive"
+	"github.com/y3owk1n/nvs/internal/infra/archivestore"
 	"github.com/y3owk1n/nvs/internal/infra/builder"
+	"github.com/y3owk1n/nvs/internal/infra/buildlog"
+	"github.com/y3owk1n/nvs/internal/infra/depscache"
 	"github.com/y3owk1n/nvs/internal/infra/downloader"
 	"github.com/y3owk1n/nvs/internal/infra/filesystem"
 	"github.com/y3owk1n/nvs/internal/infra/github"
 	"github.com/y3owk1n/nvs/internal/infra/installer"
+	"github.com/y3owk1n/nvs/internal/infra/mirror"
+	"github.com/y3owk1n/nvs/internal/infra/pinfile"
+	"github.com/y3owk1n/nvs/internal/infra/provenance"
+	"github.com/y3owk1n/nvs/internal/infra/releasesource"
 	"github.com/y3owk1n/nvs/internal/log"
 	"github.com/y3owk1n/nvs/internal/ui/style"
 )
 
 var (
-	// verbose raises the developer log to debug level. It is a
-	// shortcut for NVS_LOG=debug; the env var wins when both
-	// are set, on the principle that the more specific signal
-	// (a named level) overrides the less specific one (a
-	// boolean toggle).
+	// verbose raises the developer log to debug level. It is
+	// the flag layer of the "log" setting, so like every flag it
+	// wins over NVS_LOG and config.toml.
 	verbose bool
 
+	// offline is the flag layer of the "offline" setting.
+	offline bool
+
 	// ctx is the global context used by the CLI.
 	// cancel cancels the context, e.g. on interrupt signals.
 	ctx, cancel = context.WithCancel(context.Background())
@@ -41,19 +51,40 @@ var (
 	// Services (initialized in InitConfig).
 	versionService *versionsvc.Service
 	configService  *config.Service
+	archiveStore   *archivestore.Store
+	depsCache      *depscache.Store
+	buildLogs      *buildlog.Store
+	bisectService  *bisectsvc.Service
+
+	// downloadMirrors is the ordered list of download mirrors, nil
+	// when none is configured (initialized in InitConfig).
+	downloadMirrors *mirror.List
+
+	// githubRepo is the repository releases and changelogs come
+	// from (initialized in InitConfig).
+	githubRepo = github.DefaultRepo()
+
+	// githubToken returns the token for GitHub API requests, looked
+	// up on first use; githubClient lists the releases (both
+	// initialized in InitConfig).
+	githubToken  = func() string { return "" }
+	githubClient *github.Client
 
 	// Configuration paths (initialized in InitConfig).
-	versionsDir   string
-	cacheFilePath string
-	globalBinDir  string
+	versionsDir        string
+	cacheFilePath      string
+	globalBinDir       string
+	partialDownloadDir string
 
-	// errInvalidGitHubMirror is returned when the GitHub mirror URL is invalid.
-	errInvalidGitHubMirror = errors.New(
-		"invalid GitHub mirror URL: must be a valid absolute URL with http:// or https://",
-	)
-	errInvalidGitHubMirrorHost = errors.New(
-		"invalid GitHub mirror URL: must include a valid host",
-	)
+	// pinSources is the ordered set of pin files ReadVersionFile
+	// consults (initialized in InitConfig from pin_sources).
+	pinSources pinfile.Chain
+
+	// effectiveSettings holds the resolved settings and
+	// settingsFilePath the location of config.toml (initialized
+	// in InitConfig).
+	effectiveSettings *settings.Effective
+	settingsFilePath  string
 
 	// Version of nvs, defaults to "v0.0.0" but may be set during build time.
 	Version = "v0.0.0"
@@ -61,7 +92,9 @@ var (
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
-		"Enable verbose logging (shortcut for NVS_LOG=debug)")
+		"Enable verbose logging (shortcut for log=debug; overrides NVS_LOG)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false,
+		"Never touch the network; use cached releases and local installs (overrides NVS_OFFLINE)")
 }
 
 // Execute initializes the configuration, sets up global flags, and executes the root command.
@@ -102,55 +135,85 @@ func Execute() error {
 
 var signalOnce sync.Once
 
+// shimExecutable returns the nvs binary the nvim shim should run, or
+// "" when shim mode is off or the binary cannot be located (the
+// symlink is used then).
+func shimExecutable(effective *settings.Effective) string {
+	if !effective.Bool(settings.KeyShims) {
+		return ""
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		log.Warnf("Shim mode disabled: cannot locate the nvs binary: %v", err)
+
+		return ""
+	}
+
+	return executable
+}
+
+// settingsBuildProfile returns the build profile the build_* settings
+// describe.
+func settingsBuildProfile(effective *settings.Effective) (vtypes.BuildProfile, error) {
+	profile := vtypes.BuildProfile{
+		Type:           effective.String(settings.KeyBuildType),
+		Sanitize:       effective.Bool(settings.KeyBuildSanitize),
+		CMakeFlags:     effective.String(settings.KeyBuildCMakeFlags),
+		DepsCMakeFlags: effective.String(settings.KeyBuildDepsFlags),
+		Name:           effective.String(settings.KeyBuildName),
+	}
+
+	return profile.Normalize()
+}
+
 // InitConfig is called automatically on command initialization.
-// It sets up logging levels, handles OS signals for graceful shutdown, and initializes services.
+// It loads the settings, sets up logging levels, handles OS signals for
+// graceful shutdown, and initializes services.
 func InitConfig() error {
-	var err error
+	// Determine the base configuration directory first: config.toml
+	// lives there, so it is the one location that can only come from
+	// NVS_CONFIG_DIR or the platform default, never from the file.
+	baseConfigDir, configDirSource, err := resolveConfigDir()
+	if err != nil {
+		return err
+	}
 
-	// Initialize the developer logger first so every step
-	// below can emit traces.
-	//
-	// Resolution order for the level:
-	//   1. NVS_LOG env var (named level, most specific)
-	//   2. -v flag      (boolean shortcut for debug)
-	//   3. default       (WarnLevel — silent in normal use)
-	//
-	// NVS_LOG_FILE, if set, tees all output to that file so
-	// the terminal UI (spinners, panels) is not polluted by
-	// debug traces even when the user wants them.
-	level := log.WarnLevel
-	if verbose {
-		level = log.DebugLevel
+	// Ensure the configuration directory exists.
+	err = os.MkdirAll(baseConfigDir, constants.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	if envLevel := os.Getenv("NVS_LOG"); envLevel != "" {
-		parsed, parseErr := log.ParseLevel(envLevel)
-		if parseErr != nil {
-			_, _ = fmt.Fprintf(os.Stderr,
-				"nvs: invalid NVS_LOG=%q, defaulting to warn: %v\n",
-				envLevel, parseErr,
-			)
-		} else {
-			level = parsed
-		}
+	// Resolve every setting: flags, then env, then config.toml, then
+	// defaults. Invalid values have been skipped in favor of the next
+	// layer; report them once each, like the NVS_* warnings always were.
+	settingsFilePath = settings.Path(baseConfigDir)
+
+	effective, warnings, err := loadSettings(settingsFilePath)
+	if err != nil {
+		return err
 	}
 
-	// NVS_LOG_FILE is optional (the empty value means "no
-	// file"). Validate it before handing to the logger so a
-	// bad value produces a clear warning instead of an
-	// opaque "open log file" error from log.Init.
-	var logFilePath string
-	if raw := os.Getenv("NVS_LOG_FILE"); raw != "" {
-		if clean, ok := validPath("NVS_LOG_FILE", raw); ok {
-			logFilePath = clean
-		}
-		// On invalid, logFilePath stays ""; validPath has
-		// already written the warning.
+	effectiveSettings = effective
+	warnSettings(warnings)
+
+	// Initialize the developer logger next so every step
+	// below can emit traces.
+	//
+	// The level comes from the "log" setting, where -v is the
+	// flag layer (a shortcut for log=debug), so it beats NVS_LOG
+	// and config.toml. log_file, if set, tees all output to that
+	// file so the terminal UI (spinners, panels) is not polluted
+	// by debug traces even when the user wants them.
+	level, parseErr := log.ParseLevel(effective.String(settings.KeyLog))
+	if parseErr != nil {
+		level = log.WarnLevel
 	}
 
 	logErr := log.Init(log.Options{
 		Level:    level,
-		FilePath: logFilePath,
+		FilePath: effective.String(settings.KeyLogFile),
 		NoColor:  !style.ColorEnabled(),
 	})
 	if logErr != nil {
@@ -161,6 +224,9 @@ func InitConfig() error {
 		log.Debug("verbose mode enabled")
 	}
 
+	log.Debug("config directory ensured", "dir", baseConfigDir, "source", configDirSource)
+	log.Debug("settings loaded", "file", settingsFilePath)
+
 	// Set up a signal handler to cancel the global context on an interrupt signal.
 	signalOnce.Do(func() {
 		sigCh := make(chan os.Signal, 1)
@@ -180,40 +246,6 @@ func InitConfig() error {
 		}()
 	})
 
-	// Determine the base configuration directory.
-	var baseConfigDir string
-	if custom, ok := validPath("NVS_CONFIG_DIR", os.Getenv("NVS_CONFIG_DIR")); ok {
-		baseConfigDir = custom
-		log.Debug("using custom config directory", "dir", baseConfigDir, "source", "NVS_CONFIG_DIR")
-	} else {
-		var (
-			configDir string
-			configErr error
-		)
-
-		configDir, configErr = os.UserConfigDir()
-		if configErr == nil {
-			baseConfigDir = filepath.Join(configDir, "nvs")
-			log.Debug("using system config directory", "dir", baseConfigDir)
-		} else {
-			home, homeErr := os.UserHomeDir()
-			if homeErr != nil {
-				return fmt.Errorf("failed to get user home directory: %w", homeErr)
-			}
-
-			baseConfigDir = filepath.Join(home, ".nvs")
-			log.Debug("falling back to home directory for config", "dir", baseConfigDir)
-		}
-	}
-
-	// Ensure the configuration directory exists.
-	err = os.MkdirAll(baseConfigDir, constants.DirPerm)
-	if err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
-
-	log.Debug("config directory ensured", "dir", baseConfigDir)
-
 	// Set the directory for installed versions.
 	versionsDir = filepath.Join(baseConfigDir, "versions")
 
@@ -225,17 +257,15 @@ func InitConfig() error {
 	log.Debug("versions directory ensured", "dir", versionsDir)
 
 	// Determine the base cache directory.
-	var baseCacheDir string
-	if custom, ok := validPath("NVS_CACHE_DIR", os.Getenv("NVS_CACHE_DIR")); ok {
-		baseCacheDir = custom
-		log.Debug("using custom cache directory", "dir", baseCacheDir, "source", "NVS_CACHE_DIR")
-	} else {
-		var (
-			cacheDir string
-			cacheErr error
+	baseCacheDir := effective.String(settings.KeyCacheDir)
+	if baseCacheDir != "" {
+		log.Debug(
+			"using custom cache directory",
+			"dir", baseCacheDir,
+			"source", effective.Source(settings.KeyCacheDir),
 		)
-
-		cacheDir, cacheErr = os.UserCacheDir()
+	} else {
+		cacheDir, cacheErr := os.UserCacheDir()
 		if cacheErr == nil {
 			baseCacheDir = filepath.Join(cacheDir, "nvs")
 			log.Debug("using system cache directory", "dir", baseCacheDir)
@@ -250,30 +280,26 @@ func InitConfig() error {
 		return fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	cacheFilePath = filepath.Join(baseCacheDir, "releases.json")
 	log.Debug("cache directory ensured", "dir", baseCacheDir)
-	log.Debug("cache file path set", "path", cacheFilePath)
 
 	// Determine the base binary directory.
-	var baseBinDir string
-	if custom, ok := validPath("NVS_BIN_DIR", os.Getenv("NVS_BIN_DIR")); ok {
-		baseBinDir = custom
-		log.Debug("using custom binary directory", "dir", baseBinDir, "source", "NVS_BIN_DIR")
+	baseBinDir := effective.String(settings.KeyBinDir)
+	if baseBinDir != "" {
+		log.Debug(
+			"using custom binary directory",
+			"dir", baseBinDir,
+			"source", effective.Source(settings.KeyBinDir),
+		)
 	} else {
-		if runtime.GOOS == constants.WindowsOS {
-			home, homeErr := os.UserHomeDir()
-			if homeErr != nil {
-				return fmt.Errorf("failed to get user home directory: %w", homeErr)
-			}
+		home, homeErr := os.UserHomeDir()
+		if homeErr != nil {
+			return fmt.Errorf("failed to get user home directory: %w", homeErr)
+		}
 
+		if runtime.GOOS == constants.WindowsOS {
 			baseBinDir = filepath.Join(home, "AppData", "Local", "Programs")
 			log.Debug("using Windows binary directory", "dir", baseBinDir)
 		} else {
-			home, homeErr := os.UserHomeDir()
-			if homeErr != nil {
-				return fmt.Errorf("failed to get user home directory: %w", homeErr)
-			}
-
 			baseBinDir = filepath.Join(home, ".local", "bin")
 			log.Debug("using default binary directory", "dir", baseBinDir)
 		}
@@ -287,63 +313,201 @@ func InitConfig() error {
 	globalBinDir = baseBinDir
 	log.Debug("global binary directory ensured", "dir", globalBinDir)
 
-	// Read GitHub mirror URL from environment
-	githubMirror := os.Getenv("NVS_GITHUB_MIRROR")
+	// The mirror URLs have already been validated as absolute
+	// http(s) URLs; an invalid mirror is fatal rather than silently
+	// falling back to github.com. URLs are rewritten onto the first
+	// mirror; the downloader fails over to the others in order.
+	downloadMirrors = nil
+	normalizedMirrorURL := ""
+
+	mirrorList := effective.String(settings.KeyGitHubMirror)
+	if mirrorList != "" {
+		downloadMirrors = mirror.New(
+			strings.Split(mirrorList, ","),
+			filepath.Join(baseCacheDir, constants.MirrorStatsFile),
+		)
+		normalizedMirrorURL = downloadMirrors.Bases()[0]
+		log.Debug("using GitHub mirrors", "urls", downloadMirrors.Bases())
+	}
 
-	var normalizedMirrorURL string
-	if githubMirror != "" {
-		parsedURL, err := url.Parse(githubMirror)
-		if err != nil {
-			return fmt.Errorf("failed to parse GitHub mirror URL: %w", err)
-		}
+	// Releases, changelogs and source builds come from neovim/neovim
+	// on github.com unless pointed at another repository or a GitHub
+	// Enterprise server.
+	githubRepo = github.NewRepo(
+		effective.String(settings.KeyGitHubAPIURL),
+		effective.String(settings.KeyGitHubRepo),
+	)
+	if !githubRepo.IsDefault() {
+		log.Debug("using GitHub repository", "api", githubRepo.APIBaseURL, "repo", githubRepo.Name)
+	}
 
-		if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-			return errInvalidGitHubMirror
-		}
+	// Each repository has its own release cache.
+	cacheFilePath = filepath.Join(baseCacheDir, githubRepo.CacheFileName())
+	log.Debug("cache file path set", "path", cacheFilePath)
 
-		if parsedURL.Host == "" {
-			return errInvalidGitHubMirrorHost
+	// Looking the token up may run 'gh', so it waits until a request
+	// to the API needs it.
+	tokenRepo := githubRepo
+	githubToken = sync.OnceValue(func() string {
+		token, source := github.Token(ctx, tokenRepo)
+		if token != "" {
+			log.Debug("authenticating GitHub API requests", "source", source)
 		}
 
-		normalizedMirrorURL = strings.TrimRight(parsedURL.String(), "/")
-		log.Debug("using GitHub mirror", "url", normalizedMirrorURL)
-	}
+		return token
+	})
 
-	// Read global cache setting from environment.
-	//
-	// parseBoolEnv treats anything outside the recognized set
-	// (1/true/yes/on and 0/false/no/off) as invalid: it
-	// warns to stderr and resolves to false, matching the
-	// previous lenient behavior of the inline check.
-	resolved, _ := parseBoolEnv("NVS_USE_GLOBAL_CACHE", os.Getenv("NVS_USE_GLOBAL_CACHE"))
+	sourceRepoURL := effective.String(settings.KeySourceRepoURL)
+	if sourceRepoURL == "" {
+		sourceRepoURL = githubRepo.CloneURL()
+	}
 
-	useGlobalCache := resolved
+	useGlobalCache := effective.Bool(settings.KeyUseGlobalCache)
 	if useGlobalCache {
 		log.Debug("global cache enabled")
 	}
 
+	offlineMode := effective.Bool(settings.KeyOffline)
+	if offlineMode {
+		log.Debug("offline mode enabled", "source", effective.Source(settings.KeyOffline))
+	}
+
+	// The pin source list has already been validated; an empty
+	// list (pin_sources = [] in config.toml) means the defaults.
+	pinSources = pinfile.Default()
+
+	chain, chainErr := pinfile.ParseChain(effective.String(settings.KeyPinSources))
+	if chainErr == nil {
+		pinSources = chain
+	}
+
+	log.Debug("pin sources", "order", pinSources.Names())
+
 	// Initialize services
-	githubClient := github.NewClient(
+	githubClient = github.NewClient(
 		cacheFilePath,
-		constants.CacheTTL,
+		effective.Duration(settings.KeyCacheTTL),
 		"0.5.0",
 		normalizedMirrorURL,
 		useGlobalCache,
+		github.WithTimeout(effective.Duration(settings.KeyAPITimeout)),
+		github.WithOffline(offlineMode),
+		github.WithRepo(githubRepo),
+		github.WithToken(githubToken),
 	)
 	versionManager := filesystem.New(&filesystem.Config{
-		VersionsDir:  versionsDir,
-		GlobalBinDir: globalBinDir,
+		VersionsDir:    versionsDir,
+		GlobalBinDir:   globalBinDir,
+		ShimExecutable: shimExecutable(effective),
 	})
 
+	// Downloaded archives are kept by checksum so reinstalls skip the
+	// network. The store exists even when caching is off, so 'nvs
+	// cache' can still list and clear it.
+	archiveStore = archivestore.New(
+		filepath.Join(baseCacheDir, constants.ArchiveCacheDir),
+		int64(effective.Int(settings.KeyArchiveCacheMaxMB))<<20, //nolint:mnd // MiB
+		effective.Duration(settings.KeyArchiveCacheMaxAge),
+	)
+
+	// Source builds build their third-party dependencies once per
+	// dependency set and share them.
+	depsCache = depscache.New(
+		filepath.Join(baseCacheDir, constants.DepsCacheDir),
+		constants.DepsCacheMaxSets,
+		constants.DepsCacheMaxAge,
+	)
+
+	// Source builds keep their full output for 'nvs logs'.
+	buildLogs = buildlog.New(
+		filepath.Join(baseCacheDir, constants.BuildLogDir),
+		effective.Int(settings.KeyBuildLogKeep),
+	)
+
+	// Interrupted downloads wait here to be resumed by the next run.
+	partialDownloadDir = filepath.Join(baseCacheDir, constants.PartialDownloadDir)
+
+	// Releases are listed by GitHub unless release_source points at
+	// an index of self-hosted builds.
+	var releaseRepo release.Repository = githubClient
+
+	localReleases := false
+
+	source := effective.String(settings.KeyReleaseSource)
+	if !releasesource.IsGitHub(source) {
+		index, openErr := releasesource.Open(
+			source,
+			releasesource.WithTimeout(effective.Duration(settings.KeyAPITimeout)),
+			releasesource.WithOffline(offlineMode),
+			releasesource.WithS3Endpoint(effective.String(settings.KeyS3Endpoint)),
+		)
+		if openErr != nil {
+			return fmt.Errorf("invalid release_source: %w", openErr)
+		}
+
+		log.Debug("using release index", "url", index.URL())
+		releaseRepo = index
+		localReleases = index.IsLocal()
+	}
+
+	downloaderOpts := []downloader.Option{
+		downloader.WithTimeout(effective.Duration(settings.KeyDownloadTimeout)),
+		downloader.WithOffline(offlineMode),
+		downloader.WithPartialDir(partialDownloadDir),
+		downloader.WithConnections(effective.Int(settings.KeyDownloadConns)),
+		// Only a local release source hands out file:// archives.
+		downloader.WithLocalFiles(localReleases),
+	}
+	if effective.Bool(settings.KeyArchiveCache) {
+		downloaderOpts = append(downloaderOpts, downloader.WithStore(archiveStore))
+	}
+
+	if downloadMirrors != nil {
+		downloaderOpts = append(downloaderOpts, downloader.WithMirrors(downloadMirrors))
+	}
+
 	// Installer components
-	dl := downloader.New()
+	dl := downloader.New(downloaderOpts...)
 	extractor := archive.New()
-	srcBuilder := builder.New(nil) // nil for default exec command
+	srcBuilder := builder.New( // nil for default exec command
+		nil,
+		builder.WithAppVersion(Version),
+		builder.WithOffline(offlineMode),
+		builder.WithRepoURL(sourceRepoURL),
+		builder.WithMirrorDir(filepath.Join(baseCacheDir, constants.SourceMirrorDir)),
+		builder.WithDepsCache(depsCache),
+		builder.WithBuildLogs(buildLogs),
+	)
 
-	installService := installer.New(dl, extractor, srcBuilder)
+	verifyPolicy, err := provenance.ParsePolicy(effective.String(settings.KeyVerifyPolicy))
+	if err != nil {
+		return fmt.Errorf("invalid verify_policy: %w", err)
+	}
+
+	verifier, err := provenance.New(
+		verifyPolicy,
+		provenance.WithSigningKey(effective.String(settings.KeySigningKey)),
+		provenance.WithKnownHashes(effective.String(settings.KeyKnownHashes)),
+	)
+	if err != nil {
+		return fmt.Errorf("invalid signing_key: %w", err)
+	}
+
+	installService := installer.New(
+		dl,
+		extractor,
+		srcBuilder,
+		installer.WithAppVersion(Version),
+		installer.WithVerifier(verifier),
+	)
+
+	buildProfile, err := settingsBuildProfile(effective)
+	if err != nil {
+		return err
+	}
 
 	versionService, err = versionsvc.New(
-		githubClient,
+		releaseRepo,
 		versionManager,
 		installService,
 		&versionsvc.Config{
@@ -352,12 +516,25 @@ func InitConfig() error {
 			GlobalBinDir:   globalBinDir,
 			MirrorURL:      normalizedMirrorURL,
 			UseGlobalCache: useGlobalCache,
+			Aliases: filesystem.NewAliasStore(
+				filepath.Join(baseConfigDir, constants.AliasesFile),
+			),
+			BuildProfile: buildProfile,
 		},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create version service: %w", err)
 	}
 
+	// Bisections drive git bisect in the source mirror and install
+	// candidates through the version service.
+	bisectService = bisectsvc.New(
+		srcBuilder,
+		versionService,
+		filepath.Join(baseConfigDir, constants.BisectStateFile),
+		buildProfile,
+	)
+
 	configService = config.New()
 
 	log.Debug("services initialized")
@@ -365,6 +542,68 @@ func InitConfig() error {
 	return nil
 }
 
+// resolveConfigDir returns the nvs config directory and where it came
+// from: NVS_CONFIG_DIR, else the platform config dir, else ~/.nvs.
+func resolveConfigDir() (string, settings.Source, error) {
+	custom, ok := validPath("NVS_CONFIG_DIR", os.Getenv("NVS_CONFIG_DIR"))
+	if ok {
+		return custom, settings.SourceEnv, nil
+	}
+
+	configDir, configErr := os.UserConfigDir()
+	if configErr == nil {
+		return filepath.Join(configDir, "nvs"), settings.SourceDefault, nil
+	}
+
+	home, homeErr := os.UserHomeDir()
+	if homeErr != nil {
+		return "", "", fmt.Errorf("failed to get user home directory: %w", homeErr)
+	}
+
+	return filepath.Join(home, ".nvs"), settings.SourceDefault, nil
+}
+
+// loadSettings resolves the effective settings against the file at
+// path. A config.toml that cannot be read or parsed is reported as a
+// warning and ignored, rather than failing every command: the user
+// still needs a working 'nvs settings edit' to fix it.
+func loadSettings(path string) (*settings.Effective, []error, error) {
+	var warnings []error
+
+	fileValues := map[string]string{}
+
+	// A bad entry only drops itself: the rest of the file, strict
+	// settings included, still applies.
+	file, err := settings.Load(path)
+	if err != nil {
+		warnings = append(warnings, err)
+	}
+
+	if file != nil {
+		fileValues = file.Values()
+	}
+
+	flags := map[string]string{}
+	if verbose {
+		flags[settings.KeyLog] = "debug"
+	}
+
+	if offline {
+		flags[settings.KeyOffline] = "true"
+	}
+
+	effective, resolveWarnings, err := settings.Resolve(settings.Layers{
+		Flags: flags,
+		Env:   settings.EnvLayer(os.Getenv),
+		File:  fileValues,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return effective, append(warnings, resolveWarnings...), nil
+}
+
 // GetVersionsDir returns the versions directory path.
 // This is a compatibility function during migration.
 func GetVersionsDir() string {
@@ -398,6 +637,62 @@ func SetVersionServiceForTesting(service *versionsvc.Service) {
 	versionService = service
 }
 
+// GetSettings returns the effective settings. Before InitConfig has
+// run (e.g. in tests that call a Run* function directly) it returns
+// the built-in defaults.
+func GetSettings() *settings.Effective {
+	if effectiveSettings == nil {
+		return settings.Defaults()
+	}
+
+	return effectiveSettings
+}
+
+// GetSettingsFilePath returns the path of config.toml.
+func GetSettingsFilePath() string {
+	return settingsFilePath
+}
+
+// GetArchiveStore returns the archive cache.
+func GetArchiveStore() *archivestore.Store {
+	return archiveStore
+}
+
+// GetDepsCache returns the source build dependency cache.
+func GetDepsCache() *depscache.Store {
+	return depsCache
+}
+
+// GetBuildLogs returns the source build logs.
+func GetBuildLogs() *buildlog.Store {
+	return buildLogs
+}
+
+// GetBisectService returns the bisect service instance.
+func GetBisectService() *bisectsvc.Service {
+	return bisectService
+}
+
+// GetDownloadMirrors returns the configured download mirrors, or nil.
+func GetDownloadMirrors() *mirror.List {
+	return downloadMirrors
+}
+
+// GetGitHubRepo returns the repository releases and changelogs come from.
+func GetGitHubRepo() github.Repo {
+	return githubRepo
+}
+
+// GetGitHubClient returns the GitHub release client.
+func GetGitHubClient() *github.Client {
+	return githubClient
+}
+
+// GetPartialDownloadDir returns where interrupted downloads are kept.
+func GetPartialDownloadDir() string {
+	return partialDownloadDir
+}
+
 // GetConfigService returns the config service instance.
 func GetConfigService() *config.Service {
 	return configService
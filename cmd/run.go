@@ -9,9 +9,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/settings"
 	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/domain/vtypes"
 	"github.com/y3owk1n/nvs/internal/log"
@@ -38,13 +38,17 @@ Examples:
   nvs run stable
   nvs run nightly -- --clean
   nvs run v0.10.3 -- -c "checkhealth"
+  nvs run work                  # a version alias (see 'nvs alias')
   nvs run --pick -- --clean`,
 	RunE: RunRun,
 }
 
 // RunRun executes the run command.
 func RunRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(cmd.Context(), constants.TimeoutMinutes*time.Minute)
+	ctx, cancel := context.WithTimeout(
+		cmd.Context(),
+		GetSettings().Duration(settings.KeyCommandTimeout),
+	)
 	defer cancel()
 
 	var versionAlias string
@@ -90,6 +94,11 @@ func RunRun(cmd *cobra.Command, args []string) error {
 
 	log.Debugf("Requested version to run: %s", versionAlias)
 
+	versionAlias, err := GetVersionService().ExpandAlias(versionAlias)
+	if err != nil {
+		return err
+	}
+
 	// Check if version is installed
 	if !GetVersionService().IsVersionInstalled(versionAlias) {
 		return fmt.Errorf(
@@ -164,13 +173,18 @@ func getNvimBinaryPath(versionAlias string) (string, error) {
 	// returned binary path is passed directly to
 	// exec.CommandContext, so a malicious alias that resolves
 	// to a file under the user's control would be exec'd
-	// without this gate.
+	// without this gate. Branch and pull request refs are
+	// validated by ParseSourceRef instead.
 	err := vtypes.ValidateVersionName(versionAlias)
+	if vtypes.IsSourceRef(versionAlias) {
+		_, err = vtypes.ParseSourceRef(versionAlias)
+	}
+
 	if err != nil {
 		return "", err
 	}
 
-	// Normalize version name
+	// Normalize version name (a branch or pull request ref maps to its directory)
 	normalized := normalizeVersionForPath(versionAlias)
 
 	// Construct version directory path
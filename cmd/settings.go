@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/settings"
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/log"
+	"github.com/y3owk1n/nvs/internal/ui"
+)
+
+// settingsCmd represents the "settings" command.
+// It reads and writes config.toml, the persistent settings file in the
+// nvs config directory. Every setting can also be given as an NVS_*
+// environment variable, which takes precedence over the file.
+//
+// Example usage:
+//
+//	nvs settings list
+//	nvs settings get cache_ttl
+//	nvs settings set rollback_limit 10
+//	nvs settings unset rollback_limit
+//	nvs settings edit
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Show or change persistent settings (config.toml)",
+	Long: `Show or change the persistent settings stored in config.toml.
+
+Each setting is resolved from, highest precedence first:
+  1. command-line flags
+  2. environment variables (NVS_*)
+  3. config.toml in the nvs config directory
+  4. built-in defaults
+
+Run 'nvs settings list' to see every setting, its effective value and
+where that value came from.`,
+}
+
+var settingsGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE:  RunSettingsGet,
+}
+
+var settingsSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Store a setting in config.toml",
+	Args:  cobra.ExactArgs(2), //nolint:mnd
+	RunE:  RunSettingsSet,
+}
+
+var settingsUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting from config.toml",
+	Args:  cobra.ExactArgs(1),
+	RunE:  RunSettingsUnset,
+}
+
+var settingsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List every setting with its effective value and source",
+	Args:    cobra.NoArgs,
+	RunE:    RunSettingsList,
+}
+
+var settingsEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open config.toml in $VISUAL or $EDITOR",
+	Args:  cobra.NoArgs,
+	RunE:  RunSettingsEdit,
+}
+
+// settingJSON is the --json shape of one setting.
+type settingJSON struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Source      string `json:"source"`
+	Env         string `json:"env"`
+	Type        string `json:"type"`
+	Default     string `json:"default"`
+	Description string `json:"description"`
+}
+
+// RunSettingsGet executes the settings get command.
+func RunSettingsGet(_ *cobra.Command, args []string) error {
+	_, ok := settings.Lookup(args[0])
+	if !ok {
+		return fmt.Errorf("%w: %q (see 'nvs settings list')", settings.ErrUnknownKey, args[0])
+	}
+
+	_, err := fmt.Fprintln(os.Stdout, GetSettings().String(args[0]))
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// RunSettingsSet executes the settings set command.
+func RunSettingsSet(_ *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+
+	file, err := settings.Load(GetSettingsFilePath())
+	if err != nil {
+		return err
+	}
+
+	err = file.Set(key, value)
+	if err != nil {
+		return err
+	}
+
+	err = file.Save()
+	if err != nil {
+		return err
+	}
+
+	log.Debug("setting saved", "key", key, "file", file.Path())
+
+	stored, _ := file.Get(key)
+	ui.Message.Successf("Set %s = %s", key, ui.Message.Accent(stored))
+	warnOverridden(key)
+
+	return nil
+}
+
+// RunSettingsUnset executes the settings unset command.
+func RunSettingsUnset(_ *cobra.Command, args []string) error {
+	key := args[0]
+
+	file, err := settings.Load(GetSettingsFilePath())
+	if err != nil {
+		return err
+	}
+
+	_, wasSet := file.Get(key)
+
+	err = file.Unset(key)
+	if err != nil {
+		return err
+	}
+
+	if !wasSet {
+		ui.Message.Infof("%s is not set in %s", key, file.Path())
+
+		return nil
+	}
+
+	err = file.Save()
+	if err != nil {
+		return err
+	}
+
+	ui.Message.Successf("Unset %s", key)
+	warnOverridden(key)
+
+	return nil
+}
+
+// RunSettingsList executes the settings list command.
+func RunSettingsList(cmd *cobra.Command, _ []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	values := GetSettings().Values()
+
+	if jsonOutput {
+		out := make([]settingJSON, 0, len(values))
+		for _, value := range values {
+			out = append(out, settingJSON{
+				Key:         value.Key,
+				Value:       value.Value,
+				Source:      string(value.Source),
+				Env:         value.Env,
+				Type:        value.Kind.String(),
+				Default:     value.Default,
+				Description: value.Description,
+			})
+		}
+
+		return outputJSON(out)
+	}
+
+	tbl := ui.Table.New("Key", "Value", "Source", "Env")
+	for _, value := range values {
+		display := value.Value
+		if display == "" {
+			display = "(unset)"
+		}
+
+		tbl.Row(value.Key, ui.Message.Accent(display), string(value.Source), value.Env)
+	}
+
+	_, _ = fmt.Fprint(os.Stdout, tbl.Render(ui.Style.Palette()))
+	_, _ = fmt.Fprintln(os.Stdout)
+
+	ui.Message.Mutedf("Settings file: %s", GetSettingsFilePath())
+
+	return nil
+}
+
+// RunSettingsEdit executes the settings edit command. The file is
+// created first if it does not exist, so the editor always opens a
+// real path, and it is validated after the editor exits so a mistake
+// is reported immediately rather than on the next unrelated command.
+func RunSettingsEdit(cmd *cobra.Command, _ []string) error {
+	path := GetSettingsFilePath()
+
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		file, loadErr := settings.Load(path)
+		if loadErr != nil {
+			return loadErr
+		}
+
+		err = file.Save()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to prepare %s: %w", path, err)
+	}
+
+	editor := settingsEditor()
+	log.Debug("opening settings", "editor", editor, "file", path)
+
+	//nolint:gosec // the editor is chosen by the user via $VISUAL/$EDITOR
+	editorCmd := exec.CommandContext(cmd.Context(), editor[0], append(editor[1:], path)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+
+	err = editorCmd.Run()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrEditorFailed, strings.Join(editor, " "), err)
+	}
+
+	file, err := settings.Load(path)
+	if err != nil {
+		return err
+	}
+
+	_, warnings, err := settings.Resolve(settings.Layers{File: file.Values()})
+	if err != nil {
+		return err
+	}
+
+	if len(warnings) > 0 {
+		for _, warning := range warnings {
+			ui.Message.Warnf("%v", warning)
+		}
+
+		return fmt.Errorf("%w: %s", settings.ErrInvalidFile, path)
+	}
+
+	ui.Message.Successf("Settings saved to %s", path)
+
+	return nil
+}
+
+// settingsEditor returns the editor command line: $VISUAL, then
+// $EDITOR, then the platform fallback. The variable may carry
+// arguments (e.g. "code --wait"), so it is split on whitespace.
+func settingsEditor() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		fields := strings.Fields(os.Getenv(name))
+		if len(fields) > 0 {
+			return fields
+		}
+	}
+
+	if runtime.GOOS == constants.WindowsOS {
+		return []string{"notepad"}
+	}
+
+	return []string{"vi"}
+}
+
+// warnOverridden tells the user when a value just written to
+// config.toml is shadowed by a higher layer, which would otherwise
+// look like 'nvs settings set' silently did nothing.
+func warnOverridden(key string) {
+	setting, _ := settings.Lookup(key)
+
+	if os.Getenv(setting.Env) != "" {
+		ui.Message.Warnf(
+			"%s is set in the environment and overrides config.toml",
+			setting.Env,
+		)
+	}
+}
+
+func init() {
+	settingsListCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	settingsCmd.AddCommand(
+		settingsGetCmd,
+		settingsSetCmd,
+		settingsUnsetCmd,
+		settingsListCmd,
+		settingsEditCmd,
+	)
+	rootCmd.AddCommand(settingsCmd)
+}
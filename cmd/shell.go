@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/log"
+	"github.com/y3owk1n/nvs/internal/ui"
+)
+
+const (
+	// shellVersionEnv names the version 'nvs shell' selected for the
+	// current shell session.
+	shellVersionEnv = "NVS_SHELL_VERSION"
+	// shellBinEnv remembers the bin directory 'nvs shell' put on PATH,
+	// so a later call can take it off again.
+	shellBinEnv = "_NVS_SHELL_BIN"
+)
+
+// shellCmd represents the "shell" command.
+// It prints shell code that switches the Neovim version for the
+// current shell session only.
+//
+// Example usage:
+//
+//	eval "$(nvs shell v0.10.2)"     # bash/zsh
+//	nvs shell nightly | source      # fish
+//	eval "$(nvs shell --unset)"
+var shellCmd = &cobra.Command{
+	Use:   "shell <version> | --unset",
+	Short: "Switch the Neovim version for the current shell only",
+	Long: `Print shell code that puts an installed version's bin directory first on
+PATH and exports NVS_SHELL_VERSION, leaving the global version alone.
+Evaluate the output in the shell you want to change:
+
+  eval "$(nvs shell v0.10.2)"     # bash / zsh
+  nvs shell v0.10.2 | source      # fish
+
+While NVS_SHELL_VERSION is set, 'nvs current', 'nvs exec' and the
+directory-change hook use it instead of the pin file and the global
+version. 'nvs shell --unset' restores the shell.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: RunShell,
+}
+
+// RunShell executes the shell command.
+func RunShell(cmd *cobra.Command, args []string) error {
+	shell, _ := cmd.Flags().GetString("shell")
+	if shell == "" {
+		shell = DetectShell()
+	}
+
+	unset, _ := cmd.Flags().GetBool("unset")
+
+	// Take a previous 'nvs shell' directory off PATH first, so
+	// switching repeatedly does not pile up entries.
+	path := removePathEntry(os.Getenv("PATH"), os.Getenv(shellBinEnv))
+
+	if unset {
+		if len(args) > 0 {
+			return ErrShellUnsetWithVersion
+		}
+
+		return emitShellCode(shell, path, "", "")
+	}
+
+	if len(args) == 0 {
+		return ErrShellVersionRequired
+	}
+
+	versionName, err := GetVersionService().Resolve(cmd.Context(), args[0])
+	if err != nil {
+		if errors.Is(err, vtypes.ErrVersionNotFound) {
+			return fmt.Errorf("%w (use 'nvs install %s' first)", err, args[0])
+		}
+
+		return err
+	}
+
+	nvimPath, err := getNvimBinaryPath(versionName)
+	if err != nil {
+		return fmt.Errorf("failed to find nvim binary: %w", err)
+	}
+
+	binDir := filepath.Dir(nvimPath)
+	path = binDir + string(os.PathListSeparator) + path
+
+	log.Debugf("Shell version %s from %s", versionName, binDir)
+
+	return emitShellCode(shell, path, versionName, binDir)
+}
+
+// emitShellCode writes the code that sets PATH and the session
+// variables for shell; an empty versionName unsets them.
+func emitShellCode(shell, path, versionName, binDir string) error {
+	var lines []string
+
+	switch shell {
+	case "fish":
+		entries := strings.Split(path, string(os.PathListSeparator))
+		for idx, entry := range entries {
+			entries[idx] = shellQuote(entry)
+		}
+
+		lines = append(lines, "set -gx PATH "+strings.Join(entries, " ")+";")
+
+		if versionName == "" {
+			lines = append(lines, "set -e "+shellVersionEnv+";", "set -e "+shellBinEnv+";")
+		} else {
+			lines = append(lines,
+				"set -gx "+shellVersionEnv+" "+shellQuote(versionName)+";",
+				"set -gx "+shellBinEnv+" "+shellQuote(binDir)+";",
+			)
+		}
+	case "bash", "zsh", "sh", "":
+		lines = append(lines, "export PATH="+shellQuote(path))
+
+		if versionName == "" {
+			lines = append(lines, "unset "+shellVersionEnv+" "+shellBinEnv)
+		} else {
+			lines = append(lines,
+				"export "+shellVersionEnv+"="+shellQuote(versionName),
+				"export "+shellBinEnv+"="+shellQuote(binDir),
+			)
+		}
+	default:
+		return fmt.Errorf("%q: %w", shell, ErrUnsupportedShell)
+	}
+
+	_, err := fmt.Fprintln(os.Stdout, strings.Join(lines, "\n"))
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// removePathEntry returns list without the path-list entry dir.
+func removePathEntry(list, dir string) string {
+	if dir == "" || list == "" {
+		return list
+	}
+
+	entries := slices.DeleteFunc(
+		strings.Split(list, string(os.PathListSeparator)),
+		func(entry string) bool { return entry == dir },
+	)
+
+	return strings.Join(entries, string(os.PathListSeparator))
+}
+
+// shellVersion returns the installed version 'nvs shell' selected for
+// this session, or false when there is none (or it has since been
+// uninstalled, which is reported once as a warning).
+func shellVersion() (vtypes.Version, bool) {
+	name := strings.TrimSpace(os.Getenv(shellVersionEnv))
+	if name == "" {
+		return vtypes.Version{}, false
+	}
+
+	versions, err := GetVersionService().List()
+	if err == nil {
+		for _, version := range versions {
+			if version.Name() == name {
+				return version, true
+			}
+		}
+	}
+
+	ui.Message.Warnf(
+		"%s=%s is not installed; ignoring it (run 'nvs shell --unset')",
+		shellVersionEnv,
+		name,
+	)
+
+	return vtypes.Version{}, false
+}
+
+// init registers the shellCmd with the root command.
+func init() {
+	rootCmd.AddCommand(shellCmd)
+	shellCmd.Flags().Bool("unset", false, "Restore the shell to the global or pinned version")
+	shellCmd.Flags().
+		String("shell", "", "Shell type for the output (bash|zsh|sh|fish). Auto-detected if not provided.")
+}
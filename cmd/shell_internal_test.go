@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRemovePathEntry(t *testing.T) {
+	t.Parallel()
+
+	sep := string(os.PathListSeparator)
+	list := strings.Join([]string{"/nvs/v1/bin", "/usr/bin", "/nvs/v1/bin", "/bin"}, sep)
+
+	want := "/usr/bin" + sep + "/bin"
+
+	got := removePathEntry(list, "/nvs/v1/bin")
+	if got != want {
+		t.Errorf("removePathEntry = %q, want %q", got, want)
+	}
+
+	got = removePathEntry(list, "")
+	if got != list {
+		t.Errorf("removePathEntry with no dir = %q, want %q", got, list)
+	}
+}
+
+func TestExecVersion_ShellOverride(t *testing.T) {
+	t.Setenv(execVersionEnv, "")
+	t.Setenv(shellVersionEnv, "v0.10.2")
+
+	spec, source, err := execVersion()
+	if err != nil || spec != "v0.10.2" || source != shellVersionEnv {
+		t.Errorf("execVersion = %q, %q, %v; want v0.10.2 from %s", spec, source, err, shellVersionEnv)
+	}
+
+	// NVS_VERSION still wins over the session version.
+	t.Setenv(execVersionEnv, "nightly")
+
+	spec, source, err = execVersion()
+	if err != nil || spec != "nightly" || source != execVersionEnv {
+		t.Errorf("execVersion = %q, %q, %v; want nightly from %s", spec, source, err, execVersionEnv)
+	}
+}
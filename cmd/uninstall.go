@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/versionsvc"
 	"github.com/y3owk1n/nvs/internal/domain/vtypes"
 	"github.com/y3owk1n/nvs/internal/log"
 	"github.com/y3owk1n/nvs/internal/ui"
@@ -23,6 +24,7 @@ import (
 //
 //	nvs uninstall v0.6.0
 //	nvs rm stable
+//	nvs uninstall work          # the version the "work" alias points at
 //	nvs uninstall --pick
 var uninstallCmd = &cobra.Command{
 	Use:     "uninstall [version]",
@@ -57,6 +59,31 @@ func RunUninstall(cmd *cobra.Command, args []string) error {
 
 	log.Debugf("Requested version: %s", versionArg)
 
+	// Resolve a user alias to the version it points at, so the
+	// current-version check below compares real version names.
+	versionArg, err := GetVersionService().ExpandAlias(versionArg)
+	if err != nil {
+		return err
+	}
+
+	// Versions that aliases point at are protected: refuse before
+	// prompting about anything else, unless --force was given.
+	force, _ := cmd.Flags().GetBool("force")
+
+	aliased, err := GetVersionService().AliasesFor(versionArg)
+	if err != nil {
+		return err
+	}
+
+	if len(aliased) > 0 && !force {
+		return fmt.Errorf(
+			"%w: %s (%s); remove the alias with 'nvs alias rm' or pass --force",
+			versionsvc.ErrAliasTarget,
+			versionArg,
+			strings.Join(aliased, ", "),
+		)
+	}
+
 	// Check if the version to uninstall is currently active.
 	//
 	// Current() can fail in two distinct ways:
@@ -75,6 +102,7 @@ func RunUninstall(cmd *cobra.Command, args []string) error {
 	isCurrent := false
 
 	current, err := GetVersionService().Current()
+
 	switch {
 	case err == nil:
 		// Normalize both versions for comparison
@@ -137,8 +165,17 @@ func RunUninstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// Uninstall using service
-	// Force uninstall if it's the current version (user already confirmed)
-	err = GetVersionService().Uninstall(versionArg, isCurrent)
+	// Force uninstall if it's the current version (user already confirmed).
+	// --force also removes the aliases pointing at the version, once
+	// it is gone.
+	var removed []string
+
+	if force {
+		removed, err = GetVersionService().UninstallWithAliases(versionArg, isCurrent)
+	} else {
+		err = GetVersionService().Uninstall(versionArg, isCurrent)
+	}
+
 	if err != nil {
 		if errors.Is(err, vtypes.ErrVersionNotFound) {
 			return fmt.Errorf("version %s is not installed: %w", versionArg, ErrVersionNotInstalled)
@@ -147,6 +184,10 @@ func RunUninstall(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to uninstall version %s: %w", versionArg, err)
 	}
 
+	for _, name := range removed {
+		ui.Message.Infof("Removed alias %s", ui.Message.Accent(name))
+	}
+
 	log.Debugf("Uninstalled version: %s", versionArg)
 
 	ui.Message.Successf("Uninstalled version: %s", ui.Message.Accent(versionArg))
@@ -237,4 +278,6 @@ func promptSwitchAfterUninstall(cmd *cobra.Command) error {
 func init() {
 	rootCmd.AddCommand(uninstallCmd)
 	uninstallCmd.Flags().BoolP("pick", "p", false, "Launch interactive picker to select version")
+	uninstallCmd.Flags().
+		BoolP("force", "f", false, "Uninstall even if aliases point at the version (removes them)")
 }
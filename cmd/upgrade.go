@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/y3owk1n/nvs/internal/app/settings"
 	"github.com/y3owk1n/nvs/internal/app/versionsvc"
 	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
 	"github.com/y3owk1n/nvs/internal/infra/filesystem"
 	"github.com/y3owk1n/nvs/internal/log"
 	"github.com/y3owk1n/nvs/internal/ui"
@@ -19,22 +22,31 @@ import (
 // upgradeCmd represents the "upgrade" command (aliases: up).
 // It upgrades the installed stable and/or nightly versions of Neovim.
 // If no argument is provided, both stable and nightly versions are upgraded (if installed).
-// Only stable or "nightly" are accepted as arguments.
-// The command fetches the latest release data, compares remote and installed identifiers,
-// and if an upgrade is available, it downloads and installs the new version.
+// Accepted arguments are "stable", "nightly", or a branch or pull request
+// build ("branch:release-0.10", "pr:12345", or its directory name).
+// The command fetches the latest release data (or the ref's upstream head),
+// compares remote and installed identifiers, and if an upgrade is
+// available, it downloads (or rebuilds) the new version.
 //
 // Example usage:
 //
 //	nvs upgrade
 //	nvs upgrade stable
 //	nvs up nightly
+//	nvs upgrade pr:12345
 var upgradeCmd = &cobra.Command{
-	Use:     "upgrade [stable|nightly]",
+	Use:     "upgrade [stable|nightly|branch:<name>|pr:<number>]",
 	Aliases: []string{"up"},
-	Short:   "Upgrade installed stable and/or nightly versions",
-	Long:    "Upgrades the installed stable and/or nightly versions. If no argument is provided, both stable and nightly are upgraded (if installed).",
-	Args:    cobra.MaximumNArgs(1),
-	RunE:    RunUpgrade,
+	Short:   "Upgrade installed stable, nightly or branch/PR builds",
+	Long: `Upgrades the installed stable and/or nightly versions. If no argument is provided, both stable and nightly are upgraded (if installed).
+
+A branch or pull request build (see 'nvs install branch:<name>' and
+'nvs install pr:<number>') is upgraded by naming it, e.g.
+'nvs upgrade pr:12345' or 'nvs upgrade pr-12345': the ref is fetched
+again and rebuilt if its head has moved. Builds are never upgraded
+implicitly because they take minutes.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: RunUpgrade,
 }
 
 // RunUpgrade executes the upgrade command.
@@ -42,7 +54,10 @@ func RunUpgrade(cmd *cobra.Command, args []string) error {
 	log.Debug("Starting upgrade command")
 
 	// Create a context with a 30-minute timeout for the upgrade process.
-	ctx, cancel := context.WithTimeout(cmd.Context(), constants.TimeoutMinutes*time.Minute)
+	ctx, cancel := context.WithTimeout(
+		cmd.Context(),
+		GetSettings().Duration(settings.KeyCommandTimeout),
+	)
 	defer cancel()
 
 	// Determine which aliases (versions) to upgrade.
@@ -79,7 +94,8 @@ func resolveUpgradeAliases(cmd *cobra.Command, args []string) ([]string, error)
 		return []string{constants.Stable, constants.Nightly}, nil
 	}
 
-	if args[0] != constants.Stable && args[0] != constants.Nightly {
+	if args[0] != constants.Stable && args[0] != constants.Nightly &&
+		!vtypes.IsSourceRef(args[0]) && !vtypes.IsSourceRefDirName(args[0]) {
 		return nil, ErrInvalidUpgradeTarget
 	}
 
@@ -87,15 +103,16 @@ func resolveUpgradeAliases(cmd *cobra.Command, args []string) ([]string, error)
 }
 
 // stableNightlyAliasCount is the number of alias slots
-// the upgrade --pick picker considers. Both stable and
-// nightly are valid upgrade targets; nothing else is
-// (the upgrade command does not accept arbitrary tags).
+// the upgrade --pick picker always considers. Stable and
+// nightly are the fixed upgrade targets; installed branch
+// and pull request builds are appended after them (the
+// upgrade command does not accept arbitrary tags).
 const stableNightlyAliasCount = 2
 
 // pickUpgradeAliases shows the interactive picker for the
-// installed stable / nightly aliases. If only one of the
-// two is installed, it is used directly; if both are
-// installed, the user picks one.
+// installed stable / nightly aliases and branch / pull
+// request builds. If only one is installed, it is used
+// directly; otherwise the user picks one.
 func pickUpgradeAliases() ([]string, error) {
 	available := make([]string, 0, stableNightlyAliasCount)
 
@@ -107,6 +124,19 @@ func pickUpgradeAliases() ([]string, error) {
 		available = append(available, constants.Nightly)
 	}
 
+	names, err := GetVersionService().InstalledVersionNames()
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		if vtypes.IsSourceRefDirName(name) {
+			available = append(available, name)
+		}
+	}
+
 	if len(available) == 0 {
 		return nil, fmt.Errorf("%w for upgrade", ErrNoVersionsAvailable)
 	}
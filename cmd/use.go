@@ -5,10 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/app/settings"
 	"github.com/y3owk1n/nvs/internal/domain/vtypes"
 	"github.com/y3owk1n/nvs/internal/log"
 	"github.com/y3owk1n/nvs/internal/platform"
@@ -26,13 +25,14 @@ import (
 //	nvs use v0.6.0
 //	nvs use nightly
 //	nvs use 1a2b3c4 (a commit hash)
-//	nvs use        (reads from .nvs-version file)
+//	nvs use        (reads from the nearest pin file)
 var useCmd = &cobra.Command{
 	Use:   "use [version|stable|nightly|commit-hash]",
 	Short: "Switch to a specific version or commit hash",
 	Long: `Switch to a specific Neovim version.
-If no version is specified, reads from .nvs-version file in the current
-directory or parent directories.`,
+If no version is specified, reads the pin from the current directory or
+its parents (.nvs-version, .nvim-version, .tool-versions or mise.toml,
+see NVS_PIN_SOURCES).`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: RunUse,
 }
@@ -40,7 +40,10 @@ directory or parent directories.`,
 // RunUse executes the use command.
 func RunUse(cmd *cobra.Command, args []string) error {
 	// Create a context with a timeout for the operation.
-	ctx, cancel := context.WithTimeout(cmd.Context(), constants.TimeoutMinutes*time.Minute)
+	ctx, cancel := context.WithTimeout(
+		cmd.Context(),
+		GetSettings().Duration(settings.KeyCommandTimeout),
+	)
 	defer cancel()
 
 	var alias string
@@ -80,7 +83,7 @@ func RunUse(cmd *cobra.Command, args []string) error {
 		if len(args) > 0 {
 			alias = args[0]
 		} else {
-			// Try to read from .nvs-version file
+			// Try to read from the nearest pin file
 			cwd, err := os.Getwd()
 			if err != nil {
 				return fmt.Errorf("failed to get current directory: %w", err)
@@ -136,6 +139,11 @@ func RunUse(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	err := enforceProjectLock(alias)
+	if err != nil {
+		return err
+	}
+
 	// Use version service to switch
 	resolvedVersion, err := GetVersionService().Use(ctx, alias)
 	if err != nil {
@@ -164,6 +172,13 @@ func RunUse(cmd *cobra.Command, args []string) error {
 
 	ui.Message.Successf("Switched to %s", ui.Message.Accent(resolvedVersion))
 
+	if os.Getenv(shellVersionEnv) != "" {
+		ui.Message.Warnf(
+			"This shell still uses %s from 'nvs shell'; run 'nvs shell --unset' to follow the switch",
+			os.Getenv(shellVersionEnv),
+		)
+	}
+
 	return nil
 }
 
@@ -3,6 +3,7 @@ module github.com/y3owk1n/nvs
 go 1.26.4
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/Masterminds/semver v1.5.0
 	github.com/charmbracelet/bubbles v1.0.0
 	github.com/charmbracelet/huh v1.0.0
@@ -0,0 +1,18 @@
+package bisectsvc
+
+import "errors"
+
+// Service errors.
+var (
+	// ErrNoBisection is returned when there is no bisection in progress.
+	ErrNoBisection = errors.New("no bisection in progress")
+	// ErrBisectionInProgress is returned when starting a bisection
+	// while another is in progress.
+	ErrBisectionInProgress = errors.New("a bisection is already in progress")
+	// ErrBisectionDone is returned when marking a candidate after the
+	// bisection has finished.
+	ErrBisectionDone = errors.New("the bisection is finished")
+	// ErrInvalidRevision is returned for a bound that is not a release
+	// tag, commit or installed version.
+	ErrInvalidRevision = errors.New("not a release tag, commit or installed version")
+)
@@ -0,0 +1,630 @@
+// Package bisectsvc provides the application service for bisecting
+// Neovim versions: finding the first release or commit with a change,
+// typically a regression.
+//
+// A bisection between two release tags first narrows the range down to
+// two adjacent releases using prebuilt release assets, then bisects the
+// commits between them with git bisect, building each candidate from
+// source. Any other pair of bounds bisects commits from the start.
+// Candidates that are not installed already are installed for the test
+// and uninstalled once judged; commit candidates are built under the
+// disposable name "<hash>-bisect". The state is saved to a file between
+// commands.
+package bisectsvc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/y3owk1n/nvs/internal/app/versionsvc"
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/bisect"
+	"github.com/y3owk1n/nvs/internal/domain/installer"
+	"github.com/y3owk1n/nvs/internal/domain/release"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/infra/builder"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// Bisection modes.
+const (
+	// ModeRelease bisects release tags using prebuilt release assets.
+	ModeRelease = "release"
+	// ModeCommit bisects commits, building each candidate from source.
+	ModeCommit = "commit"
+)
+
+// releaseTagPattern matches a release tag: "v" and a full X.Y.Z with
+// no prerelease or build metadata.
+var releaseTagPattern = regexp.MustCompile(`^v(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
+
+// BuildName is the build name commit candidates are installed under,
+// which keeps them apart from the user's own builds.
+const BuildName = "bisect"
+
+// Versions is the part of the version service a bisection installs
+// candidates with.
+type Versions interface {
+	Install(
+		ctx context.Context,
+		versionAlias string,
+		progress installer.ProgressFunc,
+		opts ...versionsvc.InstallOption,
+	) error
+	Uninstall(versionAlias string, force bool) error
+	IsVersionInstalled(versionName string) bool
+	Manifest(versionName string) (vtypes.Manifest, error)
+	ListRemote(ctx context.Context, force bool) ([]release.Release, error)
+}
+
+// LogEntry records the verdict on one candidate.
+type LogEntry struct {
+	Candidate string         `json:"candidate"`
+	Verdict   bisect.Verdict `json:"verdict"`
+}
+
+// State is a bisection in progress.
+//
+//nolint:tagliatelle
+type State struct {
+	// Mode is ModeRelease or ModeCommit.
+	Mode string `json:"mode"`
+
+	// Good and Bad bound what is left to test: release tags in
+	// release mode, revisions in commit mode.
+	Good string `json:"good"`
+	Bad  string `json:"bad"`
+
+	// Candidate is the release tag or full commit hash to test next;
+	// empty once the bisection is done.
+	Candidate string `json:"candidate,omitempty"`
+
+	// StepsLeft is roughly how many candidates remain after this one.
+	StepsLeft int `json:"steps_left"`
+
+	// Releases are the release tags strictly between Good and Bad,
+	// oldest first, and Skipped those of them that were skipped
+	// (release mode).
+	Releases []string `json:"releases,omitempty"`
+	Skipped  []string `json:"skipped,omitempty"`
+
+	// ReleasesOnly stops the bisection at two adjacent releases
+	// instead of going on to bisect the commits between them.
+	ReleasesOnly bool `json:"releases_only,omitempty"`
+
+	// FirstBad is the first bad release tag or commit hash, once
+	// found; Suspects are what it is among when skipped candidates
+	// leave the bisection undecided.
+	FirstBad string   `json:"first_bad,omitempty"`
+	Suspects []string `json:"suspects,omitempty"`
+
+	// Profile is how commit candidates are built.
+	Profile vtypes.BuildProfile `json:"profile"`
+
+	// Installed are the candidates this bisection installed, which
+	// it uninstalls again.
+	Installed []string `json:"installed,omitempty"`
+
+	Log       []LogEntry `json:"log,omitempty"`
+	StartedAt time.Time  `json:"started_at"`
+}
+
+// Done reports whether the bisection has nothing more to test.
+func (s *State) Done() bool {
+	return s.Candidate == ""
+}
+
+// Service runs bisections.
+type Service struct {
+	driver    bisect.Driver
+	versions  Versions
+	statePath string
+	profile   vtypes.BuildProfile
+}
+
+// New returns a Service that bisects commits with driver, installs
+// candidates through versions, builds commit candidates with profile
+// and keeps its state in the file at statePath.
+func New(
+	driver bisect.Driver,
+	versions Versions,
+	statePath string,
+	profile vtypes.BuildProfile,
+) *Service {
+	return &Service{
+		driver:    driver,
+		versions:  versions,
+		statePath: statePath,
+		profile:   profile,
+	}
+}
+
+// Start begins a bisection between good and bad: release tags,
+// commits, or installed versions (which stand for the commit they were
+// built from). With releasesOnly, a bisection between release tags
+// stops at two adjacent releases.
+func (s *Service) Start(ctx context.Context, good, bad string, releasesOnly bool) (*State, error) {
+	_, err := s.Status()
+	if err == nil {
+		return nil, fmt.Errorf("%w (run 'nvs bisect reset' first)", ErrBisectionInProgress)
+	}
+
+	if !errors.Is(err, ErrNoBisection) {
+		return nil, err
+	}
+
+	goodRev, err := s.revision(good)
+	if err != nil {
+		return nil, err
+	}
+
+	badRev, err := s.revision(bad)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &State{
+		Good:         goodRev,
+		Bad:          badRev,
+		ReleasesOnly: releasesOnly,
+		Profile:      s.profile,
+		StartedAt:    time.Now().UTC(),
+	}
+
+	if isReleaseTag(goodRev) && isReleaseTag(badRev) {
+		state.Mode = ModeRelease
+
+		state.Releases, err = s.releasesBetween(ctx, goodRev, badRev)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.nextRelease(ctx, state)
+	} else {
+		var step bisect.Step
+
+		state.Mode = ModeCommit
+
+		step, err = s.driver.BisectStart(ctx, goodRev, badRev)
+		if err == nil {
+			applyStep(state, step)
+		}
+	}
+
+	if err != nil {
+		// A commit bisection may have started before failing.
+		if state.Mode == ModeCommit {
+			resetErr := s.driver.BisectReset(ctx)
+			if resetErr != nil {
+				log.Debugf("Failed to reset bisection: %v", resetErr)
+			}
+		}
+
+		return nil, err
+	}
+
+	return state, s.save(state)
+}
+
+// Status returns the bisection in progress, or ErrNoBisection.
+func (s *Service) Status() (*State, error) {
+	data, err := os.ReadFile(s.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoBisection
+		}
+
+		return nil, fmt.Errorf("failed to read bisection state: %w", err)
+	}
+
+	var state State
+
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bisection state %s: %w", s.statePath, err)
+	}
+
+	return &state, nil
+}
+
+// Checkout makes sure the current candidate is installed and returns
+// its version name.
+func (s *Service) Checkout(ctx context.Context, progress installer.ProgressFunc) (string, error) {
+	state, err := s.Status()
+	if err != nil {
+		return "", err
+	}
+
+	if state.Done() {
+		return "", ErrBisectionDone
+	}
+
+	var (
+		name string
+		opts []versionsvc.InstallOption
+	)
+
+	if state.Mode == ModeRelease {
+		name = state.Candidate
+	} else {
+		short := state.Candidate[:constants.ShortCommitLen]
+
+		// A plain build the user installed will do.
+		if state.Profile.IsDefault() && s.versions.IsVersionInstalled(short) {
+			return short, nil
+		}
+
+		profile := state.Profile
+		profile.Name = BuildName
+		name = profile.InstallName(short)
+		opts = append(opts, versionsvc.WithBuildProfile(profile))
+	}
+
+	if s.versions.IsVersionInstalled(name) {
+		return name, nil
+	}
+
+	err = s.versions.Install(ctx, state.Candidate, progress, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	state.Installed = append(state.Installed, name)
+
+	return name, s.save(state)
+}
+
+// Mark records the verdict on the current candidate, uninstalls it if
+// the bisection installed it, and moves on to the next one.
+func (s *Service) Mark(ctx context.Context, verdict bisect.Verdict) (*State, error) {
+	state, err := s.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Done() {
+		return nil, fmt.Errorf("%w (run 'nvs bisect reset')", ErrBisectionDone)
+	}
+
+	candidate := state.Candidate
+
+	if state.Mode == ModeRelease {
+		markRelease(state, verdict)
+
+		err = s.nextRelease(ctx, state)
+	} else {
+		var step bisect.Step
+
+		step, err = s.driver.BisectMark(ctx, verdict, candidate)
+		if err == nil {
+			markCommit(state, verdict)
+			applyStep(state, step)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	state.Log = append(state.Log, LogEntry{Candidate: candidate, Verdict: verdict})
+	s.uninstallCandidate(state, candidate)
+
+	return state, s.save(state)
+}
+
+// TestFunc tests the installed version versionName and returns its
+// verdict. An error stops the bisection run.
+type TestFunc func(ctx context.Context, versionName string) (bisect.Verdict, error)
+
+// Run tests candidates with test until the bisection is done, calling
+// report after each verdict. A candidate that fails to build is
+// skipped, as it cannot be tested.
+func (s *Service) Run(
+	ctx context.Context,
+	test TestFunc,
+	progress installer.ProgressFunc,
+	report func(candidate string, verdict bisect.Verdict, state *State),
+) (*State, error) {
+	state, err := s.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	for !state.Done() {
+		candidate := state.Candidate
+
+		verdict, testErr := s.testCandidate(ctx, test, progress)
+		if testErr != nil {
+			return state, testErr
+		}
+
+		state, err = s.Mark(ctx, verdict)
+		if err != nil {
+			return nil, err
+		}
+
+		if report != nil {
+			report(candidate, verdict, state)
+		}
+	}
+
+	return state, nil
+}
+
+// testCandidate installs the current candidate and tests it. A
+// candidate that fails to build is skipped.
+func (s *Service) testCandidate(
+	ctx context.Context,
+	test TestFunc,
+	progress installer.ProgressFunc,
+) (bisect.Verdict, error) {
+	name, err := s.Checkout(ctx, progress)
+	if err != nil {
+		if errors.Is(err, builder.ErrBuildFailed) &&
+			!errors.Is(err, builder.ErrBuildRequirementsNotMet) &&
+			ctx.Err() == nil {
+			log.Warnf("Skipping a candidate that does not build: %v", err)
+
+			return bisect.Skip, nil
+		}
+
+		return "", err
+	}
+
+	return test(ctx, name)
+}
+
+// Reset ends the bisection, uninstalling the candidates it installed,
+// and returns its final state.
+func (s *Service) Reset(ctx context.Context) (*State, error) {
+	state, err := s.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range slices.Clone(state.Installed) {
+		s.uninstallCandidate(state, name)
+	}
+
+	if state.Mode == ModeCommit {
+		err = s.driver.BisectReset(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = os.Remove(s.statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove bisection state: %w", err)
+	}
+
+	return state, nil
+}
+
+// revision maps a bound given by the user to a git revision: a release
+// tag (with "v" added to a bare X.Y.Z), the commit an installed
+// version was built from, or a commit hash.
+func (s *Service) revision(spec string) (string, error) {
+	if isReleaseTag(spec) {
+		return spec, nil
+	}
+
+	if isReleaseTag("v" + spec) {
+		return "v" + spec, nil
+	}
+
+	if vtypes.IsValidVersionName(spec) && s.versions.IsVersionInstalled(spec) {
+		manifest, err := s.versions.Manifest(spec)
+		if err == nil && manifest.Commit != "" {
+			return manifest.Commit, nil
+		}
+	}
+
+	if vtypes.IsCommitReference(spec) || spec == constants.Nightly || spec == constants.Stable {
+		// Upstream has moving "nightly" and "stable" tags.
+		return spec, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrInvalidRevision, spec)
+}
+
+// releasesBetween returns the release tags strictly between good and
+// bad, oldest first.
+func (s *Service) releasesBetween(ctx context.Context, good, bad string) ([]string, error) {
+	goodVersion, _ := semver.NewVersion(good)
+	badVersion, _ := semver.NewVersion(bad)
+
+	if !goodVersion.LessThan(badVersion) {
+		return nil, fmt.Errorf("%w: %s is not older than %s", bisect.ErrNotOlder, good, bad)
+	}
+
+	releases, err := s.versions.ListRemote(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	var (
+		versions []*semver.Version
+		tags     = map[*semver.Version]string{}
+	)
+
+	for _, rel := range releases {
+		tag := rel.TagName()
+		if rel.Prerelease() || !isReleaseTag(tag) {
+			continue
+		}
+
+		version, _ := semver.NewVersion(tag)
+		if version.GreaterThan(goodVersion) && version.LessThan(badVersion) {
+			versions = append(versions, version)
+			tags[version] = tag
+		}
+	}
+
+	slices.SortFunc(versions, func(a, b *semver.Version) int {
+		return a.Compare(b)
+	})
+
+	between := make([]string, 0, len(versions))
+	for _, version := range versions {
+		between = append(between, tags[version])
+	}
+
+	return between, nil
+}
+
+// nextRelease picks the release to test next, near the middle of what
+// is left. Once none is left, it starts bisecting the commits between
+// the two adjacent releases, unless only releases are bisected.
+func (s *Service) nextRelease(ctx context.Context, state *State) error {
+	var testable []string
+
+	for _, tag := range state.Releases {
+		if !slices.Contains(state.Skipped, tag) {
+			testable = append(testable, tag)
+		}
+	}
+
+	if len(testable) > 0 {
+		state.Candidate = testable[len(testable)/2]
+		state.StepsLeft = stepsFor(len(testable))
+
+		return nil
+	}
+
+	state.Candidate = ""
+	state.StepsLeft = 0
+
+	if state.ReleasesOnly {
+		// Releases left are all skipped ones.
+		if len(state.Releases) == 0 {
+			state.FirstBad = state.Bad
+		} else {
+			state.Suspects = append(slices.Clone(state.Releases), state.Bad)
+		}
+
+		return nil
+	}
+
+	log.Debugf("Bisecting the commits between %s and %s", state.Good, state.Bad)
+
+	// Set first, so a failed start is reset.
+	state.Mode = ModeCommit
+
+	step, err := s.driver.BisectStart(ctx, state.Good, state.Bad)
+	if err != nil {
+		return err
+	}
+
+	state.Releases = nil
+	state.Skipped = nil
+	applyStep(state, step)
+
+	return nil
+}
+
+// uninstallCandidate uninstalls name if the bisection installed it.
+func (s *Service) uninstallCandidate(state *State, name string) {
+	if state.Mode == ModeCommit && vtypes.IsCommitReference(name) {
+		name = name[:constants.ShortCommitLen]
+		profile := state.Profile
+		profile.Name = BuildName
+		name = profile.InstallName(name)
+	}
+
+	idx := slices.Index(state.Installed, name)
+	if idx < 0 {
+		return
+	}
+
+	err := s.versions.Uninstall(name, false)
+	if err != nil && !errors.Is(err, vtypes.ErrVersionNotFound) {
+		log.Warnf("Failed to uninstall bisection candidate %s: %v", name, err)
+
+		return
+	}
+
+	state.Installed = slices.Delete(state.Installed, idx, idx+1)
+}
+
+// save writes the state file.
+func (s *Service) save(state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bisection state: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.statePath), constants.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to create bisection state directory: %w", err)
+	}
+
+	err = os.WriteFile(s.statePath, data, constants.FilePerm)
+	if err != nil {
+		return fmt.Errorf("failed to write bisection state: %w", err)
+	}
+
+	return nil
+}
+
+// markRelease narrows the release range by the verdict on the
+// candidate.
+func markRelease(state *State, verdict bisect.Verdict) {
+	idx := slices.Index(state.Releases, state.Candidate)
+
+	switch verdict {
+	case bisect.Good:
+		state.Good = state.Candidate
+		state.Releases = state.Releases[idx+1:]
+	case bisect.Bad:
+		state.Bad = state.Candidate
+		state.Releases = state.Releases[:idx]
+	case bisect.Skip:
+		state.Skipped = append(state.Skipped, state.Candidate)
+	}
+}
+
+// markCommit moves the commit bounds by the verdict on the candidate.
+func markCommit(state *State, verdict bisect.Verdict) {
+	switch verdict {
+	case bisect.Good:
+		state.Good = state.Candidate
+	case bisect.Bad:
+		state.Bad = state.Candidate
+	case bisect.Skip:
+	}
+}
+
+// applyStep copies a commit bisection step into state.
+func applyStep(state *State, step bisect.Step) {
+	state.Candidate = step.Candidate
+	state.StepsLeft = step.StepsLeft
+	state.FirstBad = step.FirstBad
+	state.Suspects = step.Suspects
+}
+
+// stepsFor returns roughly how many more candidates a binary search
+// over count candidates tests after the first.
+func stepsFor(count int) int {
+	steps := 0
+	for remaining := count / 2; remaining > 0; remaining /= 2 {
+		steps++
+	}
+
+	return steps
+}
+
+// isReleaseTag reports whether tag is a release tag such as "v0.10.2".
+// The version must be a full X.Y.Z: a lenient semver parse would take
+// an all-digit short hash such as "v1234567" for version 1234567.0.0.
+func isReleaseTag(tag string) bool {
+	return releaseTagPattern.MatchString(tag)
+}
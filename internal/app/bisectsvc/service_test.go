@@ -0,0 +1,458 @@
+package bisectsvc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/app/bisectsvc"
+	"github.com/y3owk1n/nvs/internal/app/versionsvc"
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/bisect"
+	"github.com/y3owk1n/nvs/internal/domain/installer"
+	"github.com/y3owk1n/nvs/internal/domain/release"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/infra/builder"
+)
+
+var (
+	commitA = strings.Repeat("a", 40)
+	commitB = strings.Repeat("b", 40)
+)
+
+// fakeVersions records installs and uninstalls.
+type fakeVersions struct {
+	installed   map[string]bool
+	installs    []string
+	uninstalls  []string
+	releases    []string
+	installErrs map[string]error
+}
+
+func newFakeVersions(releases ...string) *fakeVersions {
+	return &fakeVersions{installed: map[string]bool{}, releases: releases}
+}
+
+func (f *fakeVersions) Install(
+	_ context.Context,
+	versionAlias string,
+	_ installer.ProgressFunc,
+	_ ...versionsvc.InstallOption,
+) error {
+	f.installs = append(f.installs, versionAlias)
+
+	return f.installErrs[versionAlias]
+}
+
+func (f *fakeVersions) Uninstall(versionAlias string, _ bool) error {
+	f.uninstalls = append(f.uninstalls, versionAlias)
+
+	return nil
+}
+
+func (f *fakeVersions) IsVersionInstalled(versionName string) bool {
+	return f.installed[versionName]
+}
+
+func (f *fakeVersions) Manifest(versionName string) (vtypes.Manifest, error) {
+	return vtypes.Manifest{Commit: commitB, Identifier: versionName}, nil
+}
+
+func (f *fakeVersions) ListRemote(context.Context, bool) ([]release.Release, error) {
+	releases := make([]release.Release, 0, len(f.releases))
+	for _, tag := range f.releases {
+		releases = append(releases, release.New(tag, false, "", time.Time{}, nil))
+	}
+
+	releases = append(releases, release.New("nightly", true, commitA, time.Time{}, nil))
+
+	return releases, nil
+}
+
+// fakeDriver bisects a history in which commitA is the only commit
+// between the bounds.
+type fakeDriver struct {
+	starts [][2]string
+	marks  []bisect.Verdict
+	resets int
+}
+
+func (f *fakeDriver) BisectStart(_ context.Context, good, bad string) (bisect.Step, error) {
+	f.starts = append(f.starts, [2]string{good, bad})
+
+	return bisect.Step{Candidate: commitA}, nil
+}
+
+func (f *fakeDriver) BisectMark(
+	_ context.Context,
+	verdict bisect.Verdict,
+	commit string,
+) (bisect.Step, error) {
+	f.marks = append(f.marks, verdict)
+
+	switch verdict {
+	case bisect.Bad:
+		return bisect.Step{FirstBad: commit}, nil
+	case bisect.Skip:
+		return bisect.Step{Suspects: []string{commit, commitB}}, nil
+	default:
+		return bisect.Step{FirstBad: commitB}, nil
+	}
+}
+
+func (f *fakeDriver) BisectReset(context.Context) error {
+	f.resets++
+
+	return nil
+}
+
+func newService(t *testing.T, versions *fakeVersions, driver *fakeDriver) *bisectsvc.Service {
+	t.Helper()
+
+	statePath := filepath.Join(t.TempDir(), "bisect.json")
+
+	return bisectsvc.New(driver, versions, statePath, vtypes.BuildProfile{})
+}
+
+// TestService_ReleasesThenCommits tests that a bisection between
+// release tags tests releases first, then bisects the commits between
+// the two adjacent ones, and cleans up every candidate it installed.
+func TestService_ReleasesThenCommits(t *testing.T) {
+	versions := newFakeVersions("v0.9.0", "v0.9.1", "v0.9.2", "v0.9.3", "v0.9.4", "v0.9.5")
+	versions.installed["v0.9.2"] = true // the user's own install
+	driver := &fakeDriver{}
+	service := newService(t, versions, driver)
+
+	state, err := service.Start(t.Context(), "0.9.0", "v0.9.5", false)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if state.Mode != bisectsvc.ModeRelease || state.Candidate != "v0.9.3" {
+		t.Fatalf("first candidate = %s %s, want release v0.9.3", state.Mode, state.Candidate)
+	}
+
+	// Releases from v0.9.3 on are bad.
+	var tested []string
+
+	test := func(_ context.Context, name string) (bisect.Verdict, error) {
+		tested = append(tested, name)
+
+		if name == "v0.9.2" || name == "v0.9.1" {
+			return bisect.Good, nil
+		}
+
+		return bisect.Bad, nil
+	}
+
+	state, err = service.Run(t.Context(), test, nil, nil)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	wantTested := []string{"v0.9.3", "v0.9.2", "aaaaaaa-bisect"}
+	if !slices.Equal(tested, wantTested) {
+		t.Errorf("tested %v, want %v", tested, wantTested)
+	}
+
+	if len(driver.starts) != 1 || driver.starts[0] != [2]string{"v0.9.2", "v0.9.3"} {
+		t.Errorf("commit bisection started with %v, want v0.9.2..v0.9.3", driver.starts)
+	}
+
+	if !state.Done() || state.FirstBad != commitA {
+		t.Errorf("state = %+v, want first bad commit %s", state, commitA)
+	}
+
+	// The user's v0.9.2 stays; what the bisection installed goes.
+	wantUninstalls := []string{"v0.9.3", "aaaaaaa-bisect"}
+	if !slices.Equal(versions.uninstalls, wantUninstalls) {
+		t.Errorf("uninstalled %v, want %v", versions.uninstalls, wantUninstalls)
+	}
+
+	_, err = service.Mark(t.Context(), bisect.Good)
+	if !errors.Is(err, bisectsvc.ErrBisectionDone) {
+		t.Errorf("Mark after the end error = %v, want ErrBisectionDone", err)
+	}
+
+	_, err = service.Reset(t.Context())
+	if err != nil || driver.resets != 1 {
+		t.Fatalf("Reset error = %v after %d driver resets", err, driver.resets)
+	}
+
+	_, err = service.Status()
+	if !errors.Is(err, bisectsvc.ErrNoBisection) {
+		t.Errorf("Status after Reset error = %v, want ErrNoBisection", err)
+	}
+}
+
+// TestService_ReleasesOnly tests that a release-only bisection stops
+// at adjacent releases and reports skipped releases as suspects.
+func TestService_ReleasesOnly(t *testing.T) {
+	versions := newFakeVersions("v0.9.0", "v0.9.1", "v0.9.2", "v0.9.3")
+	driver := &fakeDriver{}
+	service := newService(t, versions, driver)
+
+	state, err := service.Start(t.Context(), "v0.9.0", "v0.9.3", true)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	for _, verdict := range []bisect.Verdict{bisect.Skip, bisect.Good} {
+		state, err = service.Mark(t.Context(), verdict)
+		if err != nil {
+			t.Fatalf("Mark(%s) failed: %v", verdict, err)
+		}
+	}
+
+	if !state.Done() || !slices.Equal(state.Suspects, []string{"v0.9.2", "v0.9.3"}) {
+		t.Errorf("state = %+v, want suspects v0.9.2 and v0.9.3", state)
+	}
+
+	if len(driver.starts) != 0 {
+		t.Errorf("commit bisection started: %v", driver.starts)
+	}
+
+	_, err = service.Reset(t.Context())
+	if err != nil || driver.resets != 0 {
+		t.Errorf("Reset error = %v after %d driver resets, want none", err, driver.resets)
+	}
+}
+
+// TestService_Start tests how bounds are resolved and rejected.
+func TestService_Start(t *testing.T) {
+	versions := newFakeVersions("v0.9.0", "v0.9.1")
+	versions.installed["nightly"] = true
+	driver := &fakeDriver{}
+	service := newService(t, versions, driver)
+
+	_, err := service.Start(t.Context(), "v0.9.1", "v0.9.0", false)
+	if !errors.Is(err, bisect.ErrNotOlder) {
+		t.Errorf("Start(newer, older) error = %v, want ErrNotOlder", err)
+	}
+
+	_, err = service.Start(t.Context(), "v0.9.0", "../etc", false)
+	if !errors.Is(err, bisectsvc.ErrInvalidRevision) {
+		t.Errorf("Start with a bad bound error = %v, want ErrInvalidRevision", err)
+	}
+
+	// An installed nightly stands for the commit it was built from.
+	state, err := service.Start(t.Context(), "v0.9.0", "nightly", false)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if state.Mode != bisectsvc.ModeCommit || driver.starts[0] != [2]string{"v0.9.0", commitB} {
+		t.Errorf("Start(v0.9.0, nightly) = %s mode from %v", state.Mode, driver.starts)
+	}
+
+	_, err = service.Start(t.Context(), "v0.9.0", "nightly", false)
+	if !errors.Is(err, bisectsvc.ErrBisectionInProgress) {
+		t.Errorf("second Start error = %v, want ErrBisectionInProgress", err)
+	}
+}
+
+// TestService_AllDigitHashBound tests that an all-digit short hash is
+// taken for a commit, not for a release tag, and that a bare X.Y.Z
+// still names a release.
+func TestService_AllDigitHashBound(t *testing.T) {
+	versions := newFakeVersions("v0.10.0", "v0.10.1", "v0.10.2")
+	driver := &fakeDriver{}
+	service := newService(t, versions, driver)
+
+	state, err := service.Start(t.Context(), "v0.10.0", "1234567", false)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if state.Mode != bisectsvc.ModeCommit || driver.starts[0] != [2]string{"v0.10.0", "1234567"} {
+		t.Errorf("Start(v0.10.0, 1234567) = %s mode from %v, want a commit bisection",
+			state.Mode, driver.starts)
+	}
+
+	_, err = service.Reset(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = service.Start(t.Context(), "0.10.0", "0.10.2", false)
+	if err != nil || state.Mode != bisectsvc.ModeRelease {
+		t.Errorf("Start(0.10.0, 0.10.2) = %+v, %v; want a release bisection", state, err)
+	}
+}
+
+// TestService_RunSkipsBuildFailures tests that a candidate that does
+// not build is skipped rather than ending the run, while other errors
+// end it.
+func TestService_RunSkipsBuildFailures(t *testing.T) {
+	versions := newFakeVersions()
+	versions.installErrs = map[string]error{
+		commitA: fmt.Errorf("after 3 attempts: %w", builder.ErrBuildFailed),
+	}
+	driver := &fakeDriver{}
+	service := newService(t, versions, driver)
+
+	_, err := service.Start(t.Context(), commitB, "master", false)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	state, err := service.Run(t.Context(), func(context.Context, string) (bisect.Verdict, error) {
+		t.Error("a candidate that failed to build was tested")
+
+		return bisect.Bad, nil
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !slices.Equal(driver.marks, []bisect.Verdict{bisect.Skip}) || len(state.Suspects) != 2 {
+		t.Errorf("marks = %v, state = %+v; want the candidate skipped", driver.marks, state)
+	}
+
+	_, _ = service.Reset(t.Context())
+
+	// Missing build tools would fail every candidate: stop instead.
+	versions.installErrs[commitA] = errors.Join(
+		builder.ErrBuildFailed,
+		builder.ErrBuildRequirementsNotMet,
+	)
+
+	_, err = service.Start(t.Context(), commitB, "master", false)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	_, err = service.Run(t.Context(), func(context.Context, string) (bisect.Verdict, error) {
+		return bisect.Bad, nil
+	}, nil, nil)
+	if !errors.Is(err, builder.ErrBuildRequirementsNotMet) {
+		t.Errorf("Run error = %v, want ErrBuildRequirementsNotMet", err)
+	}
+}
+
+// releaseHistory creates a repository like upstream's: v0.10.0 and
+// six more commits on master, tagged v0.11.0 at the end, and v0.10.1
+// and v0.10.2 on a release-0.10 branch off v0.10.0. It returns the
+// repository's path and the master commits after v0.10.0, in order.
+func releaseHistory(t *testing.T) (string, []string) {
+	t.Helper()
+
+	_, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Setenv("GIT_AUTHOR_NAME", "nvs")
+	t.Setenv("GIT_AUTHOR_EMAIL", "nvs@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "nvs")
+	t.Setenv("GIT_COMMITTER_EMAIL", "nvs@example.com")
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.CommandContext(t.Context(), "git", args...)
+		cmd.Dir = dir
+
+		out, runErr := cmd.CombinedOutput()
+		if runErr != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), runErr, out)
+		}
+
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(message string) string {
+		writeErr := os.WriteFile(filepath.Join(dir, "f"), []byte(message), 0o644)
+		if writeErr != nil {
+			t.Fatal(writeErr)
+		}
+
+		run("add", "f")
+		run("commit", "--quiet", "-m", message)
+
+		return run("rev-parse", "HEAD")
+	}
+
+	run("init", "--quiet", "--initial-branch=master")
+	commit("release 0.10.0")
+	run("tag", "v0.10.0")
+
+	run("checkout", "--quiet", "-b", "release-0.10")
+	commit("backport 1")
+	run("tag", "v0.10.1")
+	commit("backport 2")
+	run("tag", "v0.10.2")
+
+	run("checkout", "--quiet", "master")
+
+	hashes := make([]string, 0, 6)
+	for idx := 1; idx <= 6; idx++ {
+		hashes = append(hashes, commit(fmt.Sprintf("master %d", idx)))
+	}
+
+	run("tag", "v0.11.0")
+
+	return dir, hashes
+}
+
+// TestService_PatchReleasesOnReleaseBranch tests a bisection whose
+// releases narrow it to a patch release on a release branch and the
+// next minor release on master: the commit bisection starts from
+// bounds that are not ancestors of one another.
+func TestService_PatchReleasesOnReleaseBranch(t *testing.T) {
+	repo, hashes := releaseHistory(t)
+	firstBad := hashes[3]
+
+	driver := builder.New(
+		nil,
+		builder.WithRepoURL(repo),
+		builder.WithMirrorDir(filepath.Join(t.TempDir(), "neovim.git")),
+	)
+	versions := newFakeVersions("v0.10.0", "v0.10.1", "v0.10.2", "v0.11.0")
+	service := bisectsvc.New(
+		driver,
+		versions,
+		filepath.Join(t.TempDir(), "bisect.json"),
+		vtypes.BuildProfile{},
+	)
+
+	_, err := service.Start(t.Context(), "v0.10.0", "v0.11.0", false)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// Every commit from firstBad on is bad, and so is v0.11.0.
+	test := func(_ context.Context, name string) (bisect.Verdict, error) {
+		if name == "v0.11.0" {
+			return bisect.Bad, nil
+		}
+
+		short := strings.TrimSuffix(name, "-bisect")
+		for _, hash := range hashes[slices.Index(hashes, firstBad):] {
+			if strings.HasPrefix(hash, short) && len(short) == constants.ShortCommitLen {
+				return bisect.Bad, nil
+			}
+		}
+
+		return bisect.Good, nil
+	}
+
+	state, err := service.Run(t.Context(), test, nil, nil)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !state.Done() || state.FirstBad != firstBad {
+		t.Errorf("state = %+v, want first bad commit %s", state, firstBad)
+	}
+
+	_, err = service.Reset(t.Context())
+	if err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+}
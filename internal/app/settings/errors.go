@@ -0,0 +1,39 @@
+package settings
+
+import "errors"
+
+// Settings errors.
+var (
+	// ErrUnknownKey is returned when a setting key is not part of the schema.
+	ErrUnknownKey = errors.New("unknown setting")
+
+	// ErrInvalidValue is returned when a value does not satisfy its setting's kind or rules.
+	ErrInvalidValue = errors.New("invalid setting value")
+
+	// ErrInvalidFile is returned when config.toml cannot be parsed.
+	ErrInvalidFile = errors.New("invalid settings file")
+
+	// ErrUnsupportedType is returned when config.toml holds a value of a type no setting uses.
+	ErrUnsupportedType = errors.New("unsupported value type")
+
+	// ErrNotBool is returned when a boolean setting has an unrecognized spelling.
+	ErrNotBool = errors.New("expected 1/true/yes/on or 0/false/no/off")
+
+	// ErrNotPositive is returned when a numeric or duration setting is not a positive value.
+	ErrNotPositive = errors.New("expected a positive value")
+
+	// ErrTooLarge is returned when a numeric setting exceeds its maximum.
+	ErrTooLarge = errors.New("expected at most")
+
+	// ErrControlCharacter is returned when a path setting contains a control character.
+	ErrControlCharacter = errors.New("contains a control character")
+
+	// ErrInvalidURL is returned when a URL setting is not an absolute http(s) URL.
+	ErrInvalidURL = errors.New("must be an absolute http:// or https:// URL")
+
+	// ErrInvalidRepo is returned when a repository setting is not "owner/name".
+	ErrInvalidRepo = errors.New("expected owner/name")
+
+	// ErrInvalidGitURL is returned when a clone URL could be mistaken for a git option.
+	ErrInvalidGitURL = errors.New("expected a git URL without spaces, not starting with -")
+)
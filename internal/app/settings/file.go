@@ -0,0 +1,265 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/y3owk1n/nvs/internal/constants"
+)
+
+// File is the persistent settings file (config.toml). Values are kept
+// in their normalized string form and converted to native TOML types
+// only when written, so the file reads naturally
+// (use_global_cache = true, rollback_limit = 5, pin_sources = [...]).
+type File struct {
+	path   string
+	values map[string]string
+}
+
+// Path returns the location of the settings file inside configDir.
+func Path(configDir string) string {
+	return filepath.Join(configDir, FileName)
+}
+
+// Load reads the settings file at path. A missing file is not an error
+// and yields an empty File that Save will create. Unknown keys and
+// values of the wrong TOML type are reported so a misspelled setting
+// is not silently ignored, but only that entry is skipped: Load then
+// returns both the File holding the rest and the error. Values are
+// otherwise validated lazily, by Resolve, so one bad entry does not
+// discard the rest of the file. A file that does not parse returns no
+// File.
+func Load(path string) (*File, error) {
+	file := &File{path: path, values: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return file, nil
+		}
+
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	raw := make(map[string]any)
+
+	_, err = toml.Decode(string(data), &raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidFile, path, err)
+	}
+
+	var errs []error
+
+	for _, key := range slices.Sorted(maps.Keys(raw)) {
+		_, known := Lookup(key)
+		if !known {
+			errs = append(errs, fmt.Errorf("%w: %s: %q", ErrUnknownKey, path, key))
+
+			continue
+		}
+
+		str, err := tomlToString(raw[key])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %s: %w", ErrInvalidFile, path, key, err))
+
+			continue
+		}
+
+		file.values[key] = str
+	}
+
+	return file, errors.Join(errs...)
+}
+
+// Path returns the file's location on disk.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Values returns a copy of the raw values set in the file, keyed by
+// setting key. It is the File layer passed to Resolve.
+func (f *File) Values() map[string]string {
+	out := make(map[string]string, len(f.values))
+	for key, value := range f.values {
+		out[key] = value
+	}
+
+	return out
+}
+
+// Get returns the value the file sets for key, if any.
+func (f *File) Get(key string) (string, bool) {
+	value, ok := f.values[key]
+
+	return value, ok
+}
+
+// Set validates value for key and stores its normalized form. The file
+// is not written until Save is called.
+func (f *File) Set(key, value string) error {
+	setting, ok := Lookup(key)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKey, key)
+	}
+
+	normalized, err := setting.Normalize(value)
+	if err != nil {
+		return err
+	}
+
+	f.values[key] = normalized
+
+	return nil
+}
+
+// Unset removes key from the file so lower layers take effect again.
+func (f *File) Unset(key string) error {
+	_, ok := Lookup(key)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKey, key)
+	}
+
+	delete(f.values, key)
+
+	return nil
+}
+
+// Save writes the file atomically (temp file, fsync, rename) so a
+// crash or a concurrent reader never observes a half-written config.
+// Keys are written in schema order.
+func (f *File) Save() error {
+	var buf bytes.Buffer
+
+	buf.WriteString("# nvs settings. Edit with 'nvs settings set' or 'nvs settings edit'.\n")
+	buf.WriteString("# Environment variables (NVS_*) and flags override values set here.\n\n")
+
+	for _, setting := range definitions {
+		value, ok := f.values[setting.Key]
+		if !ok {
+			continue
+		}
+
+		line, err := encodeLine(setting, value)
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString(line)
+	}
+
+	dir := filepath.Dir(f.path)
+
+	err := os.MkdirAll(dir, constants.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, FileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp settings file: %w", err)
+	}
+
+	tmpName := tmp.Name()
+
+	defer func() {
+		_ = os.Remove(tmpName)
+	}()
+
+	_, err = tmp.Write(buf.Bytes())
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write settings file: %w", err)
+	}
+
+	err = os.Rename(tmpName, f.path)
+	if err != nil {
+		return fmt.Errorf("failed to replace settings file: %w", err)
+	}
+
+	return nil
+}
+
+// encodeLine renders one "key = value" line using the TOML type that
+// matches the setting's kind.
+func encodeLine(setting Setting, value string) (string, error) {
+	var native any
+
+	switch setting.Kind {
+	case KindBool:
+		parsed, err := ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+
+		native = parsed
+	case KindInt:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s: %w", ErrInvalidValue, setting.Key, err)
+		}
+
+		native = parsed
+	case KindList:
+		items := []string{}
+		if value != "" {
+			items = strings.Split(value, ",")
+		}
+
+		native = items
+	case KindString, KindPath, KindDuration:
+		native = value
+	}
+
+	var buf bytes.Buffer
+
+	err := toml.NewEncoder(&buf).Encode(map[string]any{setting.Key: native})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", setting.Key, err)
+	}
+
+	return buf.String(), nil
+}
+
+// tomlToString converts a decoded TOML value to the string form used
+// by the layers. Arrays become comma-separated lists; tables are not
+// valid for any setting.
+func tomlToString(value any) (string, error) {
+	switch typed := value.(type) {
+	case string:
+		return typed, nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	case int64:
+		return strconv.FormatInt(typed, 10), nil
+	case []any:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			str, isString := item.(string)
+			if !isString {
+				return "", ErrUnsupportedType
+			}
+
+			items = append(items, str)
+		}
+
+		return strings.Join(items, ","), nil
+	default:
+		return "", ErrUnsupportedType
+	}
+}
@@ -0,0 +1,189 @@
+package settings
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source identifies the layer an effective value came from.
+type Source string
+
+// Layers, highest precedence first.
+const (
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+	SourceDefault Source = "default"
+)
+
+// Layers holds the raw, not yet validated values of every layer above
+// the defaults. Each map is keyed by setting key; a missing key means
+// the layer does not set that setting.
+type Layers struct {
+	Flags map[string]string
+	Env   map[string]string
+	File  map[string]string
+}
+
+// EnvLayer reads every setting's environment variable through getenv
+// (normally os.Getenv). Empty values count as unset, matching how nvs
+// has always treated NVS_* variables.
+func EnvLayer(getenv func(string) string) map[string]string {
+	env := make(map[string]string)
+
+	for _, setting := range definitions {
+		value := getenv(setting.Env)
+		if strings.TrimSpace(value) != "" {
+			env[setting.Key] = value
+		}
+	}
+
+	return env
+}
+
+// Value is the effective value of one setting.
+type Value struct {
+	Setting
+
+	// Value is the normalized effective value.
+	Value string
+	// Source is the layer Value came from.
+	Source Source
+}
+
+// Effective is the resolved value of every setting.
+type Effective struct {
+	values map[string]Value
+}
+
+// Resolve picks, for every setting, the value of the highest-precedence
+// layer that sets it to something valid. Invalid values in lenient
+// settings are returned as warnings and skipped; an invalid value in a
+// strict setting is returned as the error.
+func Resolve(layers Layers) (*Effective, []error, error) {
+	var warnings []error
+
+	effective := &Effective{values: make(map[string]Value, len(definitions))}
+
+	for _, setting := range definitions {
+		resolved := Value{Setting: setting, Value: setting.Default, Source: SourceDefault}
+
+		for _, layer := range []struct {
+			source Source
+			values map[string]string
+		}{
+			{SourceFlag, layers.Flags},
+			{SourceEnv, layers.Env},
+			{SourceFile, layers.File},
+		} {
+			raw, ok := layer.values[setting.Key]
+			if !ok {
+				continue
+			}
+
+			normalized, err := setting.Normalize(raw)
+			if err != nil {
+				if setting.strict {
+					return nil, warnings, err
+				}
+
+				warnings = append(warnings, layerError(layer.source, setting, err))
+
+				continue
+			}
+
+			resolved.Value = normalized
+			resolved.Source = layer.source
+
+			break
+		}
+
+		effective.values[setting.Key] = resolved
+	}
+
+	return effective, warnings, nil
+}
+
+// Defaults returns the effective settings when no layer sets anything.
+func Defaults() *Effective {
+	effective, _, _ := Resolve(Layers{})
+
+	return effective
+}
+
+// Get returns the effective value of key. Unknown keys return the zero
+// Value.
+func (e *Effective) Get(key string) Value {
+	return e.values[key]
+}
+
+// Values returns every effective value in display order.
+func (e *Effective) Values() []Value {
+	out := make([]Value, 0, len(definitions))
+	for _, setting := range definitions {
+		out = append(out, e.values[setting.Key])
+	}
+
+	return out
+}
+
+// String returns the effective value of key.
+func (e *Effective) String(key string) string {
+	return e.values[key].Value
+}
+
+// Bool returns the effective value of a KindBool setting.
+func (e *Effective) Bool(key string) bool {
+	parsed, _ := ParseBool(e.values[key].Value)
+
+	return parsed
+}
+
+// Int returns the effective value of a KindInt setting.
+func (e *Effective) Int(key string) int {
+	parsed, _ := strconv.Atoi(e.values[key].Value)
+
+	return parsed
+}
+
+// Duration returns the effective value of a KindDuration setting.
+func (e *Effective) Duration(key string) time.Duration {
+	parsed, _ := time.ParseDuration(e.values[key].Value)
+
+	return parsed
+}
+
+// Source returns the layer the effective value of key came from.
+func (e *Effective) Source(key string) Source {
+	return e.values[key].Source
+}
+
+// layerError prefixes a validation error with where the bad value came
+// from, so the warning tells the user which knob to fix.
+func layerError(source Source, setting Setting, err error) error {
+	switch source {
+	case SourceEnv:
+		return &LayerError{Where: setting.Env, Err: err}
+	case SourceFile:
+		return &LayerError{Where: FileName, Err: err}
+	default:
+		return &LayerError{Where: string(source), Err: err}
+	}
+}
+
+// LayerError is a validation error tagged with the layer that produced it.
+type LayerError struct {
+	// Where names the source: an environment variable, "config.toml"
+	// or "flag".
+	Where string
+	Err   error
+}
+
+func (e *LayerError) Error() string {
+	return e.Where + ": " + e.Err.Error()
+}
+
+func (e *LayerError) Unwrap() error {
+	return e.Err
+}
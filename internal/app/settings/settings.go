@@ -0,0 +1,603 @@
+// Package settings defines the nvs user settings: a typed schema of
+// every tunable, the persistent config.toml file, and the layered
+// resolution that turns flags, environment variables, the file and the
+// built-in defaults into one effective value per setting.
+//
+// Precedence, highest first:
+//
+//  1. command-line flags
+//  2. environment variables (NVS_*)
+//  3. config.toml in the nvs config directory
+//  4. built-in defaults
+//
+// A value that fails validation in one layer is reported and skipped,
+// so the next layer down supplies the effective value. This matches the
+// long-standing behavior of the NVS_* variables, which warn and fall
+// back instead of aborting every command over a typo.
+package settings
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// FileName is the name of the settings file inside the nvs config directory.
+const FileName = "config.toml"
+
+// Setting keys. Each key is also the TOML key in config.toml.
+const (
+	KeyCacheDir           = "cache_dir"
+	KeyBinDir             = "bin_dir"
+	KeyGitHubMirror       = "github_mirror"
+	KeyGitHubAPIURL       = "github_api_url"
+	KeyGitHubRepo         = "github_repo"
+	KeySourceRepoURL      = "source_repo_url"
+	KeyBuildType          = "build_type"
+	KeyBuildSanitize      = "build_sanitize"
+	KeyBuildCMakeFlags    = "build_cmake_flags"
+	KeyBuildDepsFlags     = "build_deps_flags"
+	KeyBuildName          = "build_name"
+	KeyReleaseSource      = "release_source"
+	KeyS3Endpoint         = "s3_endpoint"
+	KeyUseGlobalCache     = "use_global_cache"
+	KeyPinSources         = "pin_sources"
+	KeyShims              = "shims"
+	KeyOffline            = "offline"
+	KeyArchiveCache       = "archive_cache"
+	KeyArchiveCacheMaxMB  = "archive_cache_max_mb"
+	KeyArchiveCacheMaxAge = "archive_cache_max_age"
+	KeyLog                = "log"
+	KeyLogFile            = "log_file"
+	KeyCacheTTL           = "cache_ttl"
+	KeyRollbackLimit      = "rollback_limit"
+	KeyBuildLogKeep       = "build_log_keep"
+	KeyCommandTimeout     = "command_timeout"
+	KeyAPITimeout         = "api_timeout"
+	KeyHTTPTimeout        = "http_timeout"
+	KeyDownloadTimeout    = "download_timeout"
+	KeyDownloadConns      = "download_connections"
+	KeyVerifyPolicy       = "verify_policy"
+	KeySigningKey         = "signing_key"
+	KeyKnownHashes        = "known_hashes"
+)
+
+// Kind is the value type of a setting. It decides how raw strings are
+// validated and normalized, and how the value is written to config.toml.
+type Kind int
+
+const (
+	// KindString is free-form text.
+	KindString Kind = iota
+	// KindPath is a filesystem path; control characters are rejected.
+	KindPath
+	// KindBool accepts 1/true/yes/on and 0/false/no/off.
+	KindBool
+	// KindInt is a positive integer.
+	KindInt
+	// KindDuration is a positive Go duration such as "90s" or "5m".
+	KindDuration
+	// KindList is a comma-separated list.
+	KindList
+)
+
+// String returns the name of the kind as shown by 'nvs settings list'.
+func (k Kind) String() string {
+	switch k {
+	case KindString:
+		return "string"
+	case KindPath:
+		return "path"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		return "int"
+	case KindDuration:
+		return "duration"
+	case KindList:
+		return "list"
+	default:
+		return "unknown"
+	}
+}
+
+// Setting describes one tunable.
+type Setting struct {
+	// Key is the config.toml key and the name used by 'nvs settings'.
+	Key string
+	// Env is the environment variable that overrides the file.
+	Env string
+	// Kind is the value type.
+	Kind Kind
+	// Default is the built-in value. An empty default for a path
+	// means "platform default", computed by the caller.
+	Default string
+	// Description is a one-line summary shown by 'nvs settings list'.
+	Description string
+
+	// validate optionally rejects values the kind alone accepts.
+	validate func(value string) error
+	// strict makes an invalid value fatal instead of falling through
+	// to the next layer. It is reserved for settings where silently
+	// using the default would do something the user explicitly
+	// tried to avoid (e.g. bypassing a configured mirror).
+	strict bool
+}
+
+// definitions is the schema, in display order.
+var definitions = []Setting{
+	{
+		Key:         KeyCacheDir,
+		Env:         "NVS_CACHE_DIR",
+		Kind:        KindPath,
+		Description: "Cache directory (release lists, downloads)",
+	},
+	{
+		Key:         KeyBinDir,
+		Env:         "NVS_BIN_DIR",
+		Kind:        KindPath,
+		Description: "Directory the nvim symlink is placed in",
+	},
+	{
+		Key:         KeyGitHubMirror,
+		Env:         "NVS_GITHUB_MIRROR",
+		Kind:        KindList,
+		Description: "Mirror base URLs for release downloads, tried in order",
+		validate:    validateMirrorURLs,
+		strict:      true,
+	},
+	{
+		Key:         KeyGitHubAPIURL,
+		Env:         "NVS_GITHUB_API_URL",
+		Kind:        KindString,
+		Description: "GitHub API base URL for release lists",
+		validate:    validateURL,
+		strict:      true,
+	},
+	{
+		Key:         KeyGitHubRepo,
+		Env:         "NVS_GITHUB_REPO",
+		Kind:        KindString,
+		Default:     constants.DefaultRepo,
+		Description: "Repository releases and changelogs come from (owner/name)",
+		validate:    validateRepoName,
+		strict:      true,
+	},
+	{
+		Key:         KeySourceRepoURL,
+		Env:         "NVS_SOURCE_REPO_URL",
+		Kind:        KindString,
+		Description: "Git URL source builds clone",
+		validate:    validateGitURL,
+		strict:      true,
+	},
+	{
+		Key:         KeyBuildType,
+		Env:         "NVS_BUILD_TYPE",
+		Kind:        KindString,
+		Default:     vtypes.BuildTypeRelease,
+		Description: "CMake build type of source builds (Release, RelWithDebInfo, Debug, ...)",
+		validate:    validateBuildType,
+	},
+	{
+		Key:         KeyBuildSanitize,
+		Env:         "NVS_BUILD_SANITIZE",
+		Kind:        KindBool,
+		Default:     "false",
+		Description: "Build from source with ASAN and UBSAN",
+	},
+	{
+		Key:         KeyBuildCMakeFlags,
+		Env:         "NVS_BUILD_CMAKE_FLAGS",
+		Kind:        KindString,
+		Description: "Extra CMake flags for source builds (CMAKE_EXTRA_FLAGS)",
+	},
+	{
+		Key:         KeyBuildDepsFlags,
+		Env:         "NVS_BUILD_DEPS_FLAGS",
+		Kind:        KindString,
+		Description: "Extra CMake flags for bundled dependencies (DEPS_CMAKE_FLAGS)",
+	},
+	{
+		Key:         KeyBuildName,
+		Env:         "NVS_BUILD_NAME",
+		Kind:        KindString,
+		Description: "Install name suffix of source builds, instead of one derived from the profile",
+		validate:    vtypes.ValidateBuildName,
+	},
+	{
+		Key:         KeyReleaseSource,
+		Env:         "NVS_RELEASE_SOURCE",
+		Kind:        KindString,
+		Default:     "github",
+		Description: "Where releases are listed: github, or the URL or path of a release index",
+		strict:      true,
+	},
+	{
+		Key:         KeyS3Endpoint,
+		Env:         "NVS_S3_ENDPOINT",
+		Kind:        KindString,
+		Description: "S3-compatible server for s3:// release sources",
+		validate:    validateURL,
+		strict:      true,
+	},
+	{
+		Key:         KeyUseGlobalCache,
+		Env:         "NVS_USE_GLOBAL_CACHE",
+		Kind:        KindBool,
+		Default:     "false",
+		Description: "Fetch release lists from the shared global cache",
+	},
+	{
+		Key:         KeyPinSources,
+		Env:         "NVS_PIN_SOURCES",
+		Kind:        KindList,
+		Default:     "nvs,nvim,tool-versions,mise",
+		Description: "Pin files to read, in precedence order",
+	},
+	{
+		Key:         KeyShims,
+		Env:         "NVS_SHIMS",
+		Kind:        KindBool,
+		Default:     "false",
+		Description: "Install nvim as a shim that picks the version per directory",
+	},
+	{
+		Key:         KeyOffline,
+		Env:         "NVS_OFFLINE",
+		Kind:        KindBool,
+		Default:     "false",
+		Description: "Never touch the network; answer from the cache and local installs",
+	},
+	{
+		Key:         KeyArchiveCache,
+		Env:         "NVS_ARCHIVE_CACHE",
+		Kind:        KindBool,
+		Default:     "true",
+		Description: "Keep downloaded release archives so reinstalls skip the download",
+	},
+	{
+		Key:         KeyArchiveCacheMaxMB,
+		Env:         "NVS_ARCHIVE_CACHE_MAX_MB",
+		Kind:        KindInt,
+		Default:     strconv.Itoa(constants.DefaultArchiveCacheMaxMB),
+		Description: "Size limit of the archive cache, in megabytes",
+	},
+	{
+		Key:         KeyArchiveCacheMaxAge,
+		Env:         "NVS_ARCHIVE_CACHE_MAX_AGE",
+		Kind:        KindDuration,
+		Default:     shortDuration(constants.DefaultArchiveCacheMaxAge),
+		Description: "How long an unused archive stays in the archive cache",
+	},
+	{
+		Key:         KeyLog,
+		Env:         "NVS_LOG",
+		Kind:        KindString,
+		Default:     "warn",
+		Description: "Developer log level",
+		validate:    validateLogLevel,
+	},
+	{
+		Key:         KeyLogFile,
+		Env:         "NVS_LOG_FILE",
+		Kind:        KindPath,
+		Description: "Tee developer logs to this file",
+	},
+	{
+		Key:         KeyCacheTTL,
+		Env:         "NVS_CACHE_TTL",
+		Kind:        KindDuration,
+		Default:     shortDuration(constants.CacheTTL),
+		Description: "How long the cached release list stays fresh",
+	},
+	{
+		Key:         KeyRollbackLimit,
+		Env:         "NVS_ROLLBACK_LIMIT",
+		Kind:        KindInt,
+		Default:     strconv.Itoa(constants.DefaultRollbackLimit),
+		Description: "Nightly builds kept for 'nvs rollback'",
+	},
+	{
+		Key:         KeyBuildLogKeep,
+		Env:         "NVS_BUILD_LOG_KEEP",
+		Kind:        KindInt,
+		Default:     strconv.Itoa(constants.DefaultBuildLogKeep),
+		Description: "Source build logs kept for 'nvs logs'",
+	},
+	{
+		Key:         KeyCommandTimeout,
+		Env:         "NVS_COMMAND_TIMEOUT",
+		Kind:        KindDuration,
+		Default:     shortDuration(constants.TimeoutMinutes * time.Minute),
+		Description: "Overall timeout for install, use, upgrade and run",
+	},
+	{
+		Key:         KeyAPITimeout,
+		Env:         "NVS_API_TIMEOUT",
+		Kind:        KindDuration,
+		Default:     shortDuration(constants.ClientTimeoutSec * time.Second),
+		Description: "Timeout for GitHub release API requests",
+	},
+	{
+		Key:         KeyHTTPTimeout,
+		Env:         "NVS_HTTP_TIMEOUT",
+		Kind:        KindDuration,
+		Default:     shortDuration(constants.HTTPTimeoutSeconds * time.Second),
+		Description: "Timeout for other HTTP requests (changelogs)",
+	},
+	{
+		Key:         KeyDownloadTimeout,
+		Env:         "NVS_DOWNLOAD_TIMEOUT",
+		Kind:        KindDuration,
+		Default:     shortDuration(constants.DefaultTimeout),
+		Description: "Timeout for a single archive download",
+	},
+	{
+		Key:         KeyDownloadConns,
+		Env:         "NVS_DOWNLOAD_CONNECTIONS",
+		Kind:        KindInt,
+		Default:     "1",
+		Description: "Parallel connections per archive download (1 = single stream)",
+		validate:    validateDownloadConns,
+	},
+	{
+		Key:         KeyVerifyPolicy,
+		Env:         "NVS_VERIFY_POLICY",
+		Kind:        KindString,
+		Default:     "warn",
+		Description: "What failed archive provenance checks do: off, warn or require",
+		strict:      true,
+	},
+	{
+		Key:         KeySigningKey,
+		Env:         "NVS_SIGNING_KEY",
+		Kind:        KindString,
+		Description: "Pinned ssh-ed25519 key that release archives must be signed with",
+		strict:      true,
+	},
+	{
+		Key:         KeyKnownHashes,
+		Env:         "NVS_KNOWN_HASHES",
+		Kind:        KindPath,
+		Description: "File of trusted archive SHA256s (sha256sum format)",
+	},
+}
+
+// Definitions returns every setting in display order.
+func Definitions() []Setting {
+	out := make([]Setting, len(definitions))
+	copy(out, definitions)
+
+	return out
+}
+
+// Lookup returns the setting with the given key.
+func Lookup(key string) (Setting, bool) {
+	for _, setting := range definitions {
+		if setting.Key == key {
+			return setting, true
+		}
+	}
+
+	return Setting{}, false
+}
+
+// SetValidator makes validate the custom rule of the setting key.
+// Values parsed by infrastructure packages, which this package must
+// not import, get their rules this way from the command wiring; it
+// must run before any value is normalized or resolved.
+func SetValidator(key string, validate func(string) error) error {
+	for idx := range definitions {
+		if definitions[idx].Key == key {
+			definitions[idx].validate = validate
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownKey, key)
+}
+
+// Keys returns every setting key in display order.
+func Keys() []string {
+	keys := make([]string, 0, len(definitions))
+	for _, setting := range definitions {
+		keys = append(keys, setting.Key)
+	}
+
+	return keys
+}
+
+// Normalize validates raw against the setting's kind and custom rules
+// and returns its canonical string form: trimmed, booleans as
+// "true"/"false", lists without blank entries. The error wraps
+// ErrInvalidValue and names the setting.
+func (s Setting) Normalize(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+
+	normalized, err := s.normalizeKind(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s=%q: %w", ErrInvalidValue, s.Key, raw, err)
+	}
+
+	if s.validate != nil && normalized != "" {
+		err = s.validate(normalized)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s=%q: %w", ErrInvalidValue, s.Key, raw, err)
+		}
+	}
+
+	return normalized, nil
+}
+
+func (s Setting) normalizeKind(value string) (string, error) {
+	switch s.Kind {
+	case KindPath:
+		for idx := range len(value) {
+			if value[idx] < 0x20 || value[idx] == 0x7F {
+				return "", ErrControlCharacter
+			}
+		}
+
+		return value, nil
+	case KindBool:
+		parsed, err := ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatBool(parsed), nil
+	case KindInt:
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return "", ErrNotPositive
+		}
+
+		return strconv.Itoa(parsed), nil
+	case KindDuration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return "", ErrNotPositive
+		}
+
+		return value, nil
+	case KindList:
+		var items []string
+
+		for item := range strings.SplitSeq(value, ",") {
+			trimmed := strings.TrimSpace(item)
+			if trimmed != "" {
+				items = append(items, trimmed)
+			}
+		}
+
+		return strings.Join(items, ","), nil
+	default:
+		return value, nil
+	}
+}
+
+// ParseBool parses the boolean spellings nvs has always accepted for
+// its NVS_* toggles (case-insensitive): 1/true/yes/on and
+// 0/false/no/off.
+func ParseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	default:
+		return false, ErrNotBool
+	}
+}
+
+// shortDuration formats d without the zero-valued trailing units
+// time.Duration.String adds ("5m0s" becomes "5m"), which is how the
+// defaults are written in docs and config.toml.
+func shortDuration(duration time.Duration) string {
+	formatted := duration.String()
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = strings.TrimSuffix(formatted, "0s")
+	}
+
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+
+	return formatted
+}
+
+func validateMirrorURLs(value string) error {
+	for mirror := range strings.SplitSeq(value, ",") {
+		err := validateURL(mirror)
+		if err != nil {
+			return fmt.Errorf("%s: %w", mirror, err)
+		}
+	}
+
+	return nil
+}
+
+func validateURL(value string) error {
+	parsedURL, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return fmt.Errorf("%w: scheme must be http or https", ErrInvalidURL)
+	}
+
+	if parsedURL.Host == "" {
+		return fmt.Errorf("%w: missing host", ErrInvalidURL)
+	}
+
+	return nil
+}
+
+func validateRepoName(value string) error {
+	owner, name, found := strings.Cut(value, "/")
+	if !found || !isRepoPart(owner) || !isRepoPart(name) {
+		return ErrInvalidRepo
+	}
+
+	return nil
+}
+
+// isRepoPart reports whether part is a valid GitHub owner or
+// repository name.
+func isRepoPart(part string) bool {
+	if part == "" || part == "." || part == ".." {
+		return false
+	}
+
+	for _, char := range part {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char >= '0' && char <= '9':
+		case char == '-', char == '_', char == '.':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateGitURL rejects clone URLs git would parse as an option or
+// split into several arguments.
+func validateGitURL(value string) error {
+	if strings.HasPrefix(value, "-") || strings.ContainsFunc(value, unicode.IsSpace) {
+		return ErrInvalidGitURL
+	}
+
+	return nil
+}
+
+func validateBuildType(value string) error {
+	_, err := vtypes.ParseBuildType(value)
+
+	return err
+}
+
+func validateDownloadConns(value string) error {
+	conns, err := strconv.Atoi(value)
+	if err != nil || conns > constants.MaxDownloadConnections {
+		return fmt.Errorf("%w %d", ErrTooLarge, constants.MaxDownloadConnections)
+	}
+
+	return nil
+}
+
+func validateLogLevel(value string) error {
+	_, err := log.ParseLevel(value)
+
+	return err
+}
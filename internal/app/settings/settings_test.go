@@ -0,0 +1,407 @@
+package settings_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/app/settings"
+)
+
+func TestParseBool(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "1", want: true},
+		{value: "true", want: true},
+		{value: "YES", want: true},
+		{value: " on ", want: true},
+		{value: "0", want: false},
+		{value: "False", want: false},
+		{value: "no", want: false},
+		{value: "off", want: false},
+		{value: "", wantErr: true},
+		{value: "maybe", wantErr: true},
+		{value: "2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := settings.ParseBool(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, settings.ErrNotBool) {
+					t.Fatalf("ParseBool(%q) error = %v, want ErrNotBool", tt.value, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseBool(%q) unexpected error: %v", tt.value, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseBool(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key     string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{key: settings.KeyUseGlobalCache, raw: "yes", want: "true"},
+		{key: settings.KeyRollbackLimit, raw: " 10 ", want: "10"},
+		{key: settings.KeyRollbackLimit, raw: "0", wantErr: true},
+		{key: settings.KeyRollbackLimit, raw: "ten", wantErr: true},
+		{key: settings.KeyCacheTTL, raw: "90s", want: "90s"},
+		{key: settings.KeyCacheTTL, raw: "-1m", wantErr: true},
+		{key: settings.KeyCacheTTL, raw: "soon", wantErr: true},
+		{key: settings.KeyPinSources, raw: "mise, ,nvs", want: "mise,nvs"},
+		{key: settings.KeyLog, raw: "debug", want: "debug"},
+		{key: settings.KeyLog, raw: "potato", wantErr: true},
+		{key: settings.KeyLogFile, raw: "/tmp/a\nb", wantErr: true},
+		{key: settings.KeyGitHubMirror, raw: "https://mirror.example.com", want: "https://mirror.example.com"},
+		{key: settings.KeyGitHubMirror, raw: "ftp://mirror.example.com", wantErr: true},
+		{key: settings.KeyGitHubMirror, raw: "https://", wantErr: true},
+		{
+			key:  settings.KeyGitHubMirror,
+			raw:  "https://a.example.com, https://b.example.com",
+			want: "https://a.example.com,https://b.example.com",
+		},
+		{key: settings.KeyGitHubMirror, raw: "https://a.example.com,b.example.com", wantErr: true},
+		{
+			key:  settings.KeyGitHubAPIURL,
+			raw:  "https://ghe.example.com/api/v3",
+			want: "https://ghe.example.com/api/v3",
+		},
+		{key: settings.KeyGitHubAPIURL, raw: "ghe.example.com", wantErr: true},
+		{key: settings.KeyGitHubRepo, raw: "editors/neovim", want: "editors/neovim"},
+		{key: settings.KeyGitHubRepo, raw: "neovim", wantErr: true},
+		{key: settings.KeyGitHubRepo, raw: "editors/../neovim", wantErr: true},
+		{
+			key:  settings.KeySourceRepoURL,
+			raw:  "git@ghe.example.com:editors/neovim.git",
+			want: "git@ghe.example.com:editors/neovim.git",
+		},
+		{key: settings.KeySourceRepoURL, raw: "--upload-pack=touch", wantErr: true},
+		{key: settings.KeyDownloadConns, raw: "4", want: "4"},
+		{key: settings.KeyDownloadConns, raw: "64", wantErr: true},
+		{key: settings.KeyVerifyPolicy, raw: "require", want: "require"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.raw, func(t *testing.T) {
+			t.Parallel()
+
+			setting, ok := settings.Lookup(tt.key)
+			if !ok {
+				t.Fatalf("Lookup(%q) not found", tt.key)
+			}
+
+			got, err := setting.Normalize(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, settings.ErrInvalidValue) {
+					t.Fatalf("Normalize(%q) error = %v, want ErrInvalidValue", tt.raw, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Normalize(%q) unexpected error: %v", tt.raw, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve_Precedence(t *testing.T) {
+	t.Parallel()
+
+	effective, warnings, err := settings.Resolve(settings.Layers{
+		Flags: map[string]string{settings.KeyLog: "debug"},
+		Env: map[string]string{
+			settings.KeyLog:      "info",
+			settings.KeyCacheTTL: "1h",
+		},
+		File: map[string]string{
+			settings.KeyLog:           "error",
+			settings.KeyCacheTTL:      "10m",
+			settings.KeyRollbackLimit: "8",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+
+	tests := []struct {
+		key    string
+		value  string
+		source settings.Source
+	}{
+		{settings.KeyLog, "debug", settings.SourceFlag},
+		{settings.KeyCacheTTL, "1h", settings.SourceEnv},
+		{settings.KeyRollbackLimit, "8", settings.SourceFile},
+		{settings.KeyCommandTimeout, "30m", settings.SourceDefault},
+	}
+
+	for _, tt := range tests {
+		got := effective.Get(tt.key)
+		if got.Value != tt.value || got.Source != tt.source {
+			t.Errorf(
+				"%s = %q (%s), want %q (%s)",
+				tt.key, got.Value, got.Source, tt.value, tt.source,
+			)
+		}
+	}
+
+	if effective.Duration(settings.KeyCacheTTL) != time.Hour {
+		t.Errorf("Duration(cache_ttl) = %v, want 1h", effective.Duration(settings.KeyCacheTTL))
+	}
+
+	if effective.Int(settings.KeyRollbackLimit) != 8 {
+		t.Errorf("Int(rollback_limit) = %d, want 8", effective.Int(settings.KeyRollbackLimit))
+	}
+}
+
+func TestResolve_InvalidFallsThrough(t *testing.T) {
+	t.Parallel()
+
+	effective, warnings, err := settings.Resolve(settings.Layers{
+		Env:  map[string]string{settings.KeyRollbackLimit: "lots"},
+		File: map[string]string{settings.KeyRollbackLimit: "3"},
+	})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	got := effective.Get(settings.KeyRollbackLimit)
+	if got.Value != "3" || got.Source != settings.SourceFile {
+		t.Errorf("rollback_limit = %q (%s), want 3 (file)", got.Value, got.Source)
+	}
+
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want exactly one", warnings)
+	}
+
+	if !strings.HasPrefix(warnings[0].Error(), "NVS_ROLLBACK_LIMIT: ") {
+		t.Errorf("warning %q should name the env var", warnings[0])
+	}
+
+	if !errors.Is(warnings[0], settings.ErrInvalidValue) {
+		t.Errorf("warning %v should wrap ErrInvalidValue", warnings[0])
+	}
+}
+
+func TestResolve_StrictSettingFails(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := settings.Resolve(settings.Layers{
+		Env: map[string]string{settings.KeyGitHubMirror: "not a url"},
+	})
+	if !errors.Is(err, settings.ErrInvalidValue) {
+		t.Fatalf("Resolve error = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestSetValidator_UnknownKey(t *testing.T) {
+	t.Parallel()
+
+	err := settings.SetValidator("cache_tll", func(string) error { return nil })
+	if !errors.Is(err, settings.ErrUnknownKey) {
+		t.Errorf("SetValidator error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestEnvLayer(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"NVS_CACHE_TTL":      "2m",
+		"NVS_ROLLBACK_LIMIT": "   ",
+		"UNRELATED":          "x",
+	}
+
+	layer := settings.EnvLayer(func(name string) string { return env[name] })
+
+	if len(layer) != 1 || layer[settings.KeyCacheTTL] != "2m" {
+		t.Errorf("EnvLayer = %v, want only cache_ttl=2m", layer)
+	}
+}
+
+func TestFile_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := settings.Path(t.TempDir())
+
+	file, err := settings.Load(path)
+	if err != nil {
+		t.Fatalf("Load missing file: %v", err)
+	}
+
+	for key, value := range map[string]string{
+		settings.KeyUseGlobalCache: "on",
+		settings.KeyRollbackLimit:  "7",
+		settings.KeyPinSources:     "mise,nvs",
+		settings.KeyCacheTTL:       "90s",
+	} {
+		err = file.Set(key, value)
+		if err != nil {
+			t.Fatalf("Set(%s, %s): %v", key, value, err)
+		}
+	}
+
+	err = file.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read saved file: %v", err)
+	}
+
+	for _, line := range []string{
+		"use_global_cache = true",
+		"pin_sources = [\"mise\", \"nvs\"]",
+		"cache_ttl = \"90s\"",
+		"rollback_limit = 7",
+	} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("saved file missing %q:\n%s", line, data)
+		}
+	}
+
+	reloaded, err := settings.Load(path)
+	if err != nil {
+		t.Fatalf("Load saved file: %v", err)
+	}
+
+	want := map[string]string{
+		settings.KeyUseGlobalCache: "true",
+		settings.KeyRollbackLimit:  "7",
+		settings.KeyPinSources:     "mise,nvs",
+		settings.KeyCacheTTL:       "90s",
+	}
+
+	got := reloaded.Values()
+	if len(got) != len(want) {
+		t.Fatalf("reloaded values = %v, want %v", got, want)
+	}
+
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+
+	err = reloaded.Unset(settings.KeyCacheTTL)
+	if err != nil {
+		t.Fatalf("Unset: %v", err)
+	}
+
+	_, ok := reloaded.Get(settings.KeyCacheTTL)
+	if ok {
+		t.Error("cache_ttl still set after Unset")
+	}
+}
+
+func TestFile_SetRejectsInvalid(t *testing.T) {
+	t.Parallel()
+
+	file, err := settings.Load(filepath.Join(t.TempDir(), settings.FileName))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	err = file.Set("no_such_key", "1")
+	if !errors.Is(err, settings.ErrUnknownKey) {
+		t.Errorf("Set unknown key error = %v, want ErrUnknownKey", err)
+	}
+
+	err = file.Set(settings.KeyUseGlobalCache, "maybe")
+	if !errors.Is(err, settings.ErrInvalidValue) {
+		t.Errorf("Set invalid bool error = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+	}{
+		{name: "unknown key", content: "cache_tll = \"5m\"\n", wantErr: settings.ErrUnknownKey},
+		{name: "syntax error", content: "cache_ttl = \n", wantErr: settings.ErrInvalidFile},
+		{name: "table value", content: "[cache_ttl]\nx = 1\n", wantErr: settings.ErrInvalidFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := settings.Path(t.TempDir())
+
+			err := os.WriteFile(path, []byte(tt.content), 0o600)
+			if err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			_, err = settings.Load(path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Load error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_SkipsBadEntries(t *testing.T) {
+	t.Parallel()
+
+	path := settings.Path(t.TempDir())
+	content := "cache_tll = \"5m\"\ngithub_mirror = \"https://mirror.example.com\"\n"
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	file, err := settings.Load(path)
+	if !errors.Is(err, settings.ErrUnknownKey) {
+		t.Errorf("Load error = %v, want ErrUnknownKey", err)
+	}
+
+	if file == nil {
+		t.Fatal("Load returned no file")
+	}
+
+	mirror, ok := file.Get(settings.KeyGitHubMirror)
+	if !ok || mirror != "https://mirror.example.com" {
+		t.Errorf("github_mirror = %q, %v; want it kept", mirror, ok)
+	}
+}
@@ -0,0 +1,178 @@
+package versionsvc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// ExpandAlias returns the version a user alias points at, or name
+// unchanged when it is not an alias (or aliases are not configured).
+// Aliases never chain: a target is always an installed version name.
+func (s *Service) ExpandAlias(name string) (string, error) {
+	if s.config.Aliases == nil || vtypes.ValidateAliasName(name) != nil {
+		return name, nil
+	}
+
+	target, ok, err := s.config.Aliases.Get(name)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return name, nil
+	}
+
+	log.Debugf("Expanded alias %s to %s", name, target)
+
+	return target, nil
+}
+
+// SetAlias points the alias name at an installed version. version may
+// itself be an alias (the new alias gets the same target) or a semver
+// range, which resolves to the highest installed match; either way
+// the stored target is a concrete installed version name.
+func (s *Service) SetAlias(name, version string) (string, error) {
+	if s.config.Aliases == nil {
+		return "", ErrAliasesUnavailable
+	}
+
+	err := vtypes.ValidateAliasName(name)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := s.ExpandAlias(version)
+	if err != nil {
+		return "", err
+	}
+
+	target, err = s.installedTarget(target)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.config.Aliases.Set(name, target)
+	if err != nil {
+		return "", fmt.Errorf("failed to save alias: %w", err)
+	}
+
+	return target, nil
+}
+
+// RemoveAlias deletes the alias name.
+func (s *Service) RemoveAlias(name string) error {
+	if s.config.Aliases == nil {
+		return ErrAliasesUnavailable
+	}
+
+	return s.config.Aliases.Remove(name)
+}
+
+// Aliases returns every user alias and its target.
+func (s *Service) Aliases() (map[string]string, error) {
+	if s.config.Aliases == nil {
+		return map[string]string{}, nil
+	}
+
+	return s.config.Aliases.List()
+}
+
+// AliasesFor returns, sorted, the aliases that point at version.
+func (s *Service) AliasesFor(version string) ([]string, error) {
+	aliases, err := s.Aliases()
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := normalizeVersion(version)
+
+	var names []string
+
+	for name, target := range aliases {
+		if target == normalized {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names, nil
+}
+
+// RemoveAliasesFor deletes every alias pointing at version and returns
+// their names. UninstallWithAliases uses it so a removed version does
+// not leave dangling aliases behind.
+func (s *Service) RemoveAliasesFor(version string) ([]string, error) {
+	names, err := s.AliasesFor(version)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		err = s.config.Aliases.Remove(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove alias %s: %w", name, err)
+		}
+	}
+
+	return names, nil
+}
+
+// checkNotAliased refuses to remove a version that aliases still
+// point at, so 'nvs uninstall' cannot silently break 'nvs use work'.
+func (s *Service) checkNotAliased(version string) error {
+	names, err := s.AliasesFor(version)
+	if err != nil {
+		return err
+	}
+
+	if len(names) > 0 {
+		return fmt.Errorf("%w: %s (%s)", ErrAliasTarget, version, strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+// installedTarget resolves spec to the name of an installed version.
+// Ranges pick the highest installed match; nothing is downloaded.
+func (s *Service) installedTarget(spec string) (string, error) {
+	if vtypes.IsVersionRange(spec) {
+		err := vtypes.ValidateVersionRange(spec)
+		if err != nil {
+			return "", err
+		}
+
+		constraint, err := semver.NewConstraint(normalizeRange(spec))
+		if err != nil {
+			return "", fmt.Errorf("%w: %q: %w", vtypes.ErrInvalidVersionRange, spec, err)
+		}
+
+		names, err := s.InstalledVersionNames()
+		if err != nil {
+			return "", err
+		}
+
+		match := highestMatch(constraint, names)
+		if match == "" {
+			return "", fmt.Errorf("%w: %s", ErrNoVersionMatchesRange, spec)
+		}
+
+		return match, nil
+	}
+
+	normalized, err := installName(spec)
+	if err != nil {
+		return "", err
+	}
+
+	if !s.IsVersionInstalled(normalized) {
+		return "", fmt.Errorf("%w: %s", vtypes.ErrVersionNotFound, normalized)
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,252 @@
+package versionsvc_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/app/versionsvc"
+	"github.com/y3owk1n/nvs/internal/domain/release"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+)
+
+// mockAliasStore implements vtypes.AliasStore in memory.
+type mockAliasStore struct {
+	aliases map[string]string
+}
+
+func (m *mockAliasStore) List() (map[string]string, error) {
+	out := make(map[string]string, len(m.aliases))
+	for name, target := range m.aliases {
+		out[name] = target
+	}
+
+	return out, nil
+}
+
+func (m *mockAliasStore) Get(name string) (string, bool, error) {
+	target, ok := m.aliases[name]
+
+	return target, ok, nil
+}
+
+func (m *mockAliasStore) Set(name, target string) error {
+	m.aliases[name] = target
+
+	return nil
+}
+
+func (m *mockAliasStore) Remove(name string) error {
+	_, ok := m.aliases[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", vtypes.ErrAliasNotFound, name)
+	}
+
+	delete(m.aliases, name)
+
+	return nil
+}
+
+// newAliasTestService wires a service with the given installed tags
+// and an in-memory alias store.
+func newAliasTestService(
+	t *testing.T,
+	installedTags []string,
+	aliases map[string]string,
+) (*versionsvc.Service, *mockVersionManager, *mockAliasStore) {
+	t.Helper()
+
+	repo := &mockReleaseRepo{tags: make(map[string]release.Release)}
+	manager := &mockVersionManager{installed: make(map[string]vtypes.Version)}
+
+	for _, tag := range installedTags {
+		repo.tags[tag] = release.New(tag, false, "hash-"+tag, time.Time{}, nil)
+		manager.installed[tag] = vtypes.New(tag, vtypes.TypeTag, tag, "")
+	}
+
+	store := &mockAliasStore{aliases: aliases}
+
+	service, err := versionsvc.New(
+		repo,
+		manager,
+		&mockInstaller{installed: make(map[string]vtypes.Version)},
+		&versionsvc.Config{VersionsDir: testTmp, Aliases: store},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	return service, manager, store
+}
+
+func TestService_SetAlias(t *testing.T) {
+	service, _, store := newAliasTestService(
+		t,
+		[]string{"v0.10.2", "v0.10.4", "v0.9.5"},
+		map[string]string{},
+	)
+
+	target, err := service.SetAlias("work", "0.10.2")
+	if err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+
+	if target != "v0.10.2" || store.aliases["work"] != "v0.10.2" {
+		t.Errorf("work -> %q (stored %q), want v0.10.2", target, store.aliases["work"])
+	}
+
+	// A range resolves to the highest installed match.
+	target, err = service.SetAlias("lts", "~0.10")
+	if err != nil {
+		t.Fatalf("SetAlias(range) failed: %v", err)
+	}
+
+	if target != "v0.10.4" {
+		t.Errorf("lts -> %q, want v0.10.4", target)
+	}
+
+	// An alias target copies the other alias's version; aliases never chain.
+	target, err = service.SetAlias("plugin-ci", "work")
+	if err != nil {
+		t.Fatalf("SetAlias(alias) failed: %v", err)
+	}
+
+	if target != "v0.10.2" {
+		t.Errorf("plugin-ci -> %q, want v0.10.2", target)
+	}
+}
+
+func TestService_SetAlias_Rejects(t *testing.T) {
+	service, _, store := newAliasTestService(t, []string{"v0.10.2"}, map[string]string{})
+
+	tests := []struct {
+		name    string
+		alias   string
+		version string
+		wantErr error
+	}{
+		{"not installed", "work", "v0.11.0", vtypes.ErrVersionNotFound},
+		{"reserved name", "stable", "v0.10.2", vtypes.ErrInvalidAliasName},
+		{"version-like name", "v1", "v0.10.2", vtypes.ErrInvalidAliasName},
+		{"path traversal target", "work", "../etc", vtypes.ErrInvalidVersionName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SetAlias(tt.alias, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetAlias(%q, %q) error = %v, want %v", tt.alias, tt.version, err, tt.wantErr)
+			}
+		})
+	}
+
+	if len(store.aliases) != 0 {
+		t.Errorf("rejected aliases were stored: %v", store.aliases)
+	}
+}
+
+func TestService_Use_Alias(t *testing.T) {
+	service, manager, _ := newAliasTestService(
+		t,
+		[]string{"v0.10.2", "v0.9.5"},
+		map[string]string{"work": "v0.10.2"},
+	)
+
+	_, err := service.Use(t.Context(), "work")
+	if err != nil {
+		t.Fatalf("Use(alias) failed: %v", err)
+	}
+
+	if manager.current.Name() != "v0.10.2" {
+		t.Errorf("current = %q, want v0.10.2", manager.current.Name())
+	}
+}
+
+func TestService_Uninstall_ProtectsAliasTargets(t *testing.T) {
+	service, manager, _ := newAliasTestService(
+		t,
+		[]string{"v0.10.2", "v0.9.5"},
+		map[string]string{"work": "v0.10.2", "lts": "v0.10.2"},
+	)
+
+	for _, version := range []string{"v0.10.2", "work"} {
+		err := service.Uninstall(version, false)
+		if !errors.Is(err, versionsvc.ErrAliasTarget) {
+			t.Errorf("Uninstall(%q) error = %v, want ErrAliasTarget", version, err)
+		}
+	}
+
+	removed, err := service.RemoveAliasesFor("v0.10.2")
+	if err != nil {
+		t.Fatalf("RemoveAliasesFor failed: %v", err)
+	}
+
+	if !slices.Equal(removed, []string{"lts", "work"}) {
+		t.Errorf("removed = %v, want [lts work]", removed)
+	}
+
+	err = service.Uninstall("v0.10.2", false)
+	if err != nil {
+		t.Fatalf("Uninstall after removing aliases failed: %v", err)
+	}
+
+	if _, ok := manager.installed["v0.10.2"]; ok {
+		t.Error("v0.10.2 still installed")
+	}
+}
+
+func TestService_UninstallWithAliases(t *testing.T) {
+	service, manager, store := newAliasTestService(
+		t,
+		[]string{"v0.10.2", "v0.9.5"},
+		map[string]string{"work": "v0.10.2", "lts": "v0.10.2", "old": "v0.9.5"},
+	)
+
+	// A failed uninstall keeps the aliases.
+	manager.uninstallErr = errors.New("version in use")
+
+	_, err := service.UninstallWithAliases("work", false)
+	if err == nil || len(store.aliases) != 3 {
+		t.Fatalf("failed uninstall: error = %v, aliases = %v", err, store.aliases)
+	}
+
+	manager.uninstallErr = nil
+
+	removed, err := service.UninstallWithAliases("work", false)
+	if err != nil {
+		t.Fatalf("UninstallWithAliases failed: %v", err)
+	}
+
+	if !slices.Equal(removed, []string{"lts", "work"}) {
+		t.Errorf("removed = %v, want [lts work]", removed)
+	}
+
+	if _, ok := manager.installed["v0.10.2"]; ok || len(store.aliases) != 1 {
+		t.Errorf("installed = %v, aliases = %v; want v0.10.2 and its aliases gone",
+			manager.installed, store.aliases)
+	}
+}
+
+func TestService_ExpandAlias_WithoutStore(t *testing.T) {
+	service, err := versionsvc.New(
+		&mockReleaseRepo{},
+		&mockVersionManager{installed: make(map[string]vtypes.Version)},
+		&mockInstaller{installed: make(map[string]vtypes.Version)},
+		&versionsvc.Config{VersionsDir: testTmp},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	expanded, err := service.ExpandAlias("work")
+	if err != nil || expanded != "work" {
+		t.Errorf("ExpandAlias = %q, %v; want work unchanged", expanded, err)
+	}
+
+	_, err = service.SetAlias("work", "v0.10.2")
+	if !errors.Is(err, versionsvc.ErrAliasesUnavailable) {
+		t.Errorf("SetAlias error = %v, want ErrAliasesUnavailable", err)
+	}
+}
@@ -4,8 +4,9 @@ import "errors"
 
 // Service errors.
 var (
-	// ErrOnlyStableNightlyUpgrade is returned when trying to upgrade non-stable/nightly versions.
-	ErrOnlyStableNightlyUpgrade = errors.New("only stable and nightly versions can be upgraded")
+	// ErrOnlyStableNightlyUpgrade is returned when trying to upgrade a version that does not move
+	// (anything but stable, nightly and branch or pull request builds).
+	ErrOnlyStableNightlyUpgrade = errors.New("only stable, nightly and branch or pull request builds can be upgraded")
 	// ErrNotInstalled is returned when a version is not installed.
 	ErrNotInstalled = errors.New("not installed")
 	// ErrAlreadyUpToDate is returned when a version is already up-to-date.
@@ -14,4 +15,12 @@ var (
 	ErrConfigNil = errors.New("config cannot be nil")
 	// ErrVersionsDirEmpty is returned when VersionsDir is empty.
 	ErrVersionsDirEmpty = errors.New("config.VersionsDir cannot be empty")
+	// ErrNoVersionMatchesRange is returned when no installed or remote tag satisfies a semver range.
+	ErrNoVersionMatchesRange = errors.New("no version matches range")
+	// ErrAliasTarget is returned when uninstalling a version that user aliases point at.
+	ErrAliasTarget = errors.New("version is the target of an alias")
+	// ErrAliasesUnavailable is returned when the service has no alias store configured.
+	ErrAliasesUnavailable = errors.New("aliases are not configured")
+	// ErrSourceRefUnknown is returned when a ref build has no record of the ref it was built from.
+	ErrSourceRefUnknown = errors.New("cannot determine source ref")
 )
@@ -0,0 +1,180 @@
+package versionsvc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// resolveAlias expands a user alias to its target, validates the
+// result and, if it is a semver range, resolves it to the concrete
+// release tag it selects. A branch or pull request ref is returned
+// as-is once it parses (normalizeVersion maps it to its directory
+// name). Every other alias is returned unchanged once it has passed
+// vtypes.ValidateVersionName, so callers can treat the normalized
+// result as a safe path component either way.
+//
+// preferInstalled controls which side wins when both an installed
+// version and a newer remote release satisfy the range: 'nvs use'
+// prefers what is already on disk (no surprise downloads on every
+// cd), while 'nvs install' prefers the newest installable release.
+func (s *Service) resolveAlias(
+	ctx context.Context,
+	versionAlias string,
+	preferInstalled bool,
+) (string, error) {
+	versionAlias, err := s.ExpandAlias(versionAlias)
+	if err != nil {
+		return "", err
+	}
+
+	if vtypes.IsSourceRef(versionAlias) {
+		_, err := vtypes.ParseSourceRef(versionAlias)
+		if err != nil {
+			return "", err
+		}
+
+		return versionAlias, nil
+	}
+
+	if !vtypes.IsVersionRange(versionAlias) {
+		err := vtypes.ValidateVersionName(versionAlias)
+		if err != nil {
+			return "", err
+		}
+
+		return versionAlias, nil
+	}
+
+	err = vtypes.ValidateVersionRange(versionAlias)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := s.resolveRange(ctx, versionAlias, preferInstalled)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("Resolved range %q to %s", versionAlias, resolved)
+
+	return resolved, nil
+}
+
+// resolveRange returns the highest tag satisfying spec, looking at
+// installed versions and at the release repository. When the
+// repository cannot be reached an installed match is still returned,
+// so ranges keep working offline as long as something suitable is on
+// disk.
+func (s *Service) resolveRange(
+	ctx context.Context,
+	spec string,
+	preferInstalled bool,
+) (string, error) {
+	constraint, err := semver.NewConstraint(normalizeRange(spec))
+	if err != nil {
+		return "", fmt.Errorf("%w: %q: %w", vtypes.ErrInvalidVersionRange, spec, err)
+	}
+
+	installedNames, err := s.InstalledVersionNames()
+	if err != nil {
+		return "", err
+	}
+
+	installed := highestMatch(constraint, installedNames)
+	if preferInstalled && installed != "" {
+		return installed, nil
+	}
+
+	releases, err := s.releaseRepo.GetAll(ctx, false)
+	if err != nil {
+		if installed != "" {
+			log.Debugf("Falling back to installed %s for %q: %v", installed, spec, err)
+
+			return installed, nil
+		}
+
+		return "", fmt.Errorf("failed to fetch releases: %w", err)
+	}
+
+	tags := make([]string, 0, len(releases))
+	for _, rel := range releases {
+		tags = append(tags, rel.TagName())
+	}
+
+	remote := highestMatch(constraint, tags)
+
+	switch {
+	case remote != "":
+		return remote, nil
+	case installed != "":
+		return installed, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrNoVersionMatchesRange, spec)
+	}
+}
+
+// highestMatch returns the name in names with the highest semver that
+// satisfies constraint, or "" if none does. Names that are not semver
+// tags ("stable", "nightly", commit hashes) are ignored, and
+// pre-releases only match ranges that explicitly ask for them, per
+// the semver library's rules.
+func highestMatch(constraint *semver.Constraints, names []string) string {
+	var (
+		best     *semver.Version
+		bestName string
+	)
+
+	for _, name := range names {
+		parsed, err := semver.NewVersion(name)
+		if err != nil {
+			continue
+		}
+
+		if !constraint.Check(parsed) {
+			continue
+		}
+
+		if best == nil || parsed.GreaterThan(best) {
+			best = parsed
+			bestName = name
+		}
+	}
+
+	return bestName
+}
+
+// normalizeRange rewrites spec into the form the semver library
+// parses. The library only treats "," as AND, but users (and other
+// version managers' pin files) commonly write ">=0.9 <0.11" with a
+// plain space, so whitespace-separated terms inside each "||" group
+// are joined with commas. The " - " of a hyphen range is kept as-is.
+func normalizeRange(spec string) string {
+	groups := strings.Split(spec, "||")
+
+	for idx, group := range groups {
+		terms := strings.Fields(strings.ReplaceAll(group, ",", " "))
+
+		var builder strings.Builder
+
+		for termIdx, term := range terms {
+			switch {
+			case termIdx == 0:
+			case term == "-" || terms[termIdx-1] == "-":
+				builder.WriteString(" ")
+			default:
+				builder.WriteString(", ")
+			}
+
+			builder.WriteString(term)
+		}
+
+		groups[idx] = builder.String()
+	}
+
+	return strings.Join(groups, " || ")
+}
@@ -0,0 +1,181 @@
+package versionsvc_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/app/versionsvc"
+	"github.com/y3owk1n/nvs/internal/domain/release"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+)
+
+var errOffline = errors.New("offline")
+
+// newRangeTestService wires a service whose repository exposes the
+// given remote tags and whose version manager has the given installed
+// tags.
+func newRangeTestService(
+	t *testing.T,
+	remoteTags, installedTags []string,
+) (*versionsvc.Service, *mockReleaseRepo, *mockVersionManager, *mockInstaller) {
+	t.Helper()
+
+	repo := &mockReleaseRepo{
+		tags: make(map[string]release.Release),
+		all:  []release.Release{},
+	}
+	for _, tag := range remoteTags {
+		rel := release.New(tag, false, "hash-"+tag, time.Time{}, nil)
+		repo.tags[tag] = rel
+		repo.all = append(repo.all, rel)
+	}
+
+	manager := &mockVersionManager{installed: make(map[string]vtypes.Version)}
+	for _, tag := range installedTags {
+		manager.installed[tag] = vtypes.New(tag, vtypes.TypeTag, tag, "")
+	}
+
+	install := &mockInstaller{installed: make(map[string]vtypes.Version)}
+
+	service, err := versionsvc.New(repo, manager, install, &versionsvc.Config{VersionsDir: testTmp})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	return service, repo, manager, install
+}
+
+func TestService_Install_RangeResolvesNewestRemote(t *testing.T) {
+	service, _, _, install := newRangeTestService(
+		t,
+		[]string{"nightly", "v0.11.0", "v0.10.4", "v0.10.2", "v0.9.5"},
+		[]string{"v0.10.2"},
+	)
+
+	err := service.Install(t.Context(), "~0.10", nil)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	if _, ok := install.installed["v0.10.4"]; !ok {
+		t.Errorf("expected v0.10.4 to be installed, got %v", install.installed)
+	}
+}
+
+func TestService_Use_RangePrefersInstalled(t *testing.T) {
+	service, _, manager, _ := newRangeTestService(
+		t,
+		[]string{"v0.11.0", "v0.10.4", "v0.10.2"},
+		[]string{"v0.10.2", "v0.9.5"},
+	)
+
+	_, err := service.Use(t.Context(), ">=0.9 <0.11")
+	if err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+
+	if manager.current.Name() != "v0.10.2" {
+		t.Errorf("current = %q, want v0.10.2", manager.current.Name())
+	}
+}
+
+func TestService_Use_RangeNotInstalled(t *testing.T) {
+	service, _, _, _ := newRangeTestService(
+		t,
+		[]string{"v0.10.4"},
+		[]string{"v0.9.5"},
+	)
+
+	// The newest remote match is reported as not installed so the
+	// caller can offer to install it.
+	_, err := service.Use(t.Context(), "^0.10")
+	if !errors.Is(err, vtypes.ErrVersionNotFound) {
+		t.Fatalf("expected ErrVersionNotFound, got %v", err)
+	}
+}
+
+func TestService_Install_RangeOfflineFallsBackToInstalled(t *testing.T) {
+	service, repo, _, install := newRangeTestService(t, nil, []string{"v0.10.2"})
+	repo.getAllErr = errOffline
+	repo.tags["v0.10.2"] = release.New("v0.10.2", false, "", time.Time{}, nil)
+
+	err := service.Install(t.Context(), "0.10.x", nil)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	if _, ok := install.installed["v0.10.2"]; !ok {
+		t.Errorf("expected v0.10.2, got %v", install.installed)
+	}
+}
+
+func TestService_Install_RangeNoMatch(t *testing.T) {
+	service, _, _, _ := newRangeTestService(t, []string{"v0.9.5"}, nil)
+
+	err := service.Install(t.Context(), "~0.10", nil)
+	if !errors.Is(err, versionsvc.ErrNoVersionMatchesRange) {
+		t.Fatalf("expected ErrNoVersionMatchesRange, got %v", err)
+	}
+}
+
+func TestService_Install_RangeRejectsMalformed(t *testing.T) {
+	service, _, _, _ := newRangeTestService(t, []string{"v0.10.0"}, nil)
+
+	err := service.Install(t.Context(), "~0.10/../../etc", nil)
+	if !errors.Is(err, vtypes.ErrInvalidVersionRange) {
+		t.Fatalf("expected ErrInvalidVersionRange, got %v", err)
+	}
+}
+
+func TestService_Install_HyphenAndOrRanges(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"0.9 - 0.10.4", "v0.10.4"},
+		{"0.9.x || 0.11.x", "v0.11.0"},
+		{">=0.9, <0.10", "v0.9.5"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.spec, func(t *testing.T) {
+			service, _, _, install := newRangeTestService(
+				t,
+				[]string{"v0.11.0", "v0.10.4", "v0.9.5"},
+				nil,
+			)
+
+			err := service.Install(t.Context(), testCase.spec, nil)
+			if err != nil {
+				t.Fatalf("Install failed: %v", err)
+			}
+
+			if _, ok := install.installed[testCase.want]; !ok {
+				t.Errorf("expected %s, got %v", testCase.want, install.installed)
+			}
+		})
+	}
+}
+
+func TestService_Resolve(t *testing.T) {
+	service, _, manager, _ := newRangeTestService(
+		t,
+		[]string{"v0.11.0", "v0.10.4", "v0.10.2"},
+		[]string{"v0.10.2"},
+	)
+
+	name, err := service.Resolve(t.Context(), "^0.10")
+	if err != nil || name != "v0.10.2" {
+		t.Errorf("Resolve(^0.10) = %q, %v; want v0.10.2", name, err)
+	}
+
+	if manager.current.Name() != "" {
+		t.Errorf("Resolve switched to %q", manager.current.Name())
+	}
+
+	_, err = service.Resolve(t.Context(), "v0.11.0")
+	if !errors.Is(err, vtypes.ErrVersionNotFound) {
+		t.Errorf("Resolve(v0.11.0) error = %v, want ErrVersionNotFound", err)
+	}
+}
@@ -31,6 +31,14 @@ type Config struct {
 	GlobalBinDir   string
 	MirrorURL      string // Optional GitHub mirror URL for downloads
 	UseGlobalCache bool   // Whether to use global cache for releases (passed to GitHub client)
+
+	// Aliases stores user-defined version aliases. Optional: nil
+	// disables alias expansion.
+	Aliases vtypes.AliasStore
+
+	// BuildProfile configures source builds unless an install asks
+	// for another one (see WithBuildProfile).
+	BuildProfile vtypes.BuildProfile
 }
 
 // New creates a new version Service.
@@ -56,25 +64,69 @@ func New(
 	}, nil
 }
 
+// InstallOption customizes a single Install call.
+type InstallOption func(*installOptions)
+
+type installOptions struct {
+	lockedSHA256 string
+	buildProfile *vtypes.BuildProfile
+}
+
+// WithLockedSHA256 requires a release archive to have the given
+// SHA256, as recorded in a project lock file. The archive is checked
+// before it is extracted. Source builds are not affected.
+func WithLockedSHA256(sha string) InstallOption {
+	return func(o *installOptions) {
+		o.lockedSHA256 = sha
+	}
+}
+
+// WithBuildProfile builds a commit, branch or pull request with
+// profile instead of Config.BuildProfile. Releases are not affected.
+func WithBuildProfile(profile vtypes.BuildProfile) InstallOption {
+	return func(o *installOptions) {
+		o.buildProfile = &profile
+	}
+}
+
 // Install installs a Neovim version.
-// The versionAlias can be "stable", "nightly", a version tag, or a commit hash.
+// The versionAlias can be "stable", "nightly", a version tag, a commit hash,
+// a branch or pull request ref ("branch:release-0.10", "pr:12345"), or a
+// semver range such as "~0.10" (resolved to the newest matching release).
 func (s *Service) Install(
 	ctx context.Context,
 	versionAlias string,
 	progress installer.ProgressFunc,
+	opts ...InstallOption,
 ) error {
+	var options installOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	// Reject path-traversal or otherwise malformed input before
 	// it reaches filepath.Join(VersionsDir, ...) or any installer
 	// syscall. Without this check, an attacker who can place a
 	// crafted .nvs-version file in a project directory could
 	// coerce 'nvs install' into creating or writing outside the
 	// versions root, or into treating a non-version path as a
-	// build source.
-	validateErr := vtypes.ValidateVersionName(versionAlias)
+	// build source. Semver ranges are resolved to a concrete tag
+	// first and never reach the filesystem in their raw form.
+	versionAlias, validateErr := s.resolveAlias(ctx, versionAlias, false)
 	if validateErr != nil {
 		return validateErr
 	}
 
+	profile := s.config.BuildProfile
+	if options.buildProfile != nil {
+		profile = *options.buildProfile
+	}
+
+	// Branch and pull request heads are built under a stable name
+	if vtypes.IsSourceRef(versionAlias) {
+		return s.installSourceRef(ctx, versionAlias, profile, progress)
+	}
+
 	// Normalize version
 	normalized := normalizeVersion(versionAlias)
 
@@ -84,6 +136,7 @@ func (s *Service) Install(
 		_, err := s.installer.BuildFromCommit(
 			ctx,
 			normalized,
+			profile,
 			s.config.VersionsDir,
 			progress,
 		)
@@ -115,8 +168,9 @@ func (s *Service) Install(
 
 	// Installation logic
 	releaseInfo := &releaseAdapter{
-		Release:   rel,
-		mirrorURL: s.config.MirrorURL,
+		Release:      rel,
+		mirrorURL:    s.config.MirrorURL,
+		lockedSHA256: options.lockedSHA256,
 	}
 
 	return s.installer.InstallRelease(ctx, releaseInfo, s.config.VersionsDir, normalized, progress)
@@ -126,7 +180,8 @@ func (s *Service) Install(
 type releaseAdapter struct {
 	release.Release
 
-	mirrorURL string
+	mirrorURL    string
+	lockedSHA256 string
 
 	// assetOnce + assetResult memoize the platform-specific asset
 	// resolution so that GetAssetURL and GetChecksumURL share a
@@ -187,6 +242,18 @@ func (r *releaseAdapter) GetIdentifier() string {
 	return r.TagName()
 }
 
+func (r *releaseAdapter) GetTagName() string {
+	return r.TagName()
+}
+
+func (r *releaseAdapter) GetCommitHash() string {
+	return r.CommitHash()
+}
+
+func (r *releaseAdapter) GetLockedSHA256() string {
+	return r.lockedSHA256
+}
+
 // AssetResolveCount returns the number of times the underlying
 // asset resolution function (github.GetAssetURL) has actually been
 // invoked on this adapter. The count is incremented inside the
@@ -238,14 +305,16 @@ func (r *releaseAdapter) resolveAsset() assetLookup {
 	return r.assetResult
 }
 
-// Use switches to a specific version.
+// Use switches to a specific version. A semver range resolves to the
+// highest installed match, falling back to the newest matching release.
 func (s *Service) Use(ctx context.Context, versionAlias string) (string, error) {
 	// Reject path-traversal input before any filepath operation.
 	// This guards the .nvs-version file path: a malicious value
 	// in that file would otherwise flow into the symlink target
 	// and end up exec'd by downstream tools that respect the
-	// 'current' link.
-	err := vtypes.ValidateVersionName(versionAlias)
+	// 'current' link. Ranges resolve to the highest installed
+	// match before any of that happens.
+	versionAlias, err := s.resolveAlias(ctx, versionAlias, true)
 	if err != nil {
 		return "", err
 	}
@@ -255,9 +324,9 @@ func (s *Service) Use(ctx context.Context, versionAlias string) (string, error)
 	// Determine target version
 	var targetVersion vtypes.Version
 
-	if vtypes.IsCommitReference(normalized) {
-		// For commit hash, the version name is the hash itself
-		targetVersion = vtypes.New(normalized, vtypes.TypeCommit, normalized, "")
+	if vtypes.IsCommitBuildName(normalized) || vtypes.IsSourceRefDirName(normalized) {
+		// For commit hashes and ref builds, the version name is the directory name itself
+		targetVersion = vtypes.New(normalized, determineVersionType(normalized), normalized, "")
 	} else {
 		// Resolve from release
 		var (
@@ -274,14 +343,27 @@ func (s *Service) Use(ctx context.Context, versionAlias string) (string, error)
 			rel, err = s.releaseRepo.FindByTag(ctx, normalized)
 		}
 
-		if err != nil {
-			return "", fmt.Errorf("failed to resolve version: %w", err)
-		}
-
 		// Determine version type
 		vType := determineVersionType(normalized)
 
-		targetVersion = vtypes.New(normalized, vType, rel.TagName(), rel.CommitHash())
+		if err != nil {
+			// Offline, or the release list is unreachable: an
+			// installed copy still describes itself.
+			manifest, manifestErr := s.versionManager.GetManifest(normalized)
+			if manifestErr != nil {
+				return "", fmt.Errorf("failed to resolve version: %w", err)
+			}
+
+			log.Debugf(
+				"Release lookup for %s failed (%v); using its install manifest",
+				normalized,
+				err,
+			)
+
+			targetVersion = vtypes.New(normalized, vType, manifest.Identifier, manifest.Commit)
+		} else {
+			targetVersion = vtypes.New(normalized, vType, rel.TagName(), rel.CommitHash())
+		}
 	}
 
 	// Check if already installed
@@ -294,6 +376,13 @@ func (s *Service) Use(ctx context.Context, versionAlias string) (string, error)
 	if err == nil && current.Name() == targetVersion.Name() {
 		log.Debugf("Already using version: %s", targetVersion.Name())
 
+		// Switch would rewrite the global nvim entry; apply a change
+		// of the shims setting the same way.
+		err = s.versionManager.SyncGlobalBin()
+		if err != nil {
+			return "", fmt.Errorf("failed to update the global nvim entry: %w", err)
+		}
+
 		return targetVersion.Identifier(), nil
 	}
 
@@ -326,21 +415,54 @@ func (s *Service) Current() (vtypes.Version, error) {
 	return current, nil
 }
 
-// Uninstall removes an installed version.
+// Uninstall removes an installed version. versionAlias may be a user
+// alias, but a version that any alias points at is refused with
+// ErrAliasTarget; remove the aliases first (see RemoveAliasesFor).
 func (s *Service) Uninstall(versionAlias string, force bool) error {
+	_, err := s.uninstall(versionAlias, force, false)
+
+	return err
+}
+
+// UninstallWithAliases removes an installed version like Uninstall,
+// even when aliases point at it, then deletes those aliases and
+// returns their names. The aliases are only removed once the version
+// is gone, so a failed uninstall leaves them in place.
+func (s *Service) UninstallWithAliases(versionAlias string, force bool) ([]string, error) {
+	normalized, err := s.uninstall(versionAlias, force, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.RemoveAliasesFor(normalized)
+}
+
+// uninstall removes an installed version and returns its name,
+// refusing a version aliases point at unless aliased is set.
+func (s *Service) uninstall(versionAlias string, force, aliased bool) (string, error) {
+	versionAlias, err := s.ExpandAlias(versionAlias)
+	if err != nil {
+		return "", err
+	}
+
 	// Reject path-traversal input before any filepath operation
 	// or filesystem deletion.
-	err := vtypes.ValidateVersionName(versionAlias)
+	normalized, err := installName(versionAlias)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	normalized := normalizeVersion(versionAlias)
+	if !aliased {
+		err = s.checkNotAliased(normalized)
+		if err != nil {
+			return "", err
+		}
+	}
 
 	// Find the version
 	versions, err := s.versionManager.List()
 	if err != nil {
-		return fmt.Errorf("failed to list versions: %w", err)
+		return "", fmt.Errorf("failed to list versions: %w", err)
 	}
 
 	var targetVersion vtypes.Version
@@ -357,16 +479,16 @@ func (s *Service) Uninstall(versionAlias string, force bool) error {
 	}
 
 	if !found {
-		return fmt.Errorf("%w: %s", vtypes.ErrVersionNotFound, normalized)
+		return "", fmt.Errorf("%w: %s", vtypes.ErrVersionNotFound, normalized)
 	}
 
 	// Uninstall
 	err = s.versionManager.Uninstall(targetVersion, force)
 	if err != nil {
-		return fmt.Errorf("failed to uninstall version: %w", err)
+		return "", fmt.Errorf("failed to uninstall version: %w", err)
 	}
 
-	return nil
+	return normalized, nil
 }
 
 // ListRemote returns available remote releases.
@@ -374,19 +496,23 @@ func (s *Service) ListRemote(ctx context.Context, force bool) ([]release.Release
 	return s.releaseRepo.GetAll(ctx, force)
 }
 
-// Upgrade upgrades a version (stable or nightly).
+// Upgrade upgrades a version: stable, nightly, or a branch or pull
+// request build (by ref or by its directory name), which is rebuilt
+// from the ref's current head.
 func (s *Service) Upgrade(
 	ctx context.Context,
 	versionAlias string,
 	progress installer.ProgressFunc,
 ) error {
 	// Reject path-traversal input before any filepath operation.
-	validateErr := vtypes.ValidateVersionName(versionAlias)
+	normalized, validateErr := installName(versionAlias)
 	if validateErr != nil {
 		return validateErr
 	}
 
-	normalized := normalizeVersion(versionAlias)
+	if vtypes.IsSourceRefDirName(normalized) {
+		return s.upgradeSourceRef(ctx, versionAlias, normalized, progress)
+	}
 
 	// Only stable and nightly can be upgraded
 	if normalized != constants.Stable && normalized != constants.Nightly {
@@ -446,6 +572,27 @@ func (s *Service) Upgrade(
 	return nil
 }
 
+// installName validates a version argument and returns the directory
+// name it is installed under. Branch and pull request refs map to
+// their directory form; anything else must be a safe version name.
+func installName(versionAlias string) (string, error) {
+	if vtypes.IsSourceRef(versionAlias) {
+		ref, err := vtypes.ParseSourceRef(versionAlias)
+		if err != nil {
+			return "", err
+		}
+
+		return ref.DirName(), nil
+	}
+
+	err := vtypes.ValidateVersionName(versionAlias)
+	if err != nil {
+		return "", err
+	}
+
+	return normalizeVersion(versionAlias), nil
+}
+
 // normalizeVersion normalizes a version string.
 func normalizeVersion(versionStr string) string {
 	return vtypes.NormalizeVersionForPath(versionStr)
@@ -458,24 +605,48 @@ func determineVersionType(name string) vtypes.Type {
 		return vtypes.TypeStable
 	case strings.HasPrefix(strings.ToLower(name), "nightly"):
 		return vtypes.TypeNightly
-	case vtypes.IsCommitReference(name):
+	case vtypes.IsCommitBuildName(name):
 		return vtypes.TypeCommit
+	case vtypes.IsSourceRefDirName(name):
+		return vtypes.TypeSourceRef
 	default:
 		return vtypes.TypeTag
 	}
 }
 
+// Resolve maps a version spec (alias, range, tag, channel or source
+// ref) to the directory name of the installed version it selects,
+// without switching to it. A range prefers the highest installed
+// match. It returns vtypes.ErrVersionNotFound when the selected
+// version is not installed.
+func (s *Service) Resolve(ctx context.Context, spec string) (string, error) {
+	versionAlias, err := s.resolveAlias(ctx, spec, true)
+	if err != nil {
+		return "", err
+	}
+
+	normalized, err := installName(versionAlias)
+	if err != nil {
+		return "", err
+	}
+
+	if !s.IsVersionInstalled(normalized) {
+		return "", fmt.Errorf("%w: %s", vtypes.ErrVersionNotFound, normalized)
+	}
+
+	return normalized, nil
+}
+
 // IsVersionInstalled checks if a version is installed.
 func (s *Service) IsVersionInstalled(versionName string) bool {
 	// Reject path-traversal input up front: an invalid name
 	// cannot match an installed version, so the answer is
 	// unambiguous (false) without needing to perform the lookup.
-	err := vtypes.ValidateVersionName(versionName)
+	normalized, err := installName(versionName)
 	if err != nil {
 		return false
 	}
 
-	normalized := normalizeVersion(versionName)
 	versionType := determineVersionType(normalized)
 	v := vtypes.New(normalized, versionType, normalized, "")
 
@@ -507,8 +678,8 @@ func (s *Service) InstalledVersionNames() ([]string, error) {
 }
 
 // InstalledVersionIdentifiers returns a map of installed version
-// name -> commit identifier (the contents of <VersionsDir>/<name>
-// /version.txt) in a single pass. It is intended for callers that
+// name -> commit identifier (the manifest identifier, or the
+// contents of a legacy version.txt) in a single pass. It is intended for callers that
 // loop over the installed set and previously called
 // GetInstalledVersionIdentifier per iteration, issuing one
 // os.ReadFile per version (N+1 syscalls). With this method the
@@ -517,8 +688,8 @@ func (s *Service) InstalledVersionNames() ([]string, error) {
 // information returned.
 //
 // An entry with an empty value means the version has no
-// version.txt (e.g. a pre-existing install that was not produced
-// by nvs, or a version.txt that failed to read). Callers should
+// manifest or version.txt (e.g. a pre-existing install that was not produced
+// by nvs, or a record that failed to read). Callers should
 // treat the empty string the same way they would treat a
 // GetInstalledVersionIdentifier error.
 func (s *Service) InstalledVersionIdentifiers() (map[string]string, error) {
@@ -538,16 +709,25 @@ func (s *Service) InstalledVersionIdentifiers() (map[string]string, error) {
 // GetInstalledVersionIdentifier returns the identifier (commit hash) of an installed version.
 func (s *Service) GetInstalledVersionIdentifier(versionName string) (string, error) {
 	// Reject path-traversal input before any filepath operation.
-	err := vtypes.ValidateVersionName(versionName)
+	normalized, err := installName(versionName)
 	if err != nil {
 		return "", err
 	}
 
-	normalized := normalizeVersion(versionName)
-
 	return s.versionManager.GetInstalledReleaseIdentifier(normalized)
 }
 
+// Manifest returns the install manifest of an installed version. For
+// installs that predate manifests only the identifier is set.
+func (s *Service) Manifest(versionName string) (vtypes.Manifest, error) {
+	normalized, err := installName(versionName)
+	if err != nil {
+		return vtypes.Manifest{}, err
+	}
+
+	return s.versionManager.GetManifest(normalized)
+}
+
 // FindStable returns the latest stable release.
 func (s *Service) FindStable(ctx context.Context) (release.Release, error) {
 	return s.releaseRepo.FindStable(ctx)
@@ -18,6 +18,7 @@ import (
 var (
 	errTagNotFound   = errors.New("tag not found")
 	errInstallFailed = errors.New("installation failed")
+	errNoManifest    = errors.New("no manifest recorded")
 )
 
 const (
@@ -31,7 +32,9 @@ type mockReleaseRepo struct {
 	nightly        release.Release
 	tags           map[string]release.Release
 	findNightlyErr error
-	getAllForce    bool // records if GetAll was called with force=true
+	getAllForce    bool              // records if GetAll was called with force=true
+	all            []release.Release // returned by GetAll when non-nil
+	getAllErr      error
 }
 
 func (m *mockReleaseRepo) FindStable(ctx context.Context) (release.Release, error) {
@@ -58,6 +61,14 @@ func (m *mockReleaseRepo) FindByTag(ctx context.Context, tag string) (release.Re
 func (m *mockReleaseRepo) GetAll(ctx context.Context, force bool) ([]release.Release, error) {
 	m.getAllForce = force
 
+	if m.getAllErr != nil {
+		return nil, m.getAllErr
+	}
+
+	if m.all != nil {
+		return m.all, nil
+	}
+
 	return []release.Release{m.stable, m.nightly}, nil
 }
 
@@ -66,6 +77,13 @@ type mockVersionManager struct {
 	installed   map[string]vtypes.Version
 	current     vtypes.Version
 	identifiers map[string]string
+	manifests   map[string]vtypes.Manifest
+
+	// uninstallErr, if set, fails every Uninstall.
+	uninstallErr error
+
+	// synced counts SyncGlobalBin calls.
+	synced int
 }
 
 func (m *mockVersionManager) List() ([]vtypes.Version, error) {
@@ -87,6 +105,12 @@ func (m *mockVersionManager) Switch(v vtypes.Version) error {
 	return nil
 }
 
+func (m *mockVersionManager) SyncGlobalBin() error {
+	m.synced++
+
+	return nil
+}
+
 func (m *mockVersionManager) IsInstalled(v vtypes.Version) bool {
 	_, exists := m.installed[v.Name()]
 
@@ -94,6 +118,10 @@ func (m *mockVersionManager) IsInstalled(v vtypes.Version) bool {
 }
 
 func (m *mockVersionManager) Uninstall(v vtypes.Version, force bool) error {
+	if m.uninstallErr != nil {
+		return m.uninstallErr
+	}
+
 	delete(m.installed, v.Name())
 
 	return nil
@@ -107,12 +135,24 @@ func (m *mockVersionManager) GetInstalledReleaseIdentifier(versionName string) (
 	return versionName, nil
 }
 
+func (m *mockVersionManager) GetManifest(versionName string) (vtypes.Manifest, error) {
+	if manifest, ok := m.manifests[versionName]; ok {
+		return manifest, nil
+	}
+
+	return vtypes.Manifest{}, errNoManifest
+}
+
 // mockInstaller implements installer.Installer for testing.
 type mockInstaller struct {
 	installed             map[string]vtypes.Version
 	buildFromCommitCalled bool
 	lastCommit            string
 	lastDest              string
+	remoteRefs            map[string]string // ref spec -> upstream hash
+	builtRefs             []string
+	lastLockedSHA256      string
+	lastProfile           vtypes.BuildProfile
 }
 
 func (m *mockInstaller) InstallRelease(
@@ -124,22 +164,46 @@ func (m *mockInstaller) InstallRelease(
 	// Create a version with the installed name (using TypeTag as default)
 	v := vtypes.New(installName, vtypes.TypeTag, installName, "")
 	m.installed[installName] = v
+	m.lastLockedSHA256 = rel.GetLockedSHA256()
 
 	return nil
 }
 
 func (m *mockInstaller) BuildFromCommit(
 	ctx context.Context,
-	commit, dest string,
+	commit string,
+	profile vtypes.BuildProfile,
+	dest string,
 	progress installer.ProgressFunc,
 ) (string, error) {
 	m.buildFromCommitCalled = true
 	m.lastCommit = commit
 	m.lastDest = dest
+	m.lastProfile = profile
 
 	return "abc1234", nil
 }
 
+func (m *mockInstaller) BuildFromRef(
+	ctx context.Context,
+	ref vtypes.SourceRef,
+	profile vtypes.BuildProfile,
+	dest string,
+	progress installer.ProgressFunc,
+) (string, error) {
+	name := profile.InstallName(ref.DirName())
+	m.builtRefs = append(m.builtRefs, ref.String())
+	m.lastDest = dest
+	m.lastProfile = profile
+	m.installed[name] = vtypes.New(name, vtypes.TypeSourceRef, ref.String(), "")
+
+	return "abc1234", nil
+}
+
+func (m *mockInstaller) ResolveRef(ctx context.Context, ref vtypes.SourceRef) (string, error) {
+	return m.remoteRefs[ref.String()], nil
+}
+
 func (m *mockInstaller) UpgradeRelease(
 	ctx context.Context,
 	rel installer.ReleaseInfo,
@@ -222,6 +286,38 @@ func TestService_Use_Stable(t *testing.T) {
 	}
 }
 
+// TestService_Use_AlreadyCurrent verifies using the current version
+// brings the global nvim entry in line with the shims setting.
+func TestService_Use_AlreadyCurrent(t *testing.T) {
+	stable := vtypes.New(constants.Stable, vtypes.TypeStable, testVersionTag, "abc123")
+	repo := &mockReleaseRepo{
+		stable: release.New(testVersionTag, false, "abc123", time.Time{}, nil),
+	}
+	manager := &mockVersionManager{
+		installed: map[string]vtypes.Version{constants.Stable: stable},
+		current:   stable,
+	}
+
+	service, newErr := versionsvc.New(
+		repo,
+		manager,
+		&mockInstaller{installed: make(map[string]vtypes.Version)},
+		&versionsvc.Config{VersionsDir: testTmp},
+	)
+	if newErr != nil {
+		t.Fatalf("Failed to create service: %v", newErr)
+	}
+
+	_, err := service.Use(t.Context(), constants.Stable)
+	if err != nil {
+		t.Fatalf("Use stable failed: %v", err)
+	}
+
+	if manager.synced != 1 {
+		t.Errorf("SyncGlobalBin called %d times, want 1", manager.synced)
+	}
+}
+
 func TestService_Use_Nightly_NotAvailable(t *testing.T) {
 	repo := &mockReleaseRepo{
 		findNightlyErr: release.ErrNoNightlyRelease,
@@ -347,6 +443,39 @@ func TestService_Use_VersionNotFound(t *testing.T) {
 	}
 }
 
+func TestService_Install_LockedSHA256(t *testing.T) {
+	repo := &mockReleaseRepo{
+		tags: map[string]release.Release{
+			testVersionTag: release.New(testVersionTag, false, "abc123", time.Time{}, nil),
+		},
+	}
+	manager := &mockVersionManager{installed: make(map[string]vtypes.Version)}
+	install := &mockInstaller{installed: make(map[string]vtypes.Version)}
+
+	service, err := versionsvc.New(repo, manager, install, &versionsvc.Config{VersionsDir: testTmp})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	err = service.Install(t.Context(), testVersionTag, nil, versionsvc.WithLockedSHA256("cafe"))
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	if install.lastLockedSHA256 != "cafe" {
+		t.Errorf("GetLockedSHA256() = %q, want %q", install.lastLockedSHA256, "cafe")
+	}
+
+	err = service.Install(t.Context(), testVersionTag, nil)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	if install.lastLockedSHA256 != "" {
+		t.Errorf("GetLockedSHA256() without a lock = %q, want empty", install.lastLockedSHA256)
+	}
+}
+
 func TestService_Install_CommitHash(t *testing.T) {
 	// Test installing from a commit hash
 	repo := &mockReleaseRepo{}
@@ -699,12 +828,31 @@ func (m *mockInstallerWithErrors) InstallRelease(
 
 func (m *mockInstallerWithErrors) BuildFromCommit(
 	ctx context.Context,
-	commit, dest string,
+	commit string,
+	profile vtypes.BuildProfile,
+	dest string,
 	progress installer.ProgressFunc,
 ) (string, error) {
 	return "", nil
 }
 
+func (m *mockInstallerWithErrors) BuildFromRef(
+	ctx context.Context,
+	ref vtypes.SourceRef,
+	profile vtypes.BuildProfile,
+	dest string,
+	progress installer.ProgressFunc,
+) (string, error) {
+	return "", m.installErr
+}
+
+func (m *mockInstallerWithErrors) ResolveRef(
+	ctx context.Context,
+	ref vtypes.SourceRef,
+) (string, error) {
+	return "", nil
+}
+
 func (m *mockInstallerWithErrors) UpgradeRelease(
 	ctx context.Context,
 	rel installer.ReleaseInfo,
@@ -890,3 +1038,36 @@ func TestService_Upgrade_InvalidVersion(t *testing.T) {
 		t.Errorf("Expected ErrOnlyStableNightlyUpgrade, got: %v", err)
 	}
 }
+
+func TestService_Use_ReleaseLookupFailsUsesManifest(t *testing.T) {
+	repo := &mockReleaseRepo{
+		findNightlyErr: errOffline,
+	}
+	manager := &mockVersionManager{
+		installed: map[string]vtypes.Version{
+			constants.Nightly: vtypes.New(constants.Nightly, vtypes.TypeNightly, "abc1234", ""),
+		},
+		manifests: map[string]vtypes.Manifest{
+			constants.Nightly: {Identifier: "abc1234", Commit: "abc1234def"},
+		},
+	}
+
+	service, newErr := versionsvc.New(
+		repo,
+		manager,
+		&mockInstaller{},
+		&versionsvc.Config{VersionsDir: testTmp},
+	)
+	if newErr != nil {
+		t.Fatalf("Failed to create service: %v", newErr)
+	}
+
+	resolved, err := service.Use(t.Context(), constants.Nightly)
+	if err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+
+	if resolved != "abc1234" || manager.current.Name() != constants.Nightly {
+		t.Errorf("Use = %q, current %q; want abc1234 on nightly", resolved, manager.current.Name())
+	}
+}
@@ -0,0 +1,107 @@
+package versionsvc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/installer"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// installSourceRef builds a branch or pull request head under its
+// directory name (plus the profile's suffix). An existing build is
+// left alone; 'nvs upgrade' is what re-fetches the ref.
+func (s *Service) installSourceRef(
+	ctx context.Context,
+	spec string,
+	profile vtypes.BuildProfile,
+	progress installer.ProgressFunc,
+) error {
+	ref, err := vtypes.ParseSourceRef(spec)
+	if err != nil {
+		return err
+	}
+
+	name := ref.InstallName(profile)
+
+	if s.versionManager.IsInstalled(vtypes.New(name, vtypes.TypeSourceRef, spec, "")) {
+		log.Debugf("%s already installed as %s, skipping build", ref, name)
+
+		return nil
+	}
+
+	hash, err := s.installer.BuildFromRef(ctx, ref, profile, s.config.VersionsDir, progress)
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Built %s at %s into %s", ref, hash, name)
+
+	return nil
+}
+
+// upgradeSourceRef rebuilds an installed branch or pull request build
+// when its upstream head has moved. The ref and build profile are read
+// back from the install's manifest, so "pr-12345" upgrades as well as
+// "pr:12345", and "pr-12345-debug" stays a debug build.
+func (s *Service) upgradeSourceRef(
+	ctx context.Context,
+	versionAlias, dirName string,
+	progress installer.ProgressFunc,
+) error {
+	if !s.versionManager.IsInstalled(vtypes.New(dirName, vtypes.TypeSourceRef, dirName, "")) {
+		return ErrNotInstalled
+	}
+
+	spec := versionAlias
+
+	var profile vtypes.BuildProfile
+
+	manifest, err := s.versionManager.GetManifest(dirName)
+	if err == nil && manifest.Ref != "" {
+		spec = manifest.Ref
+	}
+
+	if err == nil && manifest.BuildProfile != nil {
+		profile = *manifest.BuildProfile
+	}
+
+	if !vtypes.IsSourceRef(spec) {
+		return fmt.Errorf("%w: %s has no ref in its manifest", ErrSourceRefUnknown, dirName)
+	}
+
+	ref, err := vtypes.ParseSourceRef(spec)
+	if err != nil {
+		return err
+	}
+
+	remoteHash, err := s.installer.ResolveRef(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+
+	currentHash, err := s.versionManager.GetInstalledReleaseIdentifier(dirName)
+	if err == nil && currentHash == remoteHash {
+		return ErrAlreadyUpToDate
+	}
+
+	log.Debugf("Upgrading %s: %s -> %s", ref, shortHash(currentHash), shortHash(remoteHash))
+
+	_, err = s.installer.BuildFromRef(ctx, ref, profile, s.config.VersionsDir, progress)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade: %w", err)
+	}
+
+	return nil
+}
+
+// shortHash truncates a commit hash for display.
+func shortHash(hash string) string {
+	if len(hash) > constants.ShortCommitLen {
+		return hash[:constants.ShortCommitLen]
+	}
+
+	return hash
+}
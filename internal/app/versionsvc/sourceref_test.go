@@ -0,0 +1,195 @@
+package versionsvc_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/app/versionsvc"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+)
+
+const (
+	testPRRef    = "pr:12345"
+	testPRDir    = "pr-12345"
+	testPRHead   = "1111111aaaaaaa"
+	testPRHeadV2 = "2222222bbbbbbb"
+)
+
+// newSourceRefTestService wires a service whose installer reports
+// remoteHead as the upstream head of testPRRef.
+func newSourceRefTestService(
+	t *testing.T,
+	remoteHead string,
+) (*versionsvc.Service, *mockVersionManager, *mockInstaller) {
+	t.Helper()
+
+	manager := &mockVersionManager{
+		installed:   make(map[string]vtypes.Version),
+		identifiers: make(map[string]string),
+		manifests:   make(map[string]vtypes.Manifest),
+	}
+	install := &mockInstaller{
+		installed:  manager.installed,
+		remoteRefs: map[string]string{testPRRef: remoteHead},
+	}
+
+	service, err := versionsvc.New(
+		&mockReleaseRepo{},
+		manager,
+		install,
+		&versionsvc.Config{VersionsDir: testTmp},
+	)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	return service, manager, install
+}
+
+func TestService_Install_SourceRef(t *testing.T) {
+	service, _, install := newSourceRefTestService(t, testPRHead)
+
+	err := service.Install(t.Context(), testPRRef, nil)
+	if err != nil {
+		t.Fatalf("Install(%s) failed: %v", testPRRef, err)
+	}
+
+	if !slices.Equal(install.builtRefs, []string{testPRRef}) {
+		t.Errorf("built %v, want [%s]", install.builtRefs, testPRRef)
+	}
+
+	if !service.IsVersionInstalled(testPRRef) || !service.IsVersionInstalled(testPRDir) {
+		t.Errorf("%s not reported installed by ref and directory name", testPRRef)
+	}
+
+	// A second install leaves the existing build alone.
+	err = service.Install(t.Context(), testPRRef, nil)
+	if err != nil {
+		t.Fatalf("second Install failed: %v", err)
+	}
+
+	if len(install.builtRefs) != 1 {
+		t.Errorf("built %d times, want 1", len(install.builtRefs))
+	}
+}
+
+func TestService_Install_InvalidSourceRef(t *testing.T) {
+	service, _, install := newSourceRefTestService(t, testPRHead)
+
+	err := service.Install(t.Context(), "pr:../1", nil)
+	if !errors.Is(err, vtypes.ErrInvalidSourceRef) {
+		t.Errorf("Install error = %v, want ErrInvalidSourceRef", err)
+	}
+
+	if len(install.builtRefs) != 0 {
+		t.Errorf("invalid ref was built: %v", install.builtRefs)
+	}
+}
+
+func TestService_Upgrade_SourceRef(t *testing.T) {
+	service, manager, install := newSourceRefTestService(t, testPRHead)
+
+	manager.installed[testPRDir] = vtypes.New(testPRDir, vtypes.TypeSourceRef, testPRDir, testPRHead)
+	manager.identifiers[testPRDir] = testPRHead
+	manager.manifests[testPRDir] = vtypes.Manifest{
+		Schema:     vtypes.ManifestSchema,
+		Source:     vtypes.ManifestSourceBuild,
+		Identifier: testPRHead,
+		Ref:        testPRRef,
+	}
+
+	err := service.Upgrade(t.Context(), testPRDir, nil)
+	if !errors.Is(err, versionsvc.ErrAlreadyUpToDate) {
+		t.Fatalf("Upgrade at same head error = %v, want ErrAlreadyUpToDate", err)
+	}
+
+	install.remoteRefs[testPRRef] = testPRHeadV2
+
+	// The directory name is enough: the ref comes from the install's manifest.
+	err = service.Upgrade(t.Context(), testPRDir, nil)
+	if err != nil {
+		t.Fatalf("Upgrade failed: %v", err)
+	}
+
+	if !slices.Equal(install.builtRefs, []string{testPRRef}) {
+		t.Errorf("built %v, want [%s]", install.builtRefs, testPRRef)
+	}
+}
+
+func TestService_Upgrade_SourceRefNotInstalled(t *testing.T) {
+	service, _, _ := newSourceRefTestService(t, testPRHead)
+
+	err := service.Upgrade(t.Context(), testPRRef, nil)
+	if !errors.Is(err, versionsvc.ErrNotInstalled) {
+		t.Errorf("Upgrade error = %v, want ErrNotInstalled", err)
+	}
+}
+
+func TestService_Upgrade_SourceRefWithoutManifest(t *testing.T) {
+	service, manager, install := newSourceRefTestService(t, testPRHead)
+
+	manager.installed[testPRDir] = vtypes.New(testPRDir, vtypes.TypeSourceRef, testPRDir, testPRHead)
+	manager.identifiers[testPRDir] = testPRHead
+
+	err := service.Upgrade(t.Context(), testPRDir, nil)
+	if !errors.Is(err, versionsvc.ErrSourceRefUnknown) {
+		t.Errorf("Upgrade(%s) error = %v, want ErrSourceRefUnknown", testPRDir, err)
+	}
+
+	// Spelling the ref out still works without a recorded one.
+	err = service.Upgrade(t.Context(), testPRRef, nil)
+	if !errors.Is(err, versionsvc.ErrAlreadyUpToDate) {
+		t.Errorf("Upgrade(%s) error = %v, want ErrAlreadyUpToDate", testPRRef, err)
+	}
+
+	if len(install.builtRefs) != 0 {
+		t.Errorf("built %v, want nothing", install.builtRefs)
+	}
+}
+
+func TestService_SourceRef_BuildProfile(t *testing.T) {
+	service, manager, install := newSourceRefTestService(t, testPRHead)
+	debug := vtypes.BuildProfile{Type: vtypes.BuildTypeDebug}
+	debugDir := testPRDir + "-debug"
+
+	err := service.Install(t.Context(), testPRRef, nil, versionsvc.WithBuildProfile(debug))
+	if err != nil {
+		t.Fatalf("Install(%s) failed: %v", testPRRef, err)
+	}
+
+	if !service.IsVersionInstalled(debugDir) || service.IsVersionInstalled(testPRDir) {
+		t.Errorf("debug build not installed as %s alone", debugDir)
+	}
+
+	// The default build of the same ref is a separate install.
+	err = service.Install(t.Context(), testPRRef, nil)
+	if err != nil {
+		t.Fatalf("Install(%s) failed: %v", testPRRef, err)
+	}
+
+	if len(install.builtRefs) != 2 || !install.lastProfile.IsDefault() {
+		t.Errorf("built %v, last with %+v; want a second, default build", install.builtRefs,
+			install.lastProfile)
+	}
+
+	// An upgrade rebuilds with the profile recorded in the manifest.
+	manager.identifiers[debugDir] = testPRHead
+	manager.manifests[debugDir] = vtypes.Manifest{
+		Schema:       vtypes.ManifestSchema,
+		Source:       vtypes.ManifestSourceBuild,
+		Identifier:   testPRHead,
+		Ref:          testPRRef,
+		BuildProfile: &debug,
+	}
+	install.remoteRefs[testPRRef] = testPRHeadV2
+
+	err = service.Upgrade(t.Context(), debugDir, nil)
+	if err != nil {
+		t.Fatalf("Upgrade(%s) failed: %v", debugDir, err)
+	}
+
+	if install.lastProfile != debug {
+		t.Errorf("upgrade built with %+v, want %+v", install.lastProfile, debug)
+	}
+}
@@ -77,16 +77,31 @@ const (
 	// VersionFileName is the name of the version sync file.
 	VersionFileName = ".nvs-version"
 
+	// LockFileName is the archive hash lock written next to a pin by 'nvs pin --lock'.
+	LockFileName = ".nvs-lock"
+
+	// ManifestFileName is the per-install metadata file in each version directory.
+	ManifestFileName = "manifest.json"
+
+	// IdentifierFileName is the bare-identifier file that installs made
+	// before manifests carry instead of ManifestFileName.
+	IdentifierFileName = "version.txt"
+
 	// NightlyHistoryFile is the name of the nightly history file.
 	NightlyHistoryFile = "nightly-history.json"
+	// AliasesFile is the name of the user alias file.
+	AliasesFile = "aliases.json"
+	// BisectStateFile is the name of the file that holds the bisection
+	// in progress.
+	BisectStateFile = "bisect.json"
 	// DefaultRollbackLimit is the default limit for rollback entries.
 	DefaultRollbackLimit = 5
 
 	// UnavailableDir is the string for unavailable directory.
 	UnavailableDir = "Unavailable"
 
-	// GitHubCompareURL is the URL for GitHub compare API.
-	GitHubCompareURL = "https://api.github.com/repos/neovim/neovim/compare"
+	// DefaultRepo is the repository releases and sources come from.
+	DefaultRepo = "neovim/neovim"
 	// DefaultAPIBaseURL is the default API base URL.
 	DefaultAPIBaseURL = "https://api.github.com"
 	// DefaultGitHubBaseURL is the default GitHub base URL for downloads.
@@ -103,6 +118,26 @@ const (
 	// GitHubInitialBackoff is the delay before the first retry.
 	// Subsequent retries double this (200ms, 400ms, 800ms).
 	GitHubInitialBackoff = 200 * time.Millisecond
+	// MaxRateLimitWait is the longest a GitHub API request waits for
+	// an exhausted rate limit to reset before failing instead.
+	MaxRateLimitWait = 15 * time.Minute
+	// LowRateLimit is the remaining GitHub API quota below which nvs
+	// warns.
+	LowRateLimit = 10
+	// GHTokenTimeout bounds how long 'gh auth token' may take.
+	GHTokenTimeout = 5 * time.Second
+	// MaxDownloadRetries is the number of times a failed or
+	// interrupted archive download is retried, resuming from the
+	// bytes already on disk.
+	MaxDownloadRetries = 3
+	// DownloadInitialBackoff is the delay before the first download
+	// retry. Subsequent retries double this (1s, 2s, 4s).
+	DownloadInitialBackoff = time.Second
+	// MaxDownloadConnections caps the download_connections setting.
+	MaxDownloadConnections = 16
+	// MinDownloadChunkSize is the smallest byte range worth its own
+	// connection; smaller assets use fewer connections, or one.
+	MinDownloadChunkSize = 1 << 20
 	// MaxGitHubResponseBytes bounds the size of a single GitHub
 	// response body. 32 MiB is far more than any plausible
 	// /releases response but still bounded so a malicious upstream
@@ -123,6 +158,35 @@ const (
 	// CacheTTL is the time-to-live for cache entries.
 	CacheTTL = 5 * time.Minute
 
+	// ArchiveCacheDir is the directory under the cache directory that
+	// holds downloaded release archives.
+	ArchiveCacheDir = "archives"
+	// DefaultArchiveCacheMaxMB is the default size limit of the archive cache.
+	DefaultArchiveCacheMaxMB = 500
+	// DefaultArchiveCacheMaxAge is how long an unused archive is kept by default.
+	DefaultArchiveCacheMaxAge = 30 * 24 * time.Hour
+	// PartialDownloadDir is the directory under the cache directory
+	// that keeps interrupted downloads until they are resumed.
+	PartialDownloadDir = "downloads"
+	// MirrorStatsFile is the file under the cache directory that
+	// records the health of each download mirror.
+	MirrorStatsFile = "mirror-stats.json"
+	// SourceMirrorDir is the bare git mirror under the cache directory
+	// that source builds fetch into and check out from.
+	SourceMirrorDir = "neovim.git"
+	// DepsCacheDir is the directory under the cache directory that
+	// holds the third-party dependencies of source builds.
+	DepsCacheDir = "deps"
+	// DepsCacheMaxSets is how many dependency sets are kept.
+	DepsCacheMaxSets = 5
+	// DepsCacheMaxAge is how long an unused dependency set is kept.
+	DepsCacheMaxAge = 30 * 24 * time.Hour
+	// BuildLogDir is the directory under the cache directory that
+	// keeps the output of source builds.
+	BuildLogDir = "build-logs"
+	// DefaultBuildLogKeep is how many build logs are kept by default.
+	DefaultBuildLogKeep = 20
+
 	// ShellBash is the bash shell name.
 	ShellBash = "bash"
 	// ShellZsh is the zsh shell name.
@@ -163,7 +227,7 @@ const (
 	// MaxAttempts is the maximum number of attempts.
 	MaxAttempts = 3
 
-	// RepoURL is the repository URL.
+	// RepoURL is the default URL source builds clone.
 	RepoURL = "https://github.com/neovim/neovim.git"
 	// GlobalCacheURL is the URL for the global cache JSON file.
 	GlobalCacheURL = "https://raw.githubusercontent.com/y3owk1n/nvs/main/versions.json"
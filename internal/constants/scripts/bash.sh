@@ -5,30 +5,92 @@
 # current shell via BASH_VERSION / ZSH_VERSION. Do not edit the embedded
 # copy that ships with the binary; edit this file and rebuild.
 
-_nvs_find_version_file() {
+# _nvs_read_pin prints the version pinned in directory $1 by the first
+# enabled pin source that has one, mirroring `nvs use` (see
+# NVS_PIN_SOURCES). Sources are tried in the configured order:
+# NVS_PIN_SOURCES, else _NVS_PIN_SOURCES, which `nvs hook` sets from
+# pin_sources in config.toml, else nvs,nvim,tool-versions,mise.
+_nvs_read_pin() {
+  local dir="$1" src file version rest
+  # Split on commas by hand: unquoted expansion does not word-split
+  # in zsh, so a plain for-loop over the list would not be portable.
+  rest="${NVS_PIN_SOURCES:-${_NVS_PIN_SOURCES:-nvs,nvim,tool-versions,mise}},"
+
+  while [[ -n "$rest" ]]; do
+    src="${rest%%,*}"
+    src="${src// /}"
+    rest="${rest#*,}"
+    version=""
+    case "$src" in
+      nvs | nvim)
+        file="$dir/.$src-version"
+        if [[ -f "$file" ]]; then
+          IFS= read -r version < "$file" || true
+          version="${version#"${version%%[![:space:]]*}"}"
+          version="${version%"${version##*[![:space:]]}"}"
+        fi
+        ;;
+      tool-versions)
+        file="$dir/.tool-versions"
+        if [[ -f "$file" ]]; then
+          version="$(awk '{ sub(/#.*/, "") } $1 == "neovim" { print $2; exit }' "$file")"
+        fi
+        ;;
+      mise)
+        for file in "$dir/mise.toml" "$dir/.mise.toml"; do
+          if [[ -f "$file" ]]; then
+            version="$(_nvs_read_mise "$file")"
+            [[ -n "$version" ]] && break
+          fi
+        done
+        ;;
+    esac
+
+    if [[ -n "$version" ]]; then
+      echo "$version"
+      return 0
+    fi
+  done
+
+  return 1
+}
+
+# _nvs_read_mise prints tools.neovim from a mise config. It understands
+# the string, array and { version = "..." } forms by taking the first
+# quoted value after the key.
+_nvs_read_mise() {
+  awk '
+    /^[[:space:]]*\[/ { in_tools = ($0 ~ /^[[:space:]]*\[tools\][[:space:]]*$/); next }
+    in_tools && $0 ~ /^[[:space:]]*"?neovim"?[[:space:]]*=/ {
+      sub(/^[^=]*=/, "")
+      if (match($0, /"[^"]*"/)) { print substr($0, RSTART + 1, RLENGTH - 2); exit }
+    }
+  ' "$1"
+}
+
+# _nvs_find_version prints the pinned version for $PWD: the nearest
+# directory with a pin wins, then the home directory.
+_nvs_find_version() {
   local dir="$PWD"
   while [[ "$dir" != "/" ]]; do
-    if [[ -f "$dir/.nvs-version" ]]; then
-      echo "$dir/.nvs-version"
+    if _nvs_read_pin "$dir"; then
       return
     fi
     dir="$(dirname "$dir")"
   done
 
   # Check home directory
-  if [[ -f "$HOME/.nvs-version" ]]; then
-    echo "$HOME/.nvs-version"
-  fi
+  _nvs_read_pin "$HOME"
 }
 
 _nvs_hook() {
-  local nvs_version_file
-  nvs_version_file="$(_nvs_find_version_file)"
+  # A version chosen with `nvs shell` overrides pins for this session.
+  [[ -n "$NVS_SHELL_VERSION" ]] && return
 
-  if [[ -n "$nvs_version_file" ]]; then
-    local version
-    version="$(tr -d '[:space:]' < "$nvs_version_file")"
+  local version
+  version="$(_nvs_find_version)"
 
+  if [[ -n "$version" ]]; then
     # Only switch if version changed
     if [[ "$version" != "$_NVS_CURRENT_VERSION" ]]; then
       if nvs use "$version" --force >/dev/null 2>&1; then
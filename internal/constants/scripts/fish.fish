@@ -3,28 +3,88 @@
 # the embedded copy that ships with the binary; edit this file and
 # rebuild.
 
-function _nvs_find_version_file
+# _nvs_read_pin prints the version pinned in directory $argv[1] by the
+# first enabled pin source that has one, mirroring `nvs use` (see
+# NVS_PIN_SOURCES). Sources are tried in the configured order:
+# NVS_PIN_SOURCES, else _NVS_PIN_SOURCES, which `nvs hook` sets from
+# pin_sources in config.toml, else nvs,nvim,tool-versions,mise.
+function _nvs_read_pin
+  set -l dir $argv[1]
+  set -l sources nvs nvim tool-versions mise
+  if set -q NVS_PIN_SOURCES; and test -n "$NVS_PIN_SOURCES"
+    set sources (string split , -- $NVS_PIN_SOURCES)
+  else if set -q _NVS_PIN_SOURCES; and test -n "$_NVS_PIN_SOURCES"
+    set sources (string split , -- $_NVS_PIN_SOURCES)
+  end
+
+  for src in (string trim -- $sources)
+    set -l nvs_version
+    switch $src
+      case nvs nvim
+        set -l file "$dir/.$src-version"
+        if test -f "$file"
+          set nvs_version (string trim < "$file")[1]
+        end
+      case tool-versions
+        set -l file "$dir/.tool-versions"
+        if test -f "$file"
+          set nvs_version (awk '{ sub(/#.*/, "") } $1 == "neovim" { print $2; exit }' "$file")
+        end
+      case mise
+        for file in "$dir/mise.toml" "$dir/.mise.toml"
+          if test -f "$file"
+            set nvs_version (_nvs_read_mise "$file")
+            test -n "$nvs_version"; and break
+          end
+        end
+    end
+
+    if test -n "$nvs_version"
+      echo $nvs_version
+      return 0
+    end
+  end
+
+  return 1
+end
+
+# _nvs_read_mise prints tools.neovim from a mise config. It understands
+# the string, array and { version = "..." } forms by taking the first
+# quoted value after the key.
+function _nvs_read_mise
+  awk '
+    /^[[:space:]]*\[/ { in_tools = ($0 ~ /^[[:space:]]*\[tools\][[:space:]]*$/); next }
+    in_tools && $0 ~ /^[[:space:]]*"?neovim"?[[:space:]]*=/ {
+      sub(/^[^=]*=/, "")
+      if (match($0, /"[^"]*"/)) { print substr($0, RSTART + 1, RLENGTH - 2); exit }
+    }
+  ' $argv[1]
+end
+
+# _nvs_find_version prints the pinned version for $PWD: the nearest
+# directory with a pin wins, then the home directory.
+function _nvs_find_version
   set -l dir "$PWD"
   while test "$dir" != "/"
-    if test -f "$dir/.nvs-version"
-      echo "$dir/.nvs-version"
+    if _nvs_read_pin "$dir"
       return
     end
     set dir (dirname -- "$dir")
   end
 
   # Check home directory
-  if test -f "$HOME/.nvs-version"
-    echo "$HOME/.nvs-version"
-  end
+  _nvs_read_pin "$HOME"
 end
 
 function _nvs_hook --on-variable PWD
-  set -l nvs_version_file (_nvs_find_version_file)
+  # A version chosen with `nvs shell` overrides pins for this session.
+  if set -q NVS_SHELL_VERSION; and test -n "$NVS_SHELL_VERSION"
+    return
+  end
 
-  if test -n "$nvs_version_file"
-    set -l nvs_version (string trim < "$nvs_version_file")
+  set -l nvs_version (_nvs_find_version)
 
+  if test -n "$nvs_version"
     # Only switch if version changed
     if test "$nvs_version" != "$_NVS_CURRENT_VERSION"
       if nvs use "$nvs_version" --force >/dev/null 2>&1
@@ -0,0 +1,70 @@
+// Package bisect provides the domain types for finding the first
+// Neovim version that introduced a change.
+package bisect
+
+import (
+	"context"
+	"fmt"
+)
+
+// Verdict is the outcome of testing one candidate.
+type Verdict string
+
+// Verdicts, named as git bisect names them.
+const (
+	// Good marks a candidate without the change.
+	Good Verdict = "good"
+	// Bad marks a candidate with the change.
+	Bad Verdict = "bad"
+	// Skip marks a candidate that cannot be tested.
+	Skip Verdict = "skip"
+)
+
+// ParseVerdict returns the verdict named by value.
+func ParseVerdict(value string) (Verdict, error) {
+	switch verdict := Verdict(value); verdict {
+	case Good, Bad, Skip:
+		return verdict, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use good, bad or skip)", ErrInvalidVerdict, value)
+	}
+}
+
+// Step is where a commit bisection stands: the next commit to test, or
+// the result once there is none.
+type Step struct {
+	// Candidate is the full hash of the commit to test next; empty
+	// once the bisection is done.
+	Candidate string
+
+	// StepsLeft is roughly how many more candidates need testing
+	// after Candidate.
+	StepsLeft int
+
+	// FirstBad is the full hash of the first bad commit, once found.
+	FirstBad string
+
+	// Suspects are the commits the first bad one is among when
+	// skipped commits leave the bisection undecided.
+	Suspects []string
+}
+
+// Done reports whether the bisection has nothing more to test.
+func (s Step) Done() bool {
+	return s.Candidate == ""
+}
+
+// Driver bisects the commit history of the Neovim repository. There is
+// at most one commit bisection at a time.
+type Driver interface {
+	// BisectStart begins a bisection between the revisions good and
+	// bad (tags or commit hashes), replacing any earlier one.
+	BisectStart(ctx context.Context, good, bad string) (Step, error)
+
+	// BisectMark records the verdict for commit and returns the next
+	// step.
+	BisectMark(ctx context.Context, verdict Verdict, commit string) (Step, error)
+
+	// BisectReset ends the bisection.
+	BisectReset(ctx context.Context) error
+}
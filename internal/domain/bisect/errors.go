@@ -0,0 +1,16 @@
+package bisect
+
+import "errors"
+
+// Domain errors for bisection.
+var (
+	// ErrInvalidVerdict is returned for a verdict other than good, bad or skip.
+	ErrInvalidVerdict = errors.New("invalid verdict")
+
+	// ErrNotOlder is returned when the good revision is not older than
+	// the bad one.
+	ErrNotOlder = errors.New("good revision is not older than the bad one")
+
+	// ErrUnknownRevision is returned when a revision is not in the repository.
+	ErrUnknownRevision = errors.New("unknown revision")
+)
@@ -10,6 +10,10 @@ var (
 	// ErrChecksumMismatch is returned when checksum verification fails.
 	ErrChecksumMismatch = errors.New("checksum mismatch")
 
+	// ErrLockMismatch is returned when an archive differs from the one
+	// recorded in a project's .nvs-lock.
+	ErrLockMismatch = errors.New("archive does not match the lock file")
+
 	// ErrExtractionFailed is returned when archive extraction fails.
 	ErrExtractionFailed = errors.New("extraction failed")
 
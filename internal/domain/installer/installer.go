@@ -1,7 +1,11 @@
 // Package installer provides the domain interface for Neovim installation.
 package installer
 
-import "context"
+import (
+	"context"
+
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+)
 
 // Installer handles Neovim installation operations.
 type Installer interface {
@@ -26,15 +30,35 @@ type Installer interface {
 		progress ProgressFunc,
 	) error
 
-	// BuildFromCommit builds Neovim from source at a specific commit.
-	// The built version is installed to the destination directory.
+	// BuildFromCommit builds Neovim from source at a specific commit,
+	// configured by profile. The built version is installed to the
+	// destination directory under profile.InstallName of the short hash.
 	// Returns the resolved commit hash that was installed.
 	BuildFromCommit(
 		ctx context.Context,
 		commit string,
+		profile vtypes.BuildProfile,
 		dest string,
 		progress ProgressFunc,
 	) (string, error)
+
+	// BuildFromRef builds Neovim from the current head of a branch or
+	// pull request and installs it under ref.InstallName(profile),
+	// replacing any earlier build of the same ref and profile. The ref,
+	// profile and resolved commit hash are recorded in the install
+	// directory.
+	// Returns the resolved commit hash that was installed.
+	BuildFromRef(
+		ctx context.Context,
+		ref vtypes.SourceRef,
+		profile vtypes.BuildProfile,
+		dest string,
+		progress ProgressFunc,
+	) (string, error)
+
+	// ResolveRef returns the full commit hash ref currently points at
+	// upstream, without building anything.
+	ResolveRef(ctx context.Context, ref vtypes.SourceRef) (string, error)
 }
 
 // ProgressFunc is a callback function for reporting installation progress.
@@ -52,4 +76,14 @@ type ReleaseInfo interface {
 
 	// GetIdentifier returns a unique identifier for this release.
 	GetIdentifier() string
+
+	// GetTagName returns the release tag, recorded in the install manifest.
+	GetTagName() string
+
+	// GetCommitHash returns the commit the release was built from, if known.
+	GetCommitHash() string
+
+	// GetLockedSHA256 returns the SHA256 a project lock file requires
+	// the asset to have, or "" when the install is not locked.
+	GetLockedSHA256() string
 }
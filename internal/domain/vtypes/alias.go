@@ -0,0 +1,61 @@
+package vtypes
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// aliasNamePattern is the shape of a user-defined alias: it must start
+// with a letter so it can never be mistaken for a version tag
+// ("0.10.2") or a range, and otherwise stays within the
+// IsValidVersionName character set.
+var aliasNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9._-]*$`)
+
+// versionTagPattern matches names that read as a version tag
+// ("v0.10.2", "v1"), which are reserved for real releases.
+var versionTagPattern = regexp.MustCompile(`^[vV][0-9]`)
+
+// reservedAliases are names with a built-in meaning that a user alias
+// must not shadow.
+var reservedAliases = map[string]bool{
+	"stable":  true,
+	"nightly": true,
+	"master":  true,
+	"main":    true,
+	"current": true,
+}
+
+// AliasStore persists user-defined version aliases: short names such
+// as "work" or "lts" that point at an installed version.
+type AliasStore interface {
+	// List returns every alias and its target version name.
+	List() (map[string]string, error)
+
+	// Get returns the target of name and whether the alias exists.
+	Get(name string) (string, bool, error)
+
+	// Set creates or retargets an alias.
+	Set(name, target string) error
+
+	// Remove deletes an alias. Removing a missing alias returns
+	// ErrAliasNotFound.
+	Remove(name string) error
+}
+
+// ValidateAliasName returns ErrInvalidAliasName wrapped with the
+// offending input unless name is usable as an alias: it must start
+// with a letter, stay within the version-name character set, and not
+// collide with a built-in alias, a version tag or a commit hash.
+func ValidateAliasName(name string) error {
+	switch {
+	case !aliasNamePattern.MatchString(name):
+		return fmt.Errorf("%w: %q (use letters, digits, '.', '-' or '_', starting with a letter)",
+			ErrInvalidAliasName, name)
+	case reservedAliases[name]:
+		return fmt.Errorf("%w: %q is a built-in name", ErrInvalidAliasName, name)
+	case versionTagPattern.MatchString(name), IsCommitBuildName(name), IsSourceRefDirName(name):
+		return fmt.Errorf("%w: %q looks like a version", ErrInvalidAliasName, name)
+	default:
+		return nil
+	}
+}
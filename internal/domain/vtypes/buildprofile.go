@@ -0,0 +1,201 @@
+package vtypes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CMake build types accepted by BuildProfile.Type.
+const (
+	BuildTypeRelease        = "Release"
+	BuildTypeRelWithDebInfo = "RelWithDebInfo"
+	BuildTypeDebug          = "Debug"
+	BuildTypeMinSizeRel     = "MinSizeRel"
+)
+
+// BuildTypes lists the accepted build types, default first.
+var BuildTypes = []string{
+	BuildTypeRelease,
+	BuildTypeRelWithDebInfo,
+	BuildTypeDebug,
+	BuildTypeMinSizeRel,
+}
+
+// sanitizeFlag is the Neovim CMake option that turns on
+// AddressSanitizer and UndefinedBehaviorSanitizer together.
+const sanitizeFlag = "-DENABLE_ASAN_UBSAN=ON"
+
+// flagsHashLen is how many hex digits of the custom flags' hash go into
+// a generated build name.
+const flagsHashLen = 6
+
+// buildNamePattern matches a build name: it ends up in a version
+// directory name, after the commit hash or ref.
+var buildNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// BuildProfile is how a source build is configured. The zero value is
+// the default profile: a Release build, installed under the plain
+// commit hash or ref directory name. Any other profile is installed
+// under "<name>-<suffix>" (see Suffix), so "1a2b3c4" and
+// "1a2b3c4-debug" can be installed side by side.
+//
+//nolint:tagliatelle
+type BuildProfile struct {
+	// Type is the CMake build type (one of BuildTypes); empty means
+	// Release.
+	Type string `json:"type,omitempty"`
+
+	// Sanitize builds with AddressSanitizer and
+	// UndefinedBehaviorSanitizer.
+	Sanitize bool `json:"sanitize,omitempty"`
+
+	// CMakeFlags are extra flags for Neovim's own CMake configure
+	// step (make's CMAKE_EXTRA_FLAGS).
+	CMakeFlags string `json:"cmake_flags,omitempty"`
+
+	// DepsCMakeFlags are extra flags for the bundled dependencies'
+	// CMake configure step (make's DEPS_CMAKE_FLAGS).
+	DepsCMakeFlags string `json:"deps_cmake_flags,omitempty"`
+
+	// Name replaces the generated install name suffix.
+	Name string `json:"name,omitempty"`
+}
+
+// ParseBuildType returns the canonical spelling of a CMake build type,
+// matched case-insensitively. An empty string is Release.
+func ParseBuildType(value string) (string, error) {
+	if value == "" {
+		return BuildTypeRelease, nil
+	}
+
+	for _, buildType := range BuildTypes {
+		if strings.EqualFold(value, buildType) {
+			return buildType, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: unknown build type %q (use %s)",
+		ErrInvalidBuildProfile, value, strings.Join(BuildTypes, ", "))
+}
+
+// ValidateBuildName returns ErrInvalidBuildProfile wrapped with the
+// offending input unless name is usable as a build name.
+func ValidateBuildName(name string) error {
+	if !buildNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: build name %q (use letters, digits, '.', '-' or '_')",
+			ErrInvalidBuildProfile, name)
+	}
+
+	return nil
+}
+
+// Normalize returns p with its build type in canonical form, or
+// ErrInvalidBuildProfile if the type or name is not valid.
+func (p BuildProfile) Normalize() (BuildProfile, error) {
+	buildType, err := ParseBuildType(p.Type)
+	if err != nil {
+		return BuildProfile{}, err
+	}
+
+	p.Type = buildType
+
+	if p.Name != "" {
+		err = ValidateBuildName(p.Name)
+		if err != nil {
+			return BuildProfile{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// BuildType returns the CMake build type, defaulting to Release.
+func (p BuildProfile) BuildType() string {
+	if p.Type == "" {
+		return BuildTypeRelease
+	}
+
+	return p.Type
+}
+
+// IsDefault reports whether p builds and installs exactly like the
+// zero profile.
+func (p BuildProfile) IsDefault() bool {
+	return p.Suffix() == ""
+}
+
+// Suffix returns what is appended to the install name of a build with
+// this profile: Name if set, otherwise the lowercased build type
+// (unless Release), "asan" when sanitizing, and a short hash of any
+// custom CMake flags, joined by "-" (e.g. "debug-asan"). It is empty
+// for the default profile.
+func (p BuildProfile) Suffix() string {
+	if p.Name != "" {
+		return p.Name
+	}
+
+	var parts []string
+
+	if p.BuildType() != BuildTypeRelease {
+		parts = append(parts, strings.ToLower(p.BuildType()))
+	}
+
+	if p.Sanitize {
+		parts = append(parts, "asan")
+	}
+
+	if p.CMakeFlags != "" || p.DepsCMakeFlags != "" {
+		sum := sha256.Sum256([]byte(p.CMakeFlags + "\x00" + p.DepsCMakeFlags))
+		parts = append(parts, "flags"+hex.EncodeToString(sum[:])[:flagsHashLen])
+	}
+
+	return strings.Join(parts, "-")
+}
+
+// InstallName returns the version directory name of a build of base (a
+// short commit hash or pull request's SourceRef.DirName) with this
+// profile. Branch builds use SourceRef.InstallName.
+func (p BuildProfile) InstallName(base string) string {
+	suffix := p.Suffix()
+	if suffix == "" {
+		return base
+	}
+
+	return base + "-" + suffix
+}
+
+// MakeArgs returns the make variables that configure a build with this
+// profile. They are recorded in the install manifest.
+func (p BuildProfile) MakeArgs() []string {
+	args := []string{"CMAKE_BUILD_TYPE=" + p.BuildType()}
+
+	extraFlags := p.CMakeFlags
+	if p.Sanitize {
+		extraFlags = strings.TrimSpace(extraFlags + " " + sanitizeFlag)
+	}
+
+	if extraFlags != "" {
+		args = append(args, "CMAKE_EXTRA_FLAGS="+extraFlags)
+	}
+
+	if p.DepsCMakeFlags != "" {
+		args = append(args, "DEPS_CMAKE_FLAGS="+p.DepsCMakeFlags)
+	}
+
+	return args
+}
+
+// IsCommitBuildName reports whether name is the directory name of a
+// commit build: a commit hash, optionally followed by "-" and a build
+// profile suffix ("1a2b3c4-debug").
+func IsCommitBuildName(name string) bool {
+	base, suffix, found := strings.Cut(name, "-")
+	if !found {
+		return IsCommitReference(name)
+	}
+
+	return IsCommitReference(base) && buildNamePattern.MatchString(suffix)
+}
@@ -15,4 +15,16 @@ var (
 
 	// ErrNoCurrentVersion is returned when no version is currently set as active.
 	ErrNoCurrentVersion = errors.New("no current version set")
+
+	// ErrInvalidAliasName is returned when a user alias name is malformed or reserved.
+	ErrInvalidAliasName = errors.New("invalid alias name")
+
+	// ErrAliasNotFound is returned when a user alias does not exist.
+	ErrAliasNotFound = errors.New("alias not found")
+
+	// ErrInvalidSourceRef is returned when a branch or pull request ref is malformed.
+	ErrInvalidSourceRef = errors.New("invalid source ref")
+
+	// ErrInvalidBuildProfile is returned when a source build's type or name is not valid.
+	ErrInvalidBuildProfile = errors.New("invalid build profile")
 )
@@ -0,0 +1,80 @@
+package vtypes
+
+import "time"
+
+// ManifestSchema is the current manifest format. Readers accept
+// older schemas; 0 marks a manifest synthesized from a legacy
+// version.txt.
+const ManifestSchema = 1
+
+// Manifest sources: how an installed version got onto disk.
+const (
+	// ManifestSourceRelease marks a pre-built release asset download.
+	ManifestSourceRelease = "release"
+	// ManifestSourceBuild marks a build from source (commit, branch or pull request).
+	ManifestSourceBuild = "build"
+	// ManifestSourceImport is reserved for versions imported into nvs
+	// rather than downloaded or built by it; nothing writes it yet.
+	ManifestSourceImport = "import"
+)
+
+// Manifest is the per-install metadata record (manifest.json in the
+// version directory). It replaces the bare identifier that used to be
+// the only content of version.txt.
+//
+//nolint:tagliatelle
+type Manifest struct {
+	Schema int `json:"schema"`
+
+	// Source is one of ManifestSourceRelease, ManifestSourceBuild or
+	// ManifestSourceImport; empty for a legacy install.
+	Source string `json:"source,omitempty"`
+
+	// Identifier is what upgrade checks compare against upstream: the
+	// tag for a stable or tagged release, the full commit hash for
+	// nightly and source builds. It is the old version.txt content.
+	Identifier string `json:"identifier"`
+
+	Tag    string `json:"tag,omitempty"`
+	Commit string `json:"commit,omitempty"`
+
+	// Ref is the branch or pull request a source build tracks
+	// (e.g. "pr:12345"); empty for releases and commit builds.
+	Ref string `json:"ref,omitempty"`
+
+	Asset *ManifestAsset `json:"asset,omitempty"`
+
+	InstalledAt       time.Time `json:"installed_at,omitzero"`
+	InstallDurationMS int64     `json:"install_duration_ms,omitempty"`
+
+	// BuildFlags are the make/cmake variables a source build used.
+	BuildFlags []string `json:"build_flags,omitempty"`
+
+	// BuildProfile is the profile a source build was configured with;
+	// nil for the default profile. Upgrades of a branch or pull
+	// request build reuse it.
+	BuildProfile *BuildProfile `json:"build_profile,omitempty"`
+
+	// NvsVersion is the nvs version that wrote the manifest.
+	NvsVersion string `json:"nvs_version,omitempty"`
+}
+
+// ManifestAsset describes the release archive a version was installed from.
+//
+//nolint:tagliatelle
+type ManifestAsset struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// IsLegacy reports whether the manifest was synthesized from a
+// version.txt written before manifests existed.
+func (m Manifest) IsLegacy() bool {
+	return m.Schema == 0
+}
+
+// InstallDuration returns how long the install or build took.
+func (m Manifest) InstallDuration() time.Duration {
+	return time.Duration(m.InstallDurationMS) * time.Millisecond
+}
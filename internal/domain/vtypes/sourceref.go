@@ -0,0 +1,158 @@
+package vtypes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Source ref kinds accepted before the ":" of a source ref spec.
+const (
+	// SourceRefBranch selects the head of an upstream branch ("branch:release-0.10").
+	SourceRefBranch = "branch"
+	// SourceRefPR selects the head of an upstream pull request ("pr:12345").
+	SourceRefPR = "pr"
+)
+
+// branchNamePattern is a conservative subset of what
+// git-check-ref-format allows: enough for every upstream branch
+// ("release-0.10", "feature/foo") without admitting shell or path
+// metacharacters.
+var branchNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// prNumberPattern matches a pull request number.
+var prNumberPattern = regexp.MustCompile(`^[1-9][0-9]*$`)
+
+// SourceRef is a moving git ref (a branch or pull request head) that is
+// built from source. Unlike a commit hash it is installed under a
+// stable name, so 'nvs upgrade' can re-fetch the ref and rebuild in
+// place.
+type SourceRef struct {
+	kind string
+	name string
+}
+
+// IsSourceRef reports whether spec is written as a source ref
+// ("branch:<name>" or "pr:<number>"). It does not validate the name;
+// use ParseSourceRef for that.
+func IsSourceRef(spec string) bool {
+	return strings.HasPrefix(spec, SourceRefBranch+":") || strings.HasPrefix(spec, SourceRefPR+":")
+}
+
+// IsSourceRefDirName reports whether name is the directory form of a
+// source ref ("branch-release-0.10", "pr-12345"), i.e. something
+// SourceRef.DirName can produce, optionally followed by a build
+// profile suffix ("pr-12345-debug").
+func IsSourceRefDirName(name string) bool {
+	if rest, ok := strings.CutPrefix(name, SourceRefPR+"-"); ok {
+		number, suffix, found := strings.Cut(rest, "-")
+
+		return prNumberPattern.MatchString(number) &&
+			(!found || buildNamePattern.MatchString(suffix))
+	}
+
+	rest, ok := strings.CutPrefix(name, SourceRefBranch+"-")
+
+	return ok && rest != "" && IsValidVersionName(name)
+}
+
+// ParseSourceRef parses a "branch:<name>" or "pr:<number>" spec,
+// returning ErrInvalidSourceRef wrapped with the offending input if
+// the kind is unknown or the name is unsafe.
+func ParseSourceRef(spec string) (SourceRef, error) {
+	kind, name, ok := strings.Cut(spec, ":")
+	if !ok {
+		return SourceRef{}, fmt.Errorf("%w: %q (use branch:<name> or pr:<number>)",
+			ErrInvalidSourceRef, spec)
+	}
+
+	switch kind {
+	case SourceRefBranch:
+		if !isValidBranchName(name) {
+			return SourceRef{}, fmt.Errorf("%w: %q is not a valid branch name", ErrInvalidSourceRef, name)
+		}
+	case SourceRefPR:
+		if !prNumberPattern.MatchString(name) {
+			return SourceRef{}, fmt.Errorf("%w: %q is not a pull request number", ErrInvalidSourceRef, name)
+		}
+	default:
+		return SourceRef{}, fmt.Errorf("%w: %q (use branch:<name> or pr:<number>)",
+			ErrInvalidSourceRef, spec)
+	}
+
+	return SourceRef{kind: kind, name: name}, nil
+}
+
+// Kind returns SourceRefBranch or SourceRefPR.
+func (r SourceRef) Kind() string {
+	return r.kind
+}
+
+// Name returns the branch name or pull request number.
+func (r SourceRef) Name() string {
+	return r.name
+}
+
+// IsZero reports whether r is the zero SourceRef.
+func (r SourceRef) IsZero() bool {
+	return r.kind == ""
+}
+
+// String returns the spec form, e.g. "branch:release-0.10".
+func (r SourceRef) String() string {
+	return r.kind + ":" + r.name
+}
+
+// branchDirEscaper turns a branch name into a single path component.
+// "_" starts every escape, so no two branch names share a directory:
+// "feature/lsp" becomes "feature_-lsp" and "feature_lsp" becomes
+// "feature__lsp".
+var branchDirEscaper = strings.NewReplacer("_", "__", "/", "_-")
+
+// DirName returns the version directory name the ref is installed
+// under, e.g. "branch-release-0.10" or "pr-12345". Branch names are
+// escaped by branchDirEscaper so the result is a single path component.
+func (r SourceRef) DirName() string {
+	return r.kind + "-" + branchDirEscaper.Replace(r.name)
+}
+
+// branchProfileSeparator joins a branch build's directory name and its
+// build profile suffix. branchDirEscaper never puts a "." right after
+// an escaping "_", so branch foo built as "debug" ("branch-foo_.debug")
+// cannot take the directory of a default build of branch foo-debug
+// ("branch-foo-debug").
+const branchProfileSeparator = "_."
+
+// InstallName returns the version directory name a build of the ref
+// with profile is installed under: DirName, plus the profile suffix
+// for a profile other than the default ("pr-12345-debug",
+// "branch-release-0.10_.debug").
+func (r SourceRef) InstallName(profile BuildProfile) string {
+	suffix := profile.Suffix()
+	if suffix == "" || r.kind != SourceRefBranch {
+		return profile.InstallName(r.DirName())
+	}
+
+	return r.DirName() + branchProfileSeparator + suffix
+}
+
+// FetchRef returns the upstream ref to fetch: "refs/heads/<name>" for
+// a branch, "refs/pull/<number>/head" for a pull request.
+func (r SourceRef) FetchRef() string {
+	if r.kind == SourceRefPR {
+		return "refs/pull/" + r.name + "/head"
+	}
+
+	return "refs/heads/" + r.name
+}
+
+// isValidBranchName applies the parts of git-check-ref-format that
+// branchNamePattern cannot express.
+func isValidBranchName(name string) bool {
+	return branchNamePattern.MatchString(name) &&
+		!strings.Contains(name, "..") &&
+		!strings.Contains(name, "//") &&
+		!strings.HasSuffix(name, "/") &&
+		!strings.HasSuffix(name, ".") &&
+		!strings.HasSuffix(name, ".lock")
+}
@@ -4,6 +4,7 @@ package vtypes
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -12,6 +13,25 @@ import (
 // names is defined by IsValidVersionName.
 var ErrInvalidVersionName = errors.New("invalid version name")
 
+// ErrInvalidVersionRange is returned when a version spec looks like
+// a semver range (see IsVersionRange) but one of its terms is not
+// a recognised "<operator><version>" pair.
+var ErrInvalidVersionRange = errors.New("invalid version range")
+
+// rangeOperatorChars are the characters that can only appear in a
+// semver range and never in a concrete version name. Their presence
+// is what IsVersionRange keys on.
+const rangeOperatorChars = "^~<>=*|, "
+
+// rangeTermPattern matches a single term of a range spec once it has
+// been split on whitespace, "," and "||": an optional comparison
+// operator followed by a (possibly partial, possibly wildcarded)
+// version. "-" on its own is the separator of a hyphen range
+// ("0.9 - 0.10") and is accepted separately.
+var rangeTermPattern = regexp.MustCompile(
+	`^(=|!=|>=|<=|>|<|~>|~|\^)?v?(\*|[xX]|[0-9]+(\.([0-9]+|[xX*]))*(-[0-9A-Za-z.]+)?)$`,
+)
+
 // Version represents a Neovim version.
 type Version struct {
 	name        string // e.g., "v0.9.0", "stable", "nightly", "1a2b3c4"
@@ -32,6 +52,8 @@ const (
 	TypeCommit
 	// TypeTag represents a specific version tag.
 	TypeTag
+	// TypeSourceRef represents a build of a branch or pull request head.
+	TypeSourceRef
 )
 
 // New creates a new Version instance.
@@ -75,6 +97,8 @@ func (t Type) String() string {
 		return "commit"
 	case TypeTag:
 		return "tag"
+	case TypeSourceRef:
+		return "ref"
 	default:
 		return "unknown"
 	}
@@ -111,6 +135,10 @@ type Manager interface {
 	// Switch activates a specific version.
 	Switch(version Version) error
 
+	// SyncGlobalBin brings the global nvim entry in line with the
+	// configured shim mode, without changing the current version.
+	SyncGlobalBin() error
+
 	// IsInstalled checks if a version is installed.
 	IsInstalled(version Version) bool
 
@@ -119,11 +147,27 @@ type Manager interface {
 
 	// GetInstalledReleaseIdentifier returns the release identifier (e.g. commit hash) for an installed version.
 	GetInstalledReleaseIdentifier(versionName string) (string, error)
+
+	// GetManifest returns the install metadata of an installed version,
+	// synthesized from version.txt for installs that predate manifests.
+	GetManifest(versionName string) (Manifest, error)
 }
 
 // NormalizeVersionForPath normalizes a version string for use as a directory name.
+// A source ref ("branch:release-0.10") maps to its directory name; an
+// unparsable one is returned unchanged so validation still rejects it.
 func NormalizeVersionForPath(versionStr string) string {
-	if versionStr == "stable" || versionStr == "nightly" || IsCommitReference(versionStr) {
+	if IsSourceRef(versionStr) {
+		ref, err := ParseSourceRef(versionStr)
+		if err != nil {
+			return versionStr
+		}
+
+		return ref.DirName()
+	}
+
+	if versionStr == "stable" || versionStr == "nightly" || IsCommitBuildName(versionStr) ||
+		IsSourceRefDirName(versionStr) {
 		return versionStr
 	}
 
@@ -150,6 +194,8 @@ func NormalizeVersionForPath(versionStr string) string {
 //   - Release tags: "v0.10.0", "v0.10.0-beta1"
 //   - Branch names: "master", "main"
 //   - Commit hashes: 7-40 hexadecimal characters
+//   - Builds with a profile: "1a2b3c4-debug", "pr-12345-asan"
+//   - Source ref builds: "branch-release-0.10", "pr-12345"
 //
 // The validator runs as the last gate before a name is joined
 // onto VersionsDir and used for filesystem or exec operations
@@ -192,3 +238,77 @@ func ValidateVersionName(name string) error {
 
 	return fmt.Errorf("%w: %q", ErrInvalidVersionName, name)
 }
+
+// IsVersionRange reports whether spec is a semver range rather than a
+// concrete version name. Ranges such as "~0.10", "^0.10",
+// ">=0.9 <0.11" or "0.10.x" select the highest matching release tag
+// at resolution time instead of naming a version directory, so they
+// are never valid as path components and must be resolved before
+// they reach the filesystem.
+func IsVersionRange(spec string) bool {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return false
+	}
+
+	if strings.ContainsAny(spec, rangeOperatorChars) {
+		return true
+	}
+
+	for segment := range strings.SplitSeq(strings.TrimPrefix(spec, "v"), ".") {
+		if segment == "x" || segment == "X" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateVersionRange returns ErrInvalidVersionRange wrapped with the
+// offending input if spec contains anything other than well-formed
+// range terms. It is a purely syntactic check: whether any release
+// actually satisfies the range is decided later, by the resolver.
+//
+// Like ValidateVersionName, it exists so that a crafted
+// .nvs-version file cannot smuggle path separators or shell
+// metacharacters past the validator simply by including a range
+// operator.
+func ValidateVersionRange(spec string) error {
+	normalized := strings.ReplaceAll(strings.TrimSpace(spec), "||", " ")
+	normalized = strings.ReplaceAll(normalized, ",", " ")
+
+	terms := strings.Fields(normalized)
+	if len(terms) == 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidVersionRange, spec)
+	}
+
+	for _, term := range terms {
+		if term == "-" {
+			continue
+		}
+
+		if !rangeTermPattern.MatchString(term) {
+			return fmt.Errorf("%w: %q", ErrInvalidVersionRange, spec)
+		}
+	}
+
+	return nil
+}
+
+// ValidateVersionSpec validates anything a user may legitimately write
+// in a version argument or .nvs-version file: a semver range (see
+// ValidateVersionRange), a branch or pull request ref (see
+// ParseSourceRef) or a concrete version name (see ValidateVersionName).
+func ValidateVersionSpec(spec string) error {
+	if IsSourceRef(spec) {
+		_, err := ParseSourceRef(spec)
+
+		return err
+	}
+
+	if IsVersionRange(spec) {
+		return ValidateVersionRange(spec)
+	}
+
+	return ValidateVersionName(spec)
+}
@@ -115,6 +115,7 @@ func TestTypeString(t *testing.T) {
 		{testNightly, vtypes.TypeNightly, testNightly},
 		{"commit", vtypes.TypeCommit, "commit"},
 		{"tag", vtypes.TypeTag, "tag"},
+		{"ref", vtypes.TypeSourceRef, "ref"},
 		{"unknown", vtypes.Type(999), "unknown"},
 	}
 
@@ -307,6 +308,9 @@ func TestNormalizeVersionForPath(t *testing.T) {
 		{"main branch preserved", testMain, testMain},
 		{"bare version gets v prefix", "0.10.0", testV0100},
 		{"already-prefixed version preserved", testV0100, testV0100},
+		{"branch ref maps to directory", "branch:release-0.10", "branch-release-0.10"},
+		{"pr ref maps to directory", "pr:12345", "pr-12345"},
+		{"ref directory preserved", "pr-12345", "pr-12345"},
 	}
 
 	for _, test := range tests {
@@ -319,3 +323,332 @@ func TestNormalizeVersionForPath(t *testing.T) {
 		})
 	}
 }
+
+func TestIsVersionRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"tilde", "~0.10", true},
+		{"caret", "^0.10", true},
+		{"comparison pair", ">=0.9 <0.11", true},
+		{"comma separated", ">=0.9, <0.11", true},
+		{"x wildcard", "0.10.x", true},
+		{"v-prefixed x wildcard", "v0.10.X", true},
+		{"star wildcard", "0.10.*", true},
+		{"or", "0.9.5 || ~0.10", true},
+		{"surrounding whitespace", "  ~0.10\n", true},
+		{"empty", "", false},
+		{"concrete tag", testV0100, false},
+		{"bare version", "0.10.0", false},
+		{"stable", testStable, false},
+		{"commit hash", testAbc1234, false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := vtypes.IsVersionRange(testCase.input); got != testCase.want {
+				t.Errorf("IsVersionRange(%q) = %v, want %v", testCase.input, got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestValidateVersionSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{"tilde range", "~0.10", nil},
+		{"caret range", "^0.10.1", nil},
+		{"comparison range", ">=0.9 <0.11", nil},
+		{"hyphen range", "0.9 - 0.10", nil},
+		{"wildcard range", "0.10.x", nil},
+		{"or range", "0.9.5 || ~0.10", nil},
+		{"concrete tag", testV0100, nil},
+		{"stable", testStable, nil},
+		{"range with path", "~/stable", vtypes.ErrInvalidVersionRange},
+		{"range with shell metachar", "~0.10; rm -rf /", vtypes.ErrInvalidVersionRange},
+		{"range with alias", ">=stable", vtypes.ErrInvalidVersionRange},
+		{"operator only", ">=", vtypes.ErrInvalidVersionRange},
+		{"traversal without operators", "../etc/passwd", vtypes.ErrInvalidVersionName},
+		{"branch ref", "branch:release-0.10", nil},
+		{"pr ref", "pr:12345", nil},
+		{"pr ref with traversal", "pr:../1", vtypes.ErrInvalidSourceRef},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := vtypes.ValidateVersionSpec(testCase.input)
+			if testCase.wantErr == nil {
+				if err != nil {
+					t.Errorf("ValidateVersionSpec(%q) = %v, want nil", testCase.input, err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, testCase.wantErr) {
+				t.Errorf(
+					"ValidateVersionSpec(%q) = %v, want %v",
+					testCase.input,
+					err,
+					testCase.wantErr,
+				)
+			}
+		})
+	}
+}
+
+func TestValidateAliasName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"simple", "work", false},
+		{"with dash and digits", "plugin-ci2", false},
+		{"with dot and underscore", "lts.old_1", false},
+		{"empty", "", true},
+		{"starts with digit", "0.10", true},
+		{"version tag", "v0", true},
+		{"reserved stable", testStable, true},
+		{"reserved nightly", "nightly", true},
+		{"reserved master", "master", true},
+		{"commit-like hex", "deadbeef", true},
+		{"path separator", "a/b", true},
+		{"space", "my alias", true},
+		{"ref build directory", "pr-12345", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := vtypes.ValidateAliasName(testCase.input)
+
+			gotErr := err != nil
+			if gotErr != testCase.wantErr {
+				t.Errorf(
+					"ValidateAliasName(%q) error = %v, wantErr %v",
+					testCase.input,
+					err,
+					testCase.wantErr,
+				)
+			}
+
+			if gotErr && !errors.Is(err, vtypes.ErrInvalidAliasName) {
+				t.Errorf("ValidateAliasName(%q) error = %v, want ErrInvalidAliasName", testCase.input, err)
+			}
+		})
+	}
+}
+
+func TestParseSourceRef(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		wantDir   string
+		wantFetch string
+		wantErr   bool
+	}{
+		{"branch", "branch:release-0.10", "branch-release-0.10", "refs/heads/release-0.10", false},
+		{"branch with slash", "branch:feature/lsp", "branch-feature_-lsp", "refs/heads/feature/lsp", false},
+		{"branch with underscore", "branch:feature_lsp", "branch-feature__lsp", "refs/heads/feature_lsp", false},
+		{"slash then underscore", "branch:a/_b", "branch-a_-__b", "refs/heads/a/_b", false},
+		{"underscore then slash", "branch:a_/b", "branch-a___-b", "refs/heads/a_/b", false},
+		{"pull request", "pr:12345", "pr-12345", "refs/pull/12345/head", false},
+		{"unknown kind", "tag:v0.10.0", "", "", true},
+		{"empty branch", "branch:", "", "", true},
+		{"branch traversal", "branch:../etc", "", "", true},
+		{"branch lock suffix", "branch:main.lock", "", "", true},
+		{"branch with space", "branch:a b", "", "", true},
+		{"pr not a number", "pr:abc", "", "", true},
+		{"pr leading zero", "pr:0123", "", "", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ref, err := vtypes.ParseSourceRef(testCase.input)
+			if testCase.wantErr {
+				if !errors.Is(err, vtypes.ErrInvalidSourceRef) {
+					t.Errorf("ParseSourceRef(%q) error = %v, want ErrInvalidSourceRef", testCase.input, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseSourceRef(%q) failed: %v", testCase.input, err)
+			}
+
+			if ref.String() != testCase.input {
+				t.Errorf("String() = %q, want %q", ref.String(), testCase.input)
+			}
+
+			if ref.DirName() != testCase.wantDir {
+				t.Errorf("DirName() = %q, want %q", ref.DirName(), testCase.wantDir)
+			}
+
+			if !vtypes.IsSourceRefDirName(ref.DirName()) {
+				t.Errorf("IsSourceRefDirName(%q) = false", ref.DirName())
+			}
+
+			if ref.FetchRef() != testCase.wantFetch {
+				t.Errorf("FetchRef() = %q, want %q", ref.FetchRef(), testCase.wantFetch)
+			}
+		})
+	}
+}
+
+func TestSourceRef_InstallName(t *testing.T) {
+	debug := vtypes.BuildProfile{Name: "debug"}
+
+	tests := []struct {
+		spec    string
+		profile vtypes.BuildProfile
+		want    string
+	}{
+		{"branch:foo", vtypes.BuildProfile{}, "branch-foo"},
+		{"branch:foo", debug, "branch-foo_.debug"},
+		{"branch:foo-debug", vtypes.BuildProfile{}, "branch-foo-debug"},
+		{"branch:foo_.debug", vtypes.BuildProfile{}, "branch-foo__.debug"},
+		{"pr:12345", debug, "pr-12345-debug"},
+	}
+
+	for _, tt := range tests {
+		ref, err := vtypes.ParseSourceRef(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := ref.InstallName(tt.profile)
+		if got != tt.want {
+			t.Errorf("%s with profile %q: InstallName() = %q, want %q",
+				tt.spec, tt.profile.Suffix(), got, tt.want)
+		}
+
+		if !vtypes.IsSourceRefDirName(got) {
+			t.Errorf("IsSourceRefDirName(%q) = false", got)
+		}
+	}
+}
+
+func TestBuildProfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    vtypes.BuildProfile
+		wantSuffix string
+		wantArgs   []string
+	}{
+		{"default", vtypes.BuildProfile{}, "", []string{"CMAKE_BUILD_TYPE=Release"}},
+		{
+			"debug",
+			vtypes.BuildProfile{Type: vtypes.BuildTypeDebug},
+			"debug",
+			[]string{"CMAKE_BUILD_TYPE=Debug"},
+		},
+		{
+			"sanitized with flags",
+			vtypes.BuildProfile{
+				Type:       vtypes.BuildTypeRelWithDebInfo,
+				Sanitize:   true,
+				CMakeFlags: "-DENABLE_LTO=OFF",
+			},
+			"relwithdebinfo-asan-flags",
+			[]string{
+				"CMAKE_BUILD_TYPE=RelWithDebInfo",
+				"CMAKE_EXTRA_FLAGS=-DENABLE_LTO=OFF -DENABLE_ASAN_UBSAN=ON",
+			},
+		},
+		{
+			"named",
+			vtypes.BuildProfile{DepsCMakeFlags: "-DUSE_BUNDLED_LUV=OFF", Name: "sys-luv"},
+			"sys-luv",
+			[]string{"CMAKE_BUILD_TYPE=Release", "DEPS_CMAKE_FLAGS=-DUSE_BUNDLED_LUV=OFF"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Custom flags without a name add a hash of the flags
+			suffix := tt.profile.Suffix()
+			if !strings.HasPrefix(suffix, tt.wantSuffix) ||
+				(tt.profile.CMakeFlags == "" && suffix != tt.wantSuffix) {
+				t.Errorf("Suffix() = %q, want %q", suffix, tt.wantSuffix)
+			}
+
+			if tt.profile.IsDefault() != (suffix == "") {
+				t.Errorf("IsDefault() = %v for suffix %q", tt.profile.IsDefault(), suffix)
+			}
+
+			if got := tt.profile.MakeArgs(); strings.Join(got, "|") != strings.Join(tt.wantArgs, "|") {
+				t.Errorf("MakeArgs() = %q, want %q", got, tt.wantArgs)
+			}
+
+			name := tt.profile.InstallName(test1a2b3c4)
+			if !vtypes.IsCommitBuildName(name) || vtypes.NormalizeVersionForPath(name) != name {
+				t.Errorf("InstallName() = %q is not recognized as a commit build", name)
+			}
+		})
+	}
+
+	other := vtypes.BuildProfile{CMakeFlags: "-DENABLE_LTO=ON"}
+	if other.Suffix() == (vtypes.BuildProfile{CMakeFlags: "-DENABLE_LTO=OFF"}).Suffix() {
+		t.Errorf("different flags share the suffix %q", other.Suffix())
+	}
+}
+
+func TestBuildProfile_Normalize(t *testing.T) {
+	profile, err := vtypes.BuildProfile{Type: "relwithdebinfo"}.Normalize()
+	if err != nil || profile.Type != vtypes.BuildTypeRelWithDebInfo {
+		t.Errorf("Normalize() = %+v, %v; want type RelWithDebInfo", profile, err)
+	}
+
+	for _, invalid := range []vtypes.BuildProfile{{Type: "Fast"}, {Name: "../x"}, {Name: "-x"}} {
+		_, err := invalid.Normalize()
+		if !errors.Is(err, vtypes.ErrInvalidBuildProfile) {
+			t.Errorf("Normalize(%+v) error = %v, want ErrInvalidBuildProfile", invalid, err)
+		}
+	}
+}
+
+func TestIsCommitBuildName(t *testing.T) {
+	tests := map[string]bool{
+		test1a2b3c4:               true,
+		"1a2b3c4-debug":           true,
+		"1a2b3c4-debug-asan":      true,
+		"1a2b3c4-":                false,
+		"v0.10.0-debug":           false,
+		"nightly-debug":           false,
+		"branch-release-0.10-dbg": false,
+	}
+
+	for name, want := range tests {
+		if got := vtypes.IsCommitBuildName(name); got != want {
+			t.Errorf("IsCommitBuildName(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	for _, name := range []string{"pr-12345-debug", "branch-release-0.10_.debug"} {
+		if !vtypes.IsSourceRefDirName(name) {
+			t.Errorf("IsSourceRefDirName(%q) = false, want true", name)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package archivestore
+
+import "errors"
+
+// Infrastructure errors for the archive store.
+var (
+	// ErrNotCached is returned when no archive with the requested hash is stored.
+	ErrNotCached = errors.New("archive not in cache")
+
+	// ErrInvalidHash is returned when a key is not a hex SHA256 digest.
+	ErrInvalidHash = errors.New("invalid SHA256 digest")
+)
@@ -0,0 +1,117 @@
+package archivestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+)
+
+// keysFile maps keys naming an archive's content, such as a release
+// asset at a fixed tag, to the SHA256 stored for them, so the archive
+// can be found without fetching its checksum.
+const keysFile = "keys.json"
+
+// Lookup returns the SHA256 of the stored archive remembered under
+// key, or ErrNotCached when there is none.
+func (s *Store) Lookup(key string) (string, error) {
+	keys, err := s.readKeys()
+	if err != nil {
+		return "", err
+	}
+
+	sha, ok := keys[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotCached, key)
+	}
+
+	_, err = s.entry(sha)
+	if err != nil {
+		return "", err
+	}
+
+	return sha, nil
+}
+
+// Remember records that key names the archive stored under sha,
+// forgetting keys of archives no longer stored.
+func (s *Store) Remember(key, sha string) error {
+	sha, err := normalizeHash(sha)
+	if err != nil {
+		return err
+	}
+
+	keys, err := s.readKeys()
+	if err != nil {
+		return err
+	}
+
+	keys[key] = sha
+
+	for name, stored := range keys {
+		_, entryErr := s.entry(stored)
+		if entryErr != nil {
+			delete(keys, name)
+		}
+	}
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive keys: %w", err)
+	}
+
+	// Write a temporary file and rename it into place, so a reader
+	// never sees a partially written one.
+	tmpFile, err := os.CreateTemp(s.dir, ".keys-*")
+	if err != nil {
+		return fmt.Errorf("failed to write archive keys: %w", err)
+	}
+
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	_, err = tmpFile.Write(data)
+
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(tmpFile.Name(), constants.FilePerm)
+	}
+
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), filepath.Join(s.dir, keysFile))
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write archive keys: %w", err)
+	}
+
+	return nil
+}
+
+// readKeys reads the remembered keys, which are empty when none has
+// been written yet.
+func (s *Store) readKeys() (map[string]string, error) {
+	keys := map[string]string{}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, keysFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive keys: %w", err)
+	}
+
+	err = json.Unmarshal(data, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse archive keys: %w", err)
+	}
+
+	return keys, nil
+}
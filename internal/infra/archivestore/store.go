@@ -0,0 +1,303 @@
+// Package archivestore keeps downloaded release archives on disk, keyed
+// by their SHA256, so a reinstall can skip the download.
+//
+// Each archive lives at <dir>/<sha256>/<asset name>. The file's
+// modification time records when it was last stored or used; Prune
+// drops archives unused for longer than the age limit, then the least
+// recently used ones until the store fits its size limit.
+package archivestore
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// sha256HexLen is the length of a hex-encoded SHA256 digest.
+const sha256HexLen = 64
+
+// Store is a content-addressed archive cache.
+type Store struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+}
+
+// Entry describes one stored archive.
+type Entry struct {
+	SHA256   string
+	Name     string
+	Path     string
+	Size     int64
+	LastUsed time.Time
+}
+
+// New returns a store rooted at dir. A zero maxBytes or maxAge
+// disables that limit.
+func New(dir string, maxBytes int64, maxAge time.Duration) *Store {
+	return &Store{
+		dir:      dir,
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+	}
+}
+
+// Dir returns the store's root directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get returns the path of the archive with the given SHA256 and marks
+// it as used. It returns ErrNotCached when there is none. The caller
+// is expected to verify the content against the digest.
+func (s *Store) Get(sha string) (string, error) {
+	entry, err := s.entry(sha)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	err = os.Chtimes(entry.Path, now, now)
+	if err != nil {
+		log.Debugf("Failed to touch cached archive %s: %v", entry.Path, err)
+	}
+
+	return entry.Path, nil
+}
+
+// Put stores the content of src under sha with the given asset name,
+// then prunes the store to its limits. Storing an archive that is
+// already present only marks it as used.
+func (s *Store) Put(sha, name string, src io.Reader) error {
+	sha, err := normalizeHash(sha)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Get(sha)
+	if err == nil {
+		return nil
+	}
+
+	err = os.MkdirAll(s.dir, constants.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to create archive cache: %w", err)
+	}
+
+	// Fill a temporary directory and rename it into place, so a
+	// reader never sees a partially written archive.
+	tmpDir, err := os.MkdirTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	defer func() {
+		removeErr := os.RemoveAll(tmpDir)
+		if removeErr != nil {
+			log.Warnf("Failed to remove %s: %v", tmpDir, removeErr)
+		}
+	}()
+
+	err = writeFile(filepath.Join(tmpDir, filepath.Base(name)), src)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpDir, filepath.Join(s.dir, sha))
+	if err != nil && !os.IsExist(err) {
+		// Another process may have stored the same archive first.
+		_, getErr := s.entry(sha)
+		if getErr != nil {
+			return fmt.Errorf("failed to store archive: %w", err)
+		}
+	}
+
+	removed, err := s.Prune()
+	if err != nil {
+		log.Warnf("Failed to prune archive cache: %v", err)
+	}
+
+	for _, entry := range removed {
+		log.Debugf("Pruned cached archive %s (%s)", entry.Name, entry.SHA256)
+	}
+
+	return nil
+}
+
+// Remove deletes the archive with the given SHA256, if stored.
+func (s *Store) Remove(sha string) error {
+	sha, err := normalizeHash(sha)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(filepath.Join(s.dir, sha))
+	if err != nil {
+		return fmt.Errorf("failed to remove cached archive: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the stored archives, most recently used first.
+func (s *Store) List() ([]Entry, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read archive cache: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(dirEntries))
+
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() || strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+
+		entry, entryErr := s.entry(dirEntry.Name())
+		if entryErr != nil {
+			log.Debugf("Skipping %s in archive cache: %v", dirEntry.Name(), entryErr)
+
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return b.LastUsed.Compare(a.LastUsed)
+	})
+
+	return entries, nil
+}
+
+// Prune removes archives unused for longer than the age limit, then
+// the least recently used ones until the store fits the size limit.
+// It returns the removed entries.
+func (s *Store) Prune() ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		removed []Entry
+		total   int64
+		errs    []error
+	)
+
+	cutoff := time.Now().Add(-s.maxAge)
+
+	// Most recently used first: keep entries while they fit.
+	for _, entry := range entries {
+		expired := s.maxAge > 0 && entry.LastUsed.Before(cutoff)
+		oversized := s.maxBytes > 0 && total+entry.Size > s.maxBytes
+
+		if !expired && !oversized {
+			total += entry.Size
+
+			continue
+		}
+
+		removeErr := s.Remove(entry.SHA256)
+		if removeErr != nil {
+			errs = append(errs, removeErr)
+
+			continue
+		}
+
+		removed = append(removed, entry)
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// Clear removes every stored archive and returns what was removed.
+func (s *Store) Clear() ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.RemoveAll(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear archive cache: %w", err)
+	}
+
+	return entries, nil
+}
+
+// entry describes the archive stored under sha.
+func (s *Store) entry(sha string) (Entry, error) {
+	sha, err := normalizeHash(sha)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	dir := filepath.Join(s.dir, sha)
+
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 1 || !files[0].Type().IsRegular() {
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotCached, sha)
+	}
+
+	info, err := files[0].Info()
+	if err != nil {
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotCached, sha)
+	}
+
+	return Entry{
+		SHA256:   sha,
+		Name:     files[0].Name(),
+		Path:     filepath.Join(dir, files[0].Name()),
+		Size:     info.Size(),
+		LastUsed: info.ModTime(),
+	}, nil
+}
+
+// normalizeHash lowercases sha and checks it is a hex SHA256 digest,
+// which also keeps it safe to use as a path component.
+func normalizeHash(sha string) (string, error) {
+	sha = strings.ToLower(strings.TrimSpace(sha))
+
+	_, err := hex.DecodeString(sha)
+	if err != nil || len(sha) != sha256HexLen {
+		return "", fmt.Errorf("%w: %q", ErrInvalidHash, sha)
+	}
+
+	return sha, nil
+}
+
+// writeFile copies src into a new file at path.
+func writeFile(path string, src io.Reader) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, constants.FilePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create cached archive: %w", err)
+	}
+
+	_, err = io.Copy(file, src)
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write cached archive: %w", err)
+	}
+
+	return nil
+}
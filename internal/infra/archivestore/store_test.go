@@ -0,0 +1,141 @@
+package archivestore_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/infra/archivestore"
+)
+
+func digest(content string) string {
+	sum := sha256.Sum256([]byte(content))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func TestStore_PutGet(t *testing.T) {
+	store := archivestore.New(t.TempDir(), 0, 0)
+	sha := digest("archive")
+
+	_, err := store.Get(sha)
+	if !errors.Is(err, archivestore.ErrNotCached) {
+		t.Fatalf("Get() on empty store error = %v, want ErrNotCached", err)
+	}
+
+	err = store.Put(strings.ToUpper(sha), "nvim-linux-x86_64.tar.gz", strings.NewReader("archive"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	path, err := store.Get(sha)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "archive" {
+		t.Errorf("cached content = %q, %v", data, err)
+	}
+
+	entries, err := store.List()
+	if err != nil || len(entries) != 1 || entries[0].Name != "nvim-linux-x86_64.tar.gz" {
+		t.Errorf("List() = %+v, %v", entries, err)
+	}
+
+	err = store.Put("../../etc", "x", strings.NewReader(""))
+	if !errors.Is(err, archivestore.ErrInvalidHash) {
+		t.Errorf("Put() with bad hash error = %v, want ErrInvalidHash", err)
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	dir := t.TempDir()
+
+	// Room for two 4-byte archives.
+	store := archivestore.New(dir, 8, time.Hour)
+
+	for idx, content := range []string{"aaaa", "bbbb", "cccc"} {
+		err := store.Put(digest(content), content+".tar.gz", strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("Put(%s) error = %v", content, err)
+		}
+
+		// Give each archive a distinct last-use time, oldest first.
+		path, _ := store.Get(digest(content))
+		stamp := time.Now().Add(time.Duration(idx-3) * time.Minute)
+		_ = os.Chtimes(path, stamp, stamp)
+	}
+
+	// Put pruned the least recently used archive to fit the limit.
+	_, err := store.Get(digest("aaaa"))
+	if !errors.Is(err, archivestore.ErrNotCached) {
+		t.Errorf("oldest archive survived the size limit: %v", err)
+	}
+
+	// An archive unused for longer than the age limit goes too.
+	path, _ := store.Get(digest("bbbb"))
+	old := time.Now().Add(-2 * time.Hour)
+	_ = os.Chtimes(path, old, old)
+
+	removed, err := store.Prune()
+	if err != nil || len(removed) != 1 || removed[0].Name != "bbbb.tar.gz" {
+		t.Errorf("Prune() = %+v, %v; want bbbb.tar.gz removed", removed, err)
+	}
+
+	removed, err = store.Clear()
+	if err != nil || len(removed) != 1 {
+		t.Errorf("Clear() = %+v, %v; want one archive removed", removed, err)
+	}
+
+	entries, err := store.List()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("List() after Clear() = %+v, %v", entries, err)
+	}
+}
+
+func TestStore_Keys(t *testing.T) {
+	store := archivestore.New(t.TempDir(), 0, 0)
+	sha := digest("archive")
+	key := "https://example.com/v0.10.0/nvim.tar.gz@v0.10.0"
+
+	_, err := store.Lookup(key)
+	if !errors.Is(err, archivestore.ErrNotCached) {
+		t.Fatalf("Lookup() on empty store error = %v, want ErrNotCached", err)
+	}
+
+	err = store.Put(sha, "nvim.tar.gz", strings.NewReader("archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.Remember(key, sha)
+	if err != nil {
+		t.Fatalf("Remember() error = %v", err)
+	}
+
+	got, err := store.Lookup(key)
+	if err != nil || got != sha {
+		t.Errorf("Lookup() = %s, %v; want %s", got, err, sha)
+	}
+
+	// The key goes with the archive.
+	err = store.Remove(sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Lookup(key)
+	if !errors.Is(err, archivestore.ErrNotCached) {
+		t.Errorf("Lookup() after Remove error = %v, want ErrNotCached", err)
+	}
+
+	entries, err := store.List()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("List() = %+v, %v; want the keys file ignored", entries, err)
+	}
+}
@@ -0,0 +1,242 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/y3owk1n/nvs/internal/domain/bisect"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// allTags is the refspec fetched alongside allBranches before a
+// bisection, so release tags can be its bounds.
+const allTags = "refs/tags/*"
+
+// Lines of "git bisect log" that report the result. Unlike the
+// messages git bisect prints, they are not translated.
+const (
+	firstBadPrefix = "# first bad commit: ["
+	suspectPrefix  = "# possible first bad commit: ["
+)
+
+// The source mirror is bare, so git bisect runs with --no-checkout:
+// it only moves BISECT_HEAD, and each candidate is built from a
+// worktree like any other commit. Bisections hold the mirror lock
+// while git runs, like fetches.
+
+// BisectStart begins a bisection between good and bad in the source
+// mirror, fetching every branch and tag first unless offline.
+func (b *SourceBuilder) BisectStart(
+	ctx context.Context,
+	good, bad string,
+) (bisect.Step, error) {
+	var step bisect.Step
+
+	err := b.withMirror(ctx, func() error {
+		err := b.initMirror(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		err = b.fetchForBisect(ctx, good, bad)
+		if err != nil {
+			return err
+		}
+
+		for _, rev := range []string{good, bad} {
+			if !b.mirrorHas(ctx, rev) {
+				return fmt.Errorf("%w: %s", bisect.ErrUnknownRevision, rev)
+			}
+		}
+
+		// Patch releases are tagged on release branches, so good need
+		// not be an ancestor of bad: git bisect then tests their merge
+		// base first. Only a good bound at or after bad is wrong.
+		err = b.git(ctx, b.mirrorDir, nil, "merge-base", "--is-ancestor", bad, good)
+		if err == nil {
+			return fmt.Errorf("%w: %s is not older than %s", bisect.ErrNotOlder, good, bad)
+		}
+
+		err = b.git(ctx, b.mirrorDir, nil, "bisect", "start", "--no-checkout", bad, good, "--")
+		if err != nil {
+			return fmt.Errorf("failed to start bisecting: %w", err)
+		}
+
+		step, err = b.bisectStep(ctx)
+
+		return err
+	})
+
+	return step, err
+}
+
+// BisectMark records the verdict for commit and returns the next step.
+func (b *SourceBuilder) BisectMark(
+	ctx context.Context,
+	verdict bisect.Verdict,
+	commit string,
+) (bisect.Step, error) {
+	if !vtypes.IsCommitReference(commit) {
+		return bisect.Step{}, fmt.Errorf("%w: %s", bisect.ErrUnknownRevision, commit)
+	}
+
+	var step bisect.Step
+
+	err := b.withMirror(ctx, func() error {
+		markErr := b.git(ctx, b.mirrorDir, nil, "bisect", string(verdict), commit)
+
+		var err error
+
+		step, err = b.bisectStep(ctx)
+		if err != nil {
+			return err
+		}
+
+		// git bisect fails once only skipped commits are left,
+		// which is a result rather than an error.
+		if markErr != nil && !step.Done() {
+			return fmt.Errorf("failed to mark %s %s: %w", commit, verdict, markErr)
+		}
+
+		return nil
+	})
+
+	return step, err
+}
+
+// BisectReset ends the bisection in the source mirror, if any.
+func (b *SourceBuilder) BisectReset(ctx context.Context) error {
+	return b.withMirror(ctx, func() error {
+		err := b.git(ctx, b.mirrorDir, nil, "bisect", "reset")
+		if err != nil {
+			return fmt.Errorf("failed to reset bisection: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// withMirror runs fn holding the source mirror lock.
+func (b *SourceBuilder) withMirror(ctx context.Context, fn func() error) error {
+	if b.mirrorDir == "" {
+		return ErrNoSourceMirror
+	}
+
+	return filesystem.NewFileLock(b.mirrorDir+".lock").WithLock(ctx, fn)
+}
+
+// fetchForBisect updates every branch and tag in the mirror. Offline,
+// it only checks that both bounds are already there.
+func (b *SourceBuilder) fetchForBisect(ctx context.Context, good, bad string) error {
+	if b.offline {
+		if b.mirrorHas(ctx, good) && b.mirrorHas(ctx, bad) {
+			return nil
+		}
+
+		return fmt.Errorf(
+			"%w: %s and %s must be fetched from %s",
+			httpclient.ErrOffline,
+			good,
+			bad,
+			b.repoURL,
+		)
+	}
+
+	log.Debugf("Fetching branches and tags from %s into the source mirror", b.repoURL)
+
+	err := b.git(
+		ctx,
+		b.mirrorDir,
+		nil,
+		"fetch",
+		"--quiet",
+		b.repoURL,
+		"+"+allBranches+":"+allBranches,
+		"+"+allTags+":"+allTags,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to fetch branches and tags: %w", err)
+	}
+
+	return nil
+}
+
+// bisectStep reads where the bisection in the mirror stands.
+func (b *SourceBuilder) bisectStep(ctx context.Context) (bisect.Step, error) {
+	var logOut bytes.Buffer
+
+	err := b.git(ctx, b.mirrorDir, &logOut, "bisect", "log")
+	if err != nil {
+		return bisect.Step{}, fmt.Errorf("failed to read bisection log: %w", err)
+	}
+
+	var step bisect.Step
+
+	scanner := bufio.NewScanner(&logOut)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, firstBadPrefix):
+			step.FirstBad = bracketedHash(line, firstBadPrefix)
+		case strings.HasPrefix(line, suspectPrefix):
+			step.Suspects = append(step.Suspects, bracketedHash(line, suspectPrefix))
+		}
+	}
+
+	if step.FirstBad != "" || len(step.Suspects) > 0 {
+		return step, nil
+	}
+
+	var head bytes.Buffer
+
+	err = b.git(ctx, b.mirrorDir, &head, "rev-parse", "--verify", "BISECT_HEAD")
+	if err != nil {
+		return bisect.Step{}, fmt.Errorf("failed to read bisection candidate: %w", err)
+	}
+
+	step.Candidate = strings.TrimSpace(head.String())
+	step.StepsLeft = b.bisectStepsLeft(ctx)
+
+	return step, nil
+}
+
+// bisectStepsLeft estimates how many candidates remain after the
+// current one, or returns 0 if git cannot tell.
+func (b *SourceBuilder) bisectStepsLeft(ctx context.Context) int {
+	var vars bytes.Buffer
+
+	err := b.git(ctx, b.mirrorDir, &vars, "rev-list", "--bisect-vars", "--bisect")
+	if err != nil {
+		log.Debugf("Failed to estimate bisection steps: %v", err)
+	}
+
+	for line := range strings.Lines(vars.String()) {
+		value, found := strings.CutPrefix(strings.TrimSpace(line), "bisect_steps=")
+		if !found {
+			continue
+		}
+
+		steps, convErr := strconv.Atoi(value)
+		if convErr == nil {
+			return steps
+		}
+	}
+
+	return 0
+}
+
+// bracketedHash returns the hash between prefix (which ends with "[")
+// and the closing bracket of line.
+func bracketedHash(line, prefix string) string {
+	hash, _, _ := strings.Cut(strings.TrimPrefix(line, prefix), "]")
+
+	return hash
+}
@@ -0,0 +1,208 @@
+package builder_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/domain/bisect"
+	"github.com/y3owk1n/nvs/internal/infra/builder"
+)
+
+// gitRepo creates a repository with commits "c1".."c<count>", tags the
+// first v1 and the last v2, and returns its path and the commit hashes
+// in order.
+func gitRepo(t *testing.T, count int) (string, []string) {
+	t.Helper()
+
+	_, err := exec.LookPath(gitCmd)
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Setenv("GIT_AUTHOR_NAME", "nvs")
+	t.Setenv("GIT_AUTHOR_EMAIL", "nvs@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "nvs")
+	t.Setenv("GIT_COMMITTER_EMAIL", "nvs@example.com")
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.CommandContext(t.Context(), gitCmd, args...)
+		cmd.Dir = dir
+
+		out, runErr := cmd.CombinedOutput()
+		if runErr != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), runErr, out)
+		}
+
+		return strings.TrimSpace(string(out))
+	}
+
+	run("init", "--quiet")
+
+	hashes := make([]string, 0, count)
+
+	for idx := 1; idx <= count; idx++ {
+		writeErr := os.WriteFile(filepath.Join(dir, "f"), []byte(strconv.Itoa(idx)), 0o644)
+		if writeErr != nil {
+			t.Fatal(writeErr)
+		}
+
+		run("add", "f")
+		run("commit", "--quiet", "-m", "c"+strconv.Itoa(idx))
+		hashes = append(hashes, run("rev-parse", "HEAD"))
+	}
+
+	run("tag", "v1", hashes[0])
+	run("tag", "v2", hashes[count-1])
+
+	return dir, hashes
+}
+
+// TestBisect_FindsFirstBadCommit tests a bisection in the source
+// mirror against a real git: it narrows the range down to the first
+// bad commit, reports undecided suspects when skipping, and rejects
+// bounds in the wrong order.
+func TestBisect_FindsFirstBadCommit(t *testing.T) {
+	repo, hashes := gitRepo(t, 8)
+	firstBad := 4 // c5
+
+	srcBuilder := builder.New(
+		nil,
+		builder.WithRepoURL(repo),
+		builder.WithMirrorDir(filepath.Join(t.TempDir(), "neovim.git")),
+	)
+
+	index := func(hash string) int {
+		for idx, candidate := range hashes {
+			if candidate == hash {
+				return idx
+			}
+		}
+
+		t.Fatalf("unknown candidate %s", hash)
+
+		return -1
+	}
+
+	step, err := srcBuilder.BisectStart(t.Context(), "v1", "v2")
+	if err != nil {
+		t.Fatalf("BisectStart failed: %v", err)
+	}
+
+	tested := 0
+
+	for !step.Done() {
+		tested++
+		if tested > len(hashes) {
+			t.Fatal("bisection does not converge")
+		}
+
+		verdict := bisect.Good
+		if index(step.Candidate) >= firstBad {
+			verdict = bisect.Bad
+		}
+
+		step, err = srcBuilder.BisectMark(t.Context(), verdict, step.Candidate)
+		if err != nil {
+			t.Fatalf("BisectMark failed: %v", err)
+		}
+	}
+
+	if step.FirstBad != hashes[firstBad] {
+		t.Errorf("first bad commit = %s, want %s", step.FirstBad, hashes[firstBad])
+	}
+
+	// Skipping everything leaves the whole range as suspects.
+	step, err = srcBuilder.BisectStart(t.Context(), hashes[5], hashes[7])
+	if err != nil {
+		t.Fatalf("BisectStart failed: %v", err)
+	}
+
+	step, err = srcBuilder.BisectMark(t.Context(), bisect.Skip, step.Candidate)
+	if err != nil {
+		t.Fatalf("BisectMark(skip) failed: %v", err)
+	}
+
+	if !step.Done() || len(step.Suspects) != 2 {
+		t.Errorf("step after skipping = %+v, want two suspects", step)
+	}
+
+	err = srcBuilder.BisectReset(t.Context())
+	if err != nil {
+		t.Fatalf("BisectReset failed: %v", err)
+	}
+
+	_, err = srcBuilder.BisectStart(t.Context(), "v2", "v1")
+	if !errors.Is(err, bisect.ErrNotOlder) {
+		t.Errorf("BisectStart(v2, v1) error = %v, want ErrNotOlder", err)
+	}
+
+	_, err = srcBuilder.BisectStart(t.Context(), "v1", "v3")
+	if !errors.Is(err, bisect.ErrUnknownRevision) {
+		t.Errorf("BisectStart(v1, v3) error = %v, want ErrUnknownRevision", err)
+	}
+}
+
+// TestBisect_PatchReleaseOnSideBranch tests a good bound tagged on a
+// release branch, which is not an ancestor of the bad one: git tests
+// their merge base first, then bisects from it.
+func TestBisect_PatchReleaseOnSideBranch(t *testing.T) {
+	repo, hashes := gitRepo(t, 8)
+	firstBad := 4 // c5
+
+	// v1.1 is a patch release on a branch off c3.
+	for _, args := range [][]string{
+		{"checkout", "--quiet", "-b", "release-1", hashes[2]},
+		{"commit", "--quiet", "--allow-empty", "-m", "backport"},
+		{"tag", "v1.1"},
+	} {
+		cmd := exec.CommandContext(t.Context(), gitCmd, args...)
+		cmd.Dir = repo
+
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	srcBuilder := builder.New(
+		nil,
+		builder.WithRepoURL(repo),
+		builder.WithMirrorDir(filepath.Join(t.TempDir(), "neovim.git")),
+	)
+
+	step, err := srcBuilder.BisectStart(t.Context(), "v1.1", "v2")
+	if err != nil {
+		t.Fatalf("BisectStart(v1.1, v2) failed: %v", err)
+	}
+
+	if step.Candidate != hashes[2] {
+		t.Errorf("first candidate = %s, want the merge base %s", step.Candidate, hashes[2])
+	}
+
+	for tested := 0; !step.Done(); tested++ {
+		if tested > len(hashes) {
+			t.Fatal("bisection does not converge")
+		}
+
+		verdict := bisect.Good
+		if slices.Index(hashes, step.Candidate) >= firstBad {
+			verdict = bisect.Bad
+		}
+
+		step, err = srcBuilder.BisectMark(t.Context(), verdict, step.Candidate)
+		if err != nil {
+			t.Fatalf("BisectMark failed: %v", err)
+		}
+	}
+
+	if step.FirstBad != hashes[firstBad] {
+		t.Errorf("first bad commit = %s, want %s", step.FirstBad, hashes[firstBad])
+	}
+}
@@ -11,7 +11,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
 	"github.com/y3owk1n/nvs/internal/infra/builder"
+	"github.com/y3owk1n/nvs/internal/infra/buildlog"
+	"github.com/y3owk1n/nvs/internal/infra/depscache"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
 )
 
 var (
@@ -137,7 +142,7 @@ func TestBuildFromCommit_CloneFailure(t *testing.T) {
 	b := builder.New(mockExec)
 	ctx := t.Context()
 
-	_, err := b.BuildFromCommit(ctx, "abc1234", t.TempDir(), nil)
+	_, err := b.BuildFromCommit(ctx, "abc1234", vtypes.BuildProfile{}, t.TempDir(), nil)
 	if err == nil {
 		t.Error("BuildFromCommit() expected error for clone failure, got nil")
 	}
@@ -175,7 +180,7 @@ func TestBuildFromCommit_ProgressReporting(t *testing.T) {
 	ctx := t.Context()
 
 	// This will fail later, but we check the progress calls up to that point
-	_, _ = b.BuildFromCommit(ctx, "abc1234", t.TempDir(), progressFunc)
+	_, _ = b.BuildFromCommit(ctx, "abc1234", vtypes.BuildProfile{}, t.TempDir(), progressFunc)
 
 	// Check that progress calls use -1 for indeterminate phases
 	for progressIndex, call := range progressCalls {
@@ -233,7 +238,7 @@ func TestBuildFromCommit_CheckoutFailure(t *testing.T) {
 	b := builder.New(mockExec)
 	ctx := t.Context()
 
-	_, err := b.BuildFromCommit(ctx, "abc1234", t.TempDir(), nil)
+	_, err := b.BuildFromCommit(ctx, "abc1234", vtypes.BuildProfile{}, t.TempDir(), nil)
 	if err == nil {
 		t.Error("BuildFromCommit() expected error for checkout failure, got nil")
 	}
@@ -302,7 +307,7 @@ func TestBuildFromCommit_ContextCancellationDuringRetry(t *testing.T) {
 	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
 	defer cancel()
 
-	_, err := b.BuildFromCommit(ctx, "abc1234", t.TempDir(), nil)
+	_, err := b.BuildFromCommit(ctx, "abc1234", vtypes.BuildProfile{}, t.TempDir(), nil)
 	if err == nil {
 		t.Error("BuildFromCommit() expected error for context cancellation, got nil")
 	}
@@ -357,7 +362,7 @@ func TestBuildFromCommit_ContextCancellationDuringBuild(t *testing.T) {
 	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
 	defer cancel()
 
-	_, err := b.BuildFromCommit(ctx, "master", t.TempDir(), nil)
+	_, err := b.BuildFromCommit(ctx, "master", vtypes.BuildProfile{}, t.TempDir(), nil)
 	if err == nil {
 		t.Error("BuildFromCommit() expected error for context cancellation during build, got nil")
 	}
@@ -403,7 +408,7 @@ func TestBuildFromCommit_ContextCancellationWithProgress(t *testing.T) {
 	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
 	defer cancel()
 
-	_, _ = b.BuildFromCommit(ctx, "master", t.TempDir(), progressFunc)
+	_, _ = b.BuildFromCommit(ctx, "master", vtypes.BuildProfile{}, t.TempDir(), progressFunc)
 
 	// Check that progress was called with build phase before cancellation
 	close(progressCalled)
@@ -472,7 +477,7 @@ func TestBuildFromCommit_ContextNotCancelledBeforeRetrySleep(t *testing.T) {
 	ctx, cancel := context.WithTimeout(t.Context(), 3*time.Second)
 	defer cancel()
 
-	_, _ = b.BuildFromCommit(ctx, "abc1234", t.TempDir(), nil)
+	_, _ = b.BuildFromCommit(ctx, "abc1234", vtypes.BuildProfile{}, t.TempDir(), nil)
 
 	// Should have made multiple clone attempts (up to MaxAttempts)
 	if cloneAttemptCount < 2 {
@@ -571,7 +576,7 @@ func TestBuildFromCommit_NoTempDirLeakOnContextCancel(t *testing.T) {
 
 	b := builder.New(mockExec)
 
-	_, _ = b.BuildFromCommit(t.Context(), "master", t.TempDir(), nil)
+	_, _ = b.BuildFromCommit(t.Context(), "master", vtypes.BuildProfile{}, t.TempDir(), nil)
 
 	after := countTempBuildDirs(t)
 
@@ -583,3 +588,507 @@ func TestBuildFromCommit_NoTempDirLeakOnContextCancel(t *testing.T) {
 		)
 	}
 }
+
+// hookCommand is a mockCommand that runs onRun before returning.
+type hookCommand struct {
+	mockCommand
+
+	onRun func() error
+}
+
+func (h *hookCommand) Run() error {
+	err := h.onRun()
+	if err != nil {
+		return err
+	}
+
+	return h.mockCommand.Run()
+}
+
+// TestBuildFromRef_ReplacesPreviousBuild tests that a ref build fetches
+// the ref, installs under the ref's directory name, records the ref and
+// hash, and replaces an earlier build of the same ref.
+func TestBuildFromRef_ReplacesPreviousBuild(t *testing.T) {
+	dest := t.TempDir()
+
+	ref, err := vtypes.ParseSourceRef("pr:12345")
+	if err != nil {
+		t.Fatalf("ParseSourceRef failed: %v", err)
+	}
+
+	installDir := filepath.Join(dest, ref.DirName())
+
+	err = os.MkdirAll(installDir, 0o755)
+	if err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(installDir, "stale.txt"), []byte("old"), 0o644)
+	if err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	var fetched []string
+
+	mockExec := func(ctx context.Context, name string, args ...string) builder.Commander {
+		switch {
+		case name == gitCmd && len(args) > 0 && args[0] == "fetch":
+			fetched = append(fetched, args[len(args)-1])
+		case name == gitCmd && len(args) > 0 && args[0] == gitRevParse:
+			return &mockCommand{stdoutStr: testCommitSHA}
+		case name == cmakeTool && len(args) > 0 && args[0] == "--install":
+			prefix := strings.TrimPrefix(args[len(args)-1], "--prefix=")
+
+			return &hookCommand{onRun: func() error {
+				binDir := filepath.Join(prefix, "bin")
+
+				mkErr := os.MkdirAll(binDir, 0o755)
+				if mkErr != nil {
+					return mkErr
+				}
+
+				return os.WriteFile(filepath.Join(binDir, "nvim"), []byte("#!/bin/sh\n"), 0o755)
+			}}
+		}
+
+		return &mockCommand{}
+	}
+
+	hash, err := builder.New(mockExec, builder.WithAppVersion("v1.2.3")).
+		BuildFromRef(t.Context(), ref, vtypes.BuildProfile{}, dest, nil)
+	if err != nil {
+		t.Fatalf("BuildFromRef failed: %v", err)
+	}
+
+	if hash != testCommitSHA[:7] {
+		t.Errorf("hash = %q, want %q", hash, testCommitSHA[:7])
+	}
+
+	if len(fetched) == 0 || fetched[0] != "refs/pull/12345/head" {
+		t.Errorf("fetched %v, want refs/pull/12345/head", fetched)
+	}
+
+	manifest, err := filesystem.ReadManifest(installDir)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+
+	if manifest.Source != vtypes.ManifestSourceBuild || manifest.Ref != "pr:12345" ||
+		manifest.Identifier != testCommitSHA || manifest.Commit != testCommitSHA {
+		t.Errorf("manifest = %+v, want a build of pr:12345 at %s", manifest, testCommitSHA)
+	}
+
+	if manifest.NvsVersion != "v1.2.3" || len(manifest.BuildFlags) == 0 {
+		t.Errorf("manifest nvs_version = %q, build_flags = %v", manifest.NvsVersion, manifest.BuildFlags)
+	}
+
+	_, err = os.Stat(filepath.Join(installDir, "stale.txt"))
+	if !os.IsNotExist(err) {
+		t.Errorf("previous build was not replaced (stat err = %v)", err)
+	}
+
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			t.Errorf("staging directory left behind: %s", entry.Name())
+		}
+	}
+}
+
+// TestBuildFromCommit_BuildProfile tests that a build profile reaches
+// make, names the install directory and is recorded in the manifest.
+func TestBuildFromCommit_BuildProfile(t *testing.T) {
+	dest := t.TempDir()
+	profile := vtypes.BuildProfile{
+		Type:           vtypes.BuildTypeDebug,
+		Sanitize:       true,
+		DepsCMakeFlags: "-DUSE_BUNDLED_LUAJIT=OFF",
+	}
+
+	var makeArgs []string
+
+	mockExec := func(ctx context.Context, name string, args ...string) builder.Commander {
+		switch {
+		case name == gitCmd && len(args) > 0 && args[0] == gitRevParse:
+			return &mockCommand{stdoutStr: testCommitSHA}
+		case name == makeTool:
+			makeArgs = args
+		case name == cmakeTool && len(args) > 0 && args[0] == "--install":
+			prefix := strings.TrimPrefix(args[len(args)-1], "--prefix=")
+
+			return &hookCommand{onRun: func() error {
+				binDir := filepath.Join(prefix, "bin")
+
+				mkErr := os.MkdirAll(binDir, 0o755)
+				if mkErr != nil {
+					return mkErr
+				}
+
+				return os.WriteFile(filepath.Join(binDir, "nvim"), []byte("#!/bin/sh\n"), 0o755)
+			}}
+		}
+
+		return &mockCommand{}
+	}
+
+	_, err := builder.New(mockExec).BuildFromCommit(t.Context(), testCommitSHA, profile, dest, nil)
+	if err != nil {
+		t.Fatalf("BuildFromCommit failed: %v", err)
+	}
+
+	wantArgs := []string{
+		"CMAKE_BUILD_TYPE=Debug",
+		"CMAKE_EXTRA_FLAGS=-DENABLE_ASAN_UBSAN=ON",
+		"DEPS_CMAKE_FLAGS=-DUSE_BUNDLED_LUAJIT=OFF",
+	}
+	if strings.Join(makeArgs, "|") != strings.Join(wantArgs, "|") {
+		t.Errorf("make args = %q, want %q", makeArgs, wantArgs)
+	}
+
+	installDir := filepath.Join(dest, profile.InstallName(testCommitSHA[:7]))
+
+	manifest, err := filesystem.ReadManifest(installDir)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+
+	if manifest.BuildProfile == nil || *manifest.BuildProfile != profile {
+		t.Errorf("manifest build_profile = %+v, want %+v", manifest.BuildProfile, profile)
+	}
+
+	if strings.Join(manifest.BuildFlags, "|") != strings.Join(wantArgs, "|") {
+		t.Errorf("manifest build_flags = %q, want %q", manifest.BuildFlags, wantArgs)
+	}
+}
+
+// TestBuildFromCommit_DepsCache tests that builds of commits pinning
+// the same dependencies build them into the cache once and point make
+// at the cached set, without recording that in the manifest.
+func TestBuildFromCommit_DepsCache(t *testing.T) {
+	dest := t.TempDir()
+	store := depscache.New(filepath.Join(t.TempDir(), "deps"), 0, 0)
+	pins := "LIBUV_SHA256 one\n"
+
+	var makeCalls [][]string
+
+	mockExec := func(ctx context.Context, name string, args ...string) builder.Commander {
+		switch {
+		case name == gitCmd && len(args) > 0 && args[0] == gitClone:
+			localPath := args[len(args)-1]
+
+			return &hookCommand{onRun: func() error {
+				depsDir := filepath.Join(localPath, "cmake.deps")
+
+				mkErr := os.MkdirAll(depsDir, 0o755)
+				if mkErr != nil {
+					return mkErr
+				}
+
+				return os.WriteFile(filepath.Join(depsDir, "deps.txt"), []byte(pins), 0o644)
+			}}
+		case name == gitCmd && len(args) > 0 && args[0] == gitRevParse:
+			return &mockCommand{stdoutStr: testCommitSHA}
+		case name == makeTool:
+			makeCalls = append(makeCalls, args)
+		case name == cmakeTool && len(args) > 0 && args[0] == "--install":
+			prefix := strings.TrimPrefix(args[len(args)-1], "--prefix=")
+
+			return &hookCommand{onRun: func() error {
+				binDir := filepath.Join(prefix, "bin")
+
+				mkErr := os.MkdirAll(binDir, 0o755)
+				if mkErr != nil {
+					return mkErr
+				}
+
+				return os.WriteFile(filepath.Join(binDir, "nvim"), []byte("#!/bin/sh\n"), 0o755)
+			}}
+		}
+
+		return &mockCommand{}
+	}
+
+	srcBuilder := builder.New(mockExec, builder.WithDepsCache(store))
+	profile := vtypes.BuildProfile{CMakeFlags: "-DFOO=ON"}
+
+	for range 2 {
+		_, err := srcBuilder.BuildFromCommit(t.Context(), testCommitSHA, profile, dest, nil)
+		if err != nil {
+			t.Fatalf("BuildFromCommit failed: %v", err)
+		}
+	}
+
+	entries, err := store.List()
+	if err != nil || len(entries) != 1 || !entries[0].Ready {
+		t.Fatalf("dependency sets = %+v, %v; want one ready set", entries, err)
+	}
+
+	depsDir := entries[0].Path
+	wantCalls := []string{
+		"deps DEPS_BUILD_DIR=" + depsDir,
+		"CMAKE_BUILD_TYPE=Release CMAKE_EXTRA_FLAGS=-DFOO=ON -DDEPS_PREFIX=" +
+			filepath.Join(depsDir, "usr") + " USE_BUNDLED=OFF",
+	}
+	wantCalls = append(wantCalls, wantCalls[1])
+
+	gotCalls := make([]string, 0, len(makeCalls))
+	for _, call := range makeCalls {
+		gotCalls = append(gotCalls, strings.Join(call, " "))
+	}
+
+	if strings.Join(gotCalls, "\n") != strings.Join(wantCalls, "\n") {
+		t.Errorf("make calls =\n%s\nwant\n%s",
+			strings.Join(gotCalls, "\n"), strings.Join(wantCalls, "\n"))
+	}
+
+	manifest, err := filesystem.ReadManifest(filepath.Join(dest, profile.InstallName("abc1234")))
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+
+	wantFlags := "CMAKE_BUILD_TYPE=Release CMAKE_EXTRA_FLAGS=-DFOO=ON"
+	if strings.Join(manifest.BuildFlags, " ") != wantFlags {
+		t.Errorf("manifest build_flags = %q, want the profile's flags only", manifest.BuildFlags)
+	}
+
+	// Different pins build a second set.
+	pins = "LIBUV_SHA256 two\n"
+	makeCalls = nil
+
+	_, err = srcBuilder.BuildFromCommit(t.Context(), testCommitSHA, profile, dest, nil)
+	if err != nil {
+		t.Fatalf("BuildFromCommit failed: %v", err)
+	}
+
+	if len(makeCalls) != 2 || makeCalls[0][0] != "deps" {
+		t.Errorf("make calls = %q, want the new dependency set built", makeCalls)
+	}
+}
+
+// TestBuildFromCommit_BuildLog tests that a build's output goes to a
+// build log, and that a failed build's error points at it.
+func TestBuildFromCommit_BuildLog(t *testing.T) {
+	store := buildlog.New(filepath.Join(t.TempDir(), "build-logs"), 0)
+
+	mockExec := func(ctx context.Context, name string, args ...string) builder.Commander {
+		switch {
+		case name == gitCmd && len(args) > 0 && args[0] == gitRevParse:
+			return &mockCommand{stdoutStr: testCommitSHA}
+		case name == makeTool:
+			return &mockCommand{
+				stdoutBuf: strings.NewReader("cc -c eval.c\nsrc/nvim/eval.c:1:1: error: boom\n"),
+				stderrBuf: strings.NewReader("make: *** [all] Error 2\n"),
+				runErr:    errors.New("exit status 2"),
+			}
+		}
+
+		return &mockCommand{}
+	}
+
+	_, err := builder.New(mockExec, builder.WithBuildLogs(store)).
+		BuildFromCommit(t.Context(), testCommitSHA, vtypes.BuildProfile{}, t.TempDir(), nil)
+	if !errors.Is(err, builder.ErrBuildFailed) {
+		t.Fatalf("BuildFromCommit error = %v, want ErrBuildFailed", err)
+	}
+
+	entry, findErr := store.Find("abc1234")
+	if findErr != nil {
+		t.Fatalf("Find failed: %v", findErr)
+	}
+
+	if entry.Status != buildlog.StatusFailed || entry.Commit != testCommitSHA {
+		t.Errorf("log entry = %+v, want a failed build of %s", entry, testCommitSHA)
+	}
+
+	if !strings.Contains(err.Error(), entry.Path) {
+		t.Errorf("error %q does not point at the log %s", err, entry.Path)
+	}
+
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	lines := buildlog.SplitLines(data)
+
+	failed := buildlog.FailurePoint(lines)
+	if failed < 0 || lines[failed] != "src/nvim/eval.c:1:1: error: boom" {
+		t.Errorf("failure point %d in log:\n%s", failed, data)
+	}
+
+	if strings.Count(string(data), "make: *** [all] Error 2") != 3 {
+		t.Errorf("log does not hold the stderr of all three attempts:\n%s", data)
+	}
+}
+
+// TestBuildFromCommit_SourceMirror tests that builds with a source
+// mirror create it once, fetch only commits it lacks, check out a
+// worktree instead of cloning, and build cached commits offline.
+func TestBuildFromCommit_SourceMirror(t *testing.T) {
+	mirrorDir := filepath.Join(t.TempDir(), "neovim.git")
+	inMirror := map[string]bool{}
+
+	var gitCalls []string
+
+	mockExec := func(ctx context.Context, name string, args ...string) builder.Commander {
+		if name == gitCmd {
+			gitCalls = append(gitCalls, strings.Join(args, " "))
+		}
+
+		switch {
+		case name == gitCmd && args[0] == "init":
+			return &hookCommand{onRun: func() error {
+				mkErr := os.MkdirAll(mirrorDir, 0o755)
+				if mkErr != nil {
+					return mkErr
+				}
+
+				head := []byte("ref: refs/heads/master\n")
+
+				return os.WriteFile(filepath.Join(mirrorDir, "HEAD"), head, 0o644)
+			}}
+		case name == gitCmd && args[0] == "fetch":
+			inMirror[testCommitSHA] = true
+		case name == gitCmd && args[0] == gitRevParse && args[1] == "--verify":
+			commit := strings.TrimSuffix(args[len(args)-1], "^{commit}")
+			if !inMirror[commit] {
+				return &mockCommand{runErr: errCheckoutFailed}
+			}
+
+			return &mockCommand{stdoutStr: commit}
+		case name == gitCmd && args[0] == gitRevParse:
+			return &mockCommand{stdoutStr: testCommitSHA}
+		case name == gitCmd && args[0] == gitClone:
+			t.Errorf("git clone called with a source mirror: %v", args)
+		case name == cmakeTool && len(args) > 0 && args[0] == "--install":
+			prefix := strings.TrimPrefix(args[len(args)-1], "--prefix=")
+
+			return &hookCommand{onRun: func() error {
+				binDir := filepath.Join(prefix, "bin")
+
+				mkErr := os.MkdirAll(binDir, 0o755)
+				if mkErr != nil {
+					return mkErr
+				}
+
+				return os.WriteFile(filepath.Join(binDir, "nvim"), []byte("#!/bin/sh\n"), 0o755)
+			}}
+		}
+
+		return &mockCommand{}
+	}
+
+	countCalls := func(prefix string) int {
+		count := 0
+
+		for _, call := range gitCalls {
+			if strings.HasPrefix(call, prefix) {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	online := builder.New(mockExec, builder.WithMirrorDir(mirrorDir))
+
+	for range 2 {
+		_, err := online.
+			BuildFromCommit(t.Context(), testCommitSHA, vtypes.BuildProfile{}, t.TempDir(), nil)
+		if err != nil {
+			t.Fatalf("BuildFromCommit failed: %v", err)
+		}
+	}
+
+	if got := countCalls("init"); got != 1 {
+		t.Errorf("git init ran %d times, want 1", got)
+	}
+
+	if got := countCalls("fetch"); got != 1 {
+		t.Errorf("git fetch ran %d times, want once for the missing commit", got)
+	}
+
+	if got := countCalls("worktree add"); got != 2 {
+		t.Errorf("git worktree add ran %d times, want once per build", got)
+	}
+
+	gitCalls = nil
+	offline := builder.New(mockExec, builder.WithMirrorDir(mirrorDir), builder.WithOffline(true))
+
+	_, err := offline.
+		BuildFromCommit(t.Context(), testCommitSHA, vtypes.BuildProfile{}, t.TempDir(), nil)
+	if err != nil {
+		t.Errorf("offline build of a mirrored commit failed: %v", err)
+	}
+
+	_, err = offline.
+		BuildFromCommit(t.Context(), "deadbeef", vtypes.BuildProfile{}, t.TempDir(), nil)
+	if !errors.Is(err, httpclient.ErrOffline) {
+		t.Errorf("offline build of a missing commit error = %v, want ErrOffline", err)
+	}
+
+	if got := countCalls("fetch"); got != 0 {
+		t.Errorf("git fetch ran %d times offline", got)
+	}
+}
+
+// TestResolveRef tests parsing of git ls-remote output.
+func TestResolveRef(t *testing.T) {
+	ref, err := vtypes.ParseSourceRef("branch:release-0.10")
+	if err != nil {
+		t.Fatalf("ParseSourceRef failed: %v", err)
+	}
+
+	output := testCommitSHA + "\trefs/heads/release-0.10\n"
+
+	mockExec := func(ctx context.Context, name string, args ...string) builder.Commander {
+		if name == gitCmd && len(args) > 0 && args[0] == "ls-remote" &&
+			args[len(args)-1] == "refs/heads/release-0.10" {
+			return &mockCommand{stdoutStr: output}
+		}
+
+		return &mockCommand{}
+	}
+
+	hash, err := builder.New(mockExec).ResolveRef(t.Context(), ref)
+	if err != nil || hash != testCommitSHA {
+		t.Errorf("ResolveRef = %q, %v; want %s", hash, err, testCommitSHA)
+	}
+
+	output = ""
+
+	_, err = builder.New(mockExec).ResolveRef(t.Context(), ref)
+	if !errors.Is(err, builder.ErrRefNotFound) {
+		t.Errorf("ResolveRef error = %v, want ErrRefNotFound", err)
+	}
+}
+
+// TestResolveRef_RepoURL tests that refs are looked up in the
+// repository set with WithRepoURL.
+func TestResolveRef_RepoURL(t *testing.T) {
+	const repoURL = "https://ghe.example.com/editors/neovim.git"
+
+	ref, err := vtypes.ParseSourceRef("branch:patched")
+	if err != nil {
+		t.Fatalf("ParseSourceRef failed: %v", err)
+	}
+
+	var remote string
+
+	mockExec := func(ctx context.Context, name string, args ...string) builder.Commander {
+		if name == gitCmd && len(args) > 1 && args[0] == "ls-remote" {
+			remote = args[1]
+		}
+
+		return &mockCommand{stdoutStr: testCommitSHA + "\trefs/heads/patched\n"}
+	}
+
+	_, err = builder.New(mockExec, builder.WithRepoURL(repoURL)).ResolveRef(t.Context(), ref)
+	if err != nil || remote != repoURL {
+		t.Errorf("ResolveRef asked %q (err %v), want %s", remote, err, repoURL)
+	}
+}
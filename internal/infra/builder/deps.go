@@ -0,0 +1,111 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/y3owk1n/nvs/internal/domain/installer"
+	"github.com/y3owk1n/nvs/internal/infra/depscache"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// depsFile pins the versions of the bundled third-party dependencies;
+// commits with the same content build the same dependency set.
+var depsFile = filepath.Join("cmake.deps", "deps.txt")
+
+// cmakeExtraFlags is the make variable that passes flags to Neovim's
+// own CMake configure step.
+const cmakeExtraFlags = "CMAKE_EXTRA_FLAGS="
+
+// prepareDeps makes sure the dependency set the checkout at localPath
+// pins is in the dependency cache, building it there first if needed,
+// and returns makeArgs changed to build against it instead of a .deps
+// directory in the checkout. makeArgs are returned as they are when
+// there is no cache or the checkout has no cmake.deps/deps.txt.
+//
+// The set is held until release is called, which the caller must do
+// once Neovim is built and installed, so the cache is not pruned from
+// under the build.
+func (b *SourceBuilder) prepareDeps(
+	ctx context.Context,
+	target buildTarget,
+	localPath string,
+	makeArgs []string,
+	progress installer.ProgressFunc,
+) ([]string, func(), error) {
+	noRelease := func() {}
+
+	if b.depsCache == nil {
+		return makeArgs, noRelease, nil
+	}
+
+	// make splits its variables, and CMake its flags, on whitespace.
+	if strings.ContainsFunc(b.depsCache.Dir(), unicode.IsSpace) {
+		log.Warnf("Not caching dependencies: %s contains whitespace", b.depsCache.Dir())
+
+		return makeArgs, noRelease, nil
+	}
+
+	pins, err := os.ReadFile(filepath.Join(localPath, depsFile))
+	if err != nil {
+		log.Debugf("Not caching dependencies: %v", err)
+
+		return makeArgs, noRelease, nil
+	}
+
+	depsFlags := target.profile.DepsCMakeFlags
+	key := depscache.Key(pins, depsFlags)
+
+	depsDir, release, err := b.depsCache.Acquire(ctx, key, func(dir string) error {
+		log.Debugf("Building dependency set %s in %s", key, dir)
+
+		args := []string{"deps", "DEPS_BUILD_DIR=" + dir}
+		if depsFlags != "" {
+			args = append(args, "DEPS_CMAKE_FLAGS="+depsFlags)
+		}
+
+		cmd := b.execCommand(ctx, "make", args...)
+		cmd.SetDir(localPath)
+
+		target.output.Markf("make %s", strings.Join(args, " "))
+
+		return runCommandWithProgress(ctx, cmd, progress, "Building dependencies", target.output)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrBuildFailed, err)
+	}
+
+	log.Debugf("Using dependency set %s", key)
+
+	// USE_BUNDLED=OFF keeps make from building .deps in the checkout;
+	// DEPS_PREFIX points CMake at the cached set instead.
+	args := withCMakeFlag(makeArgs, "-DDEPS_PREFIX="+filepath.Join(depsDir, "usr"))
+
+	return append(args, "USE_BUNDLED=OFF"), release, nil
+}
+
+// withCMakeFlag returns a copy of makeArgs with flag added to
+// CMAKE_EXTRA_FLAGS.
+func withCMakeFlag(makeArgs []string, flag string) []string {
+	args := make([]string, 0, len(makeArgs)+1)
+	added := false
+
+	for _, arg := range makeArgs {
+		if !added && strings.HasPrefix(arg, cmakeExtraFlags) {
+			arg += " " + flag
+			added = true
+		}
+
+		args = append(args, arg)
+	}
+
+	if !added {
+		args = append(args, cmakeExtraFlags+flag)
+	}
+
+	return args
+}
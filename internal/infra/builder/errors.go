@@ -13,6 +13,12 @@ var (
 	// ErrCommitHashTooShort is returned when the commit hash is too short.
 	ErrCommitHashTooShort = errors.New("commit hash too short")
 
+	// ErrRefNotFound is returned when a branch or pull request does not exist upstream.
+	ErrRefNotFound = errors.New("ref not found upstream")
+
+	// ErrNoSourceMirror is returned when bisecting without a source mirror.
+	ErrNoSourceMirror = errors.New("bisecting needs the source mirror")
+
 	// ErrBinaryNotFound is returned when the built binary is not found.
 	ErrBinaryNotFound = errors.New("built binary not found")
 
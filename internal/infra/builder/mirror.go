@@ -0,0 +1,184 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/installer"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// allBranches is the refspec fetched when a commit is not in the
+// mirror yet.
+const allBranches = "refs/heads/*"
+
+// checkoutFromMirror brings the source mirror up to date with target
+// and checks it out into a new worktree at localPath. The mirror is
+// locked only while git touches it, so builds from other processes
+// wait for each other's fetches but not for each other's compiles.
+func (b *SourceBuilder) checkoutFromMirror(
+	ctx context.Context,
+	target buildTarget,
+	localPath string,
+	progress installer.ProgressFunc,
+) error {
+	lock := filesystem.NewFileLock(b.mirrorDir + ".lock")
+
+	return lock.WithLock(ctx, func() error {
+		err := b.initMirror(ctx, progress)
+		if err != nil {
+			return err
+		}
+
+		rev, err := b.fetchIntoMirror(ctx, target, progress)
+		if err != nil {
+			return err
+		}
+
+		// Forget worktrees of earlier builds whose directories are
+		// gone, so their checkouts do not pin objects forever.
+		pruneErr := b.git(ctx, b.mirrorDir, nil, "worktree", "prune")
+		if pruneErr != nil {
+			log.Warnf("Failed to prune source mirror worktrees: %v", pruneErr)
+		}
+
+		err = b.git(ctx, b.mirrorDir, nil, "worktree", "add", "--quiet", "--detach", localPath, rev)
+		if err != nil {
+			return fmt.Errorf("failed to check out %s: %w", rev, err)
+		}
+
+		return nil
+	})
+}
+
+// initMirror creates the bare mirror repository on first use.
+func (b *SourceBuilder) initMirror(ctx context.Context, progress installer.ProgressFunc) error {
+	_, err := os.Stat(filepath.Join(b.mirrorDir, "HEAD"))
+	if err == nil {
+		return nil
+	}
+
+	if progress != nil {
+		progress("Creating source mirror", -1)
+	}
+
+	log.Debugf("Creating source mirror at %s", b.mirrorDir)
+
+	err = os.MkdirAll(filepath.Dir(b.mirrorDir), constants.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to create source mirror directory: %w", err)
+	}
+
+	err = b.git(ctx, "", nil, "init", "--quiet", "--bare", b.mirrorDir)
+	if err != nil {
+		return fmt.Errorf("failed to create source mirror: %w", err)
+	}
+
+	return nil
+}
+
+// fetchIntoMirror fetches what target needs and returns the revision
+// to check out. Branches and pull requests are fetched under their own
+// ref names. A commit already in the mirror needs no fetch, which is
+// also what lets it build offline; otherwise every branch is fetched,
+// the first time being the one full download of the repository.
+func (b *SourceBuilder) fetchIntoMirror(
+	ctx context.Context,
+	target buildTarget,
+	progress installer.ProgressFunc,
+) (string, error) {
+	var refspec, rev, what string
+
+	switch {
+	case !target.ref.IsZero():
+		refspec = target.ref.FetchRef()
+		rev = refspec
+		what = target.ref.String()
+	case target.commit == "master":
+		refspec = "refs/heads/master"
+		rev = refspec
+		what = "master"
+	default:
+		rev = target.commit
+		if b.mirrorHas(ctx, rev) {
+			log.Debugf("Commit %s is already in the source mirror", rev)
+
+			return rev, nil
+		}
+
+		refspec = allBranches
+		what = "commit " + target.commit
+	}
+
+	if b.offline {
+		return "", fmt.Errorf(
+			"%w: %s is not in the source mirror and must be fetched from %s",
+			httpclient.ErrOffline,
+			what,
+			b.repoURL,
+		)
+	}
+
+	if progress != nil {
+		progress("Fetching "+what+" (the first fetch downloads the whole repository)", -1)
+	}
+
+	log.Debugf("Fetching %s from %s into the source mirror", refspec, b.repoURL)
+
+	err := b.git(ctx, b.mirrorDir, nil, "fetch", "--quiet", b.repoURL, "+"+refspec+":"+refspec)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", what, err)
+	}
+
+	return rev, nil
+}
+
+// mirrorHas reports whether the mirror holds commit.
+func (b *SourceBuilder) mirrorHas(ctx context.Context, commit string) bool {
+	var out bytes.Buffer
+
+	err := b.git(ctx, b.mirrorDir, &out, "rev-parse", "--verify", "--quiet", commit+"^{commit}")
+
+	return err == nil && strings.TrimSpace(out.String()) != ""
+}
+
+// git runs a git command in dir (or the current directory if dir is
+// empty), writing its output to stdout when that is not nil.
+func (b *SourceBuilder) git(
+	ctx context.Context,
+	dir string,
+	stdout *bytes.Buffer,
+	args ...string,
+) error {
+	cmd := b.execCommand(ctx, "git", args...)
+	if dir != "" {
+		cmd.SetDir(dir)
+	}
+
+	var stderr bytes.Buffer
+
+	cmd.SetStderr(&stderr)
+
+	if stdout != nil {
+		cmd.SetStdout(stdout)
+	}
+
+	err := cmd.Run()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -18,6 +18,11 @@ import (
 
 	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/domain/installer"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	"github.com/y3owk1n/nvs/internal/infra/buildlog"
+	"github.com/y3owk1n/nvs/internal/infra/depscache"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
 	"github.com/y3owk1n/nvs/internal/log"
 )
 
@@ -26,6 +31,68 @@ const toolCheckTimeout = 30 * time.Second
 // SourceBuilder builds Neovim from source code.
 type SourceBuilder struct {
 	execCommand ExecCommandFunc
+	appVersion  string
+	offline     bool
+	repoURL     string
+	mirrorDir   string
+	depsCache   *depscache.Store
+	buildLogs   *buildlog.Store
+}
+
+// Option customizes a SourceBuilder built by New.
+type Option func(*SourceBuilder)
+
+// WithAppVersion sets the nvs version recorded in build manifests.
+func WithAppVersion(version string) Option {
+	return func(b *SourceBuilder) {
+		b.appVersion = version
+	}
+}
+
+// WithOffline makes builds and ref lookups fail with
+// httpclient.ErrOffline: both start by talking to the upstream
+// repository.
+func WithOffline(offline bool) Option {
+	return func(b *SourceBuilder) {
+		b.offline = offline
+	}
+}
+
+// WithRepoURL clones and looks up refs in the repository at repoURL
+// instead of constants.RepoURL. An empty repoURL keeps the default.
+func WithRepoURL(repoURL string) Option {
+	return func(b *SourceBuilder) {
+		if repoURL != "" {
+			b.repoURL = repoURL
+		}
+	}
+}
+
+// WithMirrorDir keeps a bare mirror of the repository in dir and
+// builds from worktrees of it, so each build fetches only what the
+// mirror lacks instead of cloning the whole repository. Without it,
+// every build attempt clones into a fresh temporary directory.
+func WithMirrorDir(dir string) Option {
+	return func(b *SourceBuilder) {
+		b.mirrorDir = dir
+	}
+}
+
+// WithDepsCache builds the third-party dependencies into store and
+// reuses them for every commit that pins the same ones, instead of
+// building them into each checkout.
+func WithDepsCache(store *depscache.Store) Option {
+	return func(b *SourceBuilder) {
+		b.depsCache = store
+	}
+}
+
+// WithBuildLogs writes the full output of each build to a log in
+// store, which a build failure points at.
+func WithBuildLogs(store *buildlog.Store) Option {
+	return func(b *SourceBuilder) {
+		b.buildLogs = store
+	}
 }
 
 // ExecCommandFunc is a function type for executing commands (allows mocking).
@@ -45,20 +112,171 @@ type Commander interface {
 }
 
 // New creates a new SourceBuilder instance.
-func New(execFunc ExecCommandFunc) *SourceBuilder {
+func New(execFunc ExecCommandFunc, opts ...Option) *SourceBuilder {
 	if execFunc == nil {
 		execFunc = defaultExecCommand
 	}
 
-	return &SourceBuilder{
+	builder := &SourceBuilder{
 		execCommand: execFunc,
+		repoURL:     constants.RepoURL,
 	}
+
+	for _, opt := range opts {
+		opt(builder)
+	}
+
+	return builder
+}
+
+// buildTarget says what to check out, how to configure it and where
+// the result is installed: a commit (or "master") lands under its
+// short hash, a branch or pull request under the ref's directory name,
+// either followed by the profile's suffix. output is the build's log,
+// nil when there is none.
+type buildTarget struct {
+	commit    string
+	ref       vtypes.SourceRef
+	profile   vtypes.BuildProfile
+	startedAt time.Time
+	output    *buildlog.Log
+}
+
+// label returns the name target is installed under, as far as it is
+// known before the build: a commit given as "master" is only resolved
+// once checked out.
+func (t buildTarget) label() string {
+	if !t.ref.IsZero() {
+		return t.ref.InstallName(t.profile)
+	}
+
+	commit := t.commit
+	if vtypes.IsCommitReference(commit) && len(commit) > constants.ShortCommitLen {
+		commit = commit[:constants.ShortCommitLen]
+	}
+
+	return t.profile.InstallName(commit)
 }
 
-// BuildFromCommit builds Neovim from a specific commit or "master".
+// BuildFromCommit builds Neovim from a specific commit or "master"
+// with the given build profile.
 func (b *SourceBuilder) BuildFromCommit(
 	ctx context.Context,
 	commit string,
+	profile vtypes.BuildProfile,
+	dest string,
+	progress installer.ProgressFunc,
+) (string, error) {
+	return b.build(ctx, buildTarget{commit: commit, profile: profile}, dest, progress)
+}
+
+// BuildFromRef builds Neovim from the current head of a branch or pull
+// request with the given build profile. The result is installed under
+// ref.InstallName(profile), replacing any earlier build of
+// the same ref and profile only once the new build is complete.
+func (b *SourceBuilder) BuildFromRef(
+	ctx context.Context,
+	ref vtypes.SourceRef,
+	profile vtypes.BuildProfile,
+	dest string,
+	progress installer.ProgressFunc,
+) (string, error) {
+	return b.build(ctx, buildTarget{ref: ref, profile: profile}, dest, progress)
+}
+
+// ResolveRef returns the full commit hash ref points at upstream,
+// using git ls-remote so nothing is cloned.
+func (b *SourceBuilder) ResolveRef(ctx context.Context, ref vtypes.SourceRef) (string, error) {
+	if b.offline {
+		return "", fmt.Errorf("%w: cannot look up %s upstream", httpclient.ErrOffline, ref)
+	}
+
+	cmd := b.execCommand(ctx, "git", "ls-remote", b.repoURL, ref.FetchRef())
+
+	var out bytes.Buffer
+	cmd.SetStdout(&out)
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+
+	fields := strings.Fields(out.String())
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrRefNotFound, ref)
+	}
+
+	return fields[0], nil
+}
+
+// build runs the clone/checkout/build/install pipeline for target,
+// retrying with a clean directory up to constants.MaxAttempts times,
+// and logs the output of every attempt. A failed build's error points
+// at the log.
+func (b *SourceBuilder) build(
+	ctx context.Context,
+	target buildTarget,
+	dest string,
+	progress installer.ProgressFunc,
+) (string, error) {
+	// Without a mirror, every build clones the upstream repository.
+	// With one, only what the mirror lacks is fetched.
+	if b.offline && b.mirrorDir == "" {
+		return "", fmt.Errorf(
+			"%w: building needs to clone %s",
+			httpclient.ErrOffline,
+			b.repoURL,
+		)
+	}
+
+	target.startedAt = time.Now()
+	target.output = b.createBuildLog(target)
+
+	resolvedHash, err := b.buildAttempts(ctx, target, dest, progress)
+
+	closeErr := target.output.Close(err)
+	if closeErr != nil {
+		log.Warnf("Failed to write build log: %v", closeErr)
+	}
+
+	if err != nil && target.output != nil && errors.Is(err, ErrBuildFailed) {
+		return "", fmt.Errorf("%w (full output: %s)", err, target.output.Path())
+	}
+
+	return resolvedHash, err
+}
+
+// createBuildLog starts the log of a build of target, or returns nil
+// when there are no build logs or one cannot be created.
+func (b *SourceBuilder) createBuildLog(target buildTarget) *buildlog.Log {
+	if b.buildLogs == nil {
+		return nil
+	}
+
+	buildLog, err := b.buildLogs.Create(target.label(), target.startedAt)
+	if err != nil {
+		log.Warnf("Not logging the build: %v", err)
+
+		return nil
+	}
+
+	buildLog.Markf(
+		"nvs %s building %s on %s/%s at %s",
+		b.appVersion,
+		target.label(),
+		runtime.GOOS,
+		runtime.GOARCH,
+		target.startedAt.UTC().Format(time.RFC3339),
+	)
+
+	return buildLog
+}
+
+// buildAttempts makes up to constants.MaxAttempts attempts at building
+// target, each in a clean directory.
+func (b *SourceBuilder) buildAttempts(
+	ctx context.Context,
+	target buildTarget,
 	dest string,
 	progress installer.ProgressFunc,
 ) (string, error) {
@@ -93,6 +311,8 @@ func (b *SourceBuilder) BuildFromCommit(
 		// is scoped to a single iteration. The defer fires on
 		// every exit path: success, context cancel, error,
 		// panic, or fall-through after the final attempt.
+		target.output.Attempt(attempt, constants.MaxAttempts)
+
 		resolvedHash, err = func() (string, error) {
 			// Check for required build tools on each attempt
 			checkErr := b.checkRequiredTools(ctx)
@@ -123,19 +343,25 @@ func (b *SourceBuilder) BuildFromCommit(
 				}
 			}()
 
-			return b.buildFromCommitInternal(ctx, commit, dest, localPath, progress)
+			return b.buildFromCommitInternal(ctx, target, dest, localPath, progress)
 		}()
 		if err == nil {
 			return resolvedHash, nil
 		}
 
 		log.Errorf("Build attempt %d failed: %v", attempt, err)
+		target.output.Markf("attempt %d failed: %v", attempt, err)
 
 		// Check for context cancellation - return immediately without retrying
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			return "", err
 		}
 
+		// Offline, a commit missing from the mirror stays missing.
+		if errors.Is(err, httpclient.ErrOffline) {
+			return "", err
+		}
+
 		if attempt < constants.MaxAttempts {
 			log.Info("Retrying build with clean directory...")
 
@@ -164,67 +390,13 @@ func (b *SourceBuilder) BuildFromCommit(
 // buildFromCommitInternal performs the actual build process.
 func (b *SourceBuilder) buildFromCommitInternal(
 	ctx context.Context,
-	commit, dest, localPath string,
+	target buildTarget,
+	dest, localPath string,
 	progress installer.ProgressFunc,
 ) (string, error) {
-	var err error
-
-	// Clone repository if needed
-	gitDir := filepath.Join(localPath, ".git")
-
-	_, err = os.Stat(gitDir)
-	if os.IsNotExist(err) {
-		// Ensure clean directory for clone
-		removeErr := os.RemoveAll(localPath)
-		if removeErr != nil {
-			log.Warnf("Failed to remove temp directory: %v", removeErr)
-		}
-
-		if progress != nil {
-			progress("Cloning repository (large repo, may take a while)", -1)
-		}
-
-		log.Debug("Cloning repository from ", constants.RepoURL)
-
-		cmd := b.execCommand(ctx, "git", "clone", "--quiet", constants.RepoURL, localPath)
-		cmd.SetStdout(os.Stdout)
-		cmd.SetStderr(os.Stderr)
-
-		err = cmd.Run()
-		if err != nil {
-			return "", fmt.Errorf("failed to clone repository: %w", err)
-		}
-	}
-
-	// Checkout commit or master
-	if commit == "master" {
-		if progress != nil {
-			progress("Checking out master branch", -1)
-		}
-
-		log.Debug("Checking out master branch")
-
-		checkoutCmd := b.execCommand(ctx, "git", "checkout", "--quiet", "master")
-		checkoutCmd.SetDir(localPath)
-
-		err = checkoutCmd.Run()
-		if err != nil {
-			return "", fmt.Errorf("failed to checkout master: %w", err)
-		}
-	} else {
-		if progress != nil {
-			progress("Checking out commit", -1)
-		}
-
-		log.Debugf("Checking out commit %s", commit)
-
-		checkoutCmd := b.execCommand(ctx, "git", "checkout", "--quiet", commit)
-		checkoutCmd.SetDir(localPath)
-
-		err = checkoutCmd.Run()
-		if err != nil {
-			return "", fmt.Errorf("failed to checkout commit %s: %w", commit, err)
-		}
+	err := b.prepareSource(ctx, target, localPath, progress)
+	if err != nil {
+		return "", err
 	}
 
 	// Get current commit hash
@@ -246,6 +418,7 @@ func (b *SourceBuilder) buildFromCommitInternal(
 
 	commitHash := commitHashFull[:constants.ShortCommitLen]
 	log.Debugf("Current commit hash: %s", commitHash)
+	target.output.Commit(commitHashFull)
 
 	// Clean build directory
 	buildPath := filepath.Join(localPath, "build")
@@ -260,19 +433,51 @@ func (b *SourceBuilder) buildFromCommitInternal(
 		}
 	}
 
-	// Build Neovim
-	log.Debug("Building Neovim")
+	// Build Neovim. The manifest records the profile's flags, not
+	// where the dependencies happened to come from.
+	buildFlags := target.profile.MakeArgs()
 
-	buildCmd := b.execCommand(ctx, "make", "CMAKE_BUILD_TYPE=Release")
+	makeArgs, releaseDeps, err := b.prepareDeps(ctx, target, localPath, buildFlags, progress)
+	if err != nil {
+		return "", err
+	}
+
+	// The cached dependency set must outlive the install below.
+	defer releaseDeps()
+
+	log.Debugf("Building Neovim with %s", strings.Join(makeArgs, " "))
+	target.output.Markf("make %s", strings.Join(makeArgs, " "))
+
+	buildCmd := b.execCommand(ctx, "make", makeArgs...)
 	buildCmd.SetDir(localPath)
 
-	err = runCommandWithProgress(ctx, buildCmd, progress, "Building Neovim")
+	err = runCommandWithProgress(ctx, buildCmd, progress, "Building Neovim", target.output)
 	if err != nil {
 		return "", fmt.Errorf("%w: %w", ErrBuildFailed, err)
 	}
 
-	// Create installation directory
-	targetDir := filepath.Join(dest, commitHash)
+	// Create installation directory. A ref build is installed into a
+	// hidden staging directory first and swapped into place at the
+	// end, so a failed rebuild leaves the previous build untouched.
+	installName := target.profile.InstallName(commitHash)
+	if !target.ref.IsZero() {
+		installName = target.ref.InstallName(target.profile)
+	}
+
+	targetDir := filepath.Join(dest, installName)
+	if !target.ref.IsZero() {
+		targetDir, err = os.MkdirTemp(dest, "."+installName+"-build-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create staging directory: %w", err)
+		}
+
+		defer func() {
+			removeErr := os.RemoveAll(targetDir)
+			if removeErr != nil {
+				log.Warnf("Failed to remove staging directory: %v", removeErr)
+			}
+		}()
+	}
 
 	err = os.MkdirAll(targetDir, constants.DirPerm)
 	if err != nil {
@@ -285,7 +490,9 @@ func (b *SourceBuilder) buildFromCommitInternal(
 	installCmd := b.execCommand(ctx, "cmake", "--install", "build", "--prefix="+targetDir)
 	installCmd.SetDir(localPath)
 
-	err = runCommandWithProgress(ctx, installCmd, progress, "Installing Neovim")
+	target.output.Markf("cmake --install build --prefix=%s", targetDir)
+
+	err = runCommandWithProgress(ctx, installCmd, progress, "Installing Neovim", target.output)
 	if err != nil {
 		return "", fmt.Errorf("cmake install failed: %w", err)
 	}
@@ -298,12 +505,35 @@ func (b *SourceBuilder) buildFromCommitInternal(
 		return "", fmt.Errorf("%w at %s", ErrBinaryNotFound, installedBinary)
 	}
 
-	// Write version file
-	versionFile := filepath.Join(targetDir, "version.txt")
+	// Write the install manifest
+	manifest := vtypes.Manifest{
+		Source:            vtypes.ManifestSourceBuild,
+		Identifier:        commitHashFull,
+		Commit:            commitHashFull,
+		InstalledAt:       target.startedAt.UTC(),
+		InstallDurationMS: time.Since(target.startedAt).Milliseconds(),
+		BuildFlags:        buildFlags,
+		NvsVersion:        b.appVersion,
+	}
+	if !target.ref.IsZero() {
+		manifest.Ref = target.ref.String()
+	}
 
-	err = os.WriteFile(versionFile, []byte(commitHashFull), constants.FilePerm)
+	if !target.profile.IsDefault() {
+		profile := target.profile
+		manifest.BuildProfile = &profile
+	}
+
+	err = filesystem.WriteManifest(targetDir, manifest)
 	if err != nil {
-		return "", fmt.Errorf("failed to write version file: %w", err)
+		return "", fmt.Errorf("failed to write install manifest: %w", err)
+	}
+
+	if !target.ref.IsZero() {
+		err = publishRefBuild(installName, targetDir, dest)
+		if err != nil {
+			return "", err
+		}
 	}
 
 	if progress != nil {
@@ -315,6 +545,148 @@ func (b *SourceBuilder) buildFromCommitInternal(
 	return commitHash, nil
 }
 
+// prepareSource puts a checkout of target at localPath: a worktree of
+// the source mirror if there is one, or else a fresh clone.
+func (b *SourceBuilder) prepareSource(
+	ctx context.Context,
+	target buildTarget,
+	localPath string,
+	progress installer.ProgressFunc,
+) error {
+	if b.mirrorDir != "" {
+		return b.checkoutFromMirror(ctx, target, localPath, progress)
+	}
+
+	// Clone repository if needed
+	gitDir := filepath.Join(localPath, ".git")
+
+	_, err := os.Stat(gitDir)
+	if os.IsNotExist(err) {
+		// Ensure clean directory for clone
+		removeErr := os.RemoveAll(localPath)
+		if removeErr != nil {
+			log.Warnf("Failed to remove temp directory: %v", removeErr)
+		}
+
+		if progress != nil {
+			progress("Cloning repository (large repo, may take a while)", -1)
+		}
+
+		log.Debug("Cloning repository from ", b.repoURL)
+
+		cmd := b.execCommand(ctx, "git", "clone", "--quiet", b.repoURL, localPath)
+		cmd.SetStdout(os.Stdout)
+		cmd.SetStderr(os.Stderr)
+
+		err = cmd.Run()
+		if err != nil {
+			return fmt.Errorf("failed to clone repository: %w", err)
+		}
+	}
+
+	return b.checkout(ctx, target, localPath, progress)
+}
+
+// checkout moves the clone at localPath to the target: "master", a
+// commit, or the fetched head of a branch or pull request.
+func (b *SourceBuilder) checkout(
+	ctx context.Context,
+	target buildTarget,
+	localPath string,
+	progress installer.ProgressFunc,
+) error {
+	switch {
+	case !target.ref.IsZero():
+		if progress != nil {
+			progress("Fetching "+target.ref.String(), -1)
+		}
+
+		log.Debugf("Fetching %s", target.ref.FetchRef())
+
+		// Pull request heads are not fetched by a plain clone, so
+		// fetch the ref explicitly and check out FETCH_HEAD; doing
+		// the same for branches keeps the two paths identical.
+		fetchCmd := b.execCommand(ctx, "git", "fetch", "--quiet", "origin", target.ref.FetchRef())
+		fetchCmd.SetDir(localPath)
+
+		err := fetchCmd.Run()
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", target.ref, err)
+		}
+
+		checkoutCmd := b.execCommand(ctx, "git", "checkout", "--quiet", "--detach", "FETCH_HEAD")
+		checkoutCmd.SetDir(localPath)
+
+		err = checkoutCmd.Run()
+		if err != nil {
+			return fmt.Errorf("failed to checkout %s: %w", target.ref, err)
+		}
+	case target.commit == "master":
+		if progress != nil {
+			progress("Checking out master branch", -1)
+		}
+
+		log.Debug("Checking out master branch")
+
+		checkoutCmd := b.execCommand(ctx, "git", "checkout", "--quiet", "master")
+		checkoutCmd.SetDir(localPath)
+
+		err := checkoutCmd.Run()
+		if err != nil {
+			return fmt.Errorf("failed to checkout master: %w", err)
+		}
+	default:
+		if progress != nil {
+			progress("Checking out commit", -1)
+		}
+
+		log.Debugf("Checking out commit %s", target.commit)
+
+		checkoutCmd := b.execCommand(ctx, "git", "checkout", "--quiet", target.commit)
+		checkoutCmd.SetDir(localPath)
+
+		err := checkoutCmd.Run()
+		if err != nil {
+			return fmt.Errorf("failed to checkout commit %s: %w", target.commit, err)
+		}
+	}
+
+	return nil
+}
+
+// publishRefBuild swaps the staged build into dest/installName. An
+// existing build is moved aside first and restored if the final rename
+// fails.
+func publishRefBuild(installName, stagingDir, dest string) error {
+	installDir := filepath.Join(dest, installName)
+	oldDir := stagingDir + "-old"
+
+	_, err := os.Stat(installDir)
+	if err == nil {
+		err = os.Rename(installDir, oldDir)
+		if err != nil {
+			return fmt.Errorf("failed to move previous build aside: %w", err)
+		}
+	}
+
+	err = os.Rename(stagingDir, installDir)
+	if err != nil {
+		restoreErr := os.Rename(oldDir, installDir)
+		if restoreErr != nil && !os.IsNotExist(restoreErr) {
+			log.Warnf("Failed to restore previous build: %v", restoreErr)
+		}
+
+		return fmt.Errorf("failed to install build: %w", err)
+	}
+
+	removeErr := os.RemoveAll(oldDir)
+	if removeErr != nil {
+		log.Warnf("Failed to remove previous build: %v", removeErr)
+	}
+
+	return nil
+}
+
 // checkRequiredTools verifies that all required build tools are available.
 func (b *SourceBuilder) checkRequiredTools(ctx context.Context) error {
 	requiredTools := []string{"git", "make", "cmake", "gettext", "ninja", "curl"}
@@ -462,15 +834,19 @@ func (b *SourceBuilder) cleanupTempDirectories() {
 	}
 }
 
-// runCommandWithProgress runs a command while updating progress with elapsed time.
+// runCommandWithProgress runs a command while updating progress with
+// elapsed time, copying its output to output (which may be nil).
 func runCommandWithProgress(
 	ctx context.Context,
 	cmd Commander,
 	progress installer.ProgressFunc,
 	phase string,
+	output *buildlog.Log,
 ) error {
+	output.Markf("%s", phase)
+
 	if progress == nil {
-		return runCommandWithSpinner(ctx, cmd)
+		return runCommandWithSpinner(ctx, cmd, output)
 	}
 
 	startTime := time.Now()
@@ -490,7 +866,7 @@ func runCommandWithProgress(
 	var lastMessage string
 
 	go func() {
-		done <- runCommandWithSpinnerAndOutput(ctx, cmd, func(line string) {
+		done <- runCommandWithSpinnerAndOutput(ctx, cmd, output, func(line string) {
 			// Show important cmake messages and error messages
 			isImportant := strings.HasPrefix(line, "-- ") &&
 				!strings.HasPrefix(line, "-- Looking for") &&
@@ -577,14 +953,17 @@ func runCommandWithProgress(
 }
 
 // runCommandWithSpinner runs a command while updating spinner with output.
-func runCommandWithSpinner(ctx context.Context, cmd Commander) error {
-	return runCommandWithSpinnerAndOutput(ctx, cmd, nil)
+func runCommandWithSpinner(ctx context.Context, cmd Commander, output io.Writer) error {
+	return runCommandWithSpinnerAndOutput(ctx, cmd, output, nil)
 }
 
-// runCommandWithSpinnerAndOutput runs a command while updating spinner with output.
+// runCommandWithSpinnerAndOutput runs a command while updating spinner
+// with output. Every line of stdout and stderr is also copied to
+// output when it is not nil.
 func runCommandWithSpinnerAndOutput(
 	ctx context.Context,
 	cmd Commander,
+	output io.Writer,
 	outputCallback func(string),
 ) error {
 	stdoutPipe, err := cmd.StdoutPipe()
@@ -632,7 +1011,7 @@ func runCommandWithSpinnerAndOutput(
 	// bufio.Scanner with an explicit max buffer handles partial
 	// reads and long lines correctly.
 	waitGroup.Go(func() {
-		streamLines(stdoutReader, "Build output", func(line string) {
+		streamLines(stdoutReader, "Build output", output, func(line string) {
 			if outputCallback != nil {
 				outputCallback(line)
 			}
@@ -640,7 +1019,7 @@ func runCommandWithSpinnerAndOutput(
 	})
 
 	waitGroup.Go(func() {
-		streamLines(stderrReader, "Build error", nil)
+		streamLines(stderrReader, "Build error", output, nil)
 	})
 
 	// Wait for command to complete
@@ -689,12 +1068,22 @@ func runCommandWithSpinnerAndOutput(
 // from the 64KB default to 1MB so long cmake/linker error lines are
 // not silently dropped.
 //
+// Each line, as it was, is also written to output when it is not nil;
+// a single Write per line keeps stdout and stderr lines whole.
+//
 // onLine may be nil; in that case lines are only logged.
-func streamLines(r io.Reader, label string, onLine func(string)) {
+func streamLines(r io.Reader, label string, output io.Writer, onLine func(string)) {
 	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, constants.BufferSize), constants.ScannerMaxLine)
 
 	for scanner.Scan() {
+		if output != nil {
+			_, writeErr := output.Write([]byte(scanner.Text() + "\n"))
+			if writeErr != nil {
+				log.Debugf("%s: failed to copy output: %v", label, writeErr)
+			}
+		}
+
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
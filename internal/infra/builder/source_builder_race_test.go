@@ -108,6 +108,7 @@ func TestRunCommandWithProgress_NoRaceOnLastMessage(t *testing.T) {
 			progressMu.Unlock()
 		},
 		"Testing",
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("runCommandWithProgress returned error: %v", err)
@@ -141,7 +142,7 @@ func TestRunCommandWithSpinnerAndOutput_KillsChildOnCancel(t *testing.T) {
 	done := make(chan error, 1)
 
 	go func() {
-		done <- runCommandWithSpinnerAndOutput(ctx, cmd, nil)
+		done <- runCommandWithSpinnerAndOutput(ctx, cmd, nil, nil)
 	}()
 
 	// Wait for Run() to start so we know we are exercising the
@@ -189,7 +190,7 @@ func TestStreamLines_PartialReads(t *testing.T) {
 
 	linesChan := make(chan string, 3)
 
-	streamLines(reader, "test", func(line string) {
+	streamLines(reader, "test", nil, func(line string) {
 		linesChan <- line
 	})
 
@@ -240,7 +241,7 @@ func TestStreamLines_LongLineAbove64KiB(t *testing.T) {
 
 	var gotMu sync.Mutex
 
-	streamLines(reader, "test", func(line string) {
+	streamLines(reader, "test", nil, func(line string) {
 		gotMu.Lock()
 		got = line
 		gotMu.Unlock()
@@ -0,0 +1,12 @@
+package buildlog
+
+import "errors"
+
+// Infrastructure errors for build logs.
+var (
+	// ErrInvalidLabel is returned when a build label cannot be part of a file name.
+	ErrInvalidLabel = errors.New("invalid build log label")
+
+	// ErrNotFound is returned when no build log matches.
+	ErrNotFound = errors.New("build log not found")
+)
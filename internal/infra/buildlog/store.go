@@ -0,0 +1,528 @@
+// Package buildlog keeps the full output of source builds on disk, so
+// a failed build can be inspected, or attached to a bug report, without
+// building again.
+//
+// Each build writes <dir>/<started>-<label>.log: the make and cmake
+// output of every attempt, between marker lines starting with
+// "== nvs: " that record the commit built, each attempt and the
+// result. Only the newest logs are kept. While a build runs, nvs holds
+// a lock on <log>.lock, so a log that is neither locked nor finished
+// belongs to a build that was interrupted.
+package buildlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+const (
+	// markerPrefix starts every line nvs writes into a log.
+	markerPrefix = "== nvs: "
+
+	attemptMarker = markerPrefix + "build attempt "
+	commitMarker  = markerPrefix + "commit "
+	resultMarker  = markerPrefix + "result: "
+
+	// stampLayout is the start time at the front of a log's name; it
+	// sorts by time and keeps builds in the same second apart.
+	stampLayout = "20060102T150405.000Z"
+
+	logExt = ".log"
+
+	// lockExt is added to a log's path for the lock held while the
+	// build is being written. It is a separate file because Windows
+	// locks would keep readers out of the log itself.
+	lockExt = ".lock"
+
+	// peekSize is how much of each end of a log List reads for the
+	// commit and result markers.
+	peekSize = 8 << 10
+)
+
+// Status is how a logged build ended.
+type Status string
+
+// Build statuses.
+const (
+	// StatusSucceeded is a build that was installed.
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed is a build that failed.
+	StatusFailed Status = "failed"
+	// StatusIncomplete is a build still running, or one nvs did not
+	// get to finish.
+	StatusIncomplete Status = "incomplete"
+)
+
+// failureLine matches the lines compilers, linkers, CMake and ninja
+// report a failure on; makeFailureLine the line make reports a failed
+// recipe on, which comes after the cause.
+var (
+	failureLine = regexp.MustCompile(
+		`error:|CMake Error|^FAILED:|undefined reference to|ld returned \d+ exit status`,
+	)
+	makeFailureLine = regexp.MustCompile(`\*\*\* .*Error \d+`)
+)
+
+// Store is a directory of build logs.
+type Store struct {
+	dir  string
+	keep int
+}
+
+// Entry describes one build log.
+type Entry struct {
+	// Name is the log's file name without the extension.
+	Name string
+	// Label is what was built, as it is installed: a short commit
+	// hash or ref directory, with the build profile's suffix.
+	Label string
+	// Commit is the full commit hash built, once it was resolved.
+	Commit  string
+	Started time.Time
+	Status  Status
+	Size    int64
+	Path    string
+}
+
+// New returns a store rooted at dir that keeps the newest keep logs.
+// A zero keep keeps every log.
+func New(dir string, keep int) *Store {
+	return &Store{
+		dir:  dir,
+		keep: keep,
+	}
+}
+
+// Dir returns the store's root directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Create starts the log of a build of label started at started, then
+// drops the oldest logs beyond the store's limit.
+func (s *Store) Create(label string, started time.Time) (*Log, error) {
+	if label == "" || label != filepath.Base(label) || strings.HasPrefix(label, ".") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLabel, label)
+	}
+
+	err := os.MkdirAll(s.dir, constants.DirPerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build log directory: %w", err)
+	}
+
+	name := started.UTC().Format(stampLayout) + "-" + label + logExt
+	path := filepath.Join(s.dir, name)
+
+	// Locked before the log exists, so a follower never sees a log
+	// that is being written without its lock.
+	lock := lockWriting(path)
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, constants.FilePerm)
+	if err != nil {
+		unlockWriting(lock, path)
+
+		return nil, fmt.Errorf("failed to create build log: %w", err)
+	}
+
+	s.prune(name)
+
+	return &Log{file: file, lock: lock, started: started}, nil
+}
+
+// Writing reports whether a build is still writing the log at path:
+// whether a live nvs process holds its lock. A stale lock, left by a
+// process that was killed, is removed.
+func Writing(path string) bool {
+	_, err := os.Stat(path + lockExt)
+	if err != nil {
+		return false
+	}
+
+	lock, err := tryLock(path)
+	if err != nil {
+		if errors.Is(err, filesystem.ErrLockTimeout) {
+			return true
+		}
+
+		log.Debugf("Failed to check the lock of build log %s: %v", path, err)
+
+		return false
+	}
+
+	unlockWriting(lock, path)
+
+	return false
+}
+
+// lockWriting locks the log at path as being written. A log that
+// could not be locked is still written, but looks interrupted to
+// 'nvs logs build --follow'.
+func lockWriting(path string) *filesystem.FileLock {
+	lock, err := tryLock(path)
+	if err != nil {
+		log.Debugf("Failed to lock build log %s: %v", path, err)
+
+		return nil
+	}
+
+	return lock
+}
+
+// tryLock takes the lock of the log at path if no one holds it,
+// without waiting.
+func tryLock(path string) (*filesystem.FileLock, error) {
+	// A canceled context only tries the lock once.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	lock := filesystem.NewFileLock(path + lockExt)
+
+	err := lock.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// unlockWriting releases and removes the lock of the log at path.
+func unlockWriting(lock *filesystem.FileLock, path string) {
+	if lock == nil {
+		return
+	}
+
+	err := lock.Unlock()
+	if err != nil {
+		log.Debugf("Failed to unlock build log %s: %v", path, err)
+	}
+
+	err = os.Remove(path + lockExt)
+	if err != nil && !os.IsNotExist(err) {
+		log.Debugf("Failed to remove the lock of build log %s: %v", path, err)
+	}
+}
+
+// List returns the logs, newest first.
+func (s *Store) List() ([]Entry, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read build logs: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(dirEntries))
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || filepath.Ext(dirEntry.Name()) != logExt {
+			continue
+		}
+
+		entry, entryErr := s.entry(dirEntry.Name())
+		if entryErr != nil {
+			log.Debugf("Skipping %s in build logs: %v", dirEntry.Name(), entryErr)
+
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return b.Started.Compare(a.Started)
+	})
+
+	return entries, nil
+}
+
+// Find returns the newest log of a build matching query: the log's
+// name or the label it was built under, else a label with a build
+// profile suffix or a prefix of the commit hash. An empty query
+// matches the newest log.
+func (s *Store) Find(query string) (Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	for _, entry := range entries {
+		if query == "" || entry.Name == query || entry.Label == query {
+			return entry, nil
+		}
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Label, query+"-") ||
+			(entry.Commit != "" && strings.HasPrefix(entry.Commit, query)) {
+			return entry, nil
+		}
+	}
+
+	if query == "" {
+		return Entry{}, fmt.Errorf("%w: no build has been logged", ErrNotFound)
+	}
+
+	return Entry{}, fmt.Errorf("%w for %s", ErrNotFound, query)
+}
+
+// prune removes the oldest logs beyond the limit, sparing the one just
+// created.
+func (s *Store) prune(created string) {
+	if s.keep <= 0 {
+		return
+	}
+
+	entries, err := s.List()
+	if err != nil {
+		log.Warnf("Failed to prune build logs: %v", err)
+
+		return
+	}
+
+	kept := 0
+
+	for _, entry := range entries {
+		if kept < s.keep || entry.Name+logExt == created {
+			kept++
+
+			continue
+		}
+
+		log.Debugf("Removing old build log %s", entry.Path)
+
+		err = os.Remove(entry.Path)
+		if err != nil && !os.IsNotExist(err) {
+			log.Warnf("Failed to remove build log %s: %v", entry.Path, err)
+		}
+
+		// A build killed while writing the log leaves its lock behind.
+		err = os.Remove(entry.Path + lockExt)
+		if err != nil && !os.IsNotExist(err) {
+			log.Debugf("Failed to remove the lock of build log %s: %v", entry.Path, err)
+		}
+	}
+}
+
+// entry describes the log file name, reading its commit and result
+// markers.
+func (s *Store) entry(fileName string) (Entry, error) {
+	name := strings.TrimSuffix(fileName, logExt)
+
+	stamp, label, found := strings.Cut(name, "-")
+	if !found || label == "" {
+		return Entry{}, fmt.Errorf("%w: %q", ErrInvalidLabel, name)
+	}
+
+	started, err := time.Parse(stampLayout, stamp)
+	if err != nil {
+		return Entry{}, fmt.Errorf("bad start time: %w", err)
+	}
+
+	path := filepath.Join(s.dir, fileName)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	head, tail, err := peek(path, info.Size())
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{
+		Name:    name,
+		Label:   label,
+		Started: started,
+		Status:  StatusIncomplete,
+		Size:    info.Size(),
+		Path:    path,
+	}
+
+	for line := range strings.Lines(head) {
+		commit, ok := strings.CutPrefix(strings.TrimSpace(line), commitMarker)
+		if ok {
+			entry.Commit = commit
+
+			break
+		}
+	}
+
+	for line := range strings.Lines(tail) {
+		result, ok := strings.CutPrefix(strings.TrimSpace(line), resultMarker)
+		if ok {
+			entry.Status = StatusFailed
+			if strings.HasPrefix(result, string(StatusSucceeded)) {
+				entry.Status = StatusSucceeded
+			}
+		}
+	}
+
+	return entry, nil
+}
+
+// peek returns the first and last peekSize bytes of the file at path.
+func peek(path string, size int64) (string, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	head := make([]byte, min(size, peekSize))
+
+	_, err = io.ReadFull(file, head)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read build log: %w", err)
+	}
+
+	if size <= peekSize {
+		return string(head), string(head), nil
+	}
+
+	tail := make([]byte, peekSize)
+
+	_, err = file.ReadAt(tail, size-peekSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", "", fmt.Errorf("failed to read build log: %w", err)
+	}
+
+	return string(head), string(tail), nil
+}
+
+// Log is the log of one build. Its methods are safe for concurrent
+// use, and a nil *Log discards everything, so a build that could not
+// open a log runs the same way.
+type Log struct {
+	mu      sync.Mutex
+	file    *os.File
+	lock    *filesystem.FileLock
+	started time.Time
+}
+
+// Path returns the log file's path.
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+
+	return l.file.Name()
+}
+
+// Write appends build output to the log.
+func (l *Log) Write(data []byte) (int, error) {
+	if l == nil {
+		return len(data), nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Write(data)
+}
+
+// Markf appends a line of nvs's own to the log.
+func (l *Log) Markf(format string, args ...any) {
+	_, err := l.Write([]byte(markerPrefix + fmt.Sprintf(format, args...) + "\n"))
+	if err != nil {
+		log.Debugf("Failed to write build log: %v", err)
+	}
+}
+
+// Attempt records the start of build attempt number of total.
+func (l *Log) Attempt(number, total int) {
+	l.Markf("build attempt %d of %d", number, total)
+}
+
+// Commit records the full hash of the commit being built.
+func (l *Log) Commit(hash string) {
+	l.Markf("commit %s", hash)
+}
+
+// Close records the result of the build, buildErr being nil for a
+// build that succeeded, closes the log and releases its lock.
+func (l *Log) Close(buildErr error) error {
+	if l == nil {
+		return nil
+	}
+
+	elapsed := time.Since(l.started).Round(time.Second)
+	if buildErr == nil {
+		l.Markf("result: %s in %v", StatusSucceeded, elapsed)
+	} else {
+		// errors.Join separates errors with newlines.
+		reason := strings.ReplaceAll(buildErr.Error(), "\n", "; ")
+		l.Markf("result: %s after %v: %s", StatusFailed, elapsed, reason)
+	}
+
+	err := l.file.Close()
+
+	unlockWriting(l.lock, l.file.Name())
+
+	return err
+}
+
+// IsResult reports whether line is the one a finished build's log
+// ends with.
+func IsResult(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), resultMarker)
+}
+
+// FailurePoint returns the index of the line the last attempt in the
+// log lines failed at, or -1 if none is recognized. It is the first
+// error reported by a compiler, linker, CMake or ninja, or else the
+// first failed make recipe.
+func FailurePoint(lines []string) int {
+	start := 0
+
+	for idx, line := range lines {
+		if strings.HasPrefix(line, attemptMarker) {
+			start = idx
+		}
+	}
+
+	fallback := -1
+
+	for idx := start; idx < len(lines); idx++ {
+		line := lines[idx]
+		if strings.HasPrefix(line, markerPrefix) {
+			continue
+		}
+
+		if failureLine.MatchString(line) {
+			return idx
+		}
+
+		if fallback < 0 && makeFailureLine.MatchString(line) {
+			fallback = idx
+		}
+	}
+
+	return fallback
+}
+
+// SplitLines splits log content into lines.
+func SplitLines(data []byte) []string {
+	lines := strings.Split(string(bytes.TrimRight(data, "\n")), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+
+	return lines
+}
@@ -0,0 +1,218 @@
+package buildlog_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/infra/buildlog"
+)
+
+// writeLog logs a build of label started at started that fails unless
+// buildErr is nil.
+func writeLog(
+	t *testing.T,
+	store *buildlog.Store,
+	label, commit string,
+	started time.Time,
+	buildErr error,
+) {
+	t.Helper()
+
+	buildLog, err := store.Create(label, started)
+	if err != nil {
+		t.Fatalf("Create(%s) error = %v", label, err)
+	}
+
+	buildLog.Attempt(1, 3)
+	buildLog.Commit(commit)
+	_, _ = fmt.Fprintln(buildLog, "[1/2] Building C object main.c.o")
+
+	err = buildLog.Close(buildErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore_ListAndFind(t *testing.T) {
+	t.Parallel()
+
+	store := buildlog.New(t.TempDir(), 2)
+	now := time.Now()
+
+	writeLog(t, store, "1111111", "1111111aaaa", now.Add(-3*time.Hour), nil)
+	writeLog(t, store, "abc1234", "abc1234ffff", now.Add(-2*time.Hour), nil)
+	writeLog(t, store, "abc1234-debug", "abc1234ffff", now.Add(-time.Hour), errors.New("exit 2"))
+
+	// Only the newest two are kept.
+	entries, err := store.List()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("List() = %+v, %v; want two logs", entries, err)
+	}
+
+	if entries[0].Label != "abc1234-debug" || entries[0].Status != buildlog.StatusFailed {
+		t.Errorf("newest entry = %+v, want the failed debug build", entries[0])
+	}
+
+	if entries[1].Status != buildlog.StatusSucceeded || entries[1].Commit != "abc1234ffff" {
+		t.Errorf("older entry = %+v, want the succeeded build of abc1234ffff", entries[1])
+	}
+
+	cases := map[string]string{
+		"":              "abc1234-debug",
+		"abc1234":       "abc1234",
+		"abc1234-debug": "abc1234-debug",
+		"abc1234f":      "abc1234-debug",
+	}
+
+	for query, want := range cases {
+		entry, findErr := store.Find(query)
+		if findErr != nil || entry.Label != want {
+			t.Errorf("Find(%q) = %s, %v; want %s", query, entry.Label, findErr, want)
+		}
+	}
+
+	_, err = store.Find("1111111")
+	if !errors.Is(err, buildlog.ErrNotFound) {
+		t.Errorf("Find(pruned) error = %v, want ErrNotFound", err)
+	}
+
+	_, err = store.Create("../escape", now)
+	if !errors.Is(err, buildlog.ErrInvalidLabel) {
+		t.Errorf("Create(../escape) error = %v, want ErrInvalidLabel", err)
+	}
+}
+
+func TestStore_Incomplete(t *testing.T) {
+	t.Parallel()
+
+	store := buildlog.New(t.TempDir(), 0)
+
+	buildLog, err := store.Create("master", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := store.Find("master")
+	if err != nil || entry.Status != buildlog.StatusIncomplete || entry.Path != buildLog.Path() {
+		t.Errorf("Find(master) = %+v, %v; want the running build", entry, err)
+	}
+
+	_ = buildLog.Close(nil)
+
+	// A nil log discards everything.
+	var discard *buildlog.Log
+
+	_, err = discard.Write([]byte("output"))
+	if err != nil || discard.Close(nil) != nil || discard.Path() != "" {
+		t.Error("nil *Log is not a no-op")
+	}
+}
+
+func TestWriting(t *testing.T) {
+	t.Parallel()
+
+	store := buildlog.New(t.TempDir(), 0)
+
+	buildLog, err := store.Create("master", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !buildlog.Writing(buildLog.Path()) {
+		t.Error("Writing() = false while the build is running")
+	}
+
+	_ = buildLog.Close(nil)
+
+	if buildlog.Writing(buildLog.Path()) {
+		t.Error("Writing() = true after the build finished")
+	}
+
+	// A build killed while running leaves its lock unheld.
+	stale := filepath.Join(store.Dir(), "20250101T000000.000Z-abc1234.log")
+
+	err = os.WriteFile(stale+".lock", nil, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buildlog.Writing(stale) {
+		t.Error("Writing() = true for a stale lock")
+	}
+
+	_, err = os.Stat(stale + ".lock")
+	if !os.IsNotExist(err) {
+		t.Errorf("stale lock not removed: %v", err)
+	}
+}
+
+func TestFailurePoint(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		lines []string
+		want  int
+	}{
+		{
+			name: "compiler error in the last attempt",
+			lines: []string{
+				"== nvs: build attempt 1 of 2",
+				"src/a.c:1:1: error: first attempt",
+				"== nvs: build attempt 2 of 2",
+				"-- Configuring done",
+				"src/nvim/eval.c:12:3: error: unknown type name 'foo'",
+				"make[2]: *** [src/nvim/eval.c.o] Error 1",
+			},
+			want: 4,
+		},
+		{
+			name: "make error only",
+			lines: []string{
+				"building",
+				"make: *** [Makefile:91: nvim] Error 2",
+			},
+			want: 1,
+		},
+		{
+			name: "nvs's own lines do not count",
+			lines: []string{
+				"== nvs: result: failed after 1s: build failed: error: x",
+			},
+			want: -1,
+		},
+	}
+
+	for _, tc := range cases {
+		got := buildlog.FailurePoint(tc.lines)
+		if got != tc.want {
+			t.Errorf("%s: FailurePoint() = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIsResult(t *testing.T) {
+	t.Parallel()
+
+	store := buildlog.New(t.TempDir(), 0)
+	writeLog(t, store, "abc1234", "abc1234ffff", time.Now(), nil)
+
+	entry, err := store.Find("abc1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := buildlog.SplitLines(data)
+	if len(lines) != 4 || !buildlog.IsResult(lines[3]) || buildlog.IsResult(lines[2]) {
+		t.Errorf("log lines = %q, want the result last", lines)
+	}
+}
@@ -0,0 +1,12 @@
+package depscache
+
+import "errors"
+
+// Infrastructure errors for the dependency cache.
+var (
+	// ErrInvalidKey is returned when a key is not one Key produces.
+	ErrInvalidKey = errors.New("invalid dependency set key")
+
+	// ErrFillFailed is returned when building a dependency set fails.
+	ErrFillFailed = errors.New("failed to build dependency set")
+)
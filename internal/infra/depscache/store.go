@@ -0,0 +1,376 @@
+// Package depscache keeps the third-party dependencies of source
+// builds (LuaJIT, libuv, tree-sitter, ...) on disk, so builds of
+// different commits that pin the same dependencies build them once.
+//
+// A dependency set is keyed by the hash of cmake.deps/deps.txt and the
+// dependency CMake flags, and lives at <dir>/<key>; make builds it
+// there directly (its DEPS_BUILD_DIR), because the installed files
+// record their absolute paths and cannot be moved afterwards. A set is
+// usable once its ready marker exists. The marker's modification time
+// records when the set was last used; Prune drops sets unused for
+// longer than the age limit, then the least recently used ones until
+// at most the set limit remain.
+package depscache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+const (
+	// keyLen is the length of a key in hex digits.
+	keyLen = 16
+	// readyFile marks a dependency set that was built completely.
+	readyFile = ".nvs-ready"
+	// lockSuffix is appended to a set's directory for its lock file.
+	lockSuffix = ".lock"
+)
+
+// Store is a cache of built dependency sets.
+type Store struct {
+	dir     string
+	maxSets int
+	maxAge  time.Duration
+}
+
+// Entry describes one dependency set.
+type Entry struct {
+	Key      string
+	Path     string
+	Size     int64
+	LastUsed time.Time
+
+	// Ready is false for a set whose build did not finish.
+	Ready bool
+}
+
+// New returns a store rooted at dir. A zero maxSets or maxAge disables
+// that limit.
+func New(dir string, maxSets int, maxAge time.Duration) *Store {
+	return &Store{
+		dir:     dir,
+		maxSets: maxSets,
+		maxAge:  maxAge,
+	}
+}
+
+// Key returns the key of the dependency set described by the content
+// of cmake.deps/deps.txt and the dependency CMake flags.
+func Key(depsTxt []byte, depsFlags string) string {
+	hash := sha256.New()
+	_, _ = hash.Write(depsTxt)
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write([]byte(depsFlags))
+
+	return hex.EncodeToString(hash.Sum(nil))[:keyLen]
+}
+
+// Dir returns the store's root directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Acquire returns the directory of the dependency set key, calling
+// build to create it there first unless it is ready. build gets an
+// empty directory; if it fails, the set is left incomplete and built
+// again next time.
+//
+// The set stays locked until release is called, so a build linking
+// against it must call release only once it is done with the set:
+// until then Prune and Clear skip it, and other processes acquiring
+// the same set wait.
+func (s *Store) Acquire(
+	ctx context.Context,
+	key string,
+	build func(dir string) error,
+) (string, func(), error) {
+	err := validateKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir := filepath.Join(s.dir, key)
+	lock := filesystem.NewFileLock(dir + lockSuffix)
+
+	err = lock.Lock(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	release := func() {
+		unlockErr := lock.Unlock()
+		if unlockErr != nil {
+			log.Warnf("Failed to unlock dependency set %s: %v", key, unlockErr)
+		}
+	}
+
+	if s.touch(dir) {
+		return dir, release, nil
+	}
+
+	err = s.fill(dir, build)
+	if err != nil {
+		release()
+
+		return "", nil, err
+	}
+
+	s.pruneAfterFill(key)
+
+	return dir, release, nil
+}
+
+// fill builds the set at dir from scratch and marks it ready.
+func (s *Store) fill(dir string, build func(dir string) error) error {
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return fmt.Errorf("failed to clear incomplete dependency set: %w", err)
+	}
+
+	err = os.MkdirAll(dir, constants.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to create dependency set: %w", err)
+	}
+
+	err = build(dir)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFillFailed, err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, readyFile), nil, constants.FilePerm)
+	if err != nil {
+		return fmt.Errorf("failed to mark dependency set ready: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the dependency sets, most recently used first.
+func (s *Store) List() ([]Entry, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read dependency cache: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(dirEntries))
+
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() || validateKey(dirEntry.Name()) != nil {
+			continue
+		}
+
+		entries = append(entries, s.entry(dirEntry.Name()))
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return b.LastUsed.Compare(a.LastUsed)
+	})
+
+	return entries, nil
+}
+
+// Prune removes incomplete sets, sets unused for longer than the age
+// limit, then the least recently used ones until the set limit is
+// met. Sets a build holds (see Acquire) are skipped. It returns the
+// removed entries.
+func (s *Store) Prune() ([]Entry, error) {
+	return s.prune("")
+}
+
+// Clear removes every dependency set no build holds and returns what
+// was removed.
+func (s *Store) Clear() ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		removed []Entry
+		errs    []error
+	)
+
+	for _, entry := range entries {
+		ok, removeErr := s.remove(entry.Key)
+		if removeErr != nil {
+			errs = append(errs, removeErr)
+
+			continue
+		}
+
+		if ok {
+			removed = append(removed, entry)
+		}
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// prune implements Prune, always keeping the set keep.
+func (s *Store) prune(keep string) ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		removed []Entry
+		kept    int
+		errs    []error
+	)
+
+	cutoff := time.Now().Add(-s.maxAge)
+
+	// Most recently used first: keep sets while there is room.
+	for _, entry := range entries {
+		expired := s.maxAge > 0 && entry.LastUsed.Before(cutoff)
+		overLimit := s.maxSets > 0 && kept >= s.maxSets
+
+		if entry.Key == keep || (entry.Ready && !expired && !overLimit) {
+			kept++
+
+			continue
+		}
+
+		ok, removeErr := s.remove(entry.Key)
+		if removeErr != nil {
+			errs = append(errs, removeErr)
+
+			continue
+		}
+
+		if ok {
+			removed = append(removed, entry)
+		}
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// pruneAfterFill prunes the store after a new set was built, keeping
+// that set.
+func (s *Store) pruneAfterFill(key string) {
+	removed, err := s.prune(key)
+	if err != nil {
+		log.Warnf("Failed to prune dependency cache: %v", err)
+	}
+
+	for _, entry := range removed {
+		log.Debugf("Pruned dependency set %s", entry.Key)
+	}
+}
+
+// remove deletes the set key unless another process holds its lock,
+// reporting whether it did.
+func (s *Store) remove(key string) (bool, error) {
+	dir := filepath.Join(s.dir, key)
+	lock := filesystem.NewFileLock(dir + lockSuffix)
+
+	// A context that is already done makes Lock give up as soon as it
+	// finds the lock busy.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := lock.WithLock(ctx, func() error {
+		return os.RemoveAll(dir)
+	})
+	if errors.Is(err, filesystem.ErrLockTimeout) {
+		log.Debugf("Dependency set %s is in use, keeping it", key)
+
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to remove dependency set %s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// touch marks the set at dir as used, reporting whether it is ready.
+func (s *Store) touch(dir string) bool {
+	marker := filepath.Join(dir, readyFile)
+
+	_, err := os.Stat(marker)
+	if err != nil {
+		return false
+	}
+
+	now := time.Now()
+
+	err = os.Chtimes(marker, now, now)
+	if err != nil {
+		log.Debugf("Failed to touch dependency set %s: %v", dir, err)
+	}
+
+	return true
+}
+
+// entry describes the set stored under key.
+func (s *Store) entry(key string) Entry {
+	dir := filepath.Join(s.dir, key)
+	entry := Entry{Key: key, Path: dir}
+
+	info, err := os.Stat(filepath.Join(dir, readyFile))
+	if err == nil {
+		entry.Ready = true
+		entry.LastUsed = info.ModTime()
+	} else {
+		dirInfo, statErr := os.Stat(dir)
+		if statErr == nil {
+			entry.LastUsed = dirInfo.ModTime()
+		}
+	}
+
+	entry.Size = dirSize(dir)
+
+	return entry
+}
+
+// dirSize returns the total size of the regular files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+
+	_ = filepath.WalkDir(dir, func(_ string, dirEntry fs.DirEntry, err error) error {
+		if err != nil || !dirEntry.Type().IsRegular() {
+			return nil //nolint:nilerr // an unreadable file does not count
+		}
+
+		info, infoErr := dirEntry.Info()
+		if infoErr == nil {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size
+}
+
+// validateKey checks key is one Key produces, which also keeps it safe
+// to use as a path component.
+func validateKey(key string) error {
+	_, err := hex.DecodeString(key)
+	if err != nil || len(key) != keyLen || strings.ToLower(key) != key {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return nil
+}
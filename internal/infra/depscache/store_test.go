@@ -0,0 +1,196 @@
+package depscache_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/infra/depscache"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+)
+
+// fill returns a build function that writes one file into the set and
+// counts its calls.
+func fill(calls *int) func(dir string) error {
+	return func(dir string) error {
+		*calls++
+
+		return os.WriteFile(filepath.Join(dir, "libuv.a"), []byte("lib"), 0o644)
+	}
+}
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	key := depscache.Key([]byte("LIBUV_SHA256 abc\n"), "")
+
+	if len(key) != 16 {
+		t.Errorf("Key() = %q, want 16 hex digits", key)
+	}
+
+	if depscache.Key([]byte("LIBUV_SHA256 abc\n"), "") != key {
+		t.Error("Key() is not stable")
+	}
+
+	if depscache.Key([]byte("LIBUV_SHA256 abc\n"), "-DUSE_BUNDLED_LUAJIT=OFF") == key {
+		t.Error("Key() ignores the dependency flags")
+	}
+}
+
+func TestStore_Acquire(t *testing.T) {
+	t.Parallel()
+
+	store := depscache.New(t.TempDir(), 0, 0)
+	key := depscache.Key([]byte("deps"), "")
+	calls := 0
+
+	dir, release, err := store.Acquire(t.Context(), key, fill(&calls))
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	release()
+
+	_, release, err = store.Acquire(t.Context(), key, fill(&calls))
+	if err == nil {
+		release()
+	}
+
+	if err != nil || calls != 1 {
+		t.Errorf("second Acquire() error = %v after %d builds, want the set reused", err, calls)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "libuv.a"))
+	if err != nil {
+		t.Errorf("built file missing: %v", err)
+	}
+
+	// A failed build leaves an incomplete set that is built again.
+	other := depscache.Key([]byte("other deps"), "")
+	boom := errors.New("boom")
+
+	_, _, err = store.Acquire(t.Context(), other, func(string) error { return boom })
+	if !errors.Is(err, depscache.ErrFillFailed) || !errors.Is(err, boom) {
+		t.Errorf("failed Acquire() error = %v, want ErrFillFailed wrapping the cause", err)
+	}
+
+	entries, err := store.List()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("List() = %+v, %v", entries, err)
+	}
+
+	for _, entry := range entries {
+		if entry.Ready != (entry.Key == key) {
+			t.Errorf("entry %s Ready = %v", entry.Key, entry.Ready)
+		}
+	}
+
+	_, release, err = store.Acquire(t.Context(), other, fill(&calls))
+	if err == nil {
+		release()
+	}
+
+	if err != nil || calls != 2 {
+		t.Errorf("Acquire() after a failure error = %v after %d builds", err, calls)
+	}
+
+	_, _, err = store.Acquire(t.Context(), "../../etc", fill(&calls))
+	if !errors.Is(err, depscache.ErrInvalidKey) {
+		t.Errorf("Acquire() with bad key error = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestStore_AcquireHoldsSet(t *testing.T) {
+	t.Parallel()
+
+	store := depscache.New(t.TempDir(), 0, 0)
+	key := depscache.Key([]byte("deps"), "")
+	calls := 0
+
+	dir, release, err := store.Acquire(t.Context(), key, fill(&calls))
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// A build still linking against the set keeps it from being removed.
+	removed, err := store.Clear()
+	if err != nil || len(removed) != 0 {
+		t.Errorf("Clear() while held = %+v, %v; want the set kept", removed, err)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "libuv.a"))
+	if err != nil {
+		t.Errorf("held set was removed: %v", err)
+	}
+
+	release()
+
+	removed, err = store.Clear()
+	if err != nil || len(removed) != 1 {
+		t.Errorf("Clear() after release = %+v, %v; want the set removed", removed, err)
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store := depscache.New(dir, 2, time.Hour)
+	calls := 0
+
+	keys := make([]string, 0, 3)
+
+	for idx, deps := range []string{"a", "b", "c"} {
+		key := depscache.Key([]byte(deps), "")
+		keys = append(keys, key)
+
+		_, release, err := store.Acquire(t.Context(), key, fill(&calls))
+		if err != nil {
+			t.Fatalf("Acquire(%s) error = %v", deps, err)
+		}
+
+		release()
+
+		// Give each set a distinct last-use time, oldest first.
+		stamp := time.Now().Add(time.Duration(idx-3) * time.Minute)
+		_ = os.Chtimes(filepath.Join(dir, key, ".nvs-ready"), stamp, stamp)
+	}
+
+	// Building the third set pruned the least recently used one.
+	entries, err := store.List()
+	if err != nil || len(entries) != 2 || entries[1].Key != keys[1] {
+		t.Errorf("List() = %+v, %v; want the oldest set pruned", entries, err)
+	}
+
+	// A set unused for longer than the age limit goes too, unless it
+	// is locked by a build.
+	old := time.Now().Add(-2 * time.Hour)
+	_ = os.Chtimes(filepath.Join(dir, keys[1], ".nvs-ready"), old, old)
+	_ = os.Chtimes(filepath.Join(dir, keys[2], ".nvs-ready"), old, old)
+
+	lock := filesystem.NewFileLock(filepath.Join(dir, keys[2]+".lock"))
+
+	err = lock.Lock(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := store.Prune()
+	if err != nil || len(removed) != 1 || removed[0].Key != keys[1] {
+		t.Errorf("Prune() = %+v, %v; want only the unlocked set removed", removed, err)
+	}
+
+	_ = lock.Unlock()
+
+	removed, err = store.Clear()
+	if err != nil || len(removed) != 1 {
+		t.Errorf("Clear() = %+v, %v; want one set removed", removed, err)
+	}
+
+	entries, err = store.List()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("List() after Clear() = %+v, %v", entries, err)
+	}
+}
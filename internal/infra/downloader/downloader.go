@@ -5,107 +5,395 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/archivestore"
 	"github.com/y3owk1n/nvs/internal/infra/httpclient"
+	"github.com/y3owk1n/nvs/internal/infra/mirror"
 	"github.com/y3owk1n/nvs/internal/log"
 )
 
+// maxSmallFileBytes bounds what FetchSmall reads into memory.
+const maxSmallFileBytes = 64 << 10
+
 // Downloader handles file downloads with progress tracking.
 type Downloader struct {
-	httpClient *http.Client
+	httpClient  *http.Client
+	offline     bool
+	localFiles  bool
+	store       *archivestore.Store
+	partialDir  string
+	connections int
+	mirrors     *mirror.List
+}
+
+// Option customizes a Downloader built by New.
+type Option func(*Downloader)
+
+// WithTimeout sets the overall timeout of a single download
+// (default constants.DefaultTimeout).
+func WithTimeout(timeout time.Duration) Option {
+	return func(d *Downloader) {
+		d.httpClient = httpclient.NewClient(timeout)
+	}
+}
+
+// WithOffline makes every download fail with httpclient.ErrOffline
+// instead of reaching the network.
+func WithOffline(offline bool) Option {
+	return func(d *Downloader) {
+		d.offline = offline
+	}
+}
+
+// WithLocalFiles lets downloads read file:// URLs, which only a local
+// release source should hand out. Otherwise they are refused, so a URL
+// from a remote server can never read local files.
+func WithLocalFiles(allow bool) Option {
+	return func(d *Downloader) {
+		d.localFiles = allow
+	}
+}
+
+// WithStore makes verified downloads go through an archive store: an
+// archive whose checksum is already stored is copied from disk, and a
+// fresh download is stored for next time.
+func WithStore(store *archivestore.Store) Option {
+	return func(d *Downloader) {
+		d.store = store
+	}
+}
+
+// WithPartialDir keeps interrupted downloads in dir, so a later run
+// resumes them with a Range request instead of starting over.
+func WithPartialDir(dir string) Option {
+	return func(d *Downloader) {
+		d.partialDir = dir
+	}
+}
+
+// WithConnections lets a download use up to n connections, each
+// fetching its own byte range, when the server accepts ranges. The
+// default of 1 keeps a single stream.
+func WithConnections(n int) Option {
+	return func(d *Downloader) {
+		d.connections = n
+	}
+}
+
+// WithMirrors fails a download over to the next of mirrors when the
+// current one has connection errors, 5xx responses or serves an
+// archive that does not match its checksum.
+func WithMirrors(mirrors *mirror.List) Option {
+	return func(d *Downloader) {
+		d.mirrors = mirrors
+	}
 }
 
 // New creates a new Downloader instance.
-func New() *Downloader {
-	return &Downloader{
+func New(opts ...Option) *Downloader {
+	downloader := &Downloader{
 		httpClient: httpclient.NewClient(constants.DefaultTimeout),
 	}
+
+	for _, opt := range opts {
+		opt(downloader)
+	}
+
+	if downloader.offline {
+		downloader.httpClient = httpclient.NewOfflineClient()
+	} else if downloader.mirrors != nil {
+		client := *downloader.httpClient
+		client.Transport = downloader.mirrors.Transport(client.Transport)
+		downloader.httpClient = &client
+	}
+
+	if downloader.localFiles {
+		downloader.httpClient = httpclient.AllowLocalFiles(downloader.httpClient)
+	}
+
+	return downloader
 }
 
 // ProgressFunc is a callback for download progress updates.
 type ProgressFunc func(percent int)
 
 // Download downloads a file from the given URL to the destination file.
+// Failed or interrupted transfers are retried, resuming from the bytes
+// already received.
 func (d *Downloader) Download(
 	ctx context.Context,
 	url string,
 	dest *os.File,
 	progress ProgressFunc,
+) error {
+	return d.withFailover(url, func(rewrite func(string) string) error {
+		return d.download(ctx, rewrite(url), dest, progress)
+	})
+}
+
+func (d *Downloader) download(
+	ctx context.Context,
+	url string,
+	dest *os.File,
+	progress ProgressFunc,
 ) error {
 	log.Debugf("Downloading from URL: %s", url)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	part, err := d.openPartial(url, dest)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
 
-	req.Header.Set("User-Agent", "nvs")
+	defer part.close()
 
-	resp, err := d.httpClient.Do(req)
+	_, err = d.fetch(ctx, url, part, progress)
 	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
+		return err
 	}
 
-	defer func() {
-		err := resp.Body.Close()
-		if err != nil {
-			log.Warnf("failed to close response body: %v", err)
-		}
-	}()
+	return part.finish(dest)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%w: status %d", ErrDownloadFailed, resp.StatusCode)
+// DownloadWithChecksumVerification downloads a file, verifies its checksum
+// and returns the verified SHA256 in lowercase hex. The hash is computed in
+// a single pass during download, so callers need not read the file again
+// to get it; a resumed download seeds it with the bytes already on disk.
+//
+// A non-empty cacheKey names the file's content for good, such as an
+// asset of a fixed release tag: once a download under it is stored, the
+// next is copied from the store without fetching the checksum, so it
+// works offline.
+func (d *Downloader) DownloadWithChecksumVerification(
+	ctx context.Context,
+	url string,
+	checksumURL string,
+	assetName string,
+	cacheKey string,
+	dest *os.File,
+	progress ProgressFunc,
+) (string, error) {
+	sha, ok := d.copyFromStoreByKey(cacheKey, dest, progress)
+	if ok {
+		return sha, nil
 	}
 
-	totalSize := resp.ContentLength
-	progressReader := &progressReader{
-		reader:   resp.Body,
-		total:    totalSize,
-		callback: progress,
-	}
+	err := d.withFailover(url, func(rewrite func(string) string) error {
+		var downloadErr error
+
+		sha, downloadErr = d.downloadVerified(
+			ctx,
+			rewrite(url),
+			rewrite(checksumURL),
+			assetName,
+			cacheKey,
+			dest,
+			progress,
+		)
 
-	_, err = io.Copy(dest, progressReader)
+		return downloadErr
+	})
 	if err != nil {
-		return fmt.Errorf("failed to copy download content: %w", err)
+		return "", err
 	}
 
-	return nil
+	return sha, nil
 }
 
-// DownloadWithChecksumVerification downloads a file and verifies its checksum.
-// The hash is computed in a single pass during download, avoiding a separate
-// file read to compute the hash afterwards.
-func (d *Downloader) DownloadWithChecksumVerification(
+func (d *Downloader) downloadVerified(
 	ctx context.Context,
 	url string,
 	checksumURL string,
 	assetName string,
+	cacheKey string,
 	dest *os.File,
 	progress ProgressFunc,
-) error {
+) (string, error) {
 	log.Debugf("Downloading from URL: %s", url)
 
 	expectedHash, err := d.fetchExpectedHash(ctx, checksumURL, assetName)
 	if err != nil {
+		return "", err
+	}
+
+	expectedHash = strings.ToLower(expectedHash)
+
+	if d.copyFromStore(expectedHash, dest, progress) {
+		d.rememberKey(cacheKey, expectedHash)
+
+		return expectedHash, nil
+	}
+
+	part, err := d.openPartial(url, dest)
+	if err != nil {
+		return "", err
+	}
+
+	defer part.close()
+
+	actualHash, err := d.fetch(ctx, url, part, progress)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.EqualFold(actualHash, expectedHash) {
+		part.discard()
+
+		_ = dest.Truncate(0)
+		_, _ = dest.Seek(0, io.SeekStart)
+
+		return "", fmt.Errorf(
+			"%w: expected %s, got %s",
+			ErrChecksumMismatch,
+			expectedHash,
+			actualHash,
+		)
+	}
+
+	err = part.finish(dest)
+	if err != nil {
+		return "", err
+	}
+
+	_, _ = dest.Seek(0, io.SeekStart)
+
+	if d.store != nil {
+		storeErr := d.store.Put(expectedHash, assetName, dest)
+		if storeErr != nil {
+			log.Warnf("Failed to cache downloaded archive: %v", storeErr)
+		} else {
+			d.rememberKey(cacheKey, expectedHash)
+		}
+
+		_, _ = dest.Seek(0, io.SeekStart)
+	}
+
+	return expectedHash, nil
+}
+
+// fetch downloads url into part, retrying transient failures with
+// exponential backoff. Each retry resumes from the bytes already on
+// disk. It returns the hex SHA256 of the complete content.
+func (d *Downloader) fetch(
+	ctx context.Context,
+	url string,
+	part *partial,
+	progress ProgressFunc,
+) (string, error) {
+	if d.connections > 1 {
+		sum, handled, err := d.fetchParallel(ctx, url, part, progress)
+		if handled {
+			return sum, err
+		}
+	}
+
+	var sum string
+
+	err := withRetry(ctx, func() error {
+		var err error
+
+		sum, err = d.fetchOnce(ctx, url, part, progress)
+
 		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return sum, nil
+}
+
+// withRetry runs attempt until it succeeds, fails with an error that
+// is not a *retryableError, or the retry budget is spent. It backs off
+// exponentially between attempts, or as long as the server asks.
+func withRetry(ctx context.Context, attempt func() error) error {
+	var lastErr error
+
+	for try := 0; try <= constants.MaxDownloadRetries; try++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+
+		var retryErr *retryableError
+		if !errors.As(err, &retryErr) {
+			return err
+		}
+
+		lastErr = retryErr
+
+		if try == constants.MaxDownloadRetries {
+			break
+		}
+
+		delay := constants.DownloadInitialBackoff << try
+		if retryErr.resp != nil {
+			delay = httpclient.RetryAfterDelay(retryErr.resp, delay)
+		}
+
+		log.Debugf("Download attempt %d failed: %v; retrying in %s", try+1, lastErr, delay)
+		httpclient.SleepWithContext(ctx, delay)
+
+		ctxErr := ctx.Err()
+		if ctxErr != nil {
+			return fmt.Errorf("failed to download file: %w", ctxErr)
+		}
+	}
+
+	return fmt.Errorf(
+		"failed to download file after %d attempts: %w",
+		constants.MaxDownloadRetries+1,
+		lastErr,
+	)
+}
+
+// fetchOnce makes one request for url, asking only for the bytes part
+// lacks when it already holds some. Failures worth another attempt are
+// returned as a *retryableError.
+func (d *Downloader) fetchOnce(
+	ctx context.Context,
+	url string,
+	part *partial,
+	progress ProgressFunc,
+) (string, error) {
+	offset, err := part.size()
+	if err != nil {
+		return "", err
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", "nvs")
 
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+		validator := part.meta.validator()
+		if validator != "" {
+			req.Header.Set("If-Range", validator)
+		}
+
+		log.Debugf("Resuming download at byte %d", offset)
+	}
+
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
+		err = fmt.Errorf("failed to download file: %w", err)
+		if httpclient.IsRetriableNetError(err) {
+			return "", &retryableError{err: err}
+		}
+
+		return "", err
 	}
 
 	defer func() {
@@ -115,36 +403,198 @@ func (d *Downloader) DownloadWithChecksumVerification(
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%w: status %d", ErrDownloadFailed, resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// A full body: the server ignored the range, or the file
+		// changed since the partial was written.
+		offset = 0
+
+		err = part.reset()
+		if err != nil {
+			return "", err
+		}
+
+		part.remember(resp)
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		contentRange := resp.Header.Get("Content-Range")
+		if !strings.HasPrefix(contentRange, fmt.Sprintf("bytes %d-", offset)) {
+			return "", restartDownload(part, fmt.Errorf(
+				"%w: unexpected Content-Range %q",
+				ErrDownloadFailed,
+				contentRange,
+			))
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		return "", restartDownload(part, fmt.Errorf(
+			"%w: status %d",
+			ErrDownloadFailed,
+			resp.StatusCode,
+		))
+	case httpclient.RetriableStatus(resp.StatusCode):
+		return "", &retryableError{
+			err:  fmt.Errorf("%w: status %d", ErrDownloadFailed, resp.StatusCode),
+			resp: resp,
+		}
+	default:
+		return "", fmt.Errorf("%w: status %d", ErrDownloadFailed, resp.StatusCode)
 	}
 
-	totalSize := resp.ContentLength
+	// Seed the hash with what is already on disk, so verification
+	// still covers the whole file without reading it again later.
 	hasher := sha256.New()
 
+	_, err = part.file.Seek(0, io.SeekStart)
+	if err == nil {
+		_, err = io.CopyN(hasher, part.file, offset)
+	}
+
+	if err != nil {
+		return "", restartDownload(part, fmt.Errorf("failed to read partial download: %w", err))
+	}
+
+	total := resp.ContentLength
+	if total > 0 {
+		total += offset
+	}
+
 	progressReader := &progressReader{
 		reader:   io.TeeReader(resp.Body, hasher),
-		total:    totalSize,
+		total:    total,
+		read:     offset,
 		callback: progress,
 	}
 
-	_, err = io.Copy(dest, progressReader)
+	_, err = io.Copy(part.file, progressReader)
+	if err != nil {
+		err = fmt.Errorf("failed to copy download content: %w", err)
+		if httpclient.IsRetriableNetError(err) {
+			return "", &retryableError{err: err}
+		}
+
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// restartDownload drops what part holds and marks err as worth a
+// fresh attempt from byte zero.
+func restartDownload(part *partial, err error) error {
+	resetErr := part.reset()
+	if resetErr != nil {
+		return errors.Join(err, resetErr)
+	}
+
+	return &retryableError{err: err}
+}
+
+// retryableError is a failed download attempt worth repeating. resp,
+// when set, carries the server's retry hints; its body is closed.
+type retryableError struct {
+	err  error
+	resp *http.Response
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// copyFromStore copies the stored archive with checksum expectedHash
+// into dest, verifying it on the way. It reports false, leaving dest
+// empty, when there is no store, no such archive, or the stored copy
+// no longer matches (it is evicted then).
+func (d *Downloader) copyFromStore(
+	expectedHash string,
+	dest *os.File,
+	progress ProgressFunc,
+) bool {
+	if d.store == nil {
+		return false
+	}
+
+	path, err := d.store.Get(expectedHash)
+	if err != nil {
+		log.Debugf("Archive cache miss: %v", err)
+
+		return false
+	}
+
+	cached, err := os.Open(path) //nolint:gosec // path comes from the store
 	if err != nil {
-		return fmt.Errorf("failed to copy download content: %w", err)
+		log.Debugf("Failed to open cached archive: %v", err)
+
+		return false
 	}
 
+	defer func() { _ = cached.Close() }()
+
+	hasher := sha256.New()
+
+	_, err = io.Copy(dest, io.TeeReader(cached, hasher))
 	actualHash := hex.EncodeToString(hasher.Sum(nil))
 
-	if !strings.EqualFold(actualHash, expectedHash) {
+	if err != nil || !strings.EqualFold(actualHash, expectedHash) {
+		log.Warnf("Cached archive %s is unusable; downloading again", path)
+
+		removeErr := d.store.Remove(expectedHash)
+		if removeErr != nil {
+			log.Warnf("Failed to evict cached archive: %v", removeErr)
+		}
+
 		_ = dest.Truncate(0)
 		_, _ = dest.Seek(0, io.SeekStart)
 
-		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expectedHash, actualHash)
+		return false
 	}
 
 	_, _ = dest.Seek(0, io.SeekStart)
 
-	return nil
+	log.Debugf("Using cached archive %s", path)
+
+	if progress != nil {
+		progress(constants.ProgressComplete)
+	}
+
+	return true
+}
+
+// copyFromStoreByKey copies the stored archive remembered under
+// cacheKey into dest, like copyFromStore, and returns its SHA256. It
+// reports false when the key is empty or not remembered.
+func (d *Downloader) copyFromStoreByKey(
+	cacheKey string,
+	dest *os.File,
+	progress ProgressFunc,
+) (string, bool) {
+	if d.store == nil || cacheKey == "" {
+		return "", false
+	}
+
+	expectedHash, err := d.store.Lookup(cacheKey)
+	if err != nil {
+		log.Debugf("Archive cache miss: %v", err)
+
+		return "", false
+	}
+
+	return expectedHash, d.copyFromStore(expectedHash, dest, progress)
+}
+
+// rememberKey records in the store that cacheKey names the archive
+// with checksum expectedHash, if the key is not empty.
+func (d *Downloader) rememberKey(cacheKey, expectedHash string) {
+	if d.store == nil || cacheKey == "" {
+		return
+	}
+
+	err := d.store.Remember(cacheKey, expectedHash)
+	if err != nil {
+		log.Warnf("Failed to remember cached archive: %v", err)
+	}
 }
 
 // VerifyChecksum verifies the file's SHA256 hash against a downloaded checksum file.
@@ -185,6 +635,49 @@ func (d *Downloader) VerifyChecksum(
 	return nil
 }
 
+// FetchSmall downloads a small companion file, such as a detached
+// signature, into memory. Bodies over maxSmallFileBytes are rejected.
+func (d *Downloader) FetchSmall(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "nvs")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			log.Warnf("failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s: status %d", ErrDownloadFailed, url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSmallFileBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+
+	if len(data) > maxSmallFileBytes {
+		return nil, fmt.Errorf(
+			"%w: %s is larger than %d bytes",
+			ErrDownloadFailed,
+			url,
+			maxSmallFileBytes,
+		)
+	}
+
+	return data, nil
+}
+
 func (d *Downloader) fetchExpectedHash(
 	ctx context.Context,
 	checksumURL string,
@@ -199,7 +692,12 @@ func (d *Downloader) fetchExpectedHash(
 
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to download checksum: %w", err)
+		err = fmt.Errorf("failed to download checksum: %w", err)
+		if httpclient.IsRetriableNetError(err) {
+			return "", &retryableError{err: err}
+		}
+
+		return "", err
 	}
 
 	defer func() {
@@ -210,7 +708,12 @@ func (d *Downloader) fetchExpectedHash(
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("%w: status %d", ErrChecksumDownloadFailed, resp.StatusCode)
+		err = fmt.Errorf("%w: status %d", ErrChecksumDownloadFailed, resp.StatusCode)
+		if httpclient.RetriableStatus(resp.StatusCode) {
+			return "", &retryableError{err: err}
+		}
+
+		return "", err
 	}
 
 	checksumData, err := io.ReadAll(resp.Body)
@@ -1,15 +1,26 @@
 package downloader_test
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/y3owk1n/nvs/internal/infra/archivestore"
 	"github.com/y3owk1n/nvs/internal/infra/downloader"
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
+	"github.com/y3owk1n/nvs/internal/infra/mirror"
 )
 
 // TestDownloader_Download tests the Download function with a mock HTTP server.
@@ -107,6 +118,81 @@ func TestDownloader_Download_HTTPError(t *testing.T) {
 	}
 }
 
+// TestDownloader_Download_Offline tests that an offline downloader
+// never sends the request.
+func TestDownloader_Download_Offline(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+			requested = true
+		}),
+	)
+	defer server.Close()
+
+	downloaderInstance := downloader.New(downloader.WithOffline(true))
+
+	tempFile, err := os.CreateTemp(t.TempDir(), "download-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	defer func() {
+		err := tempFile.Close()
+		if err != nil {
+			t.Logf("close error: %v", err)
+		}
+	}()
+
+	err = downloaderInstance.Download(t.Context(), server.URL, tempFile, nil)
+	if !errors.Is(err, httpclient.ErrOffline) {
+		t.Errorf("Download() error = %v, want ErrOffline", err)
+	}
+
+	if requested {
+		t.Error("Download() reached the server in offline mode")
+	}
+}
+
+// TestDownloader_Download_LocalFiles tests that file:// URLs are only
+// read when a local release source allows them.
+func TestDownloader_Download_LocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "secret")
+
+	err := os.WriteFile(source, []byte("local content"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slashed := filepath.ToSlash(source)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+
+	fileURL := "file://" + slashed
+
+	for _, allow := range []bool{false, true} {
+		downloaderInstance := downloader.New(downloader.WithLocalFiles(allow))
+
+		dest, createErr := os.Create(filepath.Join(dir, "dest-"+strconv.FormatBool(allow)))
+		if createErr != nil {
+			t.Fatal(createErr)
+		}
+
+		err = downloaderInstance.Download(t.Context(), fileURL, dest, nil)
+		_ = dest.Close()
+
+		got, _ := os.ReadFile(dest.Name())
+
+		switch {
+		case allow && (err != nil || string(got) != "local content"):
+			t.Errorf("Download() with local files = %q, %v; want the file", got, err)
+		case !allow && (!errors.Is(err, httpclient.ErrLocalFile) || len(got) != 0):
+			t.Errorf("Download() without local files = %q, %v; want an error", got, err)
+		}
+	}
+}
+
 // TestDownloader_Download_ContextCancellation tests Download with canceled context.
 func TestDownloader_Download_ContextCancellation(t *testing.T) {
 	server := httptest.NewServer(
@@ -360,11 +446,12 @@ func TestDownloader_DownloadWithChecksumVerification(t *testing.T) {
 		progressUpdates = append(progressUpdates, percent)
 	}
 
-	err = downloaderInstance.DownloadWithChecksumVerification(
+	sha, err := downloaderInstance.DownloadWithChecksumVerification(
 		ctx,
 		server.URL,
 		checksumServer.URL,
 		"test-file.tar.gz",
+		"",
 		tempFile,
 		progressFn,
 	)
@@ -372,6 +459,10 @@ func TestDownloader_DownloadWithChecksumVerification(t *testing.T) {
 		t.Fatalf("DownloadWithChecksumVerification() error = %v", err)
 	}
 
+	if sha != expectedHash {
+		t.Errorf("DownloadWithChecksumVerification() = %q, want the SHA256 %q", sha, expectedHash)
+	}
+
 	_, _ = tempFile.Seek(0, 0)
 
 	content, err := os.ReadFile(tempFile.Name())
@@ -423,11 +514,12 @@ func TestDownloader_DownloadWithChecksumVerification_Mismatch(t *testing.T) {
 		}
 	}()
 
-	err = downloaderInstance.DownloadWithChecksumVerification(
+	_, err = downloaderInstance.DownloadWithChecksumVerification(
 		ctx,
 		server.URL,
 		checksumServer.URL,
 		"test-file.tar.gz",
+		"",
 		tempFile,
 		nil,
 	)
@@ -471,11 +563,12 @@ func TestDownloader_DownloadWithChecksumVerification_HTTPError(t *testing.T) {
 		}
 	}()
 
-	err = downloaderInstance.DownloadWithChecksumVerification(
+	_, err = downloaderInstance.DownloadWithChecksumVerification(
 		ctx,
 		server.URL,
 		checksumServer.URL,
 		"test-file.tar.gz",
+		"",
 		tempFile,
 		nil,
 	)
@@ -515,11 +608,12 @@ func TestDownloader_DownloadWithChecksumVerification_ChecksumHTTPError(t *testin
 		}
 	}()
 
-	err = downloaderInstance.DownloadWithChecksumVerification(
+	_, err = downloaderInstance.DownloadWithChecksumVerification(
 		ctx,
 		server.URL,
 		checksumServer.URL,
 		"test-file.tar.gz",
+		"",
 		tempFile,
 		nil,
 	)
@@ -566,11 +660,12 @@ func TestDownloader_DownloadWithChecksumVerification_ContextCancellation(t *test
 		}
 	}()
 
-	err = downloaderInstance.DownloadWithChecksumVerification(
+	_, err = downloaderInstance.DownloadWithChecksumVerification(
 		ctx,
 		server.URL,
 		checksumServer.URL,
 		"test-file.tar.gz",
+		"",
 		tempFile,
 		nil,
 	)
@@ -578,3 +673,520 @@ func TestDownloader_DownloadWithChecksumVerification_ContextCancellation(t *test
 		t.Error("DownloadWithChecksumVerification() expected error for canceled context, got nil")
 	}
 }
+
+// TestDownloader_DownloadWithChecksumVerification_Store tests that a
+// verified download is stored and a repeat is served from the store,
+// and that a corrupted stored copy is replaced by a fresh download.
+func TestDownloader_DownloadWithChecksumVerification_Store(t *testing.T) {
+	expectedContent := "archive content"
+
+	hasher := sha256.New()
+	hasher.Write([]byte(expectedContent))
+	expectedHash := hex.EncodeToString(hasher.Sum(nil))
+
+	downloads := 0
+	server := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+			downloads++
+
+			_, _ = responseWriter.Write([]byte(expectedContent))
+		}),
+	)
+	defer server.Close()
+
+	checksumServer := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+			_, _ = responseWriter.Write([]byte(expectedHash + "  test-file.tar.gz"))
+		}),
+	)
+	defer checksumServer.Close()
+
+	store := archivestore.New(t.TempDir(), 0, 0)
+	downloaderInstance := downloader.New(downloader.WithStore(store))
+
+	download := func() string {
+		tempFile, err := os.CreateTemp(t.TempDir(), "download-store-test-*")
+		if err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+
+		defer func() { _ = tempFile.Close() }()
+
+		sha, err := downloaderInstance.DownloadWithChecksumVerification(
+			t.Context(),
+			server.URL,
+			checksumServer.URL,
+			"test-file.tar.gz",
+			"",
+			tempFile,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("DownloadWithChecksumVerification() error = %v", err)
+		}
+
+		if sha != expectedHash {
+			t.Errorf("DownloadWithChecksumVerification() = %q, want %q", sha, expectedHash)
+		}
+
+		content, err := os.ReadFile(tempFile.Name())
+		if err != nil {
+			t.Fatalf("Failed to read temp file: %v", err)
+		}
+
+		return string(content)
+	}
+
+	for range 2 {
+		content := download()
+		if content != expectedContent {
+			t.Errorf("content = %q, want %q", content, expectedContent)
+		}
+	}
+
+	if downloads != 1 {
+		t.Errorf("archive downloaded %d times, want 1", downloads)
+	}
+
+	// Corrupt the stored copy: it must fail verification and be
+	// downloaded again.
+	path, err := store.Get(expectedHash)
+	if err != nil {
+		t.Fatalf("archive was not stored: %v", err)
+	}
+
+	err = os.WriteFile(path, []byte("tampered"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := download()
+	if content != expectedContent || downloads != 2 {
+		t.Errorf("after corruption: content = %q, downloads = %d", content, downloads)
+	}
+}
+
+// TestDownloader_DownloadWithChecksumVerification_StoreByKey tests
+// that an archive stored under a cache key is reused without fetching
+// its checksum, so a reinstall works offline.
+func TestDownloader_DownloadWithChecksumVerification_StoreByKey(t *testing.T) {
+	expectedContent := "archive content"
+	sum := sha256.Sum256([]byte(expectedContent))
+	expectedHash := hex.EncodeToString(sum[:])
+
+	requests := 0
+	server := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+			requests++
+
+			if strings.HasSuffix(r.URL.Path, ".sha256") {
+				_, _ = responseWriter.Write([]byte(expectedHash + "  test-file.tar.gz"))
+
+				return
+			}
+
+			_, _ = responseWriter.Write([]byte(expectedContent))
+		}),
+	)
+	defer server.Close()
+
+	store := archivestore.New(t.TempDir(), 0, 0)
+
+	download := func(instance *downloader.Downloader, cacheKey string) (string, error) {
+		tempFile, err := os.CreateTemp(t.TempDir(), "download-key-test-*")
+		if err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+
+		defer func() { _ = tempFile.Close() }()
+
+		_, err = instance.DownloadWithChecksumVerification(
+			t.Context(),
+			server.URL+"/test-file.tar.gz",
+			server.URL+"/test-file.tar.gz.sha256",
+			"test-file.tar.gz",
+			cacheKey,
+			tempFile,
+			nil,
+		)
+		if err != nil {
+			return "", err
+		}
+
+		content, err := os.ReadFile(tempFile.Name())
+
+		return string(content), err
+	}
+
+	_, err := download(downloader.New(downloader.WithStore(store)), "v0.10.0")
+	if err != nil || requests != 2 {
+		t.Fatalf("first download: error = %v after %d requests", err, requests)
+	}
+
+	offline := downloader.New(downloader.WithStore(store), downloader.WithOffline(true))
+
+	content, err := download(offline, "v0.10.0")
+	if err != nil || content != expectedContent {
+		t.Errorf("offline download by key = %q, %v; want the stored archive", content, err)
+	}
+
+	_, err = download(offline, "v0.11.0")
+	if !errors.Is(err, httpclient.ErrOffline) {
+		t.Errorf("offline download of another key error = %v, want ErrOffline", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("%d requests, want the stored archive reused without any", requests)
+	}
+}
+
+// TestDownloader_Download_RetriesWithRange tests that a transfer cut
+// off mid-body is retried with a Range request for the rest.
+func TestDownloader_Download_RetriesWithRange(t *testing.T) {
+	expectedContent := strings.Repeat("0123456789", 100)
+
+	var ranges []string
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+			ranges = append(ranges, r.Header.Get("Range"))
+			responseWriter.Header().Set("ETag", `"v1"`)
+
+			if len(ranges) == 1 {
+				// Promise the whole body, then drop the connection.
+				responseWriter.Header().Set("Content-Length", strconv.Itoa(len(expectedContent)))
+				_, _ = responseWriter.Write([]byte(expectedContent[:400]))
+
+				return
+			}
+
+			http.ServeContent(
+				responseWriter,
+				r,
+				"",
+				time.Time{},
+				strings.NewReader(expectedContent),
+			)
+		}),
+	)
+	defer server.Close()
+
+	tempFile, err := os.CreateTemp(t.TempDir(), "download-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	defer func() { _ = tempFile.Close() }()
+
+	err = downloader.New().Download(t.Context(), server.URL, tempFile, nil)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+
+	content, err := os.ReadFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to read temp file: %v", err)
+	}
+
+	if string(content) != expectedContent {
+		t.Errorf("content has %d bytes, want %d", len(content), len(expectedContent))
+	}
+
+	if len(ranges) != 2 || ranges[1] != "bytes=400-" {
+		t.Errorf("Range headers = %q, want a retry with bytes=400-", ranges)
+	}
+}
+
+// TestDownloader_DownloadWithChecksumVerification_ResumesAcrossRuns
+// tests that a download interrupted in one run is resumed by the next
+// from the partial directory, and that the checksum still covers the
+// bytes from the first run.
+func TestDownloader_DownloadWithChecksumVerification_ResumesAcrossRuns(t *testing.T) {
+	expectedContent := strings.Repeat("abcdefghij", 100)
+
+	hasher := sha256.New()
+	hasher.Write([]byte(expectedContent))
+	expectedHash := hex.EncodeToString(hasher.Sum(nil))
+
+	firstCtx, interrupt := context.WithCancel(t.Context())
+	defer interrupt()
+
+	var ranges []string
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+			ranges = append(ranges, r.Header.Get("Range"))
+			responseWriter.Header().Set("ETag", `"v1"`)
+
+			if len(ranges) == 1 {
+				// Send part of the body and stall; the client
+				// cancels the run once it has read it.
+				responseWriter.Header().Set("Content-Length", strconv.Itoa(len(expectedContent)))
+				_, _ = responseWriter.Write([]byte(expectedContent[:300]))
+				responseWriter.(http.Flusher).Flush()
+				<-r.Context().Done()
+
+				return
+			}
+
+			http.ServeContent(
+				responseWriter,
+				r,
+				"",
+				time.Time{},
+				strings.NewReader(expectedContent),
+			)
+		}),
+	)
+	defer server.Close()
+
+	checksumServer := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+			_, _ = responseWriter.Write([]byte(expectedHash + "  test-file.tar.gz"))
+		}),
+	)
+	defer checksumServer.Close()
+
+	partialDir := t.TempDir()
+	downloaderInstance := downloader.New(downloader.WithPartialDir(partialDir))
+
+	download := func(ctx context.Context, progress downloader.ProgressFunc) (string, error) {
+		tempFile, err := os.CreateTemp(t.TempDir(), "download-resume-test-*")
+		if err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+
+		defer func() { _ = tempFile.Close() }()
+
+		_, err = downloaderInstance.DownloadWithChecksumVerification(
+			ctx,
+			server.URL,
+			checksumServer.URL,
+			"test-file.tar.gz",
+			"",
+			tempFile,
+			progress,
+		)
+
+		content, _ := os.ReadFile(tempFile.Name())
+
+		return string(content), err
+	}
+
+	_, err := download(firstCtx, func(percent int) {
+		if percent >= 30 {
+			interrupt()
+		}
+	})
+	if err == nil {
+		t.Fatal("interrupted download succeeded")
+	}
+
+	content, err := download(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("resumed download error = %v", err)
+	}
+
+	if content != expectedContent {
+		t.Errorf("content has %d bytes, want %d", len(content), len(expectedContent))
+	}
+
+	if len(ranges) != 2 || ranges[1] != "bytes=300-" {
+		t.Errorf("Range headers = %q, want a resume with bytes=300-", ranges)
+	}
+
+	leftovers, _ := os.ReadDir(partialDir)
+	if len(leftovers) != 0 {
+		t.Errorf("partial directory has %d entries after success, want 0", len(leftovers))
+	}
+}
+
+// TestDownloader_Download_Parallel tests that a large asset from a
+// server that accepts ranges is fetched over several connections and
+// reassembled in order.
+func TestDownloader_Download_Parallel(t *testing.T) {
+	expectedContent := make([]byte, 4<<20)
+	for idx := range expectedContent {
+		expectedContent[idx] = byte(idx % 251)
+	}
+
+	var (
+		mu     sync.Mutex
+		ranges []string
+	)
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				mu.Lock()
+				ranges = append(ranges, r.Header.Get("Range"))
+				mu.Unlock()
+			}
+
+			responseWriter.Header().Set("ETag", `"v1"`)
+			http.ServeContent(responseWriter, r, "", time.Time{}, bytes.NewReader(expectedContent))
+		}),
+	)
+	defer server.Close()
+
+	tempFile, err := os.CreateTemp(t.TempDir(), "download-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	defer func() { _ = tempFile.Close() }()
+
+	lastPercent := 0
+	downloaderInstance := downloader.New(downloader.WithConnections(4))
+
+	err = downloaderInstance.Download(t.Context(), server.URL, tempFile, func(percent int) {
+		lastPercent = percent
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+
+	content, err := os.ReadFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to read temp file: %v", err)
+	}
+
+	if !bytes.Equal(content, expectedContent) {
+		t.Error("reassembled content does not match the asset")
+	}
+
+	if len(ranges) != 4 || slices.Contains(ranges, "") {
+		t.Errorf("GET Range headers = %q, want 4 ranged requests", ranges)
+	}
+
+	if lastPercent != 100 {
+		t.Errorf("last progress = %d, want 100", lastPercent)
+	}
+}
+
+// TestDownloader_Download_ParallelFallback tests that a server without
+// range support gets a single plain request.
+func TestDownloader_Download_ParallelFallback(t *testing.T) {
+	expectedContent := strings.Repeat("x", 4<<20)
+
+	var gets []string
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				gets = append(gets, r.Header.Get("Range"))
+			}
+
+			responseWriter.Header().Set("Content-Length", strconv.Itoa(len(expectedContent)))
+			_, _ = responseWriter.Write([]byte(expectedContent))
+		}),
+	)
+	defer server.Close()
+
+	tempFile, err := os.CreateTemp(t.TempDir(), "download-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	defer func() { _ = tempFile.Close() }()
+
+	err = downloader.New(downloader.WithConnections(4)).
+		Download(t.Context(), server.URL, tempFile, nil)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+
+	info, err := tempFile.Stat()
+	if err != nil {
+		t.Fatalf("Failed to stat temp file: %v", err)
+	}
+
+	if info.Size() != int64(len(expectedContent)) {
+		t.Errorf("downloaded %d bytes, want %d", info.Size(), len(expectedContent))
+	}
+
+	if len(gets) != 1 || gets[0] != "" {
+		t.Errorf("GET Range headers = %q, want one plain request", gets)
+	}
+}
+
+// TestDownloader_DownloadWithChecksumVerification_MirrorFailover tests
+// that a mirror serving a corrupt archive is skipped for the next one,
+// and that a 404 does not fail over.
+func TestDownloader_DownloadWithChecksumVerification_MirrorFailover(t *testing.T) {
+	const (
+		assetPath = "/neovim/neovim/releases/download/v0.10.2/nvim.tar.gz"
+		content   = "mirrored archive"
+	)
+
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:]) + "  nvim.tar.gz"
+
+	newMirror := func(body string) *httptest.Server {
+		server := httptest.NewServer(
+			http.HandlerFunc(func(responseWriter http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case assetPath:
+					_, _ = responseWriter.Write([]byte(body))
+				case assetPath + ".sha256":
+					_, _ = responseWriter.Write([]byte(checksum))
+				default:
+					http.NotFound(responseWriter, r)
+				}
+			}),
+		)
+		t.Cleanup(server.Close)
+
+		return server
+	}
+
+	corrupt := newMirror("tampered archive")
+	healthy := newMirror(content)
+	statsPath := t.TempDir() + "/mirror-stats.json"
+	mirrors := mirror.New([]string{corrupt.URL, healthy.URL + "/"}, statsPath)
+
+	downloaderInstance := downloader.New(downloader.WithMirrors(mirrors))
+
+	tempFile, err := os.CreateTemp(t.TempDir(), "download-mirror-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = tempFile.Close() }()
+
+	_, err = downloaderInstance.DownloadWithChecksumVerification(
+		t.Context(),
+		corrupt.URL+assetPath,
+		corrupt.URL+assetPath+".sha256",
+		"nvim.tar.gz",
+		"",
+		tempFile,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("DownloadWithChecksumVerification() error = %v, want the healthy mirror", err)
+	}
+
+	data, err := os.ReadFile(tempFile.Name())
+	if err != nil || string(data) != content {
+		t.Errorf("downloaded %q, %v; want %q", data, err, content)
+	}
+
+	stats, err := mirror.ReadStats(statsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !stats[corrupt.URL].Failing() || stats[corrupt.URL].Failures != 1 {
+		t.Errorf("corrupt mirror stats = %+v, want one failure", stats[corrupt.URL])
+	}
+
+	if stats[healthy.URL].Failing() || stats[healthy.URL].Requests != 2 {
+		t.Errorf("healthy mirror stats = %+v, want two successful requests", stats[healthy.URL])
+	}
+
+	err = downloaderInstance.Download(t.Context(), corrupt.URL+"/missing", tempFile, nil)
+	if !errors.Is(err, downloader.ErrDownloadFailed) || errors.Is(err, downloader.ErrAllMirrorsFailed) {
+		t.Errorf("Download() of a missing file error = %v, want a 404 without failover", err)
+	}
+}
@@ -21,4 +21,7 @@ var (
 
 	// ErrChecksumNotFound is returned when checksum for asset is not found.
 	ErrChecksumNotFound = errors.New("checksum not found")
+
+	// ErrAllMirrorsFailed is returned when a download failed on every configured mirror.
+	ErrAllMirrorsFailed = errors.New("every mirror failed")
 )
@@ -0,0 +1,66 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// withFailover runs download against each configured mirror in turn
+// until one succeeds. rewrite maps a URL of the release (the archive
+// or its checksum file) onto the mirror being tried. Only failures
+// another mirror may not share move on to the next one; anything else,
+// such as a 404 or a cancellation, is returned at once.
+//
+// Without mirrors, or for a URL no mirror serves, download runs once
+// with the URLs unchanged.
+func (d *Downloader) withFailover(
+	url string,
+	download func(rewrite func(string) string) error,
+) error {
+	unchanged := func(u string) string { return u }
+
+	if d.mirrors == nil || len(d.mirrors.Bases()) < 2 { //nolint:mnd // nothing to fail over to
+		return download(unchanged)
+	}
+
+	_, served := d.mirrors.Rewrite(url, "")
+	if !served {
+		return download(unchanged)
+	}
+
+	bases := d.mirrors.Bases()
+
+	var err error
+
+	for idx, base := range bases {
+		err = download(func(u string) string {
+			rewritten, _ := d.mirrors.Rewrite(u, base)
+
+			return rewritten
+		})
+		if err == nil || !worthFailover(err) {
+			return err
+		}
+
+		if errors.Is(err, ErrChecksumMismatch) {
+			d.mirrors.RecordFailure(base, err)
+		}
+
+		if idx < len(bases)-1 {
+			log.Warnf("Mirror %s failed: %v; trying %s", base, err, bases[idx+1])
+		}
+	}
+
+	return fmt.Errorf("%w: %w", ErrAllMirrorsFailed, err)
+}
+
+// worthFailover reports whether err is a failure of the mirror rather
+// than of the request: a connection error or 5xx that outlasted the
+// retries, or content that does not match its checksum.
+func worthFailover(err error) bool {
+	var retryErr *retryableError
+
+	return errors.As(err, &retryErr) || errors.Is(err, ErrChecksumMismatch)
+}
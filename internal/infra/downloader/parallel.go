@@ -0,0 +1,291 @@
+package downloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// fetchParallel downloads url into part as concurrent byte ranges. It
+// reports false, having sent at most a HEAD request, when the download
+// should go over a single stream instead: part already holds bytes to
+// resume, the server does not accept ranges, or the asset is too small
+// to be worth splitting.
+//
+// The ranges arrive out of order, so the SHA256 is computed by reading
+// the assembled file once at the end.
+func (d *Downloader) fetchParallel(
+	ctx context.Context,
+	url string,
+	part *partial,
+	progress ProgressFunc,
+) (string, bool, error) {
+	offset, err := part.size()
+	if err != nil || offset > 0 {
+		return "", false, nil
+	}
+
+	size, validator, ok := d.probeRanges(ctx, url)
+	if !ok {
+		return "", false, nil
+	}
+
+	conns := min(int64(d.connections), size/constants.MinDownloadChunkSize)
+	if conns < 2 { //nolint:mnd // one connection is a single stream
+		return "", false, nil
+	}
+
+	log.Debugf("Downloading %d bytes over %d connections", size, conns)
+
+	// A file with holes cannot be resumed as a stream.
+	part.forget()
+
+	err = part.file.Truncate(size)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to allocate download: %w", err)
+	}
+
+	err = d.fetchRanges(ctx, url, validator, part.file, size, conns, progress)
+	if err != nil {
+		resetErr := part.reset()
+		if resetErr != nil {
+			log.Debugf("Failed to reset partial download: %v", resetErr)
+		}
+
+		return "", true, err
+	}
+
+	hasher := sha256.New()
+
+	_, err = part.file.Seek(0, io.SeekStart)
+	if err == nil {
+		_, err = io.Copy(hasher, part.file)
+	}
+
+	if err != nil {
+		return "", true, fmt.Errorf("failed to hash download: %w", err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), true, nil
+}
+
+// probeRanges sends a HEAD request for url and reports its size, a
+// validator that pins every range to the same version of the file,
+// and whether the server accepts byte ranges at all.
+func (d *Downloader) probeRanges(ctx context.Context, url string) (int64, string, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, "", false
+	}
+
+	req.Header.Set("User-Agent", "nvs")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		log.Debugf("Range probe failed, using a single stream: %v", err)
+
+		return 0, "", false
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK ||
+		resp.Header.Get("Accept-Ranges") != "bytes" ||
+		resp.ContentLength <= 0 {
+		log.Debugf("Server does not accept ranges for %s; using a single stream", url)
+
+		return 0, "", false
+	}
+
+	meta := partialMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	return resp.ContentLength, meta.validator(), true
+}
+
+// fetchRanges splits size bytes into conns ranges and downloads them
+// concurrently into file. The first range to fail cancels the rest.
+func (d *Downloader) fetchRanges(
+	ctx context.Context,
+	url string,
+	validator string,
+	file *os.File,
+	size int64,
+	conns int64,
+	progress ProgressFunc,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		waitGroup sync.WaitGroup
+		errOnce   sync.Once
+		firstErr  error
+	)
+
+	counter := &sharedProgress{total: size, callback: progress}
+	chunk := (size + conns - 1) / conns
+
+	for start := int64(0); start < size; start += chunk {
+		end := min(start+chunk, size) - 1
+
+		waitGroup.Go(func() {
+			err := d.fetchRange(ctx, url, validator, file, start, end, counter)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+
+					cancel()
+				})
+			}
+		})
+	}
+
+	waitGroup.Wait()
+
+	return firstErr
+}
+
+// fetchRange downloads bytes start..end (inclusive) of url into file,
+// retrying transient failures from where the range left off.
+func (d *Downloader) fetchRange(
+	ctx context.Context,
+	url string,
+	validator string,
+	file *os.File,
+	start, end int64,
+	counter *sharedProgress,
+) error {
+	pos := start
+
+	return withRetry(ctx, func() error {
+		written, err := d.fetchRangeOnce(ctx, url, validator, file, pos, end, counter)
+		pos += written
+
+		return err
+	})
+}
+
+// fetchRangeOnce makes one request for bytes pos..end of url and
+// returns how many of them it wrote. Failures worth another attempt
+// are returned as a *retryableError.
+func (d *Downloader) fetchRangeOnce(
+	ctx context.Context,
+	url string,
+	validator string,
+	file *os.File,
+	pos, end int64,
+	counter *sharedProgress,
+) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "nvs")
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", pos, end))
+
+	if validator != "" {
+		req.Header.Set("If-Range", validator)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to download file: %w", err)
+		if httpclient.IsRetriableNetError(err) {
+			return 0, &retryableError{err: err}
+		}
+
+		return 0, err
+	}
+
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			log.Warnf("failed to close response body: %v", err)
+		}
+	}()
+
+	wantRange := fmt.Sprintf("bytes %d-%d/", pos, end)
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent &&
+		strings.HasPrefix(resp.Header.Get("Content-Range"), wantRange):
+	case httpclient.RetriableStatus(resp.StatusCode):
+		return 0, &retryableError{
+			err:  fmt.Errorf("%w: status %d", ErrDownloadFailed, resp.StatusCode),
+			resp: resp,
+		}
+	default:
+		// Most likely a 200: the file changed since the probe, so
+		// the ranges would not fit together.
+		return 0, fmt.Errorf(
+			"%w: range %d-%d: status %d",
+			ErrDownloadFailed,
+			pos,
+			end,
+			resp.StatusCode,
+		)
+	}
+
+	want := end - pos + 1
+
+	written, err := io.Copy(
+		io.NewOffsetWriter(file, pos),
+		io.LimitReader(io.TeeReader(resp.Body, counter), want),
+	)
+	if err == nil && written < want {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to copy download content: %w", err)
+		if httpclient.IsRetriableNetError(err) {
+			return written, &retryableError{err: err}
+		}
+
+		return written, err
+	}
+
+	return written, nil
+}
+
+// sharedProgress adds up the bytes written by concurrent ranges and
+// reports the combined percentage, once per change, like
+// progressReader does for a single stream.
+type sharedProgress struct {
+	mu          sync.Mutex
+	total       int64
+	read        int64
+	lastPercent int
+	callback    ProgressFunc
+}
+
+// Write counts len(p) bytes as downloaded.
+func (p *sharedProgress) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.read += int64(len(data))
+	if p.callback != nil && p.total > 0 {
+		percent := int((p.read * 100) / p.total) //nolint:mnd // 100 for percentage calculation
+		if percent != p.lastPercent {
+			p.lastPercent = percent
+			p.callback(percent)
+		}
+	}
+
+	return len(data), nil
+}
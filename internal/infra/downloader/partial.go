@@ -0,0 +1,209 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// partialKeyLen is how many hex characters of the URL's SHA256 name a
+// persisted partial download.
+const partialKeyLen = 16
+
+// partialMeta is what a persisted partial download records next to
+// its bytes: the URL it came from and the validators that make a
+// later Range request safe.
+//
+//nolint:tagliatelle
+type partialMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// validator returns the value for an If-Range header, or "" when the
+// server gave nothing usable. If-Range needs a strong ETag, so a weak
+// one falls back to Last-Modified.
+func (m partialMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+
+	return m.LastModified
+}
+
+// partial is a download in progress: the file its bytes go to and,
+// when it is persisted, where its metadata lives. Without a partial
+// directory the destination file itself is used, so retries within
+// one run still resume.
+type partial struct {
+	file     *os.File
+	metaPath string
+	meta     partialMeta
+}
+
+// openPartial returns the partial download for url. With a partial
+// directory it reopens what an earlier, interrupted run left behind,
+// discarding it when it cannot be resumed safely.
+func (d *Downloader) openPartial(url string, dest *os.File) (*partial, error) {
+	if d.partialDir == "" {
+		part := &partial{file: dest, meta: partialMeta{URL: url}}
+
+		return part, part.reset()
+	}
+
+	err := os.MkdirAll(d.partialDir, constants.DirPerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create partial download directory: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(url))
+	base := filepath.Join(d.partialDir, hex.EncodeToString(sum[:])[:partialKeyLen])
+
+	file, err := os.OpenFile(base+".part", os.O_CREATE|os.O_RDWR, constants.FilePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open partial download: %w", err)
+	}
+
+	part := &partial{file: file, metaPath: base + ".json"}
+
+	data, err := os.ReadFile(part.metaPath)
+	if err == nil {
+		err = json.Unmarshal(data, &part.meta)
+	}
+
+	if err != nil || part.meta.URL != url || part.meta.validator() == "" {
+		part.meta = partialMeta{URL: url}
+
+		err = part.reset()
+		if err != nil {
+			_ = file.Close()
+
+			return nil, err
+		}
+	}
+
+	return part, nil
+}
+
+// persisted reports whether the partial outlives this run.
+func (p *partial) persisted() bool {
+	return p.metaPath != ""
+}
+
+// size returns how many bytes are already on disk.
+func (p *partial) size() (int64, error) {
+	info, err := p.file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat partial download: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// reset drops the bytes on disk so the download starts over.
+func (p *partial) reset() error {
+	err := p.file.Truncate(0)
+	if err != nil {
+		return fmt.Errorf("failed to truncate partial download: %w", err)
+	}
+
+	_, err = p.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("failed to seek partial download: %w", err)
+	}
+
+	return nil
+}
+
+// remember records the validators of a full response, so an
+// interrupted transfer can be resumed by a later run.
+func (p *partial) remember(resp *http.Response) {
+	p.meta.ETag = resp.Header.Get("ETag")
+	p.meta.LastModified = resp.Header.Get("Last-Modified")
+
+	if !p.persisted() {
+		return
+	}
+
+	data, err := json.Marshal(p.meta)
+	if err == nil {
+		err = os.WriteFile(p.metaPath, data, constants.FilePerm)
+	}
+
+	if err != nil {
+		log.Debugf("Failed to save partial download metadata: %v", err)
+	}
+}
+
+// forget drops the recorded validators, so a later run does not try
+// to resume what is on disk.
+func (p *partial) forget() {
+	p.meta = partialMeta{URL: p.meta.URL}
+
+	if !p.persisted() {
+		return
+	}
+
+	err := os.Remove(p.metaPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Debugf("Failed to remove %s: %v", p.metaPath, err)
+	}
+}
+
+// finish copies a completed persisted download into dest and removes
+// it. A non-persisted partial already is dest.
+func (p *partial) finish(dest *os.File) error {
+	if !p.persisted() {
+		return nil
+	}
+
+	_, err := p.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("failed to seek partial download: %w", err)
+	}
+
+	_, err = io.Copy(dest, p.file)
+	if err != nil {
+		return fmt.Errorf("failed to copy download content: %w", err)
+	}
+
+	p.discard()
+
+	return nil
+}
+
+// discard removes a persisted partial download, e.g. after it failed
+// verification, so the next run starts from scratch.
+func (p *partial) discard() {
+	if !p.persisted() {
+		return
+	}
+
+	// Close first: Windows cannot remove a file that is still open.
+	_ = p.file.Close()
+
+	for _, path := range []string{p.file.Name(), p.metaPath} {
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Debugf("Failed to remove %s: %v", path, err)
+		}
+	}
+}
+
+// close releases a persisted partial's file handle.
+func (p *partial) close() {
+	if p.persisted() {
+		_ = p.file.Close()
+	}
+}
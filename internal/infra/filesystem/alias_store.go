@@ -0,0 +1,133 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+)
+
+// AliasStore implements vtypes.AliasStore as a JSON file
+// (constants.AliasesFile in the config directory).
+type AliasStore struct {
+	path string
+}
+
+// aliasFile is the on-disk shape of the aliases file. The version
+// field leaves room to change the format without guessing.
+type aliasFile struct {
+	Version int               `json:"version"`
+	Aliases map[string]string `json:"aliases"`
+}
+
+// aliasFileVersion is the current aliases file format.
+const aliasFileVersion = 1
+
+// NewAliasStore returns a store backed by the file at path. The file
+// is created on the first Set.
+func NewAliasStore(path string) *AliasStore {
+	return &AliasStore{path: path}
+}
+
+// List returns every alias and its target version name.
+func (s *AliasStore) List() (map[string]string, error) {
+	return s.read()
+}
+
+// Get returns the target of name and whether the alias exists.
+func (s *AliasStore) Get(name string) (string, bool, error) {
+	aliases, err := s.read()
+	if err != nil {
+		return "", false, err
+	}
+
+	target, ok := aliases[name]
+
+	return target, ok, nil
+}
+
+// Set creates or retargets an alias. The read-modify-write runs under
+// a file lock so two concurrent 'nvs alias' calls cannot drop each
+// other's entries.
+func (s *AliasStore) Set(name, target string) error {
+	return s.update(func(aliases map[string]string) error {
+		aliases[name] = target
+
+		return nil
+	})
+}
+
+// Remove deletes an alias, returning vtypes.ErrAliasNotFound if it does
+// not exist.
+func (s *AliasStore) Remove(name string) error {
+	return s.update(func(aliases map[string]string) error {
+		_, ok := aliases[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", vtypes.ErrAliasNotFound, name)
+		}
+
+		delete(aliases, name)
+
+		return nil
+	})
+}
+
+// update applies change to the current aliases and writes the result
+// atomically while holding the aliases lock.
+func (s *AliasStore) update(change func(aliases map[string]string) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultLockTimeout)
+	defer cancel()
+
+	return NewFileLock(s.path+".lock").WithLock(ctx, func() error {
+		aliases, err := s.read()
+		if err != nil {
+			return err
+		}
+
+		err = change(aliases)
+		if err != nil {
+			return err
+		}
+
+		return s.write(aliases)
+	})
+}
+
+// read loads the aliases file. A missing file means no aliases.
+func (s *AliasStore) read() (map[string]string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return make(map[string]string), nil
+		}
+
+		return nil, fmt.Errorf("failed to read aliases: %w", err)
+	}
+
+	var file aliasFile
+
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+
+	if file.Aliases == nil {
+		file.Aliases = make(map[string]string)
+	}
+
+	return file.Aliases, nil
+}
+
+// write replaces the aliases file atomically (temp file, fsync,
+// rename) so a crash never leaves a truncated file behind.
+func (s *AliasStore) write(aliases map[string]string) error {
+	data, err := json.MarshalIndent(aliasFile{Version: aliasFileVersion, Aliases: aliases}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode aliases: %w", err)
+	}
+
+	return WriteFileAtomic(s.path, append(data, '\n'))
+}
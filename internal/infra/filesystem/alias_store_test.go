@@ -0,0 +1,73 @@
+package filesystem_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	filesystem "github.com/y3owk1n/nvs/internal/infra/filesystem"
+)
+
+func TestAliasStore_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "aliases.json")
+	store := filesystem.NewAliasStore(path)
+
+	aliases, err := store.List()
+	if err != nil {
+		t.Fatalf("List on missing file: %v", err)
+	}
+
+	if len(aliases) != 0 {
+		t.Fatalf("List on missing file = %v, want empty", aliases)
+	}
+
+	err = store.Set("work", "v0.10.2")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	err = store.Set("lts", "v0.9.5")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	err = store.Set("work", "v0.10.4")
+	if err != nil {
+		t.Fatalf("Set (retarget): %v", err)
+	}
+
+	// A fresh store reads what the first one wrote.
+	reopened := filesystem.NewAliasStore(path)
+
+	target, ok, err := reopened.Get("work")
+	if err != nil || !ok || target != "v0.10.4" {
+		t.Errorf("Get(work) = %q, %v, %v; want v0.10.4, true, nil", target, ok, err)
+	}
+
+	_, ok, err = reopened.Get("missing")
+	if err != nil || ok {
+		t.Errorf("Get(missing) = %v, %v; want false, nil", ok, err)
+	}
+
+	err = reopened.Remove("lts")
+	if err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	aliases, err = reopened.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	if len(aliases) != 1 || aliases["work"] != "v0.10.4" {
+		t.Errorf("List = %v, want only work=v0.10.4", aliases)
+	}
+
+	err = reopened.Remove("lts")
+	if !errors.Is(err, vtypes.ErrAliasNotFound) {
+		t.Errorf("Remove(missing) error = %v, want ErrAliasNotFound", err)
+	}
+}
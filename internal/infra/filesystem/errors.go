@@ -6,4 +6,7 @@ import "errors"
 var (
 	// ErrBinaryNotFound is returned when the Neovim binary cannot be found.
 	ErrBinaryNotFound = errors.New("neovim binary not found")
+
+	// ErrInvalidManifest is returned when a version's manifest.json cannot be parsed.
+	ErrInvalidManifest = errors.New("invalid install manifest")
 )
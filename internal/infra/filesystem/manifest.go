@@ -0,0 +1,68 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+)
+
+// ReadManifest loads the manifest of the version directory dir. For
+// installs that predate manifests it falls back to version.txt and
+// returns a legacy manifest (see vtypes.Manifest.IsLegacy) carrying
+// only the identifier. It fails if neither file exists.
+func ReadManifest(dir string) (vtypes.Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, constants.ManifestFileName))
+	if err == nil {
+		var manifest vtypes.Manifest
+
+		err = json.Unmarshal(data, &manifest)
+		if err != nil {
+			return vtypes.Manifest{}, fmt.Errorf("%w: %s: %w", ErrInvalidManifest, dir, err)
+		}
+
+		return manifest, nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return vtypes.Manifest{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, constants.IdentifierFileName))
+	if err != nil {
+		return vtypes.Manifest{}, fmt.Errorf("failed to read version file: %w", err)
+	}
+
+	return vtypes.Manifest{Identifier: strings.TrimSpace(string(data))}, nil
+}
+
+// WriteManifest atomically writes manifest into the version directory dir.
+func WriteManifest(dir string, manifest vtypes.Manifest) error {
+	manifest.Schema = vtypes.ManifestSchema
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	return WriteFileAtomic(filepath.Join(dir, constants.ManifestFileName), append(data, '\n'))
+}
+
+// HasInstallRecord reports whether dir holds a finished install: a
+// manifest, or the version.txt of an older install. Both are written
+// last, so a directory without either is a partial install.
+func HasInstallRecord(dir string) bool {
+	for _, name := range []string{constants.ManifestFileName, constants.IdentifierFileName} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err == nil {
+			return true
+		}
+	}
+
+	return false
+}
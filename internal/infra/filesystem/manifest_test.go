@@ -0,0 +1,113 @@
+package filesystem_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	filesystem "github.com/y3owk1n/nvs/internal/infra/filesystem"
+)
+
+func TestManifest_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	installedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	want := vtypes.Manifest{
+		Source:     vtypes.ManifestSourceRelease,
+		Identifier: "v0.10.2",
+		Tag:        "v0.10.2",
+		Commit:     "abc1234def5678",
+		Asset: &vtypes.ManifestAsset{
+			Name:   "nvim-linux-x86_64.tar.gz",
+			URL:    "https://example.com/nvim-linux-x86_64.tar.gz",
+			SHA256: "deadbeef",
+		},
+		InstalledAt:       installedAt,
+		InstallDurationMS: 1500,
+		NvsVersion:        "v1.2.3",
+	}
+
+	err := filesystem.WriteManifest(dir, want)
+	if err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+
+	got, err := filesystem.ReadManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+
+	if got.Schema != vtypes.ManifestSchema || got.IsLegacy() {
+		t.Errorf("schema = %d, want %d", got.Schema, vtypes.ManifestSchema)
+	}
+
+	if got.Identifier != want.Identifier || got.Tag != want.Tag || got.Commit != want.Commit {
+		t.Errorf("manifest = %+v, want %+v", got, want)
+	}
+
+	if got.Asset == nil || *got.Asset != *want.Asset {
+		t.Errorf("asset = %+v, want %+v", got.Asset, want.Asset)
+	}
+
+	if !got.InstalledAt.Equal(installedAt) || got.InstallDuration() != 1500*time.Millisecond {
+		t.Errorf("installed_at = %v, duration = %v", got.InstalledAt, got.InstallDuration())
+	}
+
+	if !filesystem.HasInstallRecord(dir) {
+		t.Error("HasInstallRecord = false after WriteManifest")
+	}
+}
+
+func TestManifest_LegacyVersionFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	if filesystem.HasInstallRecord(dir) {
+		t.Error("HasInstallRecord = true for an empty directory")
+	}
+
+	_, err := filesystem.ReadManifest(dir)
+	if err == nil {
+		t.Error("ReadManifest succeeded without manifest.json or version.txt")
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "version.txt"), []byte("abc1234def5678\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := filesystem.ReadManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+
+	if !got.IsLegacy() || got.Identifier != "abc1234def5678" {
+		t.Errorf("manifest = %+v, want legacy manifest with identifier abc1234def5678", got)
+	}
+
+	if !filesystem.HasInstallRecord(dir) {
+		t.Error("HasInstallRecord = false with a version.txt")
+	}
+}
+
+func TestManifest_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "manifest.json"), []byte("{not json"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = filesystem.ReadManifest(dir)
+	if !errors.Is(err, filesystem.ErrInvalidManifest) {
+		t.Errorf("ReadManifest error = %v, want ErrInvalidManifest", err)
+	}
+}
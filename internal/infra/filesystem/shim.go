@@ -0,0 +1,117 @@
+package filesystem
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+)
+
+// shimMarker is the comment line that identifies a launcher written
+// by WriteShim. Anything at the shim path without it is left to
+// Switch's usual replace-the-link logic.
+const shimMarker = "nvs shim"
+
+// ShimPath returns where the nvim shim lives under binDir. On Windows
+// binDir/nvim is the directory whose bin subdirectory is on PATH (it
+// is a junction to the install root in symlink mode), so the shim is
+// a batch file inside it.
+func ShimPath(binDir string) string {
+	if runtime.GOOS == constants.WindowsOS {
+		return filepath.Join(binDir, "nvim", "bin", "nvim.cmd")
+	}
+
+	return filepath.Join(binDir, "nvim")
+}
+
+// IsShim reports whether binDir holds an nvim shim rather than a link
+// to one installed version.
+func IsShim(binDir string) bool {
+	info, err := os.Lstat(ShimPath(binDir))
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	data, err := os.ReadFile(ShimPath(binDir))
+
+	return err == nil && bytes.Contains(data, []byte(shimMarker))
+}
+
+// WriteShim installs the nvim shim into binDir: a launcher that runs
+// 'nvs exec -- nvim', which picks the version per invocation. nvsPath
+// is tried first, then nvs on PATH, so the shim survives nvs being
+// moved by a package manager upgrade. An existing link is replaced.
+func WriteShim(binDir, nvsPath string) error {
+	script := shimScript(nvsPath)
+	shimPath := ShimPath(binDir)
+
+	existing, err := os.ReadFile(shimPath)
+	if err == nil && string(existing) == script {
+		return nil
+	}
+
+	// Replace the link (on Windows, the junction) to a single version.
+	if !IsShim(binDir) {
+		err = os.Remove(filepath.Join(binDir, "nvim"))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove existing global bin: %w", err)
+		}
+	}
+
+	err = WriteFileAtomic(shimPath, []byte(script))
+	if err != nil {
+		return fmt.Errorf("failed to write nvim shim: %w", err)
+	}
+
+	//nolint:gosec // the shim must be executable
+	err = os.Chmod(shimPath, constants.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to make nvim shim executable: %w", err)
+	}
+
+	return nil
+}
+
+// removeShim deletes the shim from binDir ahead of linking a version.
+func removeShim(binDir string) error {
+	target := ShimPath(binDir)
+	if runtime.GOOS == constants.WindowsOS {
+		target = filepath.Join(binDir, "nvim")
+	}
+
+	err := os.RemoveAll(target)
+	if err != nil {
+		return fmt.Errorf("failed to remove nvim shim: %w", err)
+	}
+
+	return nil
+}
+
+// shimScript returns the launcher for the current platform.
+func shimScript(nvsPath string) string {
+	if runtime.GOOS == constants.WindowsOS {
+		return strings.Join([]string{
+			"@echo off",
+			"rem " + shimMarker + ": runs the Neovim version pinned for the current directory.",
+			"rem Written by nvs; switch shim mode off with 'nvs settings set shims false'.",
+			`if exist "` + nvsPath + `" (`,
+			`  "` + nvsPath + `" exec -- nvim %*`,
+			") else (",
+			"  nvs exec -- nvim %*",
+			")",
+			"exit /b %ERRORLEVEL%",
+			"",
+		}, "\r\n")
+	}
+
+	return "#!/bin/sh\n" +
+		"# " + shimMarker + ": runs the Neovim version pinned for the current directory.\n" +
+		"# Written by nvs; switch shim mode off with 'nvs settings set shims false'.\n" +
+		"nvs='" + strings.ReplaceAll(nvsPath, "'", `'\''`) + "'\n" +
+		`[ -x "$nvs" ] || nvs=nvs` + "\n" +
+		`exec "$nvs" exec -- nvim "$@"` + "\n"
+}
@@ -0,0 +1,90 @@
+package filesystem_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
+	filesystem "github.com/y3owk1n/nvs/internal/infra/filesystem"
+)
+
+func TestVersionStore_ShimMode(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	versionsDir := t.TempDir()
+	binDir := t.TempDir()
+	globalBin := filepath.Join(binDir, "nvim")
+
+	versionDir := filepath.Join(versionsDir, "v1.0.0")
+
+	err := os.MkdirAll(filepath.Join(versionDir, "bin"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(versionDir, "bin", "nvim"), []byte("#!/bin/sh\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Start in symlink mode.
+	plain := filesystem.New(&filesystem.Config{VersionsDir: versionsDir, GlobalBinDir: binDir})
+
+	err = plain.Switch(vtypes.New("v1.0.0", vtypes.TypeTag, "v1.0.0", ""))
+	if err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+
+	// Turning shims on replaces the link with the launcher.
+	shimmed := filesystem.New(&filesystem.Config{
+		VersionsDir:    versionsDir,
+		GlobalBinDir:   binDir,
+		ShimExecutable: "/opt/nvs/bin/nvs",
+	})
+
+	err = shimmed.SyncGlobalBin()
+	if err != nil {
+		t.Fatalf("SyncGlobalBin failed: %v", err)
+	}
+
+	if !filesystem.IsShim(binDir) {
+		t.Fatal("IsShim = false after enabling shims")
+	}
+
+	script, err := os.ReadFile(globalBin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(script), "'/opt/nvs/bin/nvs'") ||
+		!strings.Contains(string(script), `exec "$nvs" exec -- nvim "$@"`) {
+		t.Errorf("unexpected shim:\n%s", script)
+	}
+
+	info, err := os.Stat(globalBin)
+	if err != nil || info.Mode()&0o111 == 0 {
+		t.Errorf("shim is not executable (mode %v, err %v)", info.Mode(), err)
+	}
+
+	// Switching in shim mode only moves the current link.
+	err = shimmed.Switch(vtypes.New("v1.0.0", vtypes.TypeTag, "v1.0.0", ""))
+	if err != nil || !filesystem.IsShim(binDir) {
+		t.Errorf("Switch in shim mode: err = %v, shim = %v", err, filesystem.IsShim(binDir))
+	}
+
+	// Turning shims off links the current version again.
+	err = plain.SyncGlobalBin()
+	if err != nil {
+		t.Fatalf("SyncGlobalBin failed: %v", err)
+	}
+
+	target, err := os.Readlink(globalBin)
+	if err != nil || target != filepath.Join(versionDir, "bin", "nvim") {
+		t.Errorf("global bin = %q, %v; want link to v1.0.0", target, err)
+	}
+}
@@ -1,10 +1,13 @@
 package filesystem
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/log"
 )
 
@@ -67,3 +70,54 @@ func ClearDirectory(dir string) error {
 
 	return nil
 }
+
+// WriteFileAtomic replaces the file at path with data (temp file in
+// the same directory, fsync, rename), so a crash never leaves a
+// truncated file behind. The parent directory is created if needed.
+func WriteFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+
+	err := os.MkdirAll(dir, constants.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	tmpName := tmp.Name()
+
+	defer func() {
+		removeErr := os.Remove(tmpName)
+		if removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			log.Warnf("failed to remove temp file %s: %v", tmpName, removeErr)
+		}
+	}()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(constants.FilePerm)
+	}
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+
+	err = os.Rename(tmpName, path)
+	if err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
@@ -27,6 +27,11 @@ type VersionStore struct {
 type Config struct {
 	VersionsDir  string
 	GlobalBinDir string
+
+	// ShimExecutable is the nvs binary the nvim shim runs. When set,
+	// GlobalBinDir/nvim is a shim that resolves the version on every
+	// launch (see WriteShim) instead of a link to the current version.
+	ShimExecutable string
 }
 
 // New creates a new VersionStore.
@@ -56,15 +61,19 @@ func (s *VersionStore) List() ([]vtypes.Version, error) {
 			continue
 		}
 
+		// Skip hidden staging directories (in-progress builds and backups)
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
 		// Include directories and the "nightly" symlink
 		if entry.IsDir() || (entry.Type()&os.ModeSymlink != 0 && name == constants.Nightly) {
-			// Read version.txt to get full info
-			versionFile := filepath.Join(s.config.VersionsDir, name, "version.txt")
-			data, err := os.ReadFile(versionFile)
-
+			// Read the manifest (or legacy version.txt) to get full info
 			var commitHash string
+
+			manifest, err := ReadManifest(filepath.Join(s.config.VersionsDir, name))
 			if err == nil {
-				commitHash = strings.TrimSpace(string(data))
+				commitHash = manifest.Identifier
 			}
 
 			// Determine version type
@@ -115,12 +124,11 @@ func (s *VersionStore) Current() (vtypes.Version, error) {
 	}
 
 	// Read version info
-	versionFile := filepath.Join(s.config.VersionsDir, targetName, "version.txt")
-	data, err := os.ReadFile(versionFile)
-
 	var commitHash string
+
+	manifest, err := ReadManifest(filepath.Join(s.config.VersionsDir, targetName))
 	if err == nil {
-		commitHash = strings.TrimSpace(string(data))
+		commitHash = manifest.Identifier
 	}
 
 	vType := determineVersionType(targetName)
@@ -181,6 +189,74 @@ func (s *VersionStore) Switch(version vtypes.Version) error {
 		return fmt.Errorf("failed to update current symlink: %w", err)
 	}
 
+	err = s.linkGlobalBin(versionPath)
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Switched to version: %s", version.Name())
+
+	return nil
+}
+
+// SyncGlobalBin brings GlobalBinDir/nvim in line with the configured
+// mode after the shim setting changed: it (re)writes the shim, or
+// replaces a shim with a link to the current version. It is cheap
+// when nothing needs to change, so 'nvs use' runs it even when the
+// version stays the same.
+func (s *VersionStore) SyncGlobalBin() error {
+	isShim := IsShim(s.config.GlobalBinDir)
+	if s.config.ShimExecutable == "" && !isShim {
+		return nil
+	}
+
+	if s.config.ShimExecutable != "" {
+		existing, err := os.ReadFile(ShimPath(s.config.GlobalBinDir))
+		if err == nil && string(existing) == shimScript(s.config.ShimExecutable) {
+			return nil
+		}
+	}
+
+	switchLock := NewFileLock(filepath.Join(s.config.VersionsDir, ".nvs-switch.lock"))
+
+	err := switchLock.LockWithDefaultTimeout()
+	if err != nil {
+		return fmt.Errorf("failed to acquire switch lock: %w", err)
+	}
+
+	defer func() {
+		unlockErr := switchLock.Unlock()
+		if unlockErr != nil {
+			log.Warnf("failed to unlock switch lock: %v", unlockErr)
+		}
+	}()
+
+	if s.config.ShimExecutable != "" {
+		log.Debugf("Installing nvim shim in %s", s.config.GlobalBinDir)
+
+		return WriteShim(s.config.GlobalBinDir, s.config.ShimExecutable)
+	}
+
+	current, err := s.Current()
+	if err != nil {
+		// No default version to link: drop the shim and leave the
+		// slot empty, as before the first 'nvs use'.
+		return removeShim(s.config.GlobalBinDir)
+	}
+
+	log.Debugf("Replacing nvim shim with a link to %s", current.Name())
+
+	return s.linkGlobalBin(filepath.Join(s.config.VersionsDir, current.Name()))
+}
+
+// linkGlobalBin points GlobalBinDir/nvim at the binary in versionPath,
+// or in shim mode makes sure the shim is in place: the shim reads the
+// current version itself, so switching only moves the current link.
+func (s *VersionStore) linkGlobalBin(versionPath string) error {
+	if s.config.ShimExecutable != "" {
+		return WriteShim(s.config.GlobalBinDir, s.config.ShimExecutable)
+	}
+
 	// Find nvim link target
 	nvimExec := findNvimLinkTarget(versionPath)
 	if nvimExec == "" {
@@ -190,8 +266,15 @@ func (s *VersionStore) Switch(version vtypes.Version) error {
 	// Update global binary link
 	targetBin := filepath.Join(s.config.GlobalBinDir, "nvim")
 
-	// Remove existing link
-	_, err = os.Lstat(targetBin)
+	// Remove existing link (or a shim left from shim mode)
+	if IsShim(s.config.GlobalBinDir) {
+		err := removeShim(s.config.GlobalBinDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := os.Lstat(targetBin)
 	if err == nil {
 		err = os.Remove(targetBin)
 		if err != nil {
@@ -207,8 +290,6 @@ func (s *VersionStore) Switch(version vtypes.Version) error {
 		return fmt.Errorf("failed to create global nvim link: %w", err)
 	}
 
-	log.Debugf("Switched to version: %s", version.Name())
-
 	return nil
 }
 
@@ -261,14 +342,17 @@ func (s *VersionStore) Uninstall(version vtypes.Version, force bool) error {
 
 // GetInstalledReleaseIdentifier returns the release identifier (e.g. commit hash) for an installed version.
 func (s *VersionStore) GetInstalledReleaseIdentifier(versionName string) (string, error) {
-	versionFile := filepath.Join(s.config.VersionsDir, versionName, "version.txt")
-
-	data, err := os.ReadFile(versionFile)
+	manifest, err := s.GetManifest(versionName)
 	if err != nil {
-		return "", fmt.Errorf("failed to read version file: %w", err)
+		return "", err
 	}
 
-	return strings.TrimSpace(string(data)), nil
+	return manifest.Identifier, nil
+}
+
+// GetManifest returns the install metadata of an installed version.
+func (s *VersionStore) GetManifest(versionName string) (vtypes.Manifest, error) {
+	return ReadManifest(filepath.Join(s.config.VersionsDir, versionName))
 }
 
 // updateSymlink creates or updates a symlink.
@@ -371,8 +455,10 @@ func determineVersionType(name string) vtypes.Type {
 		return vtypes.TypeStable
 	case strings.HasPrefix(strings.ToLower(name), constants.Nightly):
 		return vtypes.TypeNightly
-	case vtypes.IsCommitReference(name):
+	case vtypes.IsCommitBuildName(name):
 		return vtypes.TypeCommit
+	case vtypes.IsSourceRefDirName(name):
+		return vtypes.TypeSourceRef
 	default:
 		return vtypes.TypeTag
 	}
@@ -305,6 +305,51 @@ func TestVersionStore_GetInstalledReleaseIdentifier_NotFound(t *testing.T) {
 	}
 }
 
+func TestVersionStore_SourceRefBuild(t *testing.T) {
+	tempDir := t.TempDir()
+
+	store := filesystem.New(&filesystem.Config{
+		VersionsDir:  tempDir,
+		GlobalBinDir: t.TempDir(),
+	})
+
+	versionDir := filepath.Join(tempDir, "pr-12345")
+
+	err := os.MkdirAll(versionDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = filesystem.WriteManifest(versionDir, vtypes.Manifest{
+		Source:     vtypes.ManifestSourceBuild,
+		Identifier: "1111111aaaaaaa",
+		Ref:        "pr:12345",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A hidden staging directory from an in-progress build is not a version.
+	err = os.MkdirAll(filepath.Join(tempDir, ".pr-12345-build-1"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := store.GetManifest("pr-12345")
+	if err != nil || manifest.Ref != "pr:12345" {
+		t.Errorf("GetManifest = %+v, %v; want ref pr:12345", manifest, err)
+	}
+
+	versions, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	if len(versions) != 1 || versions[0].Type() != vtypes.TypeSourceRef {
+		t.Errorf("List = %v, want only pr-12345 as a source ref build", versions)
+	}
+}
+
 func TestVersionStore_Switch_Concurrent(t *testing.T) {
 	if runtime.GOOS == windowsOS {
 		t.Skip("Skipping symlink test on Windows")
@@ -6,16 +6,22 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/domain/release"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
 	"github.com/y3owk1n/nvs/internal/log"
 )
 
 // Cache handles caching of GitHub releases.
 //
+// Releases fetched from the API also get a metadata sidecar (see Meta)
+// next to the cache file, so a stale cache can be revalidated with
+// conditional requests instead of downloaded again.
+//
 // Cache is safe for concurrent use. Set serializes the temp-file write
 // and rename so two concurrent Set calls cannot corrupt the cache file
 // by racing on the shared temp file path.
@@ -26,6 +32,28 @@ type Cache struct {
 	setMu sync.Mutex
 }
 
+// Meta describes how the cached releases were fetched.
+//
+//nolint:tagliatelle
+type Meta struct {
+	// FetchedAt is when the releases were last fetched or confirmed
+	// unchanged; the TTL runs from here.
+	FetchedAt time.Time `json:"fetched_at"`
+	// Pages holds the validators of each page of the release list,
+	// in order.
+	Pages []PageMeta `json:"pages,omitempty"`
+}
+
+// PageMeta holds the validators GitHub returned for one page of the
+// release list.
+//
+//nolint:tagliatelle
+type PageMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
 // NewCache creates a new cache instance.
 func NewCache(filePath string, ttl time.Duration) *Cache {
 	return &Cache{
@@ -41,8 +69,16 @@ func (c *Cache) Get() ([]release.Release, error) {
 		return nil, err
 	}
 
-	// Check if cache is stale
-	if time.Since(info.ModTime()) >= c.ttl {
+	// Check if cache is stale. A revalidation moves FetchedAt on
+	// without rewriting the cache file.
+	fetchedAt := info.ModTime()
+
+	meta, metaErr := c.Meta()
+	if metaErr == nil && meta.FetchedAt.After(fetchedAt) {
+		fetchedAt = meta.FetchedAt
+	}
+
+	if time.Since(fetchedAt) >= c.ttl {
 		return nil, ErrCacheStale
 	}
 
@@ -138,11 +174,54 @@ func (c *Cache) Set(releases []release.Release) error {
 		return fmt.Errorf("rename cache temp file: %w", err)
 	}
 
+	// The old validators describe the old content.
+	removeErr := os.Remove(c.metaPath())
+	if removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+		log.Warnf("Failed to remove cache metadata %s: %v", c.metaPath(), removeErr)
+	}
+
 	log.Debugf("Cached %d releases to %s", len(releases), c.filePath)
 
 	return nil
 }
 
+// Meta reads the metadata sidecar of the cache.
+func (c *Cache) Meta() (Meta, error) {
+	data, err := os.ReadFile(c.metaPath())
+	if err != nil {
+		return Meta{}, err
+	}
+
+	var meta Meta
+
+	err = json.Unmarshal(data, &meta)
+	if err != nil {
+		return Meta{}, fmt.Errorf("failed to decode %s: %w", c.metaPath(), err)
+	}
+
+	return meta, nil
+}
+
+// SetMeta writes the metadata sidecar of the cache. Call it after Set,
+// which drops the sidecar of the previous content.
+func (c *Cache) SetMeta(meta Meta) error {
+	c.setMu.Lock()
+	defer c.setMu.Unlock()
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	return filesystem.WriteFileAtomic(c.metaPath(), data)
+}
+
+// metaPath returns the sidecar path: releases.json gets
+// releases.meta.json.
+func (c *Cache) metaPath() string {
+	return strings.TrimSuffix(c.filePath, ".json") + ".meta.json"
+}
+
 // read loads, decodes, and converts the on-disk cache file. It
 // deletes the file if the JSON is corrupted.
 func (c *Cache) read() ([]release.Release, error) {
@@ -352,3 +352,56 @@ func TestCache_Set_ConcurrentDoesNotCorrupt(t *testing.T) {
 		t.Errorf("Got %d releases, want 10", len(got))
 	}
 }
+
+func TestCache_Meta(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "releases.json")
+	cache := github.NewCache(cacheFile, time.Hour)
+
+	releases := []release.Release{
+		release.New(cacheTestTag, false, "abc123", time.Now(), []release.Asset{}),
+	}
+
+	err := cache.Set(releases)
+	if err != nil {
+		t.Fatalf("Cache.Set() error = %v", err)
+	}
+
+	meta := github.Meta{
+		FetchedAt: time.Now().UTC(),
+		Pages:     []github.PageMeta{{URL: "https://api.example.com/releases", ETag: `W/"v1"`}},
+	}
+
+	err = cache.SetMeta(meta)
+	if err != nil {
+		t.Fatalf("Cache.SetMeta() error = %v", err)
+	}
+
+	// A revalidation keeps an old cache file fresh.
+	old := time.Now().Add(-2 * time.Hour)
+
+	err = os.Chtimes(cacheFile, old, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = cache.Get()
+	if err != nil {
+		t.Errorf("Cache.Get() after a recent revalidation error = %v", err)
+	}
+
+	got, err := cache.Meta()
+	if err != nil || len(got.Pages) != 1 || got.Pages[0].ETag != `W/"v1"` {
+		t.Errorf("Cache.Meta() = %+v, %v", got, err)
+	}
+
+	// New content drops the validators of the old.
+	err = cache.Set(releases)
+	if err != nil {
+		t.Fatalf("Cache.Set() error = %v", err)
+	}
+
+	_, err = cache.Meta()
+	if !os.IsNotExist(err) {
+		t.Errorf("Cache.Meta() after Set error = %v, want not exist", err)
+	}
+}
@@ -26,8 +26,17 @@ type Client struct {
 	httpClient     *http.Client
 	cache          *Cache
 	minVersion     string
-	mirrorURL      string // Optional mirror URL for GitHub (e.g., https://mirror.ghproxy.com)
-	useGlobalCache bool   // Whether to use global cache
+	mirrorURL      string        // Optional mirror URL for GitHub (e.g., https://mirror.ghproxy.com)
+	repo           Repo          // Where releases are listed
+	token          func() string // Token for API requests, nil without one
+	useGlobalCache bool          // Whether to use global cache
+	offline        bool          // Never fetch; serve the disk cache whatever its age
+
+	// rateMu guards the quota reported by the latest API response.
+	rateMu     sync.Mutex
+	rateLimit  RateLimit
+	rateSeen   bool
+	rateWarned bool
 
 	// memCacheMu guards memCacheReleases and memCacheLoaded. The
 	// in-memory cache mirrors the disk cache (see Cache below) so
@@ -48,6 +57,51 @@ type Client struct {
 	fetchMu sync.Mutex
 }
 
+// Option customizes a Client built by NewClient.
+type Option func(*Client)
+
+// WithTimeout sets the per-request timeout of the API client
+// (default constants.ClientTimeoutSec).
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient = httpclient.NewClient(timeout)
+	}
+}
+
+// WithOffline puts the client in offline mode: releases come from the
+// on-disk cache regardless of its age, and every request fails with
+// httpclient.ErrOffline instead of reaching the network.
+func WithOffline(offline bool) Option {
+	return func(c *Client) {
+		c.offline = offline
+	}
+}
+
+// WithRepo lists the releases of repo instead of neovim/neovim on
+// github.com.
+func WithRepo(repo Repo) Option {
+	return func(c *Client) {
+		c.repo = repo
+	}
+}
+
+// WithToken authenticates API requests with the token source
+// returns. source is called once, on the first request.
+func WithToken(source func() string) Option {
+	return func(c *Client) {
+		c.token = sync.OnceValue(source)
+	}
+}
+
+// bearerToken returns the token for API requests, or "" without one.
+func (c *Client) bearerToken() string {
+	if c.token == nil {
+		return ""
+	}
+
+	return c.token()
+}
+
 // NewClient creates a new GitHub client with caching.
 // mirrorURL is optional - pass empty string to use default GitHub URLs.
 // useGlobalCache enables fetching from global cache.
@@ -56,14 +110,26 @@ func NewClient(
 	cacheTTL time.Duration,
 	minVersion, mirrorURL string,
 	useGlobalCache bool,
+	opts ...Option,
 ) *Client {
-	return &Client{
+	client := &Client{
 		httpClient:     httpclient.NewClient(constants.ClientTimeoutSec * time.Second),
 		cache:          NewCache(cacheFilePath, cacheTTL),
 		minVersion:     minVersion,
 		mirrorURL:      mirrorURL,
+		repo:           DefaultRepo(),
 		useGlobalCache: useGlobalCache,
 	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	if client.offline {
+		client.httpClient = httpclient.NewOfflineClient()
+	}
+
+	return client
 }
 
 // ApplyMirrorToURL replaces the default GitHub URL with the mirror URL if configured.
@@ -205,6 +271,10 @@ type apiAsset struct {
 // Resilience: if every fresh source (global cache, GitHub API) fails
 // AND an on-disk cache exists, the stale cache is returned as a
 // last resort so a transient network blip doesn't break the command.
+//
+// Offline: the on-disk cache is the only source, whatever its age
+// and whatever force says; without one GetAll fails with
+// httpclient.ErrOffline.
 func (c *Client) GetAll(ctx context.Context, force bool) ([]release.Release, error) {
 	// Fast path: read in-memory cache without taking the fetch lock.
 	if !force {
@@ -229,6 +299,10 @@ func (c *Client) GetAll(ctx context.Context, force bool) ([]release.Release, err
 		}
 	}
 
+	if c.offline {
+		return c.getAllOffline()
+	}
+
 	// Try local disk cache first unless force is true
 	if !force {
 		cached, err := c.cache.Get()
@@ -247,10 +321,12 @@ func (c *Client) GetAll(ctx context.Context, force bool) ([]release.Release, err
 	// Cache is stale or missing, fetch fresh data
 	var (
 		releases []release.Release
+		pages    []PageMeta
 		err      error
 	)
 
-	if c.useGlobalCache {
+	// The global cache lists neovim/neovim's releases only.
+	if c.useGlobalCache && c.repo.IsDefault() {
 		log.Debug("Fetching fresh releases from global cache")
 
 		releases, err = c.FetchRemoteVersionsJSON(ctx)
@@ -259,12 +335,25 @@ func (c *Client) GetAll(ctx context.Context, force bool) ([]release.Release, err
 				"Global cache fetch failed, falling back to GitHub API: %v",
 				err,
 			)
-			releases, err = c.fetchFromGitHubAPI(ctx)
+			releases, pages, err = c.fetchFromGitHubAPI(ctx, nil)
 		}
 	} else {
 		log.Debug("Fetching fresh releases from GitHub")
 
-		releases, err = c.fetchFromGitHubAPI(ctx)
+		releases, pages, err = c.fetchFromGitHubAPI(ctx, c.validators())
+	}
+
+	if errors.Is(err, errNotModified) {
+		cached, keepErr := c.keepCache(pages)
+		if keepErr == nil {
+			c.storeMemCache(cached)
+
+			return cached, nil
+		}
+
+		log.Debugf("Cannot reuse the revalidated cache: %v", keepErr)
+
+		releases, pages, err = c.fetchFromGitHubAPI(ctx, nil)
 	}
 
 	if err != nil {
@@ -292,6 +381,11 @@ func (c *Client) GetAll(ctx context.Context, force bool) ([]release.Release, err
 	setErr := c.cache.Set(releases)
 	if setErr != nil {
 		log.Warnf("Failed to update cache: %v", setErr)
+	} else if len(releases) > 0 && len(pages) > 0 {
+		setErr = c.cache.SetMeta(Meta{FetchedAt: time.Now().UTC(), Pages: pages})
+		if setErr != nil {
+			log.Warnf("Failed to update cache metadata: %v", setErr)
+		}
 	}
 
 	c.storeMemCache(releases)
@@ -299,6 +393,25 @@ func (c *Client) GetAll(ctx context.Context, force bool) ([]release.Release, err
 	return releases, nil
 }
 
+// getAllOffline serves the on-disk cache without checking its TTL.
+// The caller holds fetchMu.
+func (c *Client) getAllOffline() ([]release.Release, error) {
+	cached, err := c.cache.GetIgnoreStale()
+	if err != nil {
+		log.Debugf("Offline cache read failed: %v", err)
+
+		return nil, fmt.Errorf(
+			"%w: no cached release list yet (run any nvs command online once)",
+			httpclient.ErrOffline,
+		)
+	}
+
+	log.Debug("Offline: using on-disk cached releases")
+	c.storeMemCache(cached)
+
+	return cached, nil
+}
+
 // FindStable returns the latest stable release.
 func (c *Client) FindStable(ctx context.Context) (release.Release, error) {
 	releases, err := c.GetAll(ctx, false)
@@ -366,28 +479,103 @@ func (c *Client) FindByTag(ctx context.Context, tag string) (release.Release, er
 // the GitHub API.
 const apiPageSize = 100
 
-// fetchFromGitHubAPI fetches releases directly from the GitHub API.
-func (c *Client) fetchFromGitHubAPI(ctx context.Context) ([]release.Release, error) {
+// validators returns the page validators of the on-disk cache, or nil
+// when it cannot be revalidated.
+func (c *Client) validators() []PageMeta {
+	meta, err := c.cache.Meta()
+	if err != nil {
+		return nil
+	}
+
+	return meta.Pages
+}
+
+// keepCache serves the on-disk cache after the API confirmed it is
+// unchanged, restarting its TTL.
+func (c *Client) keepCache(pages []PageMeta) ([]release.Release, error) {
+	cached, err := c.cache.GetIgnoreStale()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug("Releases unchanged since the last fetch; keeping the cache")
+
+	err = c.cache.SetMeta(Meta{FetchedAt: time.Now().UTC(), Pages: pages})
+	if err != nil {
+		log.Warnf("Failed to update cache metadata: %v", err)
+	}
+
+	return cached, nil
+}
+
+// apiPage is one page of the release list.
+type apiPage struct {
+	releases []apiRelease
+	// last is set when no more pages follow.
+	last bool
+	// notModified is set when the server confirmed the validators
+	// sent with the request; releases is empty then.
+	notModified bool
+	// meta holds the page's validators for the next fetch.
+	meta PageMeta
+}
+
+// fetchFromGitHubAPI fetches releases directly from the GitHub API,
+// with the validators of each page for the next fetch.
+//
+// Given the validators of the previous fetch, pages are requested
+// conditionally; 304 responses do not count against the rate limit.
+// When every page is unchanged it returns errNotModified and the
+// validators to keep. After the first changed page the rest are
+// fetched in full, and any unchanged pages before it are fetched
+// again, since the new list needs their content.
+func (c *Client) fetchFromGitHubAPI(
+	ctx context.Context,
+	previous []PageMeta,
+) ([]release.Release, []PageMeta, error) {
 	const maxPages = 50
 
 	apiReleases := make([]apiRelease, 0, apiPageSize)
+	pages := make([]PageMeta, 0, len(previous))
+	unchanged := 0
 
 	for page := 1; page <= maxPages; page++ {
-		url := fmt.Sprintf(
-			"%s/repos/neovim/neovim/releases?page=%d&per_page=%d",
-			constants.DefaultAPIBaseURL,
-			page,
-			apiPageSize,
-		)
+		validator := PageMeta{URL: c.repo.ReleasesURL(page, apiPageSize)}
+		if unchanged == page-1 && page <= len(previous) && previous[page-1].URL == validator.URL {
+			validator = previous[page-1]
+		}
 
-		pageReleases, lastPage, err := c.fetchGitHubAPIPage(ctx, url)
+		result, err := c.fetchGitHubAPIPage(ctx, validator)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		apiReleases = append(apiReleases, pageReleases...)
+		if result.notModified {
+			unchanged++
+			pages = append(pages, result.meta)
 
-		if lastPage {
+			if unchanged == len(previous) {
+				return nil, pages, errNotModified
+			}
+
+			continue
+		}
+
+		if unchanged > 0 {
+			log.Debugf("Release list page %d changed; refetching %d unchanged page(s)", page, unchanged)
+
+			apiReleases, pages, err = c.fetchPages(ctx, unchanged)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			unchanged = 0
+		}
+
+		apiReleases = append(apiReleases, result.releases...)
+		pages = append(pages, result.meta)
+
+		if result.last {
 			break
 		}
 	}
@@ -397,19 +585,45 @@ func (c *Client) fetchFromGitHubAPI(ctx context.Context) ([]release.Release, err
 	// Filter releases >= minVersion
 	filtered := filterReleases(releases, c.minVersion)
 
-	return filtered, nil
+	return filtered, pages, nil
 }
 
-// fetchGitHubAPIPage fetches a single page of releases. lastPage is
-// true when the server returned fewer results than apiPageSize (or
+// fetchPages fetches the first count pages of the release list
+// unconditionally.
+func (c *Client) fetchPages(ctx context.Context, count int) ([]apiRelease, []PageMeta, error) {
+	apiReleases := make([]apiRelease, 0, count*apiPageSize)
+	pages := make([]PageMeta, 0, count)
+
+	for page := 1; page <= count; page++ {
+		result, err := c.fetchGitHubAPIPage(ctx, PageMeta{URL: c.repo.ReleasesURL(page, apiPageSize)})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		apiReleases = append(apiReleases, result.releases...)
+		pages = append(pages, result.meta)
+	}
+
+	return apiReleases, pages, nil
+}
+
+// fetchGitHubAPIPage fetches the page at validator.URL, conditionally
+// when validator holds an ETag or Last-Modified date. The page is the
+// last when the server returned fewer results than apiPageSize (or
 // zero) and there are no more pages to fetch.
-func (c *Client) fetchGitHubAPIPage(
-	ctx context.Context,
-	url string,
-) ([]apiRelease, bool, error) {
-	resp, err := c.doWithRetry(ctx, url)
+func (c *Client) fetchGitHubAPIPage(ctx context.Context, validator PageMeta) (apiPage, error) {
+	header := make(http.Header)
+	if validator.ETag != "" {
+		header.Set("If-None-Match", validator.ETag)
+	}
+
+	if validator.LastModified != "" {
+		header.Set("If-Modified-Since", validator.LastModified)
+	}
+
+	resp, err := c.doWithRetryHeader(ctx, validator.URL, header)
 	if err != nil {
-		return nil, false, err
+		return apiPage{}, err
 	}
 
 	defer func() {
@@ -418,12 +632,32 @@ func (c *Client) fetchGitHubAPIPage(
 
 	log.Debugf("GitHub API status code: %d", resp.StatusCode)
 
+	_, limited := rateLimitWait(resp)
+	if limited {
+		quota, _ := parseRateLimit(resp.Header)
+
+		return apiPage{}, fmt.Errorf(
+			"%w: %d requests used, resets at %s",
+			ErrRateLimitExceeded,
+			quota.Limit,
+			quota.Reset.Local().Format("15:04"),
+		)
+	}
+
 	if resp.StatusCode == http.StatusForbidden {
-		return nil, false, fmt.Errorf("%w: please try again later", ErrRateLimitExceeded)
+		return apiPage{}, fmt.Errorf("%w: please try again later", ErrRateLimitExceeded)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.bearerToken() != "" {
+		return apiPage{}, ErrBadToken
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		return apiPage{notModified: true, meta: validator}, nil
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, false, fmt.Errorf("%w: %d", ErrAPIRequestFailed, resp.StatusCode)
+		return apiPage{}, fmt.Errorf("%w: %d", ErrAPIRequestFailed, resp.StatusCode)
 	}
 
 	var apiReleases []apiRelease
@@ -432,16 +666,18 @@ func (c *Client) fetchGitHubAPIPage(
 
 	err = dec.Decode(&apiReleases)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if len(apiReleases) == 0 {
-		return apiReleases, true, nil
-	}
-
-	lastPage := len(apiReleases) < apiPageSize
-
-	return apiReleases, lastPage, nil
+		return apiPage{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return apiPage{
+		releases: apiReleases,
+		last:     len(apiReleases) < apiPageSize,
+		meta: PageMeta{
+			URL:          validator.URL,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}, nil
 }
 
 // convertReleases converts API releases to domain releases.
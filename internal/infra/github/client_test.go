@@ -2,6 +2,9 @@ package github_test
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -13,6 +16,7 @@ import (
 
 	"github.com/y3owk1n/nvs/internal/domain/release"
 	"github.com/y3owk1n/nvs/internal/infra/github"
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
 )
 
 const (
@@ -703,3 +707,167 @@ func TestClient_GetAll_ForceBypassesMemCache(t *testing.T) {
 		)
 	}
 }
+
+// TestClient_GetAll_Offline verifies offline mode serves the disk
+// cache past its TTL, even with force, and fails with ErrOffline when
+// there is no cache rather than reaching the network.
+func TestClient_GetAll_Offline(t *testing.T) {
+	cacheFile := writeCacheFile(t, []map[string]any{
+		{
+			testKeyTagName: testV090,
+			testKeyPreRel:  false,
+			testKeyTarget:  testCommitHash,
+			testKeyPubAt:   testPubAt,
+			testKeyAssets:  []map[string]any{},
+		},
+	})
+
+	// A zero TTL makes the cache stale straight away.
+	client := github.NewClient(cacheFile, 0, "", "", false, github.WithOffline(true))
+
+	releases, err := client.GetAll(t.Context(), true)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+
+	if len(releases) != 1 || releases[0].TagName() != testV090 {
+		t.Errorf("GetAll() = %v, want the cached %s", releases, testV090)
+	}
+
+	missing := github.NewClient(
+		filepath.Join(t.TempDir(), "releases.json"),
+		time.Hour,
+		"",
+		"",
+		true,
+		github.WithOffline(true),
+	)
+
+	_, err = missing.GetAll(t.Context(), false)
+	if !errors.Is(err, httpclient.ErrOffline) {
+		t.Errorf("GetAll() without cache error = %v, want ErrOffline", err)
+	}
+}
+
+// TestClient_GetAll_Repo verifies release lists come from the
+// repository and API set with WithRepo, as on GitHub Enterprise.
+func TestClient_GetAll_Repo(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/editors/neovim/releases" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode([]map[string]any{{
+			testKeyTagName: testV090,
+			testKeyPreRel:  false,
+			testKeyTarget:  testCommitHash,
+			testKeyPubAt:   testPubAt,
+			testKeyAssets:  []map[string]any{},
+		}})
+	}))
+	defer server.Close()
+
+	client := github.NewClient(
+		filepath.Join(t.TempDir(), "releases.json"),
+		time.Hour,
+		"",
+		"",
+		true,
+		github.WithRepo(github.NewRepo(server.URL+"/api/v3/", "editors/neovim")),
+	)
+
+	releases, err := client.GetAll(t.Context(), true)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+
+	if len(releases) != 1 || releases[0].TagName() != testV090 {
+		t.Errorf("GetAll() = %v, want %s from the fake API", releases, testV090)
+	}
+}
+
+// TestClient_GetAll_Revalidates verifies a stale cache is revalidated
+// with If-None-Match and kept on a 304.
+func TestClient_GetAll_Revalidates(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu          sync.Mutex
+		etag        = `"v1"`
+		tag         = testV090
+		full        int
+		revalidated int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Header.Get("If-None-Match") == etag {
+			revalidated++
+
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		full++
+
+		w.Header().Set("ETag", etag)
+		_ = json.NewEncoder(w).Encode([]map[string]any{{
+			testKeyTagName: tag,
+			testKeyPreRel:  false,
+			testKeyTarget:  testCommitHash,
+			testKeyPubAt:   testPubAt,
+			testKeyAssets:  []map[string]any{},
+		}})
+	}))
+	defer server.Close()
+
+	cacheFile := filepath.Join(t.TempDir(), "releases.json")
+	getAll := func() []release.Release {
+		t.Helper()
+
+		// A zero TTL makes every call refresh; a new client skips
+		// the in-memory cache.
+		client := github.NewClient(
+			cacheFile,
+			0,
+			"",
+			"",
+			false,
+			github.WithRepo(github.NewRepo(server.URL, "")),
+		)
+
+		releases, err := client.GetAll(t.Context(), false)
+		if err != nil {
+			t.Fatalf("GetAll() error = %v", err)
+		}
+
+		return releases
+	}
+
+	getAll()
+	releases := getAll()
+
+	if full != 1 || revalidated != 1 {
+		t.Errorf("requests: %d full, %d not modified; want the second one revalidated", full, revalidated)
+	}
+
+	if len(releases) != 1 || releases[0].TagName() != testV090 {
+		t.Errorf("revalidated GetAll() = %v, want the cached %s", releases, testV090)
+	}
+
+	mu.Lock()
+	etag, tag = `"v2"`, "v0.10.0"
+	mu.Unlock()
+
+	releases = getAll()
+	if full != 2 || len(releases) != 1 || releases[0].TagName() != "v0.10.0" {
+		t.Errorf("GetAll() after a change = %v (%d full requests), want v0.10.0", releases, full)
+	}
+}
@@ -7,6 +7,9 @@ var (
 	// ErrRateLimitExceeded is returned when GitHub API rate limit is exceeded.
 	ErrRateLimitExceeded = errors.New("GitHub API rate limit exceeded")
 
+	// ErrBadToken is returned when GitHub rejects the configured token.
+	ErrBadToken = errors.New("GitHub rejected the token")
+
 	// ErrAPIRequestFailed is returned when an API request fails.
 	ErrAPIRequestFailed = errors.New("API request failed")
 
@@ -21,4 +24,8 @@ var (
 
 	// ErrCacheStale is returned when the cache file exists but has expired.
 	ErrCacheStale = errors.New("cache is stale")
+
+	// errNotModified is returned when a revalidation found the cached
+	// releases unchanged.
+	errNotModified = errors.New("releases not modified")
 )
@@ -0,0 +1,98 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// RateLimit is the API quota GitHub reported on a response.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// parseRateLimit reads the X-RateLimit-* headers. It reports false
+// when they are missing, as on responses from anything but the API.
+func parseRateLimit(header http.Header) (RateLimit, bool) {
+	limit, limitErr := strconv.Atoi(header.Get("X-Ratelimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(header.Get("X-Ratelimit-Remaining"))
+
+	if limitErr != nil || remainingErr != nil {
+		return RateLimit{}, false
+	}
+
+	quota := RateLimit{Limit: limit, Remaining: remaining}
+
+	reset, err := strconv.ParseInt(header.Get("X-Ratelimit-Reset"), 10, 64)
+	if err == nil {
+		quota.Reset = time.Unix(reset, 0)
+	}
+
+	return quota, true
+}
+
+// rateLimitWait reports whether resp was refused because the quota
+// ran out and, if so, how long until it resets.
+func rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	quota, ok := parseRateLimit(resp.Header)
+	if !ok || quota.Remaining > 0 {
+		return 0, false
+	}
+
+	// A second of slack covers clock skew with GitHub.
+	return max(time.Until(quota.Reset), 0) + time.Second, true
+}
+
+// RateLimit returns the quota reported by the latest API response in
+// this process. It reports false until the client has reached the API.
+func (c *Client) RateLimit() (RateLimit, bool) {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+
+	return c.rateLimit, c.rateSeen
+}
+
+// recordRateLimit keeps the quota reported by resp, warning once when
+// it runs low.
+func (c *Client) recordRateLimit(resp *http.Response) {
+	quota, ok := parseRateLimit(resp.Header)
+	if !ok {
+		return
+	}
+
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+
+	c.rateLimit = quota
+	c.rateSeen = true
+
+	log.Debugf("GitHub API quota: %d of %d left", quota.Remaining, quota.Limit)
+
+	if quota.Remaining >= constants.LowRateLimit || c.rateWarned {
+		return
+	}
+
+	c.rateWarned = true
+
+	hint := ""
+	if c.bearerToken() == "" {
+		hint = "; set GITHUB_TOKEN for a higher limit"
+	}
+
+	log.Warnf(
+		"GitHub API quota low: %d of %d requests left until %s%s",
+		quota.Remaining,
+		quota.Limit,
+		quota.Reset.Local().Format("15:04"),
+		hint,
+	)
+}
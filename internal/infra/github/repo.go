@@ -0,0 +1,113 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+)
+
+// githubEnterpriseAPIPath is where GitHub Enterprise Server serves its
+// REST API under the server's web URL.
+const githubEnterpriseAPIPath = "/api/v3"
+
+const (
+	// releasesCacheFile caches the default repository's releases.
+	releasesCacheFile = "releases.json"
+
+	// repoKeyLen is how many hex characters of a hash of the API and
+	// repository name the cache file of another repository carries.
+	repoKeyLen = 12
+)
+
+// Repo locates the Neovim repository on a GitHub server: github.com
+// by default, or a GitHub Enterprise server carrying a fork.
+type Repo struct {
+	// APIBaseURL is the REST API root, such as
+	// https://api.github.com or https://ghe.example.com/api/v3.
+	APIBaseURL string
+	// Name is the repository as "owner/name".
+	Name string
+}
+
+// DefaultRepo returns neovim/neovim on github.com.
+func DefaultRepo() Repo {
+	return Repo{APIBaseURL: constants.DefaultAPIBaseURL, Name: constants.DefaultRepo}
+}
+
+// NewRepo returns the repository name on the API at apiBaseURL. Empty
+// arguments keep the defaults of DefaultRepo.
+func NewRepo(apiBaseURL, name string) Repo {
+	repo := DefaultRepo()
+
+	if apiBaseURL != "" {
+		repo.APIBaseURL = strings.TrimRight(apiBaseURL, "/")
+	}
+
+	if name != "" {
+		repo.Name = name
+	}
+
+	return repo
+}
+
+// IsDefault reports whether r is neovim/neovim on github.com.
+func (r Repo) IsDefault() bool {
+	return r == DefaultRepo()
+}
+
+// ReleasesURL returns the API URL of one page of the release list.
+func (r Repo) ReleasesURL(page, perPage int) string {
+	return fmt.Sprintf(
+		"%s/repos/%s/releases?page=%d&per_page=%d",
+		r.APIBaseURL,
+		r.Name,
+		page,
+		perPage,
+	)
+}
+
+// CompareURL returns the API URL comparing two commits.
+func (r Repo) CompareURL(base, head string) string {
+	return fmt.Sprintf(
+		"%s/repos/%s/compare/%s...%s",
+		r.APIBaseURL,
+		r.Name,
+		url.PathEscape(base),
+		url.PathEscape(head),
+	)
+}
+
+// WebURL returns the repository's page in the browser. The server is
+// github.com unless the API is a GitHub Enterprise one, which lives
+// under /api/v3 of the server's web URL.
+func (r Repo) WebURL() string {
+	server := constants.DefaultGitHubBaseURL
+	if strings.HasSuffix(r.APIBaseURL, githubEnterpriseAPIPath) {
+		server = strings.TrimSuffix(r.APIBaseURL, githubEnterpriseAPIPath)
+	}
+
+	return server + "/" + r.Name
+}
+
+// CloneURL returns the HTTPS URL to clone the repository from.
+func (r Repo) CloneURL() string {
+	return r.WebURL() + ".git"
+}
+
+// CacheFileName returns the name of the file the repository's release
+// list is cached in: releases.json for the default repository, and a
+// name keyed by the API and repository otherwise, so switching either
+// never serves another repository's releases.
+func (r Repo) CacheFileName() string {
+	if r.IsDefault() {
+		return releasesCacheFile
+	}
+
+	sum := sha256.Sum256([]byte(r.APIBaseURL + "/" + r.Name))
+
+	return fmt.Sprintf("releases-%s.json", hex.EncodeToString(sum[:])[:repoKeyLen])
+}
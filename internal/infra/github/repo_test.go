@@ -0,0 +1,69 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/infra/github"
+)
+
+func TestRepo_URLs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		repo        github.Repo
+		wantCompare string
+		wantClone   string
+	}{
+		{
+			name:        "github.com",
+			repo:        github.NewRepo("", ""),
+			wantCompare: "https://api.github.com/repos/neovim/neovim/compare/abc...def",
+			wantClone:   "https://github.com/neovim/neovim.git",
+		},
+		{
+			name:        "enterprise",
+			repo:        github.NewRepo("https://ghe.example.com/api/v3/", "editors/neovim"),
+			wantCompare: "https://ghe.example.com/api/v3/repos/editors/neovim/compare/abc...def",
+			wantClone:   "https://ghe.example.com/editors/neovim.git",
+		},
+		{
+			name:        "API proxy",
+			repo:        github.NewRepo("https://gh-api.example.com", ""),
+			wantCompare: "https://gh-api.example.com/repos/neovim/neovim/compare/abc...def",
+			wantClone:   "https://github.com/neovim/neovim.git",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.repo.CompareURL("abc", "def"); got != tt.wantCompare {
+				t.Errorf("CompareURL() = %q, want %q", got, tt.wantCompare)
+			}
+
+			if got := tt.repo.CloneURL(); got != tt.wantClone {
+				t.Errorf("CloneURL() = %q, want %q", got, tt.wantClone)
+			}
+		})
+	}
+
+	if !github.NewRepo("", "").IsDefault() || github.NewRepo("", "editors/neovim").IsDefault() {
+		t.Error("IsDefault() should hold for neovim/neovim on github.com only")
+	}
+
+	names := map[string]bool{}
+	for _, repo := range []github.Repo{
+		github.NewRepo("", ""),
+		github.NewRepo("", "editors/neovim"),
+		github.NewRepo("https://ghe.example.com/api/v3", ""),
+		github.NewRepo("https://ghe.example.com/api/v3", "editors/neovim"),
+	} {
+		names[repo.CacheFileName()] = true
+	}
+
+	if len(names) != 4 || !names["releases.json"] {
+		t.Errorf("CacheFileName() = %v, want a distinct name per repository", names)
+	}
+}
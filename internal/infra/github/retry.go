@@ -5,86 +5,21 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
 	"github.com/y3owk1n/nvs/internal/log"
 )
 
-// retriableStatus reports whether an HTTP status is worth retrying.
-// 429 (rate limit) and 5xx (server errors) are; everything else is
-// treated as terminal.
-func retriableStatus(code int) bool {
-	return code == http.StatusTooManyRequests ||
-		(code >= 500 && code < 600)
-}
-
-// retryAfterDelay returns how long to wait before the next attempt
-// based on the server's Retry-After / X-Ratelimit-Reset headers. If
-// the server did not provide a hint, the fallback delay is used.
-// A Retry-After of 0 is honored as "retry immediately".
-func retryAfterDelay(resp *http.Response, fallback time.Duration) time.Duration {
-	const maxHonored = time.Minute
-
-	headerVal := resp.Header.Get("Retry-After")
-	if headerVal != "" {
-		// Retry-After is in seconds (HTTP-date is also allowed but rare).
-		secs, err := strconv.Atoi(headerVal)
-		if err == nil && secs >= 0 {
-			delay := time.Duration(secs) * time.Second
-			if delay <= maxHonored {
-				return delay
-			}
-		}
-	}
-
-	headerVal = resp.Header.Get("X-Ratelimit-Reset")
-	if headerVal != "" {
-		// X-Ratelimit-Reset is a Unix timestamp (seconds).
-		resetUnix, err := strconv.ParseInt(headerVal, 10, 64)
-		if err == nil {
-			resetAt := time.Unix(resetUnix, 0)
-
-			delay := time.Until(resetAt)
-			if delay > 0 && delay <= maxHonored {
-				return delay
-			}
-		}
-	}
-
-	return fallback
-}
-
-// isRetriableNetError reports whether a network error is worth
-// retrying. Connection resets, refused connections, and DNS errors
-// are retriable; context cancellation is not.
-func isRetriableNetError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-		return false
-	}
-
-	return true
-}
-
-// sleepWithCtx sleeps for d, returning early if ctx is canceled.
-func sleepWithCtx(ctx context.Context, d time.Duration) {
-	if d <= 0 {
-		return
-	}
-
-	t := time.NewTimer(d)
-	defer t.Stop()
-
-	select {
-	case <-t.C:
-	case <-ctx.Done():
-	}
-}
+// The retry policy is shared with the downloader; these names keep
+// the call sites below short.
+var (
+	retriableStatus     = httpclient.RetriableStatus
+	retryAfterDelay     = httpclient.RetryAfterDelay
+	isRetriableNetError = httpclient.IsRetriableNetError
+	sleepWithCtx        = httpclient.SleepWithContext
+)
 
 // errExhaustedRetries is the sentinel error used when all retry
 // attempts have been used.
@@ -92,10 +27,22 @@ var errExhaustedRetries = errors.New("exhausted retries")
 
 // doWithRetry executes a GET with bounded exponential backoff for
 // transient failures (network errors, 429, 5xx). On 429 the
-// server's Retry-After / X-Ratelimit-Reset headers are respected.
-// The user-agent and the vnd.github+json Accept header are set on
-// every attempt.
+// server's Retry-After / X-Ratelimit-Reset headers are respected,
+// and when the API quota is used up the request waits for it to
+// reset (up to constants.MaxRateLimitWait). The user-agent and the
+// vnd.github+json Accept header are set on every attempt, and the
+// token on requests to the API.
 func (c *Client) doWithRetry(ctx context.Context, url string) (*http.Response, error) {
+	return c.doWithRetryHeader(ctx, url, nil)
+}
+
+// doWithRetryHeader is doWithRetry sending header, such as the
+// validators of a conditional request, on every attempt.
+func (c *Client) doWithRetryHeader(
+	ctx context.Context,
+	url string,
+	header http.Header,
+) (*http.Response, error) {
 	var lastErr error
 
 	for attempt := 0; attempt <= constants.MaxGitHubRetries; attempt++ {
@@ -104,8 +51,13 @@ func (c *Client) doWithRetry(ctx context.Context, url string) (*http.Response, e
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
 
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
 		req.Header.Set("User-Agent", "nvs")
 		req.Header.Set("Accept", "application/vnd.github+json")
+		c.repo.Authorize(req, c.bearerToken())
 
 		resp, doErr := c.httpClient.Do(req)
 		if doErr != nil {
@@ -116,6 +68,27 @@ func (c *Client) doWithRetry(ctx context.Context, url string) (*http.Response, e
 
 			log.Debugf("GitHub fetch attempt %d failed: %v", attempt+1, doErr)
 		} else {
+			c.recordRateLimit(resp)
+
+			wait, limited := rateLimitWait(resp)
+			if limited {
+				if wait > constants.MaxRateLimitWait || attempt == constants.MaxGitHubRetries {
+					return resp, nil
+				}
+
+				log.Warnf(
+					"GitHub API rate limit reached; waiting %s for it to reset",
+					wait.Round(time.Second),
+				)
+
+				_ = resp.Body.Close()
+				lastErr = ErrRateLimitExceeded
+
+				sleepWithCtx(ctx, wait)
+
+				continue
+			}
+
 			if !retriableStatus(resp.StatusCode) {
 				return resp, nil
 			}
@@ -220,6 +220,111 @@ func TestDoWithRetry_AbortsOnContextCancel(t *testing.T) {
 	}
 }
 
+func TestDoWithRetry_SendsTokenToAPIOnly(t *testing.T) {
+	auth := make(map[string]string)
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			auth[req.URL.Path] = req.Header.Get("Authorization")
+
+			writer.WriteHeader(http.StatusOK)
+		},
+	))
+	defer server.Close()
+
+	client := &Client{
+		httpClient: httpclient.NewClient(5 * time.Second),
+		repo:       NewRepo(server.URL+"/api", ""),
+		token:      func() string { return "s3cret" },
+	}
+
+	for _, path := range []string{"/api/repos/neovim/neovim/releases", "/mirror/nvim.tar.gz"} {
+		err := doWithRetryForTest(t.Context(), client, server.URL+path)
+		if err != nil {
+			t.Fatalf("doWithRetry(%s) error: %v", path, err)
+		}
+	}
+
+	if got := auth["/api/repos/neovim/neovim/releases"]; got != "Bearer s3cret" {
+		t.Errorf("API request Authorization = %q, want the bearer token", got)
+	}
+
+	if got := auth["/mirror/nvim.tar.gz"]; got != "" {
+		t.Errorf("non-API request Authorization = %q, want none", got)
+	}
+}
+
+func TestDoWithRetry_WaitsForRateLimitReset(t *testing.T) {
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			calls++
+
+			writer.Header().Set("X-Ratelimit-Limit", "60")
+			writer.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+
+			if calls == 1 {
+				writer.Header().Set("X-Ratelimit-Remaining", "0")
+				writer.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			writer.Header().Set("X-Ratelimit-Remaining", "59")
+			writer.WriteHeader(http.StatusOK)
+		},
+	))
+	defer server.Close()
+
+	client := &Client{httpClient: httpclient.NewClient(5 * time.Second)}
+
+	resp, err := client.doWithRetry(t.Context(), server.URL)
+	if err != nil {
+		t.Fatalf("doWithRetry error: %v", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if calls != 2 || resp.StatusCode != http.StatusOK {
+		t.Errorf("calls = %d, status = %d; want a retry after the reset", calls, resp.StatusCode)
+	}
+
+	quota, ok := client.RateLimit()
+	if !ok || quota.Limit != 60 || quota.Remaining != 59 {
+		t.Errorf("RateLimit() = %+v, %v; want 59 of 60 left", quota, ok)
+	}
+}
+
+func TestFetchGitHubAPIPage_RateLimitBeyondMaxWait(t *testing.T) {
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			calls++
+
+			reset := time.Now().Add(constants.MaxRateLimitWait + time.Hour)
+
+			writer.Header().Set("X-Ratelimit-Limit", "60")
+			writer.Header().Set("X-Ratelimit-Remaining", "0")
+			writer.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			writer.WriteHeader(http.StatusForbidden)
+		},
+	))
+	defer server.Close()
+
+	client := &Client{httpClient: httpclient.NewClient(5 * time.Second)}
+
+	_, err := client.fetchGitHubAPIPage(t.Context(), PageMeta{URL: server.URL})
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("error = %v, want ErrRateLimitExceeded", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (no wait past MaxRateLimitWait)", calls)
+	}
+}
+
 func TestRetryAfterDelay_RetryAfterSeconds(t *testing.T) {
 	resp := httptest.NewRecorder()
 	resp.Header().Set("Retry-After", strconv.Itoa(5))
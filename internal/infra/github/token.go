@@ -0,0 +1,94 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+const (
+	// explicitTokenEnv holds a token meant for nvs, which is sent to
+	// whichever API nvs is pointed at.
+	explicitTokenEnv = "NVS_GITHUB_TOKEN"
+	// githubTokenEnv holds a token for github.com, such as the one
+	// GitHub Actions provides, which only api.github.com receives.
+	githubTokenEnv = "GITHUB_TOKEN"
+)
+
+// Token returns the token to authenticate API requests to repo's
+// server with, and where it came from. NVS_GITHUB_TOKEN is used for
+// any API. GITHUB_TOKEN is only used for api.github.com, and the
+// GitHub CLI ('gh auth token') is only asked for a token of the API's
+// own host, so an API that is a proxy or some other server never
+// receives a token it was not given explicitly. Both are empty
+// without one.
+func Token(ctx context.Context, repo Repo) (string, string) {
+	token := strings.TrimSpace(os.Getenv(explicitTokenEnv))
+	if token != "" {
+		return token, explicitTokenEnv
+	}
+
+	var host string
+
+	if repo.APIBaseURL == constants.DefaultAPIBaseURL {
+		token = strings.TrimSpace(os.Getenv(githubTokenEnv))
+		if token != "" {
+			return token, githubTokenEnv
+		}
+
+		// gh knows github.com by its web host.
+		host = strings.TrimPrefix(constants.DefaultGitHubBaseURL, "https://")
+	} else {
+		apiURL, err := url.Parse(repo.APIBaseURL)
+		if err != nil || apiURL.Host == "" {
+			return "", ""
+		}
+
+		host = apiURL.Host
+	}
+
+	return ghToken(ctx, host)
+}
+
+// ghToken returns the GitHub CLI's token for host, which gh only has
+// when logged in to that host.
+func ghToken(ctx context.Context, host string) (string, string) {
+	_, err := exec.LookPath("gh")
+	if err != nil {
+		return "", ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.GHTokenTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		log.Debugf("No token from gh for %s: %v", host, err)
+
+		return "", ""
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", ""
+	}
+
+	return token, "gh auth token"
+}
+
+// Authorize adds token to req as a bearer token when req goes to
+// repo's API. Requests anywhere else, such as mirrors or release
+// downloads, are left anonymous.
+func (r Repo) Authorize(req *http.Request, token string) {
+	if token == "" || !strings.HasPrefix(req.URL.String(), r.APIBaseURL+"/") {
+		return
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+}
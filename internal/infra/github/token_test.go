@@ -0,0 +1,88 @@
+package github_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/infra/github"
+)
+
+func TestToken(t *testing.T) {
+	// Without gh on PATH only the environment counts.
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("NVS_GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+
+	token, source := github.Token(t.Context(), github.DefaultRepo())
+	if token != "" || source != "" {
+		t.Errorf("Token() = %q, %q; want none", token, source)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "from-actions")
+
+	token, source = github.Token(t.Context(), github.DefaultRepo())
+	if token != "from-actions" || source != "GITHUB_TOKEN" {
+		t.Errorf("Token() = %q, %q; want GITHUB_TOKEN", token, source)
+	}
+
+	t.Setenv("NVS_GITHUB_TOKEN", "for-nvs")
+
+	token, source = github.Token(t.Context(), github.DefaultRepo())
+	if token != "for-nvs" || source != "NVS_GITHUB_TOKEN" {
+		t.Errorf("Token() = %q, %q; want NVS_GITHUB_TOKEN to win", token, source)
+	}
+}
+
+func TestToken_OnlyVouchedHosts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as gh")
+	}
+
+	// The fake gh is only logged in to github.com and ghe.example.com.
+	binDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(binDir, "gh"), []byte(`#!/bin/sh
+case "$4" in
+github.com) echo from-gh ;;
+ghe.example.com) echo from-ghe ;;
+*) exit 1 ;;
+esac
+`), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", binDir)
+	t.Setenv("NVS_GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "from-actions")
+
+	proxy := github.NewRepo("https://proxy.example.com", "")
+
+	token, source := github.Token(t.Context(), proxy)
+	if token != "" || source != "" {
+		t.Errorf("Token(proxy) = %q, %q; want none", token, source)
+	}
+
+	enterprise := github.NewRepo("https://ghe.example.com/api/v3", "")
+
+	token, _ = github.Token(t.Context(), enterprise)
+	if token != "from-ghe" {
+		t.Errorf("Token(enterprise) = %q, want the token gh has for its host", token)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "")
+
+	token, _ = github.Token(t.Context(), github.DefaultRepo())
+	if token != "from-gh" {
+		t.Errorf("Token(default) = %q, want the token gh has for github.com", token)
+	}
+
+	t.Setenv("NVS_GITHUB_TOKEN", "for-nvs")
+
+	token, _ = github.Token(t.Context(), proxy)
+	if token != "for-nvs" {
+		t.Errorf("Token(proxy) = %q, want an explicit NVS_GITHUB_TOKEN", token)
+	}
+}
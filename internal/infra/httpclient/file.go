@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrFileRedirect is returned when a remote server redirects to a
+// file:// URL.
+var ErrFileRedirect = errors.New("refusing to follow a redirect to a local file")
+
+// ErrLocalFile is returned for a file:// URL on a client that does not
+// serve local files.
+var ErrLocalFile = errors.New("refusing to read a local file outside a local release source")
+
+// fileTransport serves file:// URLs from the local filesystem, so a
+// release source or archive on disk (an NFS share, a test fixture)
+// goes through the same download path as one on a server, Range
+// requests included.
+var fileTransport http.RoundTripper = guardedFileTransport{
+	next: http.NewFileTransport(localFS{}),
+}
+
+// AllowLocalFiles returns a copy of client that also serves file://
+// URLs from the local filesystem, even when client is offline. Only a
+// client for a release source on a local path should get it: any other
+// client would let a URL from a remote server, such as an asset URL in
+// a release list, read local files.
+func AllowLocalFiles(client *http.Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	local := *client
+	local.Transport = localFileTransport{next: next}
+
+	return &local
+}
+
+// localFileTransport serves file:// URLs with fileTransport and sends
+// every other request to next.
+type localFileTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t localFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "file" {
+		return fileTransport.RoundTrip(req)
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// refusedFileTransport fails every file:// request with ErrLocalFile.
+type refusedFileTransport struct{}
+
+// RoundTrip implements http.RoundTripper.
+func (refusedFileTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, ErrLocalFile
+}
+
+// guardedFileTransport only serves file:// URLs the caller asked for.
+// Without the guard, any server nvs talks to could read local files
+// into a download or an error message by redirecting to them.
+type guardedFileTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t guardedFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Response != nil {
+		return nil, ErrFileRedirect
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// localFS maps the slash-separated path of a file:// URL onto the
+// local filesystem. On Windows, file:///C:/nvim arrives as "/C:/nvim".
+type localFS struct{}
+
+// Open implements http.FileSystem.
+func (localFS) Open(name string) (http.File, error) {
+	if runtime.GOOS == "windows" {
+		name = strings.TrimPrefix(name, "/")
+	}
+
+	return os.Open(filepath.FromSlash(name))
+}
@@ -22,10 +22,15 @@
 package httpclient
 
 import (
+	"errors"
 	"net/http"
 	"time"
 )
 
+// ErrOffline is returned instead of sending a request when offline
+// mode is on.
+var ErrOffline = errors.New("network access is disabled in offline mode")
+
 // DefaultTimeout is the per-request timeout used when a caller does
 // not specify one. It is the same as the previous ad-hoc
 // constants.ClientTimeoutSec default in the GitHub client.
@@ -62,6 +67,9 @@ var sharedTransport = func() *http.Transport {
 	// Bound how long an idle connection lingers in the pool so we
 	// do not hold sockets open between unrelated CLI invocations.
 	cloned.IdleConnTimeout = idleConnTimeout
+	// URLs from a remote server must never read local files; only
+	// clients passed through AllowLocalFiles serve file:// URLs.
+	cloned.RegisterProtocol("file", refusedFileTransport{})
 
 	return cloned
 }()
@@ -83,3 +91,20 @@ func NewClient(timeout time.Duration) *http.Client {
 func Transport() *http.Transport {
 	return sharedTransport
 }
+
+// NewOfflineClient returns an *http.Client that never touches the
+// network: every request fails with ErrOffline. Components that take
+// an offline option swap it in for their usual client, so any request
+// path they grow later is covered too.
+func NewOfflineClient() *http.Client {
+	return &http.Client{Transport: offlineTransport{}}
+}
+
+// offlineTransport refuses every request.
+type offlineTransport struct{}
+
+// RoundTrip implements http.RoundTripper. The client wraps the error
+// in a *url.Error that already names the request.
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, ErrOffline
+}
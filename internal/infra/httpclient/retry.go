@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxHonoredRetryAfter caps how long a server's retry hint is
+// honored; longer hints fall back to the caller's own backoff.
+const maxHonoredRetryAfter = time.Minute
+
+// RetriableStatus reports whether an HTTP status is worth retrying.
+// 429 (rate limit) and 5xx (server errors) are; everything else is
+// treated as terminal.
+func RetriableStatus(code int) bool {
+	return code == http.StatusTooManyRequests ||
+		(code >= 500 && code < 600)
+}
+
+// RetryAfterDelay returns how long to wait before the next attempt
+// based on the server's Retry-After / X-Ratelimit-Reset headers. If
+// the server did not provide a hint, the fallback delay is used.
+// A Retry-After of 0 is honored as "retry immediately".
+func RetryAfterDelay(resp *http.Response, fallback time.Duration) time.Duration {
+	headerVal := resp.Header.Get("Retry-After")
+	if headerVal != "" {
+		// Retry-After is in seconds (HTTP-date is also allowed but rare).
+		secs, err := strconv.Atoi(headerVal)
+		if err == nil && secs >= 0 {
+			delay := time.Duration(secs) * time.Second
+			if delay <= maxHonoredRetryAfter {
+				return delay
+			}
+		}
+	}
+
+	headerVal = resp.Header.Get("X-Ratelimit-Reset")
+	if headerVal != "" {
+		// X-Ratelimit-Reset is a Unix timestamp (seconds).
+		resetUnix, err := strconv.ParseInt(headerVal, 10, 64)
+		if err == nil {
+			resetAt := time.Unix(resetUnix, 0)
+
+			delay := time.Until(resetAt)
+			if delay > 0 && delay <= maxHonoredRetryAfter {
+				return delay
+			}
+		}
+	}
+
+	return fallback
+}
+
+// IsRetriableNetError reports whether a network error is worth
+// retrying. Connection resets, refused connections, and DNS errors
+// are retriable; context cancellation, offline mode and refused
+// local files are not.
+func IsRetriableNetError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrOffline) {
+		return false
+	}
+
+	if errors.Is(err, ErrLocalFile) || errors.Is(err, ErrFileRedirect) {
+		return false
+	}
+
+	return true
+}
+
+// SleepWithContext sleeps for d, returning early if ctx is canceled.
+func SleepWithContext(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
@@ -3,19 +3,24 @@ package installer
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/y3owk1n/nvs/internal/constants"
 	"github.com/y3owk1n/nvs/internal/domain/installer"
+	"github.com/y3owk1n/nvs/internal/domain/vtypes"
 	"github.com/y3owk1n/nvs/internal/infra/archive"
 	"github.com/y3owk1n/nvs/internal/infra/builder"
 	"github.com/y3owk1n/nvs/internal/infra/downloader"
 	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+	"github.com/y3owk1n/nvs/internal/infra/provenance"
 	"github.com/y3owk1n/nvs/internal/log"
 )
 
@@ -24,6 +29,26 @@ type Service struct {
 	downloader *downloader.Downloader
 	extractor  *archive.Extractor
 	builder    *builder.SourceBuilder
+	verifier   *provenance.Verifier
+	appVersion string
+}
+
+// Option customizes a Service built by New.
+type Option func(*Service)
+
+// WithAppVersion sets the nvs version recorded in install manifests.
+func WithAppVersion(version string) Option {
+	return func(s *Service) {
+		s.appVersion = version
+	}
+}
+
+// WithVerifier checks each downloaded release archive with verifier
+// before it is extracted.
+func WithVerifier(verifier *provenance.Verifier) Option {
+	return func(s *Service) {
+		s.verifier = verifier
+	}
 }
 
 // New creates a new installer Service.
@@ -31,12 +56,19 @@ func New(
 	d *downloader.Downloader,
 	e *archive.Extractor,
 	b *builder.SourceBuilder,
+	opts ...Option,
 ) *Service {
-	return &Service{
+	service := &Service{
 		downloader: d,
 		extractor:  e,
 		builder:    b,
 	}
+
+	for _, opt := range opts {
+		opt(service)
+	}
+
+	return service
 }
 
 // InstallRelease installs a pre-built release with per-version locking.
@@ -49,12 +81,10 @@ func (s *Service) InstallRelease(
 	progress installer.ProgressFunc,
 ) error {
 	// Fast path: check if already installed before acquiring lock
-	// Check for version.txt to ensure installation was complete
+	// Check for the manifest (or an older version.txt) to ensure installation was complete
 	versionPath := filepath.Join(dest, installName)
-	versionFile := filepath.Join(versionPath, "version.txt")
 
-	_, err := os.Stat(versionFile)
-	if err == nil {
+	if filesystem.HasInstallRecord(versionPath) {
 		log.Debugf("Version %s already exists, skipping install", installName)
 
 		return nil
@@ -71,7 +101,7 @@ func (s *Service) InstallRelease(
 	installCtx, cancel := context.WithTimeout(ctx, installLockTimeout)
 	defer cancel()
 
-	err = lock.Lock(installCtx)
+	err := lock.Lock(installCtx)
 	if err != nil {
 		return fmt.Errorf("failed to acquire install lock for %s: %w", installName, err)
 	}
@@ -84,9 +114,7 @@ func (s *Service) InstallRelease(
 	}()
 
 	// Double-check after acquiring lock (another process may have installed it)
-	// Check for version.txt to ensure installation was complete
-	_, err = os.Stat(versionFile)
-	if err == nil {
+	if filesystem.HasInstallRecord(versionPath) {
 		log.Debugf("Version %s was installed by another process", installName)
 
 		return nil
@@ -104,17 +132,21 @@ func (s *Service) InstallRelease(
 func (s *Service) BuildFromCommit(
 	ctx context.Context,
 	commit string,
+	profile vtypes.BuildProfile,
 	dest string,
 	progress installer.ProgressFunc,
 ) (string, error) {
 	// Compute the short hash for the lock key.
-	// The builder always creates the version directory with a 7-character short hash,
-	// so we must use the same short hash for locking to coordinate with Switch/Uninstall.
+	// The builder always creates the version directory with a 7-character short hash
+	// (plus the profile suffix), so we must use the same name for locking to
+	// coordinate with Switch/Uninstall.
 	versionName := commit
 	if len(commit) > constants.ShortCommitLen {
 		versionName = commit[:constants.ShortCommitLen]
 	}
 
+	versionName = profile.InstallName(versionName)
+
 	// Acquire per-version lock using the short hash
 	lockPath := filepath.Join(dest, fmt.Sprintf(".nvs-version-%s.lock", versionName))
 	lock := filesystem.NewFileLock(lockPath)
@@ -138,7 +170,48 @@ func (s *Service) BuildFromCommit(
 		}
 	}()
 
-	return s.builder.BuildFromCommit(buildCtx, commit, dest, progress)
+	return s.builder.BuildFromCommit(buildCtx, commit, profile, dest, progress)
+}
+
+// BuildFromRef builds a branch or pull request head under the same
+// per-version lock (keyed by its install name) as Switch and Uninstall,
+// so a rebuild cannot swap the directory out from under them.
+func (s *Service) BuildFromRef(
+	ctx context.Context,
+	ref vtypes.SourceRef,
+	profile vtypes.BuildProfile,
+	dest string,
+	progress installer.ProgressFunc,
+) (string, error) {
+	versionName := ref.InstallName(profile)
+
+	lockPath := filepath.Join(dest, fmt.Sprintf(".nvs-version-%s.lock", versionName))
+	lock := filesystem.NewFileLock(lockPath)
+
+	// Same budget as BuildFromCommit: clone, build and retries.
+	const buildLockTimeout = 15 * time.Minute
+
+	buildCtx, cancel := context.WithTimeout(ctx, buildLockTimeout)
+	defer cancel()
+
+	err := lock.Lock(buildCtx)
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire build lock for %s: %w", versionName, err)
+	}
+
+	defer func() {
+		unlockErr := lock.Unlock()
+		if unlockErr != nil {
+			log.Warnf("failed to unlock build lock for %s: %v", versionName, unlockErr)
+		}
+	}()
+
+	return s.builder.BuildFromRef(buildCtx, ref, profile, dest, progress)
+}
+
+// ResolveRef returns the upstream commit hash of a branch or pull request.
+func (s *Service) ResolveRef(ctx context.Context, ref vtypes.SourceRef) (string, error) {
+	return s.builder.ResolveRef(ctx, ref)
 }
 
 // UpgradeRelease upgrades an existing installation to a new release atomically.
@@ -255,6 +328,8 @@ func (s *Service) installReleaseInternal(
 	installName string,
 	progress installer.ProgressFunc,
 ) error {
+	startedAt := time.Now()
+
 	// 1. Get asset URL
 	assetURL, err := rel.GetAssetURL()
 	if err != nil {
@@ -275,6 +350,10 @@ func (s *Service) installReleaseInternal(
 	checksumURL, err := rel.GetChecksumURL()
 	hasChecksum := err == nil && checksumURL != ""
 
+	// sha is the archive's SHA256, for the lock file, provenance checks
+	// and the manifest.
+	var sha string
+
 	if hasChecksum {
 		if progress != nil {
 			progress("Downloading & Verifying", 0)
@@ -282,11 +361,12 @@ func (s *Service) installReleaseInternal(
 
 		assetName := filepath.Base(assetURL)
 
-		err = s.downloader.DownloadWithChecksumVerification(
+		sha, err = s.downloader.DownloadWithChecksumVerification(
 			ctx,
 			assetURL,
 			checksumURL,
 			assetName,
+			archiveKey(rel, assetURL),
 			tempFile,
 			func(p int) {
 				if progress != nil {
@@ -314,9 +394,33 @@ func (s *Service) installReleaseInternal(
 		if err != nil {
 			return fmt.Errorf("download failed: %w", err)
 		}
+
+		// Without a published checksum the download was not hashed.
+		sha, err = fileSHA256(tempFile)
+		if err != nil {
+			log.Warnf("Failed to hash downloaded asset: %v", err)
+		}
+	}
+
+	// 4. Check the lock file and provenance (if configured) before anything is unpacked
+
+	locked := rel.GetLockedSHA256()
+	if locked != "" && !strings.EqualFold(sha, locked) {
+		return fmt.Errorf(
+			"%w: %s has SHA256 %s, the lock file records %s",
+			installer.ErrLockMismatch,
+			filepath.Base(assetURL),
+			sha,
+			locked,
+		)
 	}
 
-	// 4. Extract
+	err = s.verifyProvenance(ctx, rel, assetURL, sha, tempFile)
+	if err != nil {
+		return err
+	}
+
+	// 5. Extract
 	//
 	// The installer emits a single "Extracting" phase event
 	// for the whole extraction step, with the percent value
@@ -364,12 +468,23 @@ func (s *Service) installReleaseInternal(
 		return fmt.Errorf("extraction failed: %w", err)
 	}
 
-	// 5. Write version file
-	versionFile := filepath.Join(installPath, "version.txt")
-
-	err = os.WriteFile(versionFile, []byte(rel.GetIdentifier()), constants.FilePerm)
+	// 6. Write the install manifest (written last: its presence marks a complete install)
+	err = filesystem.WriteManifest(installPath, vtypes.Manifest{
+		Source:     vtypes.ManifestSourceRelease,
+		Identifier: rel.GetIdentifier(),
+		Tag:        rel.GetTagName(),
+		Commit:     rel.GetCommitHash(),
+		Asset: &vtypes.ManifestAsset{
+			Name:   filepath.Base(assetURL),
+			URL:    assetURL,
+			SHA256: sha,
+		},
+		InstalledAt:       startedAt.UTC(),
+		InstallDurationMS: time.Since(startedAt).Milliseconds(),
+		NvsVersion:        s.appVersion,
+	})
 	if err != nil {
-		log.Warnf("Failed to write version file: %v", err)
+		log.Warnf("Failed to write install manifest: %v", err)
 	}
 
 	if progress != nil {
@@ -378,3 +493,89 @@ func (s *Service) installReleaseInternal(
 
 	return nil
 }
+
+// archiveKey names the content of the release archive at assetURL for
+// the archive cache: the URL with the release's tag, or its commit for
+// the moving "stable" and "nightly" tags. It returns "" when a moving
+// tag's commit is unknown, as the URL can then serve anything.
+func archiveKey(rel installer.ReleaseInfo, assetURL string) string {
+	identifier := rel.GetIdentifier()
+	if identifier == constants.Stable || identifier == constants.Nightly {
+		identifier = rel.GetCommitHash()
+	}
+
+	if identifier == "" {
+		return ""
+	}
+
+	return assetURL + "@" + identifier
+}
+
+// verifyProvenance runs the configured provenance checks on the
+// downloaded archive in file, fetching its detached signature from
+// next to assetURL when a signing key is pinned.
+func (s *Service) verifyProvenance(
+	ctx context.Context,
+	rel installer.ReleaseInfo,
+	assetURL string,
+	sha string,
+	file *os.File,
+) error {
+	if s.verifier == nil {
+		return nil
+	}
+
+	artifact := provenance.Artifact{
+		Name:    filepath.Base(assetURL),
+		Tag:     rel.GetTagName(),
+		SHA256:  sha,
+		Content: file,
+	}
+
+	if s.verifier.WantsSignature() {
+		artifact.Signature, artifact.SignatureErr = s.downloader.FetchSmall(
+			ctx,
+			assetURL+provenance.SignatureSuffix,
+		)
+	}
+
+	_, err := file.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("failed to seek temp file: %w", err)
+	}
+
+	err = s.verifier.Verify(artifact)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("failed to seek temp file: %w", err)
+	}
+
+	return nil
+}
+
+// fileSHA256 returns the hex SHA256 of file's content, leaving the
+// offset at the start.
+func fileSHA256(file *os.File) (string, error) {
+	_, err := file.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", fmt.Errorf("failed to seek file: %w", err)
+	}
+
+	hasher := sha256.New()
+
+	_, err = io.Copy(hasher, file)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", fmt.Errorf("failed to seek file: %w", err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
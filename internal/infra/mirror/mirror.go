@@ -0,0 +1,229 @@
+// Package mirror spreads release downloads over an ordered list of
+// GitHub download mirrors and keeps health statistics per mirror.
+//
+// A mirror serves github.com's download paths under its own base URL,
+// so https://github.com/neovim/neovim/releases/download/... becomes
+// <base>/neovim/neovim/releases/download/.... The downloader tries
+// the mirrors in order; the statistics (latency, failures, last
+// error) are recorded by the List's Transport and stored in the cache
+// directory for 'nvs doctor'.
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// Stat is the health record of one mirror.
+//
+//nolint:tagliatelle
+type Stat struct {
+	Requests int `json:"requests"`
+	Failures int `json:"failures"`
+
+	// LatencyMS is a moving average of the time to response
+	// headers of successful requests.
+	LatencyMS int64 `json:"latency_ms"`
+
+	LastError   string    `json:"last_error,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitzero"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+}
+
+// Failing reports whether the mirror's most recent request failed.
+func (s Stat) Failing() bool {
+	return s.LastFailure.After(s.LastSuccess)
+}
+
+// List is an ordered set of mirror base URLs.
+type List struct {
+	bases     []string
+	statsPath string
+
+	// mu serializes updates of the stats file within the process.
+	mu sync.Mutex
+}
+
+// New returns the mirrors in bases, tried in that order, recording
+// their statistics in statsPath. Trailing slashes are dropped.
+func New(bases []string, statsPath string) *List {
+	trimmed := make([]string, 0, len(bases))
+	for _, base := range bases {
+		trimmed = append(trimmed, strings.TrimRight(base, "/"))
+	}
+
+	return &List{bases: trimmed, statsPath: statsPath}
+}
+
+// Bases returns the mirror base URLs in order.
+func (l *List) Bases() []string {
+	return l.bases
+}
+
+// StatsPath returns the file the statistics are kept in.
+func (l *List) StatsPath() string {
+	return l.statsPath
+}
+
+// Rewrite returns url as served by base. It reports false, returning
+// url unchanged, when url is not on github.com or one of the mirrors.
+func (l *List) Rewrite(url, base string) (string, bool) {
+	from := l.baseOf(url, true)
+	if from == "" {
+		return url, false
+	}
+
+	return base + strings.TrimPrefix(url, from), true
+}
+
+// baseOf returns the longest known base url lives under: a mirror,
+// or github.com itself when withOrigin is set.
+func (l *List) baseOf(url string, withOrigin bool) string {
+	known := l.bases
+	if withOrigin {
+		known = append([]string{constants.DefaultGitHubBaseURL}, l.bases...)
+	}
+
+	match := ""
+
+	for _, base := range known {
+		if len(base) > len(match) && (url == base || strings.HasPrefix(url, base+"/")) {
+			match = base
+		}
+	}
+
+	return match
+}
+
+// Transport wraps next so that every request to a mirror updates its
+// statistics: connection errors and 5xx responses count as failures,
+// anything else as a success timed up to the response headers.
+func (l *List) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &recordingTransport{list: l, next: next}
+}
+
+type recordingTransport struct {
+	list *List
+	next http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+
+	resp, err := t.next.RoundTrip(req)
+
+	base := t.list.baseOf(req.URL.String(), false)
+	if base == "" {
+		return resp, err
+	}
+
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+	case err != nil:
+		t.list.record(base, func(stat *Stat) {
+			stat.Requests++
+			markFailed(stat, err.Error())
+		})
+	case resp.StatusCode >= http.StatusInternalServerError:
+		t.list.record(base, func(stat *Stat) {
+			stat.Requests++
+			markFailed(stat, "status "+strconv.Itoa(resp.StatusCode))
+		})
+	default:
+		t.list.record(base, func(stat *Stat) {
+			stat.Requests++
+
+			latency := time.Since(started).Milliseconds()
+			if stat.LatencyMS == 0 {
+				stat.LatencyMS = latency
+			} else {
+				stat.LatencyMS = (stat.LatencyMS*3 + latency) / 4 //nolint:mnd // weight 1/4
+			}
+
+			stat.LastSuccess = time.Now().UTC()
+		})
+	}
+
+	return resp, err
+}
+
+// RecordFailure marks base as failed after a request that succeeded
+// at the HTTP level, such as a download that did not match its
+// checksum.
+func (l *List) RecordFailure(base string, err error) {
+	l.record(base, func(stat *Stat) {
+		markFailed(stat, err.Error())
+	})
+}
+
+func markFailed(stat *Stat, reason string) {
+	stat.Failures++
+	stat.LastError = reason
+	stat.LastFailure = time.Now().UTC()
+}
+
+// record applies update to base's statistics and saves them. Stats are
+// best effort: a concurrent nvs process may overwrite an update.
+func (l *List) record(base string, update func(stat *Stat)) {
+	if l.statsPath == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats, err := ReadStats(l.statsPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Debugf("Discarding unreadable mirror stats: %v", err)
+	}
+
+	if stats == nil {
+		stats = make(map[string]Stat)
+	}
+
+	stat := stats[base]
+	update(&stat)
+	stats[base] = stat
+
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err == nil {
+		err = filesystem.WriteFileAtomic(l.statsPath, data)
+	}
+
+	if err != nil {
+		log.Debugf("Failed to save mirror stats: %v", err)
+	}
+}
+
+// ReadStats reads the statistics file at path, keyed by mirror base URL.
+func ReadStats(path string) (map[string]Stat, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats map[string]Stat
+
+	err = json.Unmarshal(data, &stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return stats, nil
+}
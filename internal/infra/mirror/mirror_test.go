@@ -0,0 +1,93 @@
+package mirror_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/infra/mirror"
+)
+
+func TestList_Rewrite(t *testing.T) {
+	t.Parallel()
+
+	list := mirror.New(
+		[]string{"https://mirror.example.com/gh/", "https://mirror.example.com/gh/extra"},
+		"",
+	)
+
+	tests := []struct {
+		url    string
+		base   string
+		want   string
+		served bool
+	}{
+		{
+			url:    "https://github.com/neovim/neovim/releases/download/v0.10.0/nvim.tar.gz",
+			base:   "https://other.example.com",
+			want:   "https://other.example.com/neovim/neovim/releases/download/v0.10.0/nvim.tar.gz",
+			served: true,
+		},
+		{
+			url:    "https://mirror.example.com/gh/extra/nvim.tar.gz",
+			base:   "https://other.example.com",
+			want:   "https://other.example.com/nvim.tar.gz",
+			served: true,
+		},
+		{
+			url:    "https://mirror.example.com/ghost/nvim.tar.gz",
+			base:   "https://other.example.com",
+			want:   "https://mirror.example.com/ghost/nvim.tar.gz",
+			served: false,
+		},
+	}
+
+	for _, tt := range tests {
+		got, served := list.Rewrite(tt.url, tt.base)
+		if got != tt.want || served != tt.served {
+			t.Errorf("Rewrite(%q) = %q, %v; want %q, %v", tt.url, got, served, tt.want, tt.served)
+		}
+	}
+}
+
+func TestList_Transport(t *testing.T) {
+	t.Parallel()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	statsPath := filepath.Join(t.TempDir(), "mirror-stats.json")
+	list := mirror.New([]string{healthy.URL, broken.URL}, statsPath)
+	client := &http.Client{Transport: list.Transport(nil)}
+
+	for _, url := range []string{healthy.URL + "/a", broken.URL + "/a", healthy.URL + "/b"} {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = resp.Body.Close()
+	}
+
+	stats, err := mirror.ReadStats(statsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat := stats[healthy.URL]; stat.Requests != 2 || stat.Failures != 0 || stat.Failing() {
+		t.Errorf("healthy mirror stats = %+v", stat)
+	}
+
+	if stat := stats[broken.URL]; stat.Requests != 1 || stat.Failures != 1 || !stat.Failing() ||
+		stat.LastError != "status 502" {
+		t.Errorf("broken mirror stats = %+v", stat)
+	}
+}
@@ -0,0 +1,18 @@
+package pinfile
+
+import "errors"
+
+// Infrastructure errors for pin file operations.
+var (
+	// ErrUnknownSource is returned when a configured pin source name is not recognized.
+	ErrUnknownSource = errors.New("unknown pin source")
+
+	// ErrNoSources is returned when a pin source list enables no sources at all.
+	ErrNoSources = errors.New("no pin sources enabled")
+
+	// ErrInvalidPinFile is returned when a pin file exists but cannot be parsed.
+	ErrInvalidPinFile = errors.New("invalid pin file")
+
+	// ErrInvalidLockFile is returned when a lock file exists but cannot be parsed.
+	ErrInvalidLockFile = errors.New("invalid lock file")
+)
@@ -0,0 +1,105 @@
+package pinfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/y3owk1n/nvs/internal/constants"
+	"github.com/y3owk1n/nvs/internal/infra/filesystem"
+)
+
+// LockSchema is the current lock file format.
+const LockSchema = 1
+
+// Lock is a .nvs-lock file: the release archive a pinned version must
+// be installed from, per platform. Entries are trusted on first use:
+// whoever first locks or installs the version on a platform records
+// what they downloaded, and later installs must match it.
+//
+//nolint:tagliatelle
+type Lock struct {
+	Schema int `json:"schema"`
+
+	// Version is the release tag the lock applies to, as installed
+	// (e.g. "v0.10.2").
+	Version string `json:"version"`
+
+	// Assets maps a platform ("linux/amd64") to its archive.
+	Assets map[string]LockedAsset `json:"assets"`
+}
+
+// LockedAsset is the archive recorded for one platform.
+//
+//nolint:tagliatelle
+type LockedAsset struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// Platform returns the Lock.Assets key for the running OS and architecture.
+func Platform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// LockPath returns the lock file that belongs to the pin file at pinFile.
+func LockPath(pinFile string) string {
+	return filepath.Join(filepath.Dir(pinFile), constants.LockFileName)
+}
+
+// ReadLock reads the lock file at path. A missing file is reported as
+// an error matching os.ErrNotExist.
+func ReadLock(path string) (Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Lock{}, err
+	}
+
+	var lock Lock
+
+	err = json.Unmarshal(data, &lock)
+	if err != nil {
+		return Lock{}, fmt.Errorf("%w: %s: %w", ErrInvalidLockFile, path, err)
+	}
+
+	if lock.Version == "" {
+		return Lock{}, fmt.Errorf("%w: %s: no version", ErrInvalidLockFile, path)
+	}
+
+	return lock, nil
+}
+
+// WriteLock atomically writes lock to path.
+func WriteLock(path string, lock Lock) error {
+	lock.Schema = LockSchema
+
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+
+	err = filesystem.WriteFileAtomic(path, append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+
+	return nil
+}
+
+// Entry returns the asset recorded for the running platform.
+func (l Lock) Entry() (LockedAsset, bool) {
+	asset, ok := l.Assets[Platform()]
+
+	return asset, ok
+}
+
+// Record sets the asset for the running platform.
+func (l *Lock) Record(asset LockedAsset) {
+	if l.Assets == nil {
+		l.Assets = make(map[string]LockedAsset)
+	}
+
+	l.Assets[Platform()] = asset
+}
@@ -0,0 +1,69 @@
+package pinfile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/infra/pinfile"
+)
+
+func TestLock_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := pinfile.LockPath(filepath.Join(dir, ".nvs-version"))
+
+	if path != filepath.Join(dir, ".nvs-lock") {
+		t.Errorf("LockPath = %q, want .nvs-lock next to the pin", path)
+	}
+
+	_, err := pinfile.ReadLock(path)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadLock on a missing file error = %v, want os.ErrNotExist", err)
+	}
+
+	lock := pinfile.Lock{
+		Version: "v0.10.2",
+		Assets: map[string]pinfile.LockedAsset{
+			"plan9/mips": {Name: "nvim-plan9.tar.gz", SHA256: "2222"},
+		},
+	}
+	lock.Record(pinfile.LockedAsset{Name: "nvim-here.tar.gz", SHA256: "1111"})
+
+	err = pinfile.WriteLock(path, lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := pinfile.ReadLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Schema != pinfile.LockSchema || got.Version != "v0.10.2" || len(got.Assets) != 2 {
+		t.Errorf("ReadLock = %+v", got)
+	}
+
+	entry, ok := got.Entry()
+	if !ok || entry.SHA256 != "1111" {
+		t.Errorf("Entry() = %+v, %v; want the asset recorded for %s", entry, ok, pinfile.Platform())
+	}
+}
+
+func TestReadLock_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"garbled":    "{\"version\": ",
+		"no-version": "{\"schema\": 1, \"assets\": {}}",
+	} {
+		_, err := pinfile.ReadLock(writeFile(t, dir, name, content))
+		if !errors.Is(err, pinfile.ErrInvalidLockFile) {
+			t.Errorf("%s: expected ErrInvalidLockFile, got %v", name, err)
+		}
+	}
+}
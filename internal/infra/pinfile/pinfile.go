@@ -0,0 +1,274 @@
+// Package pinfile reads Neovim version pins from the per-project files
+// understood by nvs and by other version managers.
+//
+// Each file format is a Source. Sources are combined into a Chain whose
+// order is the precedence order: when a directory contains more than one
+// pin file, the first source in the chain that yields a version wins.
+// The directory walk itself (nearest directory first, then the home
+// directory) lives with the caller; the chain only answers "what does
+// this one directory pin?".
+package pinfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/y3owk1n/nvs/internal/constants"
+)
+
+// Source names accepted by Lookup and ParseChain.
+const (
+	// SourceNvs reads .nvs-version, the native nvs pin file.
+	SourceNvs = "nvs"
+	// SourceNvim reads .nvim-version, a plain-text pin used by other tools.
+	SourceNvim = "nvim"
+	// SourceToolVersions reads the "neovim" entry of an asdf .tool-versions file.
+	SourceToolVersions = "tool-versions"
+	// SourceMise reads tools.neovim from mise.toml or .mise.toml.
+	SourceMise = "mise"
+)
+
+// toolName is the plugin/tool name asdf and mise use for Neovim.
+const toolName = "neovim"
+
+// Source reads a version pin from one kind of file.
+type Source interface {
+	// Name is the identifier used to enable the source in configuration.
+	Name() string
+
+	// FileNames lists the candidate file names inside a directory,
+	// in the order they are tried.
+	FileNames() []string
+
+	// Parse extracts the pinned version from a file's contents. It
+	// returns "" when the file does not pin Neovim (e.g. a
+	// .tool-versions file that only lists other tools).
+	Parse(data []byte) (string, error)
+}
+
+// Chain is an ordered list of sources; earlier sources take precedence.
+type Chain []Source
+
+// Match is a pin found by a Chain.
+type Match struct {
+	// Version is the raw pinned version spec, trimmed of whitespace.
+	Version string
+	// File is the absolute path of the file the pin was read from.
+	File string
+	// Source is the Name of the source that produced the pin.
+	Source string
+}
+
+// Default returns every built-in source in default precedence order:
+// .nvs-version, .nvim-version, .tool-versions, mise.toml.
+func Default() Chain {
+	return Chain{
+		plainSource{name: SourceNvs, fileName: constants.VersionFileName},
+		plainSource{name: SourceNvim, fileName: ".nvim-version"},
+		toolVersionsSource{},
+		miseSource{},
+	}
+}
+
+// Names returns the names of the built-in sources in default order.
+func Names() []string {
+	return Default().Names()
+}
+
+// Lookup returns the built-in source with the given name.
+func Lookup(name string) (Source, bool) {
+	for _, src := range Default() {
+		if src.Name() == name {
+			return src, true
+		}
+	}
+
+	return nil, false
+}
+
+// ParseChain builds a chain from a comma-separated list of source names,
+// preserving the given order. Blank entries are skipped and duplicates
+// collapse to their first occurrence. An unknown name fails the whole
+// list with ErrUnknownSource so a typo does not silently disable a
+// source the user meant to enable.
+func ParseChain(spec string) (Chain, error) {
+	var chain Chain
+
+	seen := make(map[string]bool)
+
+	for raw := range strings.SplitSeq(spec, ",") {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" || seen[name] {
+			continue
+		}
+
+		src, ok := Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf(
+				"%w: %q (known: %s)",
+				ErrUnknownSource,
+				name,
+				strings.Join(Names(), ", "),
+			)
+		}
+
+		seen[name] = true
+		chain = append(chain, src)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNoSources, spec)
+	}
+
+	return chain, nil
+}
+
+// Find returns the pin declared in dir by the highest-precedence source
+// that has one. Missing or unreadable files are skipped. A file that
+// exists but cannot be parsed is reported, since silently falling
+// through to a lower-precedence file would pin a version the user did
+// not ask for.
+func (c Chain) Find(dir string) (Match, bool, error) {
+	for _, src := range c {
+		for _, fileName := range src.FileNames() {
+			path := filepath.Join(dir, fileName)
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+
+			version, err := src.Parse(data)
+			if err != nil {
+				return Match{}, false, fmt.Errorf("%s: %w", path, err)
+			}
+
+			if version == "" {
+				continue
+			}
+
+			return Match{Version: version, File: path, Source: src.Name()}, true, nil
+		}
+	}
+
+	return Match{}, false, nil
+}
+
+// Names returns the names of the sources in the chain, in order.
+func (c Chain) Names() []string {
+	names := make([]string, 0, len(c))
+	for _, src := range c {
+		names = append(names, src.Name())
+	}
+
+	return names
+}
+
+// plainSource is a file whose entire (trimmed) content is the version.
+type plainSource struct {
+	name     string
+	fileName string
+}
+
+func (p plainSource) Name() string {
+	return p.name
+}
+
+func (p plainSource) FileNames() []string {
+	return []string{p.fileName}
+}
+
+func (p plainSource) Parse(data []byte) (string, error) {
+	return strings.TrimSpace(string(data)), nil
+}
+
+// toolVersionsSource reads asdf's .tool-versions: one "<tool> <version>
+// [<fallback>...]" entry per line, '#' comments allowed. Only the first
+// version of the neovim entry is used; asdf's fallback versions have
+// no nvs equivalent.
+type toolVersionsSource struct{}
+
+func (toolVersionsSource) Name() string {
+	return SourceToolVersions
+}
+
+func (toolVersionsSource) FileNames() []string {
+	return []string{".tool-versions"}
+}
+
+func (toolVersionsSource) Parse(data []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == toolName {
+			return fields[1], nil
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return "", fmt.Errorf("failed to read .tool-versions: %w", err)
+	}
+
+	return "", nil
+}
+
+// miseSource reads the [tools] table of a mise config. mise accepts
+// three shapes for a tool entry, all of which are handled:
+//
+//	neovim = "0.10.2"
+//	neovim = ["0.10.2", "0.9.5"]   # first entry is the active one
+//	neovim = { version = "0.10.2" }
+type miseSource struct{}
+
+func (miseSource) Name() string {
+	return SourceMise
+}
+
+func (miseSource) FileNames() []string {
+	return []string{"mise.toml", ".mise.toml"}
+}
+
+func (miseSource) Parse(data []byte) (string, error) {
+	var doc struct {
+		Tools map[string]any `toml:"tools"`
+	}
+
+	_, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPinFile, err)
+	}
+
+	entry, ok := doc.Tools[toolName]
+	if !ok {
+		return "", nil
+	}
+
+	switch value := entry.(type) {
+	case string:
+		return strings.TrimSpace(value), nil
+	case []any:
+		if len(value) == 0 {
+			break
+		}
+
+		first, isString := value[0].(string)
+		if isString {
+			return strings.TrimSpace(first), nil
+		}
+	case map[string]any:
+		version, isString := value["version"].(string)
+		if isString {
+			return strings.TrimSpace(version), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: unsupported tools.%s value", ErrInvalidPinFile, toolName)
+}
@@ -0,0 +1,157 @@
+package pinfile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/infra/pinfile"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+
+	err := os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestChainFind_Sources(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		fileName string
+		content  string
+		want     string
+	}{
+		{"nvs-version", ".nvs-version", "  v0.10.2\n", "v0.10.2"},
+		{"nvim-version", ".nvim-version", "stable\n", "stable"},
+		{
+			"tool-versions",
+			".tool-versions",
+			"# pinned tools\nnodejs 20.1.0\nneovim 0.10.2 0.9.5 # fallback ignored\n",
+			"0.10.2",
+		},
+		{"mise string", "mise.toml", "[tools]\nneovim = \"0.10.2\"\n", "0.10.2"},
+		{"mise array", ".mise.toml", "[tools]\nneovim = [\"~0.10\", \"0.9\"]\n", "~0.10"},
+		{
+			"mise table",
+			"mise.toml",
+			"[env]\nFOO = \"neovim\"\n\n[tools]\nneovim = { version = \"nightly\" }\n",
+			"nightly",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := writeFile(t, dir, testCase.fileName, testCase.content)
+
+			match, found, err := pinfile.Default().Find(dir)
+			if err != nil {
+				t.Fatalf("Find failed: %v", err)
+			}
+
+			if !found {
+				t.Fatal("expected a pin to be found")
+			}
+
+			if match.Version != testCase.want || match.File != path {
+				t.Errorf("Find = %+v, want version %q from %s", match, testCase.want, path)
+			}
+		})
+	}
+}
+
+func TestChainFind_Precedence(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".tool-versions", "neovim 0.9.5\n")
+	writeFile(t, dir, "mise.toml", "[tools]\nneovim = \"0.8.0\"\n")
+	writeFile(t, dir, ".nvim-version", "v0.10.0\n")
+
+	match, _, err := pinfile.Default().Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if match.Source != pinfile.SourceNvim {
+		t.Errorf("default chain picked %s, want %s", match.Source, pinfile.SourceNvim)
+	}
+
+	chain, err := pinfile.ParseChain("mise,tool-versions")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match, _, err = chain.Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if match.Version != "0.8.0" {
+		t.Errorf("custom chain picked %q, want 0.8.0", match.Version)
+	}
+}
+
+func TestChainFind_SkipsFilesWithoutNeovim(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".tool-versions", "nodejs 20.1.0\n")
+	writeFile(t, dir, "mise.toml", "[tools]\npython = \"3.12\"\n")
+
+	_, found, err := pinfile.Default().Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found {
+		t.Error("expected no pin when no file mentions neovim")
+	}
+}
+
+func TestChainFind_InvalidMise(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "mise.toml", "[tools\nneovim = ")
+
+	_, _, err := pinfile.Default().Find(dir)
+	if !errors.Is(err, pinfile.ErrInvalidPinFile) {
+		t.Errorf("expected ErrInvalidPinFile, got %v", err)
+	}
+}
+
+func TestParseChain(t *testing.T) {
+	t.Parallel()
+
+	chain, err := pinfile.ParseChain(" Mise , nvs,,mise ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := chain.Names(); len(got) != 2 || got[0] != "mise" || got[1] != "nvs" {
+		t.Errorf("ParseChain names = %v, want [mise nvs]", got)
+	}
+
+	_, err = pinfile.ParseChain("nvs,asdf")
+	if !errors.Is(err, pinfile.ErrUnknownSource) {
+		t.Errorf("expected ErrUnknownSource, got %v", err)
+	}
+
+	_, err = pinfile.ParseChain(" , ")
+	if !errors.Is(err, pinfile.ErrNoSources) {
+		t.Errorf("expected ErrNoSources, got %v", err)
+	}
+}
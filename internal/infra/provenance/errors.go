@@ -0,0 +1,27 @@
+package provenance
+
+import "errors"
+
+// Infrastructure errors for provenance verification.
+var (
+	// ErrInvalidPolicy is returned when a verification policy is not off, warn or require.
+	ErrInvalidPolicy = errors.New("expected off, warn or require")
+
+	// ErrInvalidKey is returned when a signing key is not an ssh-ed25519 public key.
+	ErrInvalidKey = errors.New("invalid signing key")
+
+	// ErrInvalidSignature is returned when a signature file cannot be parsed.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrBadSignature is returned when a signature does not verify against the pinned key.
+	ErrBadSignature = errors.New("signature verification failed")
+
+	// ErrSignatureMissing is returned when a signing key is pinned but no signature is available.
+	ErrSignatureMissing = errors.New("no signature available")
+
+	// ErrKnownHashMismatch is returned when the known-hashes file lists a different hash.
+	ErrKnownHashMismatch = errors.New("hash differs from the known-hashes file")
+
+	// ErrUnverified is returned under the require policy when nothing vouches for an archive.
+	ErrUnverified = errors.New("archive provenance could not be verified")
+)
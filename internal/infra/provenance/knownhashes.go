@@ -0,0 +1,70 @@
+package provenance
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// knownHash reports what the known-hashes file at path says about an
+// archive. The file uses sha256sum's format, one "<sha256>  <name>"
+// per line, where name is either "<tag>/<asset>" or a bare asset name;
+// blank lines and lines starting with # are skipped.
+//
+// An entry for "<tag>/<asset>" is authoritative: a different hash is
+// ErrKnownHashMismatch. Otherwise the archive is known when its hash
+// appears on any line, since bare asset names such as
+// nvim-linux-x86_64.tar.gz repeat across releases.
+func knownHash(path string, artifact Artifact) (bool, error) {
+	file, err := os.Open(path) //nolint:gosec // path is the user's own setting
+	if err != nil {
+		return false, fmt.Errorf("failed to open known-hashes file: %w", err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	qualified := artifact.Tag + "/" + artifact.Name
+	listed := false
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 { //nolint:mnd // hash and name
+			continue
+		}
+
+		sum := fields[0]
+		name := strings.TrimPrefix(fields[1], "*") // sha256sum's binary-mode marker
+
+		if artifact.Tag != "" && name == qualified {
+			if !strings.EqualFold(sum, artifact.SHA256) {
+				return false, fmt.Errorf(
+					"%w: %s is listed as %s, got %s",
+					ErrKnownHashMismatch,
+					qualified,
+					sum,
+					artifact.SHA256,
+				)
+			}
+
+			return true, nil
+		}
+
+		if strings.EqualFold(sum, artifact.SHA256) {
+			listed = true
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return false, fmt.Errorf("failed to read known-hashes file: %w", err)
+	}
+
+	return listed, nil
+}
@@ -0,0 +1,209 @@
+// Package provenance checks where a downloaded release archive came
+// from, beyond the SHA256 published next to it. That checksum is
+// fetched from the same origin as the archive, so a compromised
+// mirror could serve both; the checks here rely on trust roots the
+// user keeps locally instead:
+//
+//   - a detached SSH signature (<asset URL>.sig, made with
+//     ssh-keygen -Y sign) verified against a pinned ssh-ed25519 key;
+//   - a known-hashes file listing the SHA256 of trusted archives.
+//
+// A Policy decides what a failed or missing check means.
+package provenance
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// Policy decides how verification failures are handled.
+type Policy string
+
+const (
+	// PolicyOff skips provenance checks; only the published SHA256
+	// is verified.
+	PolicyOff Policy = "off"
+	// PolicyWarn runs the configured checks and reports failures as
+	// warnings without stopping the install.
+	PolicyWarn Policy = "warn"
+	// PolicyRequire fails the install unless a configured check
+	// vouches for the archive and none of them fails.
+	PolicyRequire Policy = "require"
+)
+
+// ParsePolicy parses off, warn or require (case-insensitive). The
+// caller names the offending value.
+func ParsePolicy(value string) (Policy, error) {
+	policy := Policy(strings.ToLower(strings.TrimSpace(value)))
+
+	switch policy {
+	case PolicyOff, PolicyWarn, PolicyRequire:
+		return policy, nil
+	default:
+		return "", ErrInvalidPolicy
+	}
+}
+
+// Artifact is a downloaded archive to verify.
+type Artifact struct {
+	// Name is the asset file name.
+	Name string
+	// Tag is the release tag, used to find "<tag>/<name>" entries
+	// in the known-hashes file.
+	Tag string
+	// SHA256 is the hex digest of the archive.
+	SHA256 string
+	// Content is the archive itself; it is read to check a signature.
+	Content io.Reader
+	// Signature is the armored detached signature, when one could be
+	// fetched; SignatureErr says why not otherwise.
+	Signature    []byte
+	SignatureErr error
+}
+
+// Verifier applies a Policy to the configured checks.
+type Verifier struct {
+	policy      Policy
+	key         *PublicKey
+	knownHashes string
+}
+
+// Option customizes a Verifier built by New.
+type Option func(*Verifier) error
+
+// WithSigningKey pins the ssh-ed25519 key signatures must be made
+// with. An empty key disables signature checks.
+func WithSigningKey(line string) Option {
+	return func(v *Verifier) error {
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
+
+		key, err := ParsePublicKey(line)
+		if err != nil {
+			return err
+		}
+
+		v.key = &key
+
+		return nil
+	}
+}
+
+// WithKnownHashes checks archives against the known-hashes file at
+// path. An empty path disables the check.
+func WithKnownHashes(path string) Option {
+	return func(v *Verifier) error {
+		v.knownHashes = path
+
+		return nil
+	}
+}
+
+// New returns a Verifier enforcing policy.
+func New(policy Policy, opts ...Option) (*Verifier, error) {
+	verifier := &Verifier{policy: policy}
+
+	for _, opt := range opts {
+		err := opt(verifier)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return verifier, nil
+}
+
+// WantsSignature reports whether Verify will look at a signature, so
+// callers only fetch one when it is needed.
+func (v *Verifier) WantsSignature() bool {
+	return v.policy != PolicyOff && v.key != nil
+}
+
+// Verify runs the configured checks on artifact. Under PolicyWarn it
+// logs failures, or that no check vouched for the archive, and
+// returns nil; under PolicyRequire it returns them,
+// or ErrUnverified when no check vouched for the archive.
+func (v *Verifier) Verify(artifact Artifact) error {
+	if v.policy == PolicyOff {
+		return nil
+	}
+
+	var (
+		vouched  []string
+		problems []error
+	)
+
+	if v.knownHashes != "" {
+		known, err := knownHash(v.knownHashes, artifact)
+
+		switch {
+		case err != nil:
+			problems = append(problems, err)
+		case known:
+			vouched = append(vouched, "known-hashes file")
+		default:
+			log.Debugf("%s is not in the known-hashes file", artifact.Name)
+		}
+	}
+
+	if v.key != nil {
+		err := v.checkSignature(artifact)
+		if err != nil {
+			problems = append(problems, err)
+		} else {
+			vouched = append(vouched, "signature")
+		}
+	}
+
+	if len(problems) == 0 && len(vouched) > 0 {
+		log.Debugf("%s verified by %s", artifact.Name, strings.Join(vouched, " and "))
+
+		return nil
+	}
+
+	if v.policy == PolicyWarn {
+		for _, problem := range problems {
+			log.Warnf("%s: %v (continuing; verify_policy is warn)", artifact.Name, problem)
+		}
+
+		// With no check configured, warn changes nothing.
+		configured := v.key != nil || v.knownHashes != ""
+		if configured && len(vouched) == 0 {
+			log.Warnf(
+				"%s: no pinned signing key or known-hashes entry vouches for it "+
+					"(continuing; verify_policy is warn)",
+				artifact.Name,
+			)
+		}
+
+		return nil
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s: %w", ErrUnverified, artifact.Name, errors.Join(problems...))
+	}
+
+	return fmt.Errorf(
+		"%w: %s: no pinned signing key or known-hashes entry vouches for it",
+		ErrUnverified,
+		artifact.Name,
+	)
+}
+
+// checkSignature verifies artifact's detached signature.
+func (v *Verifier) checkSignature(artifact Artifact) error {
+	if artifact.Signature == nil {
+		if artifact.SignatureErr != nil {
+			return fmt.Errorf("%w: %w", ErrSignatureMissing, artifact.SignatureErr)
+		}
+
+		return ErrSignatureMissing
+	}
+
+	return verifySSHSignature(*v.key, artifact.Signature, artifact.Content)
+}
@@ -0,0 +1,265 @@
+package provenance_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/infra/provenance"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+func sshString(buf *bytes.Buffer, value []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(value)))
+	buf.Write(value)
+}
+
+// newSigner returns an authorized_keys line and a function producing
+// armored SSHSIG signatures, as ssh-keygen -Y sign -n file would.
+func newSigner(t *testing.T) (string, func(message []byte) []byte) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keyBlob bytes.Buffer
+
+	sshString(&keyBlob, []byte("ssh-ed25519"))
+	sshString(&keyBlob, public)
+
+	line := "ssh-ed25519 " + base64.StdEncoding.EncodeToString(keyBlob.Bytes()) + " test"
+
+	sign := func(message []byte) []byte {
+		digest := sha512.Sum512(message)
+
+		var signed bytes.Buffer
+
+		signed.WriteString("SSHSIG")
+		sshString(&signed, []byte(provenance.SignatureNamespace))
+		sshString(&signed, nil)
+		sshString(&signed, []byte("sha512"))
+		sshString(&signed, digest[:])
+
+		var sigBlob bytes.Buffer
+
+		sshString(&sigBlob, []byte("ssh-ed25519"))
+		sshString(&sigBlob, ed25519.Sign(private, signed.Bytes()))
+
+		var blob bytes.Buffer
+
+		blob.WriteString("SSHSIG")
+		_ = binary.Write(&blob, binary.BigEndian, uint32(1))
+		sshString(&blob, keyBlob.Bytes())
+		sshString(&blob, []byte(provenance.SignatureNamespace))
+		sshString(&blob, nil)
+		sshString(&blob, []byte("sha512"))
+		sshString(&blob, sigBlob.Bytes())
+
+		encoded := base64.StdEncoding.EncodeToString(blob.Bytes())
+
+		return []byte("-----BEGIN SSH SIGNATURE-----\n" + encoded + "\n-----END SSH SIGNATURE-----\n")
+	}
+
+	return line, sign
+}
+
+func TestVerify_Signature(t *testing.T) {
+	key, sign := newSigner(t)
+	archive := []byte("release archive")
+
+	verifier, err := provenance.New(provenance.PolicyRequire, provenance.WithSigningKey(key))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if !verifier.WantsSignature() {
+		t.Error("WantsSignature() = false with a pinned key")
+	}
+
+	err = verifier.Verify(provenance.Artifact{
+		Name:      "nvim.tar.gz",
+		Content:   bytes.NewReader(archive),
+		Signature: sign(archive),
+	})
+	if err != nil {
+		t.Errorf("Verify() with a valid signature error = %v", err)
+	}
+
+	err = verifier.Verify(provenance.Artifact{
+		Name:      "nvim.tar.gz",
+		Content:   strings.NewReader("tampered archive"),
+		Signature: sign(archive),
+	})
+	if !errors.Is(err, provenance.ErrBadSignature) {
+		t.Errorf("Verify() with tampered content error = %v, want ErrBadSignature", err)
+	}
+
+	otherKey, _ := newSigner(t)
+
+	other, err := provenance.New(provenance.PolicyRequire, provenance.WithSigningKey(otherKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = other.Verify(provenance.Artifact{
+		Name:      "nvim.tar.gz",
+		Content:   bytes.NewReader(archive),
+		Signature: sign(archive),
+	})
+	if !errors.Is(err, provenance.ErrBadSignature) {
+		t.Errorf("Verify() against another key error = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestVerify_Policies(t *testing.T) {
+	key, _ := newSigner(t)
+	missing := provenance.Artifact{Name: "nvim.tar.gz", Content: strings.NewReader("x")}
+
+	tests := []struct {
+		policy provenance.Policy
+		opts   []provenance.Option
+		want   error
+	}{
+		{provenance.PolicyOff, []provenance.Option{provenance.WithSigningKey(key)}, nil},
+		{provenance.PolicyWarn, []provenance.Option{provenance.WithSigningKey(key)}, nil},
+		{
+			provenance.PolicyRequire,
+			[]provenance.Option{provenance.WithSigningKey(key)},
+			provenance.ErrSignatureMissing,
+		},
+		{provenance.PolicyWarn, nil, nil},
+		{provenance.PolicyRequire, nil, provenance.ErrUnverified},
+	}
+
+	for _, tt := range tests {
+		verifier, err := provenance.New(tt.policy, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = verifier.Verify(missing)
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("policy %s (%d options): Verify() error = %v, want %v",
+				tt.policy, len(tt.opts), err, tt.want)
+		}
+	}
+}
+
+func TestVerify_KnownHashes(t *testing.T) {
+	const (
+		good  = "1111111111111111111111111111111111111111111111111111111111111111"
+		other = "2222222222222222222222222222222222222222222222222222222222222222"
+	)
+
+	path := filepath.Join(t.TempDir(), "known-hashes")
+
+	err := os.WriteFile(path, []byte(
+		"# trusted archives\n"+
+			good+"  v0.10.2/nvim-linux-x86_64.tar.gz\n"+
+			other+"  nvim-macos-arm64.tar.gz\n",
+	), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := provenance.New(provenance.PolicyRequire, provenance.WithKnownHashes(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tag  string
+		sha  string
+		want error
+	}{
+		{"nvim-linux-x86_64.tar.gz", "v0.10.2", good, nil},
+		{"nvim-linux-x86_64.tar.gz", "v0.10.2", other, provenance.ErrKnownHashMismatch},
+		{"nvim-macos-arm64.tar.gz", "nightly", other, nil},
+		{"nvim-macos-arm64.tar.gz", "nightly", strings.Repeat("3", 64), provenance.ErrUnverified},
+	}
+
+	for _, tt := range tests {
+		err := verifier.Verify(provenance.Artifact{Name: tt.name, Tag: tt.tag, SHA256: tt.sha})
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("Verify(%s/%s, %.8s) error = %v, want %v", tt.tag, tt.name, tt.sha, err, tt.want)
+		}
+	}
+}
+
+func TestVerify_WarnKnownHashesMiss(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := log.Init(log.Options{Output: &buf, NoColor: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = log.Close() })
+
+	path := filepath.Join(t.TempDir(), "known-hashes")
+
+	err = os.WriteFile(path, []byte(strings.Repeat("1", 64)+"  nvim-macos-arm64.tar.gz\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := provenance.New(provenance.PolicyWarn, provenance.WithKnownHashes(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = verifier.Verify(provenance.Artifact{
+		Name:   "nvim-linux-x86_64.tar.gz",
+		Tag:    "v0.10.2",
+		SHA256: strings.Repeat("2", 64),
+	})
+	if err != nil {
+		t.Fatalf("Verify() error = %v, want nil under warn", err)
+	}
+
+	if !strings.Contains(buf.String(), "nvim-linux-x86_64.tar.gz: no pinned signing key") {
+		t.Errorf("Verify() logged %q, want a warning that nothing vouches", buf.String())
+	}
+
+	// With no check configured, warn stays quiet.
+	buf.Reset()
+
+	unconfigured, err := provenance.New(provenance.PolicyWarn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = unconfigured.Verify(provenance.Artifact{Name: "nvim-linux-x86_64.tar.gz"})
+	if err != nil || buf.Len() != 0 {
+		t.Errorf("Verify() with no checks = %v, logged %q; want silence", err, buf.String())
+	}
+}
+
+func TestParsePolicyAndKey(t *testing.T) {
+	policy, err := provenance.ParsePolicy(" Require ")
+	if err != nil || policy != provenance.PolicyRequire {
+		t.Errorf("ParsePolicy(Require) = %q, %v", policy, err)
+	}
+
+	_, err = provenance.ParsePolicy("strict")
+	if !errors.Is(err, provenance.ErrInvalidPolicy) {
+		t.Errorf("ParsePolicy(strict) error = %v, want ErrInvalidPolicy", err)
+	}
+
+	for _, line := range []string{"", "ssh-rsa AAAAB3NzaC1yc2E=", "ssh-ed25519 not-base64!"} {
+		_, err = provenance.ParsePublicKey(line)
+		if !errors.Is(err, provenance.ErrInvalidKey) {
+			t.Errorf("ParsePublicKey(%q) error = %v, want ErrInvalidKey", line, err)
+		}
+	}
+}
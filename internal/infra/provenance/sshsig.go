@@ -0,0 +1,211 @@
+package provenance
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// Signatures use OpenSSH's SSHSIG format (ssh-keygen -Y sign), which
+// only needs the standard library to check for Ed25519 keys. See
+// PROTOCOL.sshsig in the OpenSSH sources.
+const (
+	sshKeyType      = "ssh-ed25519"
+	sshsigMagic     = "SSHSIG"
+	sshsigVersion   = 1
+	sshsigArmorHead = "-----BEGIN SSH SIGNATURE-----"
+	sshsigArmorTail = "-----END SSH SIGNATURE-----"
+
+	// SignatureNamespace is the namespace signatures must be made
+	// for: ssh-keygen -Y sign -n file.
+	SignatureNamespace = "file"
+	// SignatureSuffix is appended to an asset's URL to find its
+	// detached signature, as ssh-keygen -Y sign names it.
+	SignatureSuffix = ".sig"
+)
+
+// PublicKey is a pinned ssh-ed25519 signing key.
+type PublicKey struct {
+	key  ed25519.PublicKey
+	blob []byte
+}
+
+// ParsePublicKey parses a key in authorized_keys form, e.g.
+// "ssh-ed25519 AAAAC3Nza... release-signing".
+func ParsePublicKey(line string) (PublicKey, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != sshKeyType { //nolint:mnd // type and blob
+		return PublicKey{}, fmt.Errorf(
+			"%w: want %q followed by the base64 key",
+			ErrInvalidKey,
+			sshKeyType,
+		)
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	reader := bytes.NewReader(blob)
+
+	keyType, err := readSSHString(reader)
+	if err != nil || string(keyType) != sshKeyType {
+		return PublicKey{}, fmt.Errorf("%w: key blob is not %s", ErrInvalidKey, sshKeyType)
+	}
+
+	key, err := readSSHString(reader)
+	if err != nil || len(key) != ed25519.PublicKeySize || reader.Len() != 0 {
+		return PublicKey{}, fmt.Errorf("%w: malformed %s key", ErrInvalidKey, sshKeyType)
+	}
+
+	return PublicKey{key: ed25519.PublicKey(key), blob: blob}, nil
+}
+
+// verifySSHSignature checks that armored is a valid SSHSIG signature
+// of message by key in SignatureNamespace.
+func verifySSHSignature(key PublicKey, armored []byte, message io.Reader) error {
+	blob, err := dearmor(armored)
+	if err != nil {
+		return err
+	}
+
+	reader := bytes.NewReader(blob)
+
+	magic := make([]byte, len(sshsigMagic))
+
+	_, err = io.ReadFull(reader, magic)
+	if err != nil || string(magic) != sshsigMagic {
+		return fmt.Errorf("%w: missing %s preamble", ErrInvalidSignature, sshsigMagic)
+	}
+
+	var version uint32
+
+	err = binary.Read(reader, binary.BigEndian, &version)
+	if err != nil || version != sshsigVersion {
+		return fmt.Errorf("%w: unsupported version", ErrInvalidSignature)
+	}
+
+	fields := make([][]byte, 5) //nolint:mnd // key, namespace, reserved, hash, signature
+	for idx := range fields {
+		fields[idx], err = readSSHString(reader)
+		if err != nil {
+			return fmt.Errorf("%w: truncated", ErrInvalidSignature)
+		}
+	}
+
+	signerKey, namespace, reserved := fields[0], fields[1], fields[2]
+	hashAlg, sigBlob := fields[3], fields[4]
+
+	if !bytes.Equal(signerKey, key.blob) {
+		return fmt.Errorf("%w: signed by a different key", ErrBadSignature)
+	}
+
+	if string(namespace) != SignatureNamespace {
+		return fmt.Errorf(
+			"%w: namespace %q, want %q",
+			ErrBadSignature,
+			namespace,
+			SignatureNamespace,
+		)
+	}
+
+	var hasher hash.Hash
+
+	switch string(hashAlg) {
+	case "sha256":
+		hasher = sha256.New()
+	case "sha512":
+		hasher = sha512.New()
+	default:
+		return fmt.Errorf("%w: unsupported hash %q", ErrInvalidSignature, hashAlg)
+	}
+
+	_, err = io.Copy(hasher, message)
+	if err != nil {
+		return fmt.Errorf("failed to hash archive: %w", err)
+	}
+
+	sigReader := bytes.NewReader(sigBlob)
+
+	sigType, err := readSSHString(sigReader)
+	if err != nil || string(sigType) != sshKeyType {
+		return fmt.Errorf("%w: signature is not %s", ErrInvalidSignature, sshKeyType)
+	}
+
+	sig, err := readSSHString(sigReader)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed %s signature", ErrInvalidSignature, sshKeyType)
+	}
+
+	var signed bytes.Buffer
+
+	signed.WriteString(sshsigMagic)
+	writeSSHString(&signed, namespace)
+	writeSSHString(&signed, reserved)
+	writeSSHString(&signed, hashAlg)
+	writeSSHString(&signed, hasher.Sum(nil))
+
+	if !ed25519.Verify(key.key, signed.Bytes(), sig) {
+		return ErrBadSignature
+	}
+
+	return nil
+}
+
+// dearmor extracts the binary blob from an armored SSH signature.
+func dearmor(armored []byte) ([]byte, error) {
+	text := strings.TrimSpace(string(armored))
+
+	body, ok := strings.CutPrefix(text, sshsigArmorHead)
+	if ok {
+		body, ok = strings.CutSuffix(body, sshsigArmorTail)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: not an armored SSH signature", ErrInvalidSignature)
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	return blob, nil
+}
+
+// readSSHString reads a uint32-length-prefixed byte string.
+func readSSHString(reader *bytes.Reader) ([]byte, error) {
+	var length uint32
+
+	err := binary.Read(reader, binary.BigEndian, &length)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(length) > int64(reader.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	value := make([]byte, length)
+
+	_, err = io.ReadFull(reader, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// writeSSHString writes value with its uint32 length prefix.
+func writeSSHString(buf *bytes.Buffer, value []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(value))) //nolint:gosec // bounded by input
+	buf.Write(value)
+}
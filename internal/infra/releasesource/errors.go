@@ -0,0 +1,21 @@
+package releasesource
+
+import "errors"
+
+// Infrastructure errors for release sources.
+var (
+	// ErrUnknownSource is returned when a release source is not "github" and
+	// its URL scheme has no backend.
+	ErrUnknownSource = errors.New("unknown release source")
+
+	// ErrIndexFetchFailed is returned when a release index cannot be downloaded.
+	ErrIndexFetchFailed = errors.New("failed to fetch release index")
+
+	// ErrInvalidIndex is returned when a release index cannot be parsed or
+	// is missing required fields.
+	ErrInvalidIndex = errors.New("invalid release index")
+
+	// ErrUnsupportedSchema is returned when a release index uses a schema
+	// newer than this version of nvs understands.
+	ErrUnsupportedSchema = errors.New("unsupported release index schema")
+)
@@ -0,0 +1,305 @@
+package releasesource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/domain/release"
+	"github.com/y3owk1n/nvs/internal/log"
+)
+
+// IndexSchema is the current release index format. An index with a
+// higher schema is rejected rather than half understood.
+const IndexSchema = 1
+
+// maxIndexBytes bounds how much of an index is read into memory.
+const maxIndexBytes = 32 << 20
+
+// IndexFile is a release index: every release a source offers and the
+// files attached to each.
+//
+//nolint:tagliatelle
+type IndexFile struct {
+	Schema   int            `json:"schema"`
+	Releases []IndexRelease `json:"releases"`
+}
+
+// IndexRelease is one release of an index.
+//
+//nolint:tagliatelle
+type IndexRelease struct {
+	// Tag is the version, such as "v0.10.2" or "nightly".
+	Tag string `json:"tag"`
+
+	// Prerelease marks nightly builds; the newest prerelease whose tag
+	// starts with "nightly" is what "nvs install nightly" installs.
+	Prerelease bool `json:"prerelease,omitempty"`
+
+	// Commit is the commit the release was built from. Nightly
+	// installs are identified by it, so it is required for them.
+	Commit string `json:"commit,omitempty"`
+
+	// PublishedAt orders the releases: the newest non-prerelease is
+	// "stable".
+	PublishedAt time.Time `json:"published_at"`
+
+	Assets []IndexAsset `json:"assets"`
+}
+
+// IndexAsset is one file of a release: an archive, or the checksum
+// file for it.
+//
+//nolint:tagliatelle
+type IndexAsset struct {
+	// Name follows Neovim's release names (nvim-linux-x86_64.tar.gz,
+	// nvim-macos-arm64.tar.gz, nvim-win64.zip, ...), which is how the
+	// archive for the running platform is picked. Checksums are read
+	// from "<archive>.sha256" or "shasum.txt".
+	Name string `json:"name"`
+
+	// URL is where the file is downloaded from, relative to the index
+	// or absolute. It defaults to "<tag>/<name>" next to the index.
+	URL string `json:"url,omitempty"`
+
+	Size int64 `json:"size,omitempty"`
+}
+
+// Index is a release.Repository backed by a release index. The index
+// is read once and kept for the life of the Index.
+type Index struct {
+	url    *url.URL
+	client *http.Client
+
+	mu       sync.Mutex
+	releases []release.Release
+	loaded   bool
+}
+
+// URL returns the location of the index.
+func (i *Index) URL() string {
+	return i.url.String()
+}
+
+// IsLocal reports whether the index is on a local or mounted
+// filesystem, so its file:// asset URLs may be downloaded.
+func (i *Index) IsLocal() bool {
+	return i.url.Scheme == "file"
+}
+
+// GetAll returns every release in the index, newest first. If force is
+// true, the index is read again.
+func (i *Index) GetAll(ctx context.Context, force bool) ([]release.Release, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.loaded || force {
+		releases, err := i.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		i.releases = releases
+		i.loaded = true
+	}
+
+	return slices.Clone(i.releases), nil
+}
+
+// FindStable returns the newest release that is not a prerelease.
+func (i *Index) FindStable(ctx context.Context) (release.Release, error) {
+	releases, err := i.GetAll(ctx, false)
+	if err != nil {
+		return release.Release{}, err
+	}
+
+	for _, rel := range releases {
+		if !rel.Prerelease() {
+			return rel, nil
+		}
+	}
+
+	return release.Release{}, release.ErrNoStableRelease
+}
+
+// FindNightly returns the newest nightly prerelease.
+func (i *Index) FindNightly(ctx context.Context) (release.Release, error) {
+	releases, err := i.GetAll(ctx, false)
+	if err != nil {
+		return release.Release{}, err
+	}
+
+	for _, rel := range releases {
+		if rel.Prerelease() && strings.HasPrefix(strings.ToLower(rel.TagName()), "nightly") {
+			return rel, nil
+		}
+	}
+
+	return release.Release{}, release.ErrNoNightlyRelease
+}
+
+// FindByTag returns the release tagged tag.
+func (i *Index) FindByTag(ctx context.Context, tag string) (release.Release, error) {
+	releases, err := i.GetAll(ctx, false)
+	if err != nil {
+		return release.Release{}, err
+	}
+
+	for _, rel := range releases {
+		if rel.TagName() == tag {
+			return rel, nil
+		}
+	}
+
+	return release.Release{}, fmt.Errorf("%w: %s", release.ErrReleaseNotFound, tag)
+}
+
+// fetch downloads and parses the index.
+func (i *Index) fetch(ctx context.Context) ([]release.Release, error) {
+	log.Debug("fetching release index", "url", i.url.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, i.url.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "nvs")
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIndexFetchFailed, err)
+	}
+
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			log.Warnf("failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"%w: %s: status %d",
+			ErrIndexFetchFailed,
+			i.url.String(),
+			resp.StatusCode,
+		)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIndexBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrIndexFetchFailed, i.url.String(), err)
+	}
+
+	if len(data) > maxIndexBytes {
+		return nil, fmt.Errorf(
+			"%w: %s is larger than %d bytes",
+			ErrInvalidIndex,
+			i.url.String(),
+			maxIndexBytes,
+		)
+	}
+
+	return parseIndex(data, i.url)
+}
+
+// parseIndex decodes an index read from base, resolving asset URLs
+// against it. The releases are sorted newest first, like GitHub's.
+func parseIndex(data []byte, base *url.URL) ([]release.Release, error) {
+	var index IndexFile
+
+	err := json.Unmarshal(data, &index)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidIndex, base.String(), err)
+	}
+
+	switch {
+	case index.Schema == 0:
+		return nil, fmt.Errorf("%w: %s: no schema", ErrInvalidIndex, base.String())
+	case index.Schema > IndexSchema:
+		return nil, fmt.Errorf(
+			"%w %d in %s: this nvs reads schema %d; upgrade nvs",
+			ErrUnsupportedSchema,
+			index.Schema,
+			base.String(),
+			IndexSchema,
+		)
+	}
+
+	releases := make([]release.Release, 0, len(index.Releases))
+
+	for _, rel := range index.Releases {
+		if rel.Tag == "" {
+			return nil, fmt.Errorf("%w: %s: a release has no tag", ErrInvalidIndex, base.String())
+		}
+
+		if rel.Prerelease && strings.HasPrefix(strings.ToLower(rel.Tag), "nightly") &&
+			rel.Commit == "" {
+			return nil, fmt.Errorf(
+				"%w: %s: nightly %s has no commit",
+				ErrInvalidIndex,
+				base.String(),
+				rel.Tag,
+			)
+		}
+
+		assets := make([]release.Asset, 0, len(rel.Assets))
+
+		for _, asset := range rel.Assets {
+			if asset.Name == "" {
+				return nil, fmt.Errorf(
+					"%w: %s: an asset of %s has no name",
+					ErrInvalidIndex,
+					base.String(),
+					rel.Tag,
+				)
+			}
+
+			location, err := assetURL(base, rel.Tag, asset)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"%w: %s: %s: %w",
+					ErrInvalidIndex,
+					base.String(),
+					rel.Tag,
+					err,
+				)
+			}
+
+			assets = append(assets, release.NewAsset(asset.Name, location, asset.Size))
+		}
+
+		releases = append(
+			releases,
+			release.New(rel.Tag, rel.Prerelease, rel.Commit, rel.PublishedAt, assets),
+		)
+	}
+
+	slices.SortStableFunc(releases, func(a, b release.Release) int {
+		return b.PublishedAt().Compare(a.PublishedAt())
+	})
+
+	return releases, nil
+}
+
+// assetURL returns the absolute download URL of asset.
+func assetURL(base *url.URL, tag string, asset IndexAsset) (string, error) {
+	ref := asset.URL
+	if ref == "" {
+		ref = url.PathEscape(tag) + "/" + url.PathEscape(asset.Name)
+	}
+
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("asset %s: %w", asset.Name, err)
+	}
+
+	return base.ResolveReference(parsed).String(), nil
+}
@@ -0,0 +1,229 @@
+// Package releasesource lists Neovim releases from self-hosted artifact
+// stores instead of GitHub.
+//
+// A release source is a URL whose scheme picks the backend that locates
+// the store's index:
+//
+//	https://artifacts.example.com/nvim   an index served over HTTP(S)
+//	file:///mnt/nvim or /mnt/nvim        an index on a local or NFS directory
+//	s3://bucket/nvim                     an index in an S3-compatible bucket
+//
+// A URL ending in ".json" names the index itself; otherwise the index is
+// index.json under it. Every backend reads the same versioned index (see
+// IndexFile), and the archives it lists are downloaded like GitHub's.
+package releasesource
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
+)
+
+// GitHub is the release source that lists the GitHub repository's
+// releases. It is the default and is not handled by this package.
+const GitHub = "github"
+
+// IndexFileName is the index looked up under a source URL that does not
+// name a .json file.
+const IndexFileName = "index.json"
+
+// defaultS3Host is the virtual-hosted endpoint of an s3:// source when
+// no S3 endpoint is configured.
+const defaultS3Host = "s3.amazonaws.com"
+
+// Option customizes a source opened by Open.
+type Option func(*options)
+
+type options struct {
+	timeout    time.Duration
+	offline    bool
+	s3Endpoint string
+}
+
+// WithTimeout sets the timeout of the index request (default
+// httpclient.DefaultTimeout).
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithOffline makes the index request fail with httpclient.ErrOffline
+// unless the index is a local file.
+func WithOffline(offline bool) Option {
+	return func(o *options) {
+		o.offline = offline
+	}
+}
+
+// WithS3Endpoint serves s3:// sources from an S3-compatible server at
+// endpoint, addressing buckets path-style (<endpoint>/<bucket>/...).
+// Without it, buckets are addressed on AWS as <bucket>.s3.amazonaws.com.
+func WithS3Endpoint(endpoint string) Option {
+	return func(o *options) {
+		o.s3Endpoint = strings.TrimRight(endpoint, "/")
+	}
+}
+
+// backend maps a source URL onto the URL of the directory or file
+// holding its index.
+type backend func(source *url.URL, opts *options) (*url.URL, error)
+
+// backends maps each supported source URL scheme to its backend.
+var backends = map[string]backend{
+	"http":  httpBackend,
+	"https": httpBackend,
+	"file":  fileBackend,
+	"s3":    s3Backend,
+}
+
+// Schemes returns the source URL schemes with a backend, sorted.
+func Schemes() []string {
+	schemes := make([]string, 0, len(backends))
+	for scheme := range backends {
+		schemes = append(schemes, scheme)
+	}
+
+	slices.Sort(schemes)
+
+	return schemes
+}
+
+// IsGitHub reports whether source selects the GitHub releases rather
+// than a backend of this package.
+func IsGitHub(source string) bool {
+	return source == "" || strings.EqualFold(source, GitHub)
+}
+
+// Locate returns the URL of the index of source, which is either a URL
+// with a supported scheme or an absolute local path.
+func Locate(source string, opts ...Option) (*url.URL, error) {
+	cfg := newOptions(opts)
+
+	parsed, err := parseSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	locate, ok := backends[parsed.Scheme]
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: %q (expected %q, an absolute path or a %s URL)",
+			ErrUnknownSource,
+			source,
+			GitHub,
+			strings.Join(Schemes(), "/"),
+		)
+	}
+
+	location, err := locate(parsed, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(location.Path, ".json") {
+		return location, nil
+	}
+
+	return location.JoinPath(IndexFileName), nil
+}
+
+// Open returns the release repository at source. The index is not read
+// until releases are first asked for.
+func Open(source string, opts ...Option) (*Index, error) {
+	cfg := newOptions(opts)
+
+	location, err := Locate(source, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := httpclient.NewClient(cfg.timeout)
+	if cfg.offline {
+		client = httpclient.NewOfflineClient()
+	}
+
+	if location.Scheme == "file" {
+		client = httpclient.AllowLocalFiles(client)
+	}
+
+	return &Index{url: location, client: client}, nil
+}
+
+func newOptions(opts []Option) *options {
+	cfg := &options{timeout: httpclient.DefaultTimeout}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// parseSource parses source as a URL, turning an absolute local path
+// into a file:// URL first.
+func parseSource(source string) (*url.URL, error) {
+	if filepath.IsAbs(source) {
+		path := filepath.ToSlash(source)
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+
+		return &url.URL{Scheme: "file", Path: path}, nil
+	}
+
+	parsed, err := url.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", ErrUnknownSource, source, err)
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+
+	return parsed, nil
+}
+
+func httpBackend(source *url.URL, _ *options) (*url.URL, error) {
+	if source.Host == "" {
+		return nil, fmt.Errorf("%w: %q has no host", ErrUnknownSource, source.String())
+	}
+
+	return source, nil
+}
+
+func fileBackend(source *url.URL, _ *options) (*url.URL, error) {
+	if source.Host != "" && source.Host != "localhost" {
+		return nil, fmt.Errorf(
+			"%w: %q names a remote host; mount it and use a local path",
+			ErrUnknownSource,
+			source.String(),
+		)
+	}
+
+	if source.Path == "" {
+		return nil, fmt.Errorf("%w: %q has no path", ErrUnknownSource, source.String())
+	}
+
+	return &url.URL{Scheme: "file", Path: source.Path}, nil
+}
+
+func s3Backend(source *url.URL, opts *options) (*url.URL, error) {
+	bucket := source.Host
+	if bucket == "" {
+		return nil, fmt.Errorf("%w: %q has no bucket", ErrUnknownSource, source.String())
+	}
+
+	if opts.s3Endpoint == "" {
+		return &url.URL{Scheme: "https", Host: bucket + "." + defaultS3Host, Path: source.Path}, nil
+	}
+
+	endpoint, err := url.Parse(opts.s3Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%w: S3 endpoint %q: %w", ErrUnknownSource, opts.s3Endpoint, err)
+	}
+
+	return endpoint.JoinPath(bucket, source.Path), nil
+}
@@ -0,0 +1,287 @@
+package releasesource_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/y3owk1n/nvs/internal/domain/release"
+	"github.com/y3owk1n/nvs/internal/infra/httpclient"
+	"github.com/y3owk1n/nvs/internal/infra/releasesource"
+)
+
+const testIndex = `{
+  "schema": 1,
+  "releases": [
+    {
+      "tag": "v0.10.1",
+      "published_at": "2024-07-01T00:00:00Z",
+      "assets": [{"name": "nvim-linux-x86_64.tar.gz"}]
+    },
+    {
+      "tag": "nightly",
+      "prerelease": true,
+      "commit": "abc1234",
+      "published_at": "2024-09-01T00:00:00Z",
+      "assets": [{"name": "nvim-linux-x86_64.tar.gz", "url": "https://cdn.example.com/n.tar.gz"}]
+    },
+    {
+      "tag": "v0.10.2",
+      "published_at": "2024-08-01T00:00:00Z",
+      "assets": [{"name": "shasum.txt", "url": "../sums/shasum.txt"}]
+    }
+  ]
+}`
+
+func TestLocate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source string
+		opts   []releasesource.Option
+		want   string
+	}{
+		{
+			source: "https://artifacts.example.com/nvim/",
+			want:   "https://artifacts.example.com/nvim/index.json",
+		},
+		{
+			source: "https://artifacts.example.com/nvim/releases.json",
+			want:   "https://artifacts.example.com/nvim/releases.json",
+		},
+		{
+			source: "file:///mnt/nvim",
+			want:   "file:///mnt/nvim/index.json",
+		},
+		{
+			source: "s3://builds/nvim",
+			want:   "https://builds.s3.amazonaws.com/nvim/index.json",
+		},
+		{
+			source: "s3://builds/nvim",
+			opts:   []releasesource.Option{releasesource.WithS3Endpoint("http://minio:9000/")},
+			want:   "http://minio:9000/builds/nvim/index.json",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := releasesource.Locate(tt.source, tt.opts...)
+		if err != nil {
+			t.Errorf("Locate(%q) error = %v", tt.source, err)
+
+			continue
+		}
+
+		if got.String() != tt.want {
+			t.Errorf("Locate(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+
+	for _, source := range []string{"ftp://example.com/nvim", "nvim", "https:///nvim", "s3:///nvim"} {
+		_, err := releasesource.Locate(source)
+		if !errors.Is(err, releasesource.ErrUnknownSource) {
+			t.Errorf("Locate(%q) error = %v, want ErrUnknownSource", source, err)
+		}
+	}
+}
+
+func TestIndex_LocalDirectory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "index.json"), testIndex)
+	writeFile(t, filepath.Join(dir, "v0.10.1", "nvim-linux-x86_64.tar.gz"), "archive")
+
+	index, err := releasesource.Open(dir, releasesource.WithOffline(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := index.GetAll(t.Context(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags := make([]string, 0, len(all))
+	for _, rel := range all {
+		tags = append(tags, rel.TagName())
+	}
+
+	if strings.Join(tags, ",") != "nightly,v0.10.2,v0.10.1" {
+		t.Errorf("GetAll tags = %v, want newest first", tags)
+	}
+
+	stable, err := index.FindStable(t.Context())
+	if err != nil || stable.TagName() != "v0.10.2" {
+		t.Errorf("FindStable = %q, %v; want v0.10.2", stable.TagName(), err)
+	}
+
+	nightly, err := index.FindNightly(t.Context())
+	if err != nil || nightly.CommitHash() != "abc1234" {
+		t.Errorf("FindNightly = %q, %v; want commit abc1234", nightly.CommitHash(), err)
+	}
+
+	_, err = index.FindByTag(t.Context(), "v0.9.0")
+	if !errors.Is(err, release.ErrReleaseNotFound) {
+		t.Errorf("FindByTag(v0.9.0) error = %v, want ErrReleaseNotFound", err)
+	}
+
+	assetURLs := map[string]string{
+		"nightly": "https://cdn.example.com/n.tar.gz",
+		"v0.10.2": fileURL(filepath.Join(filepath.Dir(dir), "sums", "shasum.txt")),
+		"v0.10.1": fileURL(filepath.Join(dir, "v0.10.1", "nvim-linux-x86_64.tar.gz")),
+	}
+
+	for _, rel := range all {
+		got := rel.Assets()[0].DownloadURL()
+		if got != assetURLs[rel.TagName()] {
+			t.Errorf("%s asset URL = %q, want %q", rel.TagName(), got, assetURLs[rel.TagName()])
+		}
+	}
+
+	if !index.IsLocal() {
+		t.Error("IsLocal() = false for a local directory")
+	}
+
+	// Clients of a remote source refuse file:// URLs, online or not.
+	for _, client := range []*http.Client{
+		httpclient.NewClient(httpclient.DefaultTimeout),
+		httpclient.NewOfflineClient(),
+	} {
+		resp, getErr := client.Get(assetURLs["v0.10.1"])
+		if getErr == nil {
+			_ = resp.Body.Close()
+
+			t.Error("file:// download without AllowLocalFiles succeeded")
+		}
+	}
+
+	// A local source's archives are read through the same client as
+	// remote ones, even offline.
+	resp, err := httpclient.AllowLocalFiles(httpclient.NewOfflineClient()).Get(assetURLs["v0.10.1"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "archive" {
+		t.Errorf("file download = %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestIndex_HTTP(t *testing.T) {
+	t.Parallel()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.URL.Path != "/nvim/index.json" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = io.WriteString(w, testIndex)
+	}))
+	defer server.Close()
+
+	index, err := releasesource.Open(server.URL + "/nvim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rel, err := index.FindByTag(t.Context(), "v0.10.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := server.URL + "/nvim/v0.10.1/nvim-linux-x86_64.tar.gz"
+	if got := rel.Assets()[0].DownloadURL(); got != want {
+		t.Errorf("asset URL = %q, want %q", got, want)
+	}
+
+	_, err = index.FindStable(t.Context())
+	if err != nil || requests != 1 {
+		t.Errorf("FindStable error = %v after %d requests, want the index read once", err, requests)
+	}
+
+	offline, err := releasesource.Open(server.URL+"/nvim", releasesource.WithOffline(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = offline.GetAll(t.Context(), false)
+	if !errors.Is(err, httpclient.ErrOffline) {
+		t.Errorf("offline GetAll error = %v, want ErrOffline", err)
+	}
+}
+
+func TestIndex_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		content string
+		want    error
+	}{
+		"garbled":   {content: `{"schema": `, want: releasesource.ErrInvalidIndex},
+		"no-schema": {content: `{"releases": []}`, want: releasesource.ErrInvalidIndex},
+		"newer":     {content: `{"schema": 2, "releases": []}`, want: releasesource.ErrUnsupportedSchema},
+		"no-tag": {
+			content: `{"schema": 1, "releases": [{"assets": []}]}`,
+			want:    releasesource.ErrInvalidIndex,
+		},
+		"nightly-without-commit": {
+			content: `{"schema": 1, "releases": [{"tag": "nightly", "prerelease": true}]}`,
+			want:    releasesource.ErrInvalidIndex,
+		},
+		"missing": {want: releasesource.ErrIndexFetchFailed},
+	}
+
+	for name, tt := range tests {
+		dir := t.TempDir()
+		if tt.content != "" {
+			writeFile(t, filepath.Join(dir, "index.json"), tt.content)
+		}
+
+		index, err := releasesource.Open(fileURL(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = index.GetAll(t.Context(), false)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: GetAll error = %v, want %v", name, err, tt.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fileURL(path string) string {
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+
+	return "file://" + slashed
+}
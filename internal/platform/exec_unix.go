@@ -0,0 +1,18 @@
+//go:build !windows
+
+package platform
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Exec replaces the nvs process with path, so stdio, signals, job
+// control and the exit code belong to the program from here on. It
+// only returns if the exec itself fails.
+func Exec(path string, args, env []string) error {
+	//nolint:gosec // path and args are the user's own command line
+	err := syscall.Exec(path, args, env)
+
+	return fmt.Errorf("failed to exec %s: %w", path, err)
+}
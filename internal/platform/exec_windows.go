@@ -0,0 +1,40 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// Exec runs path as a child with the inherited stdio and exits nvs
+// with the child's exit code; Windows has no exec(2). Console Ctrl+C
+// reaches the child directly, so nvs ignores it while waiting. It
+// only returns if the program cannot be started.
+func Exec(path string, args, env []string) error {
+	//nolint:gosec // path and args are the user's own command line
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	signal.Ignore(os.Interrupt)
+
+	err := cmd.Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to run %s: %w", path, err)
+		}
+
+		os.Exit(exitErr.ExitCode())
+	}
+
+	os.Exit(0)
+
+	return nil
+}